@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/tools/collab"
+)
+
+// Agent authentication for MCP transports.
+//
+// Workers get a per-spawn token in STRINGWORK_TOKEN and send it as a bearer
+// token over HTTP. The driver is authenticated by its transport instead: stdio
+// in standalone mode, the daemon's unix socket (mode 0700) in daemon mode. The
+// identity is put on the request context, where collab.IdentityMiddleware binds
+// it to the MCP session and checks tool arguments against it.
+
+// driverConnKey marks requests that arrived on the daemon's unix socket.
+type driverConnKey struct{}
+
+// markDriverConn is the unix socket server's ConnContext.
+func markDriverConn(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, driverConnKey{}, true)
+}
+
+func isDriverConn(ctx context.Context) bool {
+	ok, _ := ctx.Value(driverConnKey{}).(bool)
+	return ok
+}
+
+// agentAuth authenticates MCP requests against the issued agent credentials.
+type agentAuth struct {
+	creds        *app.AgentCredentials
+	driver       string // agent name of stdio and unix socket clients; "" = none
+	requireToken bool   // reject TCP requests without a valid agent token
+}
+
+// bearerToken returns the bearer token of r, or "".
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// httpContext is the SSE and streamable HTTP context func.
+func (a *agentAuth) httpContext(ctx context.Context, r *http.Request) context.Context {
+	if isDriverConn(r.Context()) {
+		if a.driver != "" {
+			return collab.WithAuthenticatedAgent(ctx, a.driver)
+		}
+		return ctx
+	}
+	if agent, ok := a.creds.Authenticate(bearerToken(r)); ok {
+		return collab.WithAuthenticatedAgent(ctx, agent)
+	}
+	return ctx
+}
+
+// stdioContext is the stdio server's context func: stdio is the driver.
+func (a *agentAuth) stdioContext(ctx context.Context) context.Context {
+	if a.driver == "" {
+		return ctx
+	}
+	return collab.WithAuthenticatedAgent(ctx, a.driver)
+}
+
+// wrap rejects MCP HTTP requests with an unknown or revoked agent token and,
+// with require_token, TCP requests without one. Other bearer tokens (from the
+// mock OAuth flow) pass as unauthenticated.
+func (a *agentAuth) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isDriverConn(r.Context()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := bearerToken(r)
+		_, ok := a.creds.Authenticate(token)
+		switch {
+		case ok:
+		case strings.HasPrefix(token, app.AgentTokenPrefix):
+			writeUnauthorized(w, "invalid_token", "unknown or revoked agent token")
+			return
+		case a.requireToken:
+			writeUnauthorized(w, "invalid_request", "an agent token is required (auth.require_token)")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeUnauthorized(w http.ResponseWriter, code, description string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="`+code+`"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": description})
+}
@@ -52,7 +52,8 @@ func runDaemon(bundle *serverBundle) {
 		onDisconnect: tracker.driverDisconnected,
 	}
 
-	unixServer := &http.Server{Handler: handler}
+	// Requests on the socket come from the driver's proxies (see auth.go).
+	unixServer := &http.Server{Handler: handler, ConnContext: markDriverConn}
 	go func() {
 		if err := unixServer.Serve(trackingLn); err != http.ErrServerClosed {
 			bundle.logger.Printf("Unix socket server error: %v", err)
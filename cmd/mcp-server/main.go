@@ -46,6 +46,8 @@ type serverBundle struct {
 	wm        *app.WorkerManager
 	notifier  *app.Notifier
 	watchdog  *app.Watchdog
+	scheduler *app.Scheduler
+	auth      *agentAuth
 	cleanup   func()
 }
 
@@ -55,6 +57,24 @@ func main() {
 		case "status":
 			runStatusCommand()
 			return
+		case "migrate":
+			runMigrateCommand()
+			return
+		case "export":
+			runExportCommand()
+			return
+		case "import":
+			runImportCommand()
+			return
+		case "backup":
+			runBackupCommand()
+			return
+		case "archive":
+			runArchiveCommand()
+			return
+		case "plan":
+			runPlanCommand()
+			return
 		case "--version", "-v", "version":
 			fmt.Println("mcp-stringwork " + Version)
 			return
@@ -119,8 +139,25 @@ func hasFlag(flag string) bool {
 	return false
 }
 
+// flagValue returns the value of "--flag value" or "--flag=value" in os.Args
+// and removes both from os.Args.
+func flagValue(flag string) (string, bool) {
+	for i, arg := range os.Args {
+		if v, ok := strings.CutPrefix(arg, flag+"="); ok {
+			os.Args = append(os.Args[:i], os.Args[i+1:]...)
+			return v, true
+		}
+		if arg == flag && i+1 < len(os.Args) {
+			v := os.Args[i+1]
+			os.Args = append(os.Args[:i], os.Args[i+2:]...)
+			return v, true
+		}
+	}
+	return "", false
+}
+
 // initializeServer creates all server components: MCPServer, services, hooks,
-// tools, notifier, watchdog, and background goroutines. The returned bundle
+// tools, notifier, watchdog, scheduler, and background goroutines. The returned bundle
 // is ready to be wired to a transport (stdio, HTTP, or both).
 func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 	logger := setupLogger(pol.LogFile())
@@ -128,7 +165,7 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 	logger.Printf("Log file: %s", pol.LogFile())
 	logger.Printf("Workspace root: %s", cfg.WorkspaceRoot)
 
-	repo, err := repository.NewStateRepository(pol.StateFile())
+	repo, err := repository.NewStateRepository(pol.StateBackend(), pol.StateFile())
 	if err != nil {
 		logger.Fatalf("State repository: %v", err)
 	}
@@ -144,6 +181,15 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 	registry := app.NewSessionRegistry()
 	sessions := newSessionStore()
 
+	// The driver authenticates through its transport; its name is reserved
+	// for it like the worker names WorkerManager protects.
+	creds := app.NewAgentCredentials()
+	auth := &agentAuth{creds: creds, requireToken: pol.RequireAgentToken()}
+	if o := pol.Orchestration(); o != nil && o.Driver != "" {
+		auth.driver = o.Driver
+		creds.Protect(o.Driver)
+	}
+
 	hooks := &server.Hooks{}
 	hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result any) {
 		if message != nil {
@@ -207,6 +253,8 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 		"mcp-stringwork",
 		Version,
 		server.WithInstructions(collab.InstructionsText()),
+		server.WithToolHandlerMiddleware(collab.IdentityMiddleware(registry, creds)),
+		server.WithToolHandlerMiddleware(collab.AuthorizationMiddleware(svc, creds, logger)),
 		server.WithToolHandlerMiddleware(collab.PiggybackMiddleware(svc, registry)),
 		server.WithHooks(hooks),
 		server.WithResourceCapabilities(false, true),
@@ -267,7 +315,7 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 		return ""
 	}
 
-	var notifierOpts []app.NotifierOption
+	notifierOpts := []app.NotifierOption{app.WithNotifierWorkspace(pol.WorkspaceRoot())}
 	var wm *app.WorkerManager
 	orchCfg := pol.Orchestration()
 	if orchCfg != nil {
@@ -275,6 +323,16 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 		wm.SetSessionChecker(func(instanceOrType string) bool {
 			return registry.HasActiveSession(instanceOrType)
 		})
+		wm.SetCredentials(creds)
+		var maxRuns, maxMB int
+		if h := orchCfg.RunHistory; h != nil {
+			maxRuns, maxMB = h.MaxRuns, h.MaxTotalMB
+		}
+		if runLog, err := app.NewWorkerRunLog(filepath.Join(policy.GlobalStateDir(), "worker-runs"), maxRuns, int64(maxMB)<<20); err != nil {
+			logger.Printf("Warning: worker run history unavailable: %v (logging to stringwork-worker-<id>.log)", err)
+		} else {
+			wm.SetRunLog(runLog)
+		}
 		if mcpCfg := pol.MCPServers(); len(mcpCfg) > 0 {
 			var entries []app.MCPServerEntry
 			for name, sc := range mcpCfg {
@@ -292,6 +350,7 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 		notifierOpts = append(notifierOpts, app.WithWorkerManager(wm))
 		logger.Printf("WorkerManager enabled: driver=%s, %d worker type(s)", orchCfg.Driver, len(orchCfg.Workers))
 		wm.StartupCheck()
+		wm.StartIdleReaper(ctx)
 	}
 
 	var wtManager *worktree.Manager
@@ -336,6 +395,10 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 	}
 	if wm != nil {
 		regOpts = append(regOpts, collab.WithProcessProvider(&processAdapter{wm: wm}))
+		regOpts = append(regOpts, collab.WithWorkerPoolProvider(&poolAdapter{wm: wm}))
+		if runLog := wm.RunLog(); runLog != nil {
+			regOpts = append(regOpts, collab.WithWorkerRunStore(runLog))
+		}
 	}
 	collab.Register(mcpServer, svc, logger, registry, taskOrch, regOpts...)
 
@@ -348,9 +411,15 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 	)
 	go watchdog.Start(ctx)
 
+	scheduler := app.NewScheduler(svc, logger,
+		app.WithSchedulerNotifier(notifier),
+	)
+	go scheduler.Start(ctx)
+
 	cleanupFunc := func() {
 		cancel()
 		watchdog.Stop()
+		scheduler.Stop()
 		notifier.Stop()
 		if wtManager != nil {
 			if err := wtManager.CleanupAll(cfg.WorkspaceRoot); err != nil {
@@ -381,21 +450,28 @@ func initializeServer(cfg *policy.Config, pol *policy.Policy) *serverBundle {
 		wm:        wm,
 		notifier:  notifier,
 		watchdog:  watchdog,
+		scheduler: scheduler,
+		auth:      auth,
 		cleanup:   cleanupFunc,
 	}
 }
 
 // buildHTTPHandler creates the HTTP handler with all routes (MCP, SSE, dashboard, health, auth).
 func buildHTTPHandler(bundle *serverBundle, baseURL string, port int) http.Handler {
-	sseSrv := server.NewSSEServer(bundle.mcpServer, server.WithBaseURL(baseURL))
-	streamSrv := server.NewStreamableHTTPServer(bundle.mcpServer)
+	sseSrv := server.NewSSEServer(bundle.mcpServer,
+		server.WithBaseURL(baseURL),
+		server.WithSSEContextFunc(bundle.auth.httpContext),
+	)
+	streamSrv := server.NewStreamableHTTPServer(bundle.mcpServer,
+		server.WithHTTPContextFunc(bundle.auth.httpContext),
+	)
 	mockAuth := newMockAuthServer(baseURL, bundle.logger)
 
 	mux := http.NewServeMux()
-	mux.Handle("/sse", sseSrv)
-	mux.Handle("/sse/", sseSrv)
-	mux.Handle("/message", sseSrv)
-	mux.Handle("/mcp", streamSrv)
+	mux.Handle("/sse", bundle.auth.wrap(sseSrv))
+	mux.Handle("/sse/", bundle.auth.wrap(sseSrv))
+	mux.Handle("/message", bundle.auth.wrap(sseSrv))
+	mux.Handle("/mcp", bundle.auth.wrap(streamSrv))
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		fmt.Fprintf(w, `{"status":"ok","port":%d,"agents":%d}`, port, bundle.registry.AgentCount())
@@ -405,6 +481,9 @@ func buildHTTPHandler(bundle *serverBundle, baseURL string, port int) http.Handl
 	var dashOpts []dashboard.HandlerOption
 	if bundle.wm != nil {
 		dashOpts = append(dashOpts, dashboard.WithWorkerController(bundle.wm))
+		if runLog := bundle.wm.RunLog(); runLog != nil {
+			dashOpts = append(dashOpts, dashboard.WithWorkerRuns(runLog))
+		}
 	}
 	dash := dashboard.NewHandler(bundle.svc, bundle.registry, dashOpts...)
 	dash.RegisterRoutes(mux)
@@ -456,6 +535,7 @@ func runStandalone(bundle *serverBundle) {
 
 	bundle.logger.Println("Stdio ready (driver connection)")
 	stdioSrv := server.NewStdioServer(bundle.mcpServer)
+	stdioSrv.SetContextFunc(bundle.auth.stdioContext)
 	if err := stdioSrv.Listen(context.Background(), os.Stdin, os.Stdout); err != nil {
 		bundle.logger.Printf("Stdio server stopped: %v", err)
 	}
@@ -565,6 +645,26 @@ func (a *processAdapter) GetProcessInfo() map[string]collab.ProcessInfoSnapshot
 	return result
 }
 
+type poolAdapter struct {
+	wm *app.WorkerManager
+}
+
+func (a *poolAdapter) GetWorkerPool() collab.WorkerPoolSnapshot {
+	pool := a.wm.PoolStatus()
+	result := collab.WorkerPoolSnapshot{MaxWorkers: pool.MaxWorkers, Active: pool.Active}
+	for _, t := range pool.Types {
+		result.Types = append(result.Types, collab.WorkerTypeSnapshot{
+			Type:         t.Type,
+			Autoscaled:   t.Autoscaled,
+			Instances:    t.Instances,
+			MinInstances: t.MinInstances,
+			Active:       t.Active,
+			IdleTimeout:  t.IdleTimeout,
+		})
+	}
+	return result
+}
+
 type worktreeAdapter struct {
 	mgr *worktree.Manager
 }
@@ -606,22 +706,28 @@ func (a *knowledgeStateAdapter) SessionNotes() []knowledge.SessionNoteData {
 	return notes
 }
 
+// CompletedTasks includes archived tasks so they stay searchable after
+// task_retention_days.
 func (a *knowledgeStateAdapter) CompletedTasks() []knowledge.TaskData {
-	var tasks []knowledge.TaskData
-	_ = a.svc.Query(func(state *domain.CollabState) error {
-		for _, t := range state.Tasks {
-			if t.Status == "completed" {
-				tasks = append(tasks, knowledge.TaskData{
-					ID:            t.ID,
-					Title:         t.Title,
-					Description:   t.Description,
-					AssignedTo:    t.AssignedTo,
-					ResultSummary: t.ResultSummary,
-				})
-			}
-		}
+	completed, err := a.svc.Queries().TasksByStatus("completed")
+	if err != nil {
 		return nil
-	})
+	}
+	if archived, err := a.svc.ArchivedTasks(app.ArchiveFilter{Status: "completed"}); err == nil {
+		for _, at := range archived {
+			completed = append(completed, at.Task)
+		}
+	}
+	tasks := make([]knowledge.TaskData, 0, len(completed))
+	for _, t := range completed {
+		tasks = append(tasks, knowledge.TaskData{
+			ID:            t.ID,
+			Title:         t.Title,
+			Description:   t.Description,
+			AssignedTo:    t.AssignedTo,
+			ResultSummary: t.ResultSummary,
+		})
+	}
 	return tasks
 }
 
@@ -635,7 +741,7 @@ func runStatusCommand() {
 	cfg := loadConfig(logger)
 	pol := policy.New(cfg)
 
-	repo, err := repository.NewStateRepository(pol.StateFile())
+	repo, err := repository.NewStateRepository(pol.StateBackend(), pol.StateFile())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -646,24 +752,54 @@ func runStatusCommand() {
 		}
 	}()
 
-	state, err := repo.Load()
-	if err != nil {
-		state = domain.NewCollabState()
-	}
+	// On query failure report zeros, as the status line must never break callers.
+	unread, pending, _ := app.AgentBacklog(app.QueriesFor(repo), agent, pol.WorkspaceRoot())
 
-	unread := 0
-	for _, msg := range state.Messages {
-		if (msg.To == agent || msg.To == "all") && !msg.Read {
-			unread++
-		}
+	fmt.Printf("unread=%d pending=%d\n", unread, pending)
+}
+
+// runMigrateCommand upgrades the state database schema. With --dry-run it
+// only lists the pending migrations.
+func runMigrateCommand() {
+	dryRun := hasFlag("--dry-run")
+
+	logger := log.New(os.Stderr, "", 0)
+	cfg := loadConfig(logger)
+	pol := policy.New(cfg)
+	if pol.StateBackend() != policy.StateBackendSQLite {
+		fmt.Printf("state backend %q has no schema to migrate\n", pol.StateBackend())
+		return
 	}
 
-	pending := 0
-	for _, task := range state.Tasks {
-		if (task.AssignedTo == agent || task.AssignedTo == "any") && task.Status == "pending" {
-			pending++
-		}
+	report, err := repository.MigrateStateFile(pol.StateFile(), dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("unread=%d pending=%d\n", unread, pending)
+	fmt.Printf("state: %s\n", pol.StateFile())
+	switch {
+	case report.Legacy:
+		fmt.Println("schema version: unversioned (created before schema versioning)")
+	default:
+		fmt.Printf("schema version: %d\n", report.From)
+	}
+	if len(report.Pending) == 0 {
+		fmt.Println("up to date")
+		return
+	}
+	if dryRun {
+		fmt.Println("pending migrations:")
+	} else {
+		fmt.Println("applied migrations:")
+	}
+	for _, m := range report.Pending {
+		fmt.Println("  " + m)
+	}
+	if report.Backup != "" {
+		fmt.Printf("backup: %s\n", report.Backup)
+	}
+	if !dryRun {
+		fmt.Printf("schema version now: %d\n", report.To)
+	}
 }
@@ -11,7 +11,8 @@ package main
 // These mock endpoints implement the minimum OAuth 2.1 surface required by the
 // MCP specification so that clients complete the auth handshake without any
 // real credentials. Every token request is approved, every client registration
-// is accepted. This is appropriate for a local-only development tool.
+// is accepted. The tokens it issues carry no identity: agents authenticate with
+// the tokens from app.AgentCredentials instead (see auth.go).
 
 import (
 	"crypto/rand"
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+const planUsage = `usage: mcp-stringwork plan export [--project PATH] [--output FILE] [ID]
+       mcp-stringwork plan import [--project PATH] [--id ID] [--replace] FILE|-`
+
+// runPlanCommand exports a plan as markdown or imports one (see
+// app.PlanMarkdown for the format). --project defaults to the current
+// directory: export picks its active plan and import files the plan there.
+func runPlanCommand() {
+	project, _ := flagValue("--project")
+	if project == "" {
+		project, _ = os.Getwd()
+	}
+	project = app.ProjectKey(project)
+	if len(os.Args) < 3 {
+		fatalf("%s", planUsage)
+	}
+	switch os.Args[2] {
+	case "export":
+		runPlanExport(project)
+	case "import":
+		runPlanImport(project)
+	default:
+		fatalf("%s", planUsage)
+	}
+}
+
+// runPlanExport writes plan ID, or the project's active plan, as markdown to
+// stdout or --output.
+//
+//	mcp-stringwork plan export [--project PATH] [--output FILE] [ID]
+func runPlanExport(project string) {
+	output, _ := flagValue("--output")
+	var id string
+	if len(os.Args) > 3 {
+		id = os.Args[3]
+	}
+
+	svc, _, closeRepo := openStateService()
+	defer closeRepo()
+
+	var text string
+	if err := svc.Query(func(state *domain.CollabState) error {
+		if id == "" {
+			id = app.ActivePlanID(state, project)
+		}
+		if id == "" {
+			return fmt.Errorf("no active plan in %s; pass a plan ID", project)
+		}
+		plan, ok := state.Plans[id]
+		if !ok || plan == nil {
+			return fmt.Errorf("plan %q not found", id)
+		}
+		text = app.PlanMarkdown(plan)
+		return nil
+	}); err != nil {
+		fatalf("%v", err)
+	}
+
+	if output == "" || output == "-" {
+		fmt.Print(text)
+	} else if err := os.WriteFile(output, []byte(text), 0o644); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// runPlanImport creates a plan from markdown, or with --replace updates the
+// plan with its ID.
+//
+//	mcp-stringwork plan import [--project PATH] [--id ID] [--replace] FILE|-
+func runPlanImport(project string) {
+	replace := hasFlag("--replace")
+	id, _ := flagValue("--id")
+	if len(os.Args) < 4 {
+		fatalf("%s", planUsage)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if file := os.Args[3]; file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+	parsed, err := app.ParsePlanMarkdown(string(data))
+	if err != nil {
+		fatalf("parse %s: %v", os.Args[3], err)
+	}
+	if id != "" {
+		parsed.ID = id
+	}
+
+	svc, _, closeRepo := openStateService()
+	defer closeRepo()
+
+	var created bool
+	if err := svc.Run(func(state *domain.CollabState) error {
+		_, created, err = app.ImportPlan(state, parsed, project, "cli", replace, time.Now())
+		return err
+	}); err != nil {
+		fatalf("%v", err)
+	}
+	verb := "updated"
+	if created {
+		verb = "created"
+	}
+	fmt.Printf("plan %q %s with %d items\n", parsed.ID, verb, len(parsed.Items))
+}
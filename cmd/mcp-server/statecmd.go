@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+	"github.com/jaakkos/stringwork/internal/repository"
+)
+
+// defaultBackupKeep is how many snapshots per database `backup` keeps.
+const defaultBackupKeep = 10
+
+// fatalf prints an error for a CLI subcommand and exits.
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// openStateService opens the configured state file for a CLI subcommand.
+func openStateService() (*app.CollabService, *policy.Policy, func()) {
+	logger := log.New(os.Stderr, "", 0)
+	cfg := loadConfig(logger)
+	pol := policy.New(cfg)
+	repo, err := repository.NewStateRepository(pol.StateBackend(), pol.StateFile())
+	if err != nil {
+		fatalf("%v", err)
+	}
+	closeRepo := func() {
+		if c, ok := repo.(interface{ Close() error }); ok {
+			_ = c.Close()
+		}
+	}
+	return app.NewCollabService(repo, pol, logger), pol, closeRepo
+}
+
+// runExportCommand writes the session of one project (or all projects),
+// including the artifacts of its task results, as JSON to stdout or --output.
+// --include-untagged adds the records that predate project scoping.
+//
+//	mcp-stringwork export [--project PATH|all] [--include-untagged] [--output FILE]
+func runExportCommand() {
+	project, _ := flagValue("--project")
+	output, _ := flagValue("--output")
+	includeUntagged := hasFlag("--include-untagged")
+	if project != app.AllProjects {
+		project = app.ProjectKey(project)
+	}
+
+	svc, _, closeRepo := openStateService()
+	defer closeRepo()
+
+	var exp *app.SessionExport
+	if err := svc.Query(func(state *domain.CollabState) error {
+		exp = app.ExportSession(state, project, includeUntagged)
+		return nil
+	}); err != nil {
+		fatalf("%v", err)
+	}
+	missing, err := app.ExportArtifacts(exp, svc.Artifact)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data, err := json.MarshalIndent(exp, "", "  ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data = append(data, '\n')
+
+	if output == "" || output == "-" {
+		_, _ = os.Stdout.Write(data)
+	} else if err := os.WriteFile(output, data, 0o644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d tasks, %d plans, %d work contexts, %d notes, %d messages, %d artifacts\n",
+		len(exp.Tasks), len(exp.Plans), len(exp.WorkContexts), len(exp.SessionNotes), len(exp.Messages), len(exp.Artifacts))
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d artifacts listed in task results were not found and are not exported\n", missing)
+	}
+}
+
+// runImportCommand adds a session written by export to the state. Records get
+// new IDs; --project moves them to a workspace path on this machine. Task
+// verify commands are dropped unless --keep-verify is given: they run as
+// shell commands on this machine.
+//
+//	mcp-stringwork import [--project PATH] [--keep-verify] FILE|-
+func runImportCommand() {
+	project, _ := flagValue("--project")
+	project = app.ProjectKey(project)
+	keepVerify := hasFlag("--keep-verify")
+	if len(os.Args) < 3 {
+		fatalf("usage: mcp-stringwork import [--project PATH] [--keep-verify] FILE|-")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if file := os.Args[2]; file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var exp app.SessionExport
+	if err := json.Unmarshal(data, &exp); err != nil {
+		fatalf("parse %s: %v", os.Args[2], err)
+	}
+
+	svc, _, closeRepo := openStateService()
+	defer closeRepo()
+
+	var sum app.ImportSummary
+	if err := svc.Run(func(state *domain.CollabState) error {
+		sum, err = app.ImportSession(state, &exp, project, keepVerify)
+		return err
+	}); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Println(sum)
+	if sum.DroppedVerify > 0 {
+		fmt.Fprintln(os.Stderr, "verify commands run as shell commands here; import with --keep-verify only if you trust the file")
+	}
+}
+
+// runBackupCommand snapshots state.sqlite and knowledge.db with the SQLite
+// online backup API, keeping the newest --keep snapshots of each.
+//
+//	mcp-stringwork backup [--dir DIR] [--keep N]
+func runBackupCommand() {
+	dir, _ := flagValue("--dir")
+	keep := defaultBackupKeep
+	if v, ok := flagValue("--keep"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			fatalf("--keep must be a non-negative number, got %q", v)
+		}
+		keep = n
+	}
+
+	logger := log.New(os.Stderr, "", 0)
+	cfg := loadConfig(logger)
+	pol := policy.New(cfg)
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(pol.StateFile()), "backups")
+	}
+
+	// Only SQLite databases can be snapshotted; a json state file can be copied by hand.
+	paths := []string{pol.KnowledgeDBPath()}
+	if pol.StateBackend() == policy.StateBackendSQLite {
+		paths = append([]string{pol.StateFile()}, paths...)
+	}
+	written, err := repository.BackupFiles(dir, keep, time.Now(), paths...)
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if len(written) == 0 {
+		fmt.Println("nothing to back up")
+	}
+}
+
+// runArchiveCommand moves completed and cancelled tasks older than
+// --older-than days (default task_retention_days) into the task archive.
+//
+//	mcp-stringwork archive [--older-than DAYS] [--dry-run]
+func runArchiveCommand() {
+	dryRun := hasFlag("--dry-run")
+	olderThan, hasOlderThan := flagValue("--older-than")
+
+	svc, pol, closeRepo := openStateService()
+	defer closeRepo()
+
+	days := pol.TaskRetentionDays()
+	if hasOlderThan {
+		n, err := strconv.Atoi(strings.TrimSuffix(olderThan, "d"))
+		if err != nil || n < 1 {
+			fatalf("--older-than must be a number of days (at least 1), got %q", olderThan)
+		}
+		days = n
+	}
+	if days < 1 {
+		fmt.Println("task_retention_days is 0 (archiving disabled); pass --older-than DAYS")
+		return
+	}
+
+	var archived []domain.ArchivedTask
+	run := svc.Run
+	if dryRun {
+		// Query never saves, so the tasks stay where they are.
+		run = svc.Query
+	}
+	if err := run(func(state *domain.CollabState) error {
+		app.ArchiveTasks(state, days, time.Now())
+		archived = state.PendingArchive
+		return nil
+	}); err != nil {
+		fatalf("%v", err)
+	}
+
+	verb := "archived"
+	if dryRun {
+		verb = "would archive"
+	}
+	fmt.Printf("%s %d tasks finished more than %d days ago\n", verb, len(archived), days)
+	for _, a := range archived {
+		fmt.Printf("  #%d [%s] %s\n", a.Task.ID, a.Task.Status, a.Task.Title)
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// ErrNoArchive is returned when the repository keeps no task archive.
+var ErrNoArchive = errors.New("task archive not available for this state backend")
+
+// ArchiveTasks moves completed and cancelled tasks last updated more than
+// maxAgeDays before now out of state.Tasks, together with their work
+// contexts, into state.PendingArchive. Returns the number archived;
+// maxAgeDays <= 0 archives nothing.
+func ArchiveTasks(state *domain.CollabState, maxAgeDays int, now time.Time) int {
+	if state == nil || maxAgeDays <= 0 {
+		return 0
+	}
+	cutoff := now.AddDate(0, 0, -maxAgeDays)
+	kept := state.Tasks[:0]
+	archived := 0
+	for _, t := range state.Tasks {
+		if (t.Status != "completed" && t.Status != "cancelled") || !t.UpdatedAt.Before(cutoff) {
+			kept = append(kept, t)
+			continue
+		}
+		entry := domain.ArchivedTask{Task: t, ArchivedAt: now}
+		if wc, ok := state.WorkContexts[t.ContextID]; ok && t.ContextID != "" {
+			entry.WorkContext = wc
+			delete(state.WorkContexts, t.ContextID)
+		}
+		state.PendingArchive = append(state.PendingArchive, entry)
+		if state.ArchivedDependencies == nil {
+			state.ArchivedDependencies = make(map[int]string)
+		}
+		state.ArchivedDependencies[t.ID] = t.Status
+		archived++
+	}
+	state.Tasks = kept
+	return archived
+}
+
+// FilterArchived returns the archived tasks matching f in task ID order,
+// keeping the f.Limit highest IDs. It is the reference semantics of
+// ArchiveFilter for archives kept in memory.
+func FilterArchived(tasks []domain.ArchivedTask, f ArchiveFilter) []domain.ArchivedTask {
+	var out []domain.ArchivedTask
+	for _, a := range tasks {
+		t := a.Task
+		switch {
+		case f.TaskID != 0 && t.ID != f.TaskID:
+		case f.Status != "" && t.Status != f.Status:
+		case f.AssignedTo != "" && t.AssignedTo != f.AssignedTo && t.AssignedTo != "any":
+		case !InProject(t.Project, f.Project):
+		default:
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Task.ID < out[j].Task.ID })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// archiveTestRepo keeps tasks archived on Save.
+type archiveTestRepo struct {
+	journalTestRepo
+	archived []domain.ArchivedTask
+}
+
+func (r *archiveTestRepo) Save(state *domain.CollabState) error {
+	r.archived = append(r.archived, state.PendingArchive...)
+	return r.journalTestRepo.Save(state)
+}
+
+func (r *archiveTestRepo) ArchivedTasks(f ArchiveFilter) ([]domain.ArchivedTask, error) {
+	return FilterArchived(r.archived, f), nil
+}
+
+func TestArchiveTasks(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -40)
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "completed", UpdatedAt: old, ContextID: "ctx-1"},
+		{ID: 2, Status: "cancelled", UpdatedAt: old},
+		{ID: 3, Status: "completed", UpdatedAt: now.AddDate(0, 0, -5)},
+		{ID: 4, Status: "pending", UpdatedAt: old},
+		{ID: 5, Status: "in_progress", UpdatedAt: old},
+	}
+	state.WorkContexts["ctx-1"] = &domain.WorkContext{ID: "ctx-1", TaskID: 1}
+
+	if n := ArchiveTasks(state, 0, now); n != 0 || len(state.Tasks) != 5 {
+		t.Fatalf("retention 0 archived %d tasks", n)
+	}
+	if n := ArchiveTasks(state, 30, now); n != 2 {
+		t.Fatalf("ArchiveTasks = %d, want 2", n)
+	}
+	var live []int
+	for _, task := range state.Tasks {
+		live = append(live, task.ID)
+	}
+	if len(live) != 3 || live[0] != 3 || live[1] != 4 || live[2] != 5 {
+		t.Errorf("live tasks = %v, want [3 4 5]", live)
+	}
+	if len(state.PendingArchive) != 2 || state.PendingArchive[0].WorkContext == nil || !state.PendingArchive[0].ArchivedAt.Equal(now) {
+		t.Errorf("pending archive = %+v", state.PendingArchive)
+	}
+	if _, ok := state.WorkContexts["ctx-1"]; ok {
+		t.Error("work context of archived task still live")
+	}
+}
+
+func TestRun_ArchivesAndJournals(t *testing.T) {
+	repo := &archiveTestRepo{journalTestRepo: journalTestRepo{notifierTestRepo: notifierTestRepo{state: domain.NewCollabState()}}}
+	repo.state.Tasks = []domain.Task{{ID: 1, Title: "Done", Status: "completed", AssignedTo: "claude-code", UpdatedAt: time.Now().AddDate(0, 0, -60)}}
+	svc := NewCollabService(repo, testPolicy(), log.New(os.Stderr, "[test] ", 0))
+
+	if err := svc.Run(func(s *domain.CollabState) error {
+		ArchiveTasks(s, 30, time.Now())
+		return nil
+	}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := eventTypes(repo.events); got[domain.EventTaskArchived] != 1 || got[domain.EventTaskRemoved] != 0 {
+		t.Errorf("events = %v, want one task_archived", got)
+	}
+	if repo.state.PendingArchive != nil {
+		t.Error("PendingArchive not cleared after Run")
+	}
+	archived, err := svc.ArchivedTasks(ArchiveFilter{AssignedTo: "claude-code"})
+	if err != nil || len(archived) != 1 || archived[0].Task.Title != "Done" {
+		t.Errorf("ArchivedTasks = %+v, %v", archived, err)
+	}
+}
+
+func TestRun_RefusesToArchiveWithoutArchive(t *testing.T) {
+	repo := &notifierTestRepo{state: domain.NewCollabState()}
+	repo.state.Tasks = []domain.Task{{ID: 1, Status: "completed", UpdatedAt: time.Now().AddDate(0, 0, -60)}}
+	svc := NewCollabService(repo, testPolicy(), log.New(os.Stderr, "[test] ", 0))
+
+	err := svc.Run(func(s *domain.CollabState) error {
+		ArchiveTasks(s, 30, time.Now())
+		return nil
+	})
+	if !errors.Is(err, ErrNoArchive) {
+		t.Fatalf("Run error = %v, want ErrNoArchive", err)
+	}
+	if _, err := svc.ArchivedTasks(ArchiveFilter{}); !errors.Is(err, ErrNoArchive) {
+		t.Errorf("ArchivedTasks error = %v, want ErrNoArchive", err)
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Outcomes of a task attempt set by the server rather than by a status change.
+const (
+	AttemptReassigned   = "reassigned"    // the task moved to another agent while in progress
+	AttemptWorkerExited = "worker_exited" // the worker exited without finishing the task
+	AttemptWorkerFailed = "worker_failed" // the worker process failed; see ExitClass
+	AttemptStalled      = "stalled"       // the watchdog recovered the task
+)
+
+// maxLogExcerpt bounds TaskAttempt.LogExcerpt.
+const maxLogExcerpt = 2000
+
+// OpenAttempt returns the task's running attempt, or nil.
+func OpenAttempt(t *domain.Task) *domain.TaskAttempt {
+	if n := len(t.Attempts); n > 0 && t.Attempts[n-1].EndedAt.IsZero() {
+		return &t.Attempts[n-1]
+	}
+	return nil
+}
+
+// CloseAttempt ends the task's running attempt with outcome, keeping the
+// task's last reported progress. Call it before resetting a task so the reason
+// is recorded; otherwise the status change closes the attempt generically.
+// Returns the closed attempt, or nil if none was running.
+func CloseAttempt(t *domain.Task, outcome string, now time.Time) *domain.TaskAttempt {
+	a := OpenAttempt(t)
+	if a == nil {
+		return nil
+	}
+	a.EndedAt = now
+	a.Outcome = outcome
+	a.ProgressPercent = t.ProgressPercent
+	a.LastProgress = t.ProgressDescription
+	return a
+}
+
+// LogExcerpt trims worker output to the tail kept in TaskAttempt.LogExcerpt.
+func LogExcerpt(output string) string {
+	if len(output) <= maxLogExcerpt {
+		return output
+	}
+	return "…" + output[len(output)-maxLogExcerpt:]
+}
+
+// trackAttempts opens an attempt for every task that entered in_progress (or
+// changed hands while in progress) since before, and closes the running
+// attempt of every task that left in_progress. A task back in pending after
+// MaxAttempts attempts is blocked instead of being handed out again.
+func trackAttempts(before stateDigest, state *domain.CollabState, now time.Time) {
+	for i := range state.Tasks {
+		t := &state.Tasks[i]
+		prev, existed := before.tasks[t.ID]
+		wasRunning := existed && prev.status == "in_progress"
+		running := t.Status == "in_progress"
+
+		switch {
+		case running && (!wasRunning || prev.assignedTo != t.AssignedTo):
+			if wasRunning {
+				CloseAttempt(t, AttemptReassigned, now)
+			}
+			t.Attempts = append(t.Attempts, domain.TaskAttempt{
+				Number:    len(t.Attempts) + 1,
+				Agent:     t.AssignedTo,
+				StartedAt: now,
+			})
+		case !running && OpenAttempt(t) != nil:
+			outcome := t.Status
+			if outcome == "pending" || outcome == "waiting" {
+				outcome = "released"
+			}
+			CloseAttempt(t, outcome, now)
+		}
+
+		if wasRunning && t.Status == "pending" && t.MaxAttempts > 0 && len(t.Attempts) >= t.MaxAttempts {
+			t.Status = "blocked"
+			t.BlockedBy = fmt.Sprintf("gave up after %d attempts (max_attempts); see get_task_history", len(t.Attempts))
+			t.UpdatedAt = now
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestRun_TracksTaskAttempts(t *testing.T) {
+	repo := &notifierTestRepo{state: domain.NewCollabState()}
+	svc := NewCollabService(repo, testPolicy(), log.New(os.Stderr, "[test] ", 0))
+	task := func() *domain.Task { return &repo.state.Tasks[0] }
+	set := func(fn func(t *domain.Task)) {
+		t.Helper()
+		if err := svc.Run(func(s *domain.CollabState) error {
+			fn(&s.Tasks[0])
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_ = svc.Run(func(s *domain.CollabState) error {
+		s.Tasks = append(s.Tasks, domain.Task{ID: 1, Title: "T", Status: "pending", AssignedTo: "any", MaxAttempts: 3})
+		return nil
+	})
+
+	// Claim, then a worker exit resets the task with details.
+	set(func(t *domain.Task) { t.Status, t.AssignedTo = "in_progress", "claude-code-1" })
+	if a := OpenAttempt(task()); a == nil || a.Number != 1 || a.Agent != "claude-code-1" {
+		t.Fatalf("attempt after claim = %+v", task().Attempts)
+	}
+	set(func(t *domain.Task) {
+		t.ProgressPercent, t.ProgressDescription = 40, "tests written"
+		a := CloseAttempt(t, AttemptWorkerFailed, time.Now())
+		a.ExitClass = "quota_exhausted"
+		t.Status = "pending"
+	})
+	if a := task().Attempts[0]; a.Outcome != AttemptWorkerFailed || a.ExitClass != "quota_exhausted" || a.ProgressPercent != 40 || a.LastProgress != "tests written" || a.EndedAt.IsZero() {
+		t.Errorf("closed attempt = %+v", a)
+	}
+
+	// Reassignment while in progress starts a new attempt.
+	set(func(t *domain.Task) { t.Status, t.AssignedTo = "in_progress", "claude-code-1" })
+	set(func(t *domain.Task) { t.AssignedTo = "codex" })
+	if n := len(task().Attempts); n != 3 || task().Attempts[1].Outcome != AttemptReassigned || task().Attempts[2].Agent != "codex" {
+		t.Fatalf("attempts after reassignment = %+v", task().Attempts)
+	}
+
+	// Releasing the third attempt hits max_attempts.
+	set(func(t *domain.Task) { t.Status = "pending" })
+	if got := task(); got.Status != "blocked" || !strings.Contains(got.BlockedBy, "3 attempts") || got.Attempts[2].Outcome != "released" {
+		t.Errorf("task after max attempts = %s %q %+v", got.Status, got.BlockedBy, got.Attempts[2])
+	}
+}
+
+func TestLogExcerpt(t *testing.T) {
+	if got := LogExcerpt("short"); got != "short" {
+		t.Errorf("LogExcerpt(short) = %q", got)
+	}
+	long := strings.Repeat("x", maxLogExcerpt) + "tail"
+	if got := LogExcerpt(long); !strings.HasSuffix(got, "tail") || len(got) > maxLogExcerpt+len("…") {
+		t.Errorf("LogExcerpt(long) has length %d", len(got))
+	}
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// WorkerPoolStatus summarizes worker capacity for worker_status.
+type WorkerPoolStatus struct {
+	MaxWorkers int // worker processes allowed at once across all types (0 = no limit)
+	Active     int // instances with a spawn in flight
+	Types      []WorkerTypeStatus
+}
+
+// WorkerTypeStatus is the capacity of one worker type.
+type WorkerTypeStatus struct {
+	Type         string
+	Autoscaled   bool
+	Instances    int // configured instance IDs (max_instances when autoscaled)
+	MinInstances int
+	Active       int
+	IdleTimeout  time.Duration // autoscaled types only
+}
+
+// scaleTarget returns how many instances of an autoscaled type should be
+// running: enough to give every pending task a free slot and at least
+// minInstances (or one) while the type has any work, up to maxInstances.
+func scaleTarget(minInstances, maxInstances, maxTasks, running, freeSlots, pending, unread int) int {
+	if pending == 0 && unread == 0 {
+		return running
+	}
+	want := running
+	if over := pending - freeSlots; over > 0 {
+		want += (over + maxTasks - 1) / maxTasks
+	}
+	return min(max(want, minInstances, 1), maxInstances)
+}
+
+// scaleBudgets returns, per autoscaled worker type, how many more instances
+// may start for work addressed to the type (or "any") rather than an instance.
+func (m *WorkerManager) scaleBudgets(q StateQueries, unreadFor, pendingFor map[string]int) map[string]int {
+	type pool struct {
+		c       WorkerSpawnConfig
+		running int
+		free    int
+	}
+	pools := make(map[string]*pool)
+	for _, c := range m.configs {
+		if !c.Autoscaled {
+			continue
+		}
+		p := pools[c.AgentType]
+		if p == nil {
+			p = &pool{c: c}
+			pools[c.AgentType] = p
+		}
+		if !m.isActive(c.InstanceID) {
+			continue
+		}
+		p.running++
+		counts, err := q.TaskStatusCounts("", c.InstanceID)
+		if err != nil {
+			continue
+		}
+		if free := c.MaxTasks - counts["in_progress"]; free > 0 {
+			p.free += free
+		}
+	}
+	budget := make(map[string]int, len(pools))
+	for typ, p := range pools {
+		want := scaleTarget(p.c.MinInstances, p.c.MaxInstances, p.c.MaxTasks, p.running, p.free, pendingFor[typ], unreadFor[typ])
+		budget[typ] = want - p.running
+	}
+	return budget
+}
+
+func (m *WorkerManager) isActive(instanceID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.active[instanceID]
+	return ok
+}
+
+func (m *WorkerManager) setActive(instanceID string, active bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if active {
+		m.active[instanceID] = struct{}{}
+	} else {
+		delete(m.active, instanceID)
+	}
+}
+
+func (m *WorkerManager) activeCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.active)
+}
+
+// StartIdleReaper periodically stops surplus instances of autoscaled worker
+// types that have gone their idle timeout without a task or output. It returns
+// immediately when no worker type is autoscaled; otherwise it runs until ctx is done.
+func (m *WorkerManager) StartIdleReaper(ctx context.Context) {
+	autoscaled := false
+	for _, c := range m.configs {
+		autoscaled = autoscaled || c.Autoscaled
+	}
+	if !autoscaled {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(idleReapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.reapIdle(time.Now())
+			}
+		}
+	}()
+}
+
+// reapIdle cancels idle autoscaled instances, newest instance IDs first, while
+// their type keeps more than MinInstances running. Returns the stopped IDs.
+func (m *WorkerManager) reapIdle(now time.Time) []string {
+	q := QueriesFor(m.repo)
+	running := make(map[string]int)
+	var candidates []WorkerSpawnConfig
+	m.mu.Lock()
+	for _, c := range m.configs {
+		if !c.Autoscaled {
+			continue
+		}
+		if _, ok := m.active[c.InstanceID]; !ok {
+			continue
+		}
+		running[c.AgentType]++
+		if p := m.processActivity[c.InstanceID]; p != nil && now.Sub(p.LastOutputAt) >= c.IdleTimeout {
+			candidates = append(candidates, c)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].InstanceID > candidates[j].InstanceID })
+
+	var stopped []string
+	for _, c := range candidates {
+		if running[c.AgentType] <= c.MinInstances {
+			continue
+		}
+		counts, err := q.TaskStatusCounts("", c.InstanceID)
+		if err != nil || counts["in_progress"] > 0 {
+			continue
+		}
+		m.mu.Lock()
+		cancel, ok := m.runningWorkers[c.InstanceID]
+		if ok {
+			m.scaledDown[c.InstanceID] = true
+			cancel()
+		}
+		m.mu.Unlock()
+		if !ok {
+			continue
+		}
+		running[c.AgentType]--
+		stopped = append(stopped, c.InstanceID)
+		m.logger.Printf("WorkerManager: scaled down %s — idle for %s", c.InstanceID, c.IdleTimeout)
+	}
+	return stopped
+}
+
+// PoolStatus returns the configured capacity and current activity per worker type.
+func (m *WorkerManager) PoolStatus() WorkerPoolStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := WorkerPoolStatus{MaxWorkers: m.maxWorkers, Active: len(m.active)}
+	index := make(map[string]int)
+	for _, c := range m.configs {
+		i, ok := index[c.AgentType]
+		if !ok {
+			i = len(status.Types)
+			index[c.AgentType] = i
+			ts := WorkerTypeStatus{Type: c.AgentType, Autoscaled: c.Autoscaled}
+			if c.Autoscaled {
+				ts.MinInstances = c.MinInstances
+				ts.IdleTimeout = c.IdleTimeout
+			}
+			status.Types = append(status.Types, ts)
+		}
+		status.Types[i].Instances++
+		if _, ok := m.active[c.InstanceID]; ok {
+			status.Types[i].Active++
+		}
+	}
+	return status
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestScaleTarget(t *testing.T) {
+	tests := []struct {
+		name                                                       string
+		minInst, maxInst, maxTasks, running, free, pending, unread int
+		want                                                       int
+	}{
+		{"no work keeps running", 0, 4, 1, 2, 2, 0, 0, 2},
+		{"first task starts one", 0, 4, 1, 0, 0, 1, 0, 1},
+		{"message starts one", 0, 4, 1, 0, 0, 0, 1, 1},
+		{"min instances on any work", 2, 4, 1, 0, 0, 1, 0, 2},
+		{"free slots absorb queue", 0, 4, 1, 2, 2, 2, 0, 2},
+		{"queue beyond free slots", 0, 4, 1, 1, 0, 3, 0, 4},
+		{"capped at max", 0, 3, 1, 1, 0, 10, 0, 3},
+		{"multi-slot instances", 0, 4, 3, 1, 1, 5, 0, 3},
+	}
+	for _, tc := range tests {
+		got := scaleTarget(tc.minInst, tc.maxInst, tc.maxTasks, tc.running, tc.free, tc.pending, tc.unread)
+		if got != tc.want {
+			t.Errorf("%s: scaleTarget = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func autoscaleTestManager(state *domain.CollabState, workers ...policy.WorkerConfig) *WorkerManager {
+	orch := &policy.OrchestrationConfig{Driver: "cursor", Workers: workers, MaxWorkers: 3}
+	repo := &notifierTestRepo{state: state}
+	return NewWorkerManager(orch, func() string { return "" }, repo, nil, "/tmp", log.New(io.Discard, "", 0))
+}
+
+func TestNewWorkerManager_Autoscaling(t *testing.T) {
+	wm := autoscaleTestManager(domain.NewCollabState(),
+		policy.WorkerConfig{Type: "claude-code", MinInstances: 1, MaxInstances: 3, Command: []string{"claude"}},
+		policy.WorkerConfig{Type: "codex", Instances: 1, Command: []string{"codex"}},
+	)
+	if len(wm.configs) != 4 {
+		t.Fatalf("configs = %d, want 4 (3 autoscaled + 1 fixed)", len(wm.configs))
+	}
+	c := wm.configs[2]
+	if c.InstanceID != "claude-code-3" || !c.Autoscaled || c.IdleTimeout != defaultIdleTimeout || c.MaxTasks != 1 {
+		t.Errorf("autoscaled config = %+v", c)
+	}
+	if wm.configs[3].Autoscaled {
+		t.Error("fixed worker type should not be autoscaled")
+	}
+
+	wm.setActive("claude-code-1", true)
+	pool := wm.PoolStatus()
+	if pool.MaxWorkers != 3 || pool.Active != 1 || len(pool.Types) != 2 {
+		t.Fatalf("pool = %+v", pool)
+	}
+	if ct := pool.Types[0]; ct.Type != "claude-code" || ct.Instances != 3 || ct.MinInstances != 1 || ct.Active != 1 {
+		t.Errorf("claude-code status = %+v", ct)
+	}
+}
+
+func TestScaleBudgets(t *testing.T) {
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "in_progress", AssignedTo: "claude-code-1"},
+		{ID: 2, Status: "pending", AssignedTo: "any"},
+		{ID: 3, Status: "pending", AssignedTo: "any"},
+	}
+	wm := autoscaleTestManager(state,
+		policy.WorkerConfig{Type: "claude-code", MaxInstances: 4, Command: []string{"claude"}},
+	)
+	wm.setActive("claude-code-1", true)
+
+	budget := wm.scaleBudgets(QueriesFor(wm.repo), nil, map[string]int{"claude-code": 2})
+	if budget["claude-code"] != 2 {
+		t.Errorf("budget = %d, want 2 (busy instance leaves no free slot)", budget["claude-code"])
+	}
+}
+
+func TestReapIdle(t *testing.T) {
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{{ID: 1, Status: "in_progress", AssignedTo: "claude-code-2"}}
+	wm := autoscaleTestManager(state,
+		policy.WorkerConfig{Type: "claude-code", MinInstances: 1, MaxInstances: 3, IdleTimeoutSeconds: 60, Command: []string{"claude"}},
+	)
+	now := time.Now()
+	cancelled := make(map[string]bool)
+	for _, id := range []string{"claude-code-1", "claude-code-2", "claude-code-3"} {
+		wm.setActive(id, true)
+		wm.runningWorkers[id] = func() { cancelled[id] = true }
+		wm.processActivity[id] = &ProcessInfo{InstanceID: id, LastOutputAt: now.Add(-2 * time.Minute)}
+	}
+	wm.processActivity["claude-code-1"].LastOutputAt = now.Add(-10 * time.Second)
+
+	stopped := wm.reapIdle(now)
+	// claude-code-2 has a task in progress; claude-code-1 produced output recently.
+	if len(stopped) != 1 || stopped[0] != "claude-code-3" || !cancelled["claude-code-3"] {
+		t.Fatalf("stopped = %v, cancelled = %v", stopped, cancelled)
+	}
+	if !wm.scaledDown["claude-code-3"] {
+		t.Error("reaped instance should be marked scaled down so it is not retried")
+	}
+	if cancelled["claude-code-1"] || cancelled["claude-code-2"] {
+		t.Errorf("cancelled = %v, want only claude-code-3", cancelled)
+	}
+
+	// With claude-code-3 gone and claude-code-2 done, claude-code-1 is the
+	// minimum instance and keeps running however long it idles.
+	wm.setActive("claude-code-2", false)
+	wm.setActive("claude-code-3", false)
+	if stopped := wm.reapIdle(now.Add(time.Hour)); len(stopped) != 0 {
+		t.Errorf("stopped = %v, want none at min_instances", stopped)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// AgentTokenPrefix marks bearer tokens issued by AgentCredentials. Bearer
+// tokens without it (e.g. from the mock OAuth flow) carry no identity.
+const AgentTokenPrefix = "swk_"
+
+// WorkerTokenEnv is the environment variable that carries a spawned worker's
+// token. Worker CLIs send it as "Authorization: Bearer $STRINGWORK_TOKEN".
+const WorkerTokenEnv = "STRINGWORK_TOKEN"
+
+// AgentCredentials issues per-agent bearer tokens and resolves them back to
+// agent names. Tokens live in memory only: they are valid for the lifetime of
+// the server process that spawned the workers holding them.
+type AgentCredentials struct {
+	mu      sync.RWMutex
+	tokens  map[string]string   // token → agent
+	current map[string]string   // agent → current token ("" when protected without one)
+	aliases map[string][]string // agent → other names it may act as (its agent type)
+}
+
+// NewAgentCredentials creates an empty credential store.
+func NewAgentCredentials() *AgentCredentials {
+	return &AgentCredentials{
+		tokens:  make(map[string]string),
+		current: make(map[string]string),
+		aliases: make(map[string][]string),
+	}
+}
+
+// Protect reserves agent and its aliases without issuing a token, so nobody
+// can act under those names until the agent itself authenticates. aliases are
+// further names the agent may use in tool arguments, e.g. the agent type
+// "claude-code" for instance "claude-code-2".
+func (c *AgentCredentials) Protect(agent string, aliases ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.current[agent]; !ok {
+		c.current[agent] = ""
+	}
+	c.aliases[agent] = aliases
+}
+
+// Issue creates a new token for agent, revoking any token issued before, and
+// protects the agent's names like Protect.
+func (c *AgentCredentials) Issue(agent string, aliases ...string) string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	token := AgentTokenPrefix + hex.EncodeToString(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old := c.current[agent]; old != "" {
+		delete(c.tokens, old)
+	}
+	c.tokens[token] = agent
+	c.current[agent] = token
+	c.aliases[agent] = aliases
+	return token
+}
+
+// Authenticate returns the agent a token was issued to.
+func (c *AgentCredentials) Authenticate(token string) (string, bool) {
+	if !strings.HasPrefix(token, AgentTokenPrefix) {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	agent, ok := c.tokens[token]
+	return agent, ok
+}
+
+// Revoke invalidates the agent's token. Its name stays protected.
+func (c *AgentCredentials) Revoke(agent string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token := c.current[agent]; token != "" {
+		delete(c.tokens, token)
+		c.current[agent] = ""
+	}
+}
+
+// Protected reports whether name belongs to an agent that has been issued
+// credentials (directly or as an alias). Unauthenticated callers may not act
+// under a protected name.
+func (c *AgentCredentials) Protected(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if _, ok := c.current[name]; ok {
+		return true
+	}
+	for _, aliases := range c.aliases {
+		for _, a := range aliases {
+			if a == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Names returns the names agent may act under: itself and its aliases.
+func (c *AgentCredentials) Names(agent string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string{agent}, c.aliases[agent]...)
+}
+
+// Allows reports whether the authenticated agent may act under name: its own
+// name or one of its aliases.
+func (c *AgentCredentials) Allows(agent, name string) bool {
+	if agent == name {
+		return true
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, a := range c.aliases[agent] {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
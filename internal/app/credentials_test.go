@@ -0,0 +1,47 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAgentCredentials(t *testing.T) {
+	c := NewAgentCredentials()
+	if c.Protected("claude-code-1") {
+		t.Fatal("unknown agent should not be protected")
+	}
+
+	c.Protect("cursor")
+	if !c.Protected("cursor") {
+		t.Error("Protect should reserve the name")
+	}
+	if _, ok := c.Authenticate(""); ok {
+		t.Error("empty token authenticated")
+	}
+
+	tok := c.Issue("claude-code-1", "claude-code")
+	if !strings.HasPrefix(tok, AgentTokenPrefix) {
+		t.Errorf("token %q lacks prefix %q", tok, AgentTokenPrefix)
+	}
+	if agent, ok := c.Authenticate(tok); !ok || agent != "claude-code-1" {
+		t.Errorf("Authenticate = %q, %v", agent, ok)
+	}
+	if !c.Protected("claude-code") || !c.Allows("claude-code-1", "claude-code") {
+		t.Error("alias should be protected and allowed for its agent")
+	}
+	if c.Allows("claude-code-1", "cursor") {
+		t.Error("agent may not act as another agent")
+	}
+
+	next := c.Issue("claude-code-1", "claude-code")
+	if _, ok := c.Authenticate(tok); ok {
+		t.Error("re-issuing should revoke the previous token")
+	}
+	c.Revoke("claude-code-1")
+	if _, ok := c.Authenticate(next); ok {
+		t.Error("revoked token still authenticates")
+	}
+	if !c.Protected("claude-code-1") {
+		t.Error("revoking should keep the name protected")
+	}
+}
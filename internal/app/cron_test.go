@@ -0,0 +1,77 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday 2026-01-14 10:17:30 UTC.
+	from := time.Date(2026, 1, 14, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"* * * * *", "2026-01-14T10:18:00Z"},
+		{"*/15 * * * *", "2026-01-14T10:30:00Z"},
+		{"0 3 * * *", "2026-01-15T03:00:00Z"},
+		{"30 10 * * *", "2026-01-14T10:30:00Z"},
+		{"0 9-17/4 * * *", "2026-01-14T13:00:00Z"},
+		{"0 0 * * 1-5", "2026-01-15T00:00:00Z"},
+		{"0 0 * * 0", "2026-01-18T00:00:00Z"},
+		{"0 0 * * 7", "2026-01-18T00:00:00Z"},
+		{"0 0 1,15 * *", "2026-01-15T00:00:00Z"},
+		{"0 0 1 * 5", "2026-01-16T00:00:00Z"}, // day-of-month OR day-of-week
+		{"0 0 29 2 *", "2028-02-29T00:00:00Z"},
+		{"5/20 * * * *", "2026-01-14T10:25:00Z"},
+		{"@hourly", "2026-01-14T11:00:00Z"},
+		{"@daily", "2026-01-15T00:00:00Z"},
+		{"@weekly", "2026-01-18T00:00:00Z"},
+		{"@monthly", "2026-02-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from).Format(time.RFC3339); got != tt.want {
+			t.Errorf("%q: next = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNext_NeverRuns(t *testing.T) {
+	s, err := ParseSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("next = %v, want zero", next)
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	tests := []struct {
+		spec, wantErr string
+	}{
+		{"", "want 5 fields"},
+		{"* * * *", "want 5 fields"},
+		{"60 * * * *", "minute"},
+		{"* 24 * * *", "hour"},
+		{"* * 0 * *", "day-of-month"},
+		{"* * * 13 *", "month"},
+		{"* * * * 8", "day-of-week"},
+		{"*/0 * * * *", "bad step"},
+		{"5-1 * * * *", "bad range"},
+		{"x * * * *", "bad value"},
+		{"@often", "want 5 fields"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSchedule(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%q: error = %v, want %q", tt.spec, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Task dependencies form a DAG. A pending task with open dependencies is held
+// in the "waiting" status, where claim_next and the orchestrator skip it;
+// SyncDependencies moves it back to "pending" once its last dependency has
+// completed.
+
+// What cancelling a task does to the tasks that depend on it.
+const (
+	DependentsCascade = "cascade" // cancel all unfinished dependents, transitively
+	DependentsOrphan  = "orphan"  // drop the dependency so dependents can proceed
+)
+
+// taskIndex maps task IDs to the live tasks in state.
+func taskIndex(state *domain.CollabState) map[int]*domain.Task {
+	idx := make(map[int]*domain.Task, len(state.Tasks))
+	for i := range state.Tasks {
+		idx[state.Tasks[i].ID] = &state.Tasks[i]
+	}
+	return idx
+}
+
+// ValidateDependencies checks that deps may be dependencies of task taskID:
+// every dependency must exist and none may depend on taskID, directly or
+// transitively. taskID need not exist yet (create_task).
+func ValidateDependencies(state *domain.CollabState, taskID int, deps []int) error {
+	idx := taskIndex(state)
+	for _, dep := range deps {
+		if dep == taskID {
+			return fmt.Errorf("task cannot depend on itself")
+		}
+		if idx[dep] == nil {
+			return fmt.Errorf("dependency task #%d not found", dep)
+		}
+		if path := dependencyPath(idx, dep, taskID); path != nil {
+			return fmt.Errorf("dependency cycle: %s", formatTaskPath(append([]int{taskID}, path...)))
+		}
+	}
+	return nil
+}
+
+// dependencyPath returns the chain of task IDs from `from` to `to` following
+// dependency edges, or nil if `to` is not reachable.
+func dependencyPath(idx map[int]*domain.Task, from, to int) []int {
+	seen := make(map[int]bool)
+	var walk func(id int) []int
+	walk = func(id int) []int {
+		if id == to {
+			return []int{id}
+		}
+		if seen[id] || idx[id] == nil {
+			return nil
+		}
+		seen[id] = true
+		for _, dep := range idx[id].Dependencies {
+			if path := walk(dep); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+		return nil
+	}
+	return walk(from)
+}
+
+func formatTaskPath(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("#%d", id)
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// OpenDependencies returns the dependencies of task that have not completed.
+// A dependency no longer in the live state counts as satisfied only when it
+// was archived as completed (see CollabState.ArchivedDependencies).
+func OpenDependencies(state *domain.CollabState, task *domain.Task) []int {
+	if len(task.Dependencies) == 0 {
+		return nil
+	}
+	idx := taskIndex(state)
+	var open []int
+	for _, dep := range task.Dependencies {
+		if t := idx[dep]; t != nil {
+			if t.Status != "completed" {
+				open = append(open, dep)
+			}
+		} else if state.ArchivedDependencies[dep] != "completed" {
+			open = append(open, dep)
+		}
+	}
+	return open
+}
+
+// MissingDependencies returns the IDs, in order, that live tasks depend on
+// but that are not in the live state. Repositories look these up in their
+// archive to fill CollabState.ArchivedDependencies.
+func MissingDependencies(state *domain.CollabState) []int {
+	idx := taskIndex(state)
+	seen := make(map[int]bool)
+	var out []int
+	for _, t := range state.Tasks {
+		for _, dep := range t.Dependencies {
+			if idx[dep] == nil && !seen[dep] {
+				seen[dep] = true
+				out = append(out, dep)
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// SyncDependencies moves pending tasks with open dependencies to "waiting" and
+// waiting tasks whose dependencies have all completed back to "pending".
+// Returns the IDs of the tasks released to pending. CollabService.Run calls it
+// after every mutation, so status changes are journaled like any other.
+func SyncDependencies(state *domain.CollabState, now time.Time) []int {
+	var released []int
+	for i := range state.Tasks {
+		t := &state.Tasks[i]
+		if t.Status != "pending" && t.Status != "waiting" {
+			continue
+		}
+		waiting := len(OpenDependencies(state, t)) > 0
+		switch {
+		case waiting && t.Status == "pending":
+			t.Status = "waiting"
+		case !waiting && t.Status == "waiting":
+			t.Status = "pending"
+			released = append(released, t.ID)
+		default:
+			continue
+		}
+		t.UpdatedAt = now
+	}
+	return released
+}
+
+// Dependents returns the IDs of tasks that depend on taskID, in ID order;
+// transitive also includes their dependents in turn.
+func Dependents(state *domain.CollabState, taskID int, transitive bool) []int {
+	var out []int
+	seen := map[int]bool{taskID: true}
+	queue := []int{taskID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, t := range state.Tasks {
+			if seen[t.ID] || !slices.Contains(t.Dependencies, id) {
+				continue
+			}
+			seen[t.ID] = true
+			out = append(out, t.ID)
+			if transitive {
+				queue = append(queue, t.ID)
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ResolveDependents applies mode (DependentsCascade or DependentsOrphan) to the
+// dependents of the cancelled task taskID and returns the IDs it changed.
+// Cascade cancels every unfinished transitive dependent and frees it from its
+// worker instance; orphan removes taskID from the direct dependents'
+// dependencies, and SyncDependencies then releases those with none left open.
+func ResolveDependents(state *domain.CollabState, taskID int, mode string, now time.Time) ([]int, error) {
+	idx := taskIndex(state)
+	var changed []int
+	switch mode {
+	case DependentsCascade:
+		for _, id := range Dependents(state, taskID, true) {
+			t := idx[id]
+			if t.Status == "completed" || t.Status == "cancelled" {
+				continue
+			}
+			t.Status = "cancelled"
+			t.UpdatedAt = now
+			releaseInstanceTask(state, id)
+			changed = append(changed, id)
+		}
+	case DependentsOrphan:
+		for _, id := range Dependents(state, taskID, false) {
+			t := idx[id]
+			t.Dependencies = slices.DeleteFunc(t.Dependencies, func(d int) bool { return d == taskID })
+			t.UpdatedAt = now
+			changed = append(changed, id)
+		}
+	default:
+		return nil, fmt.Errorf("invalid dependents mode %q (want %q or %q)", mode, DependentsCascade, DependentsOrphan)
+	}
+	return changed, nil
+}
+
+// releaseInstanceTask removes taskID from every instance's CurrentTasks.
+func releaseInstanceTask(state *domain.CollabState, taskID int) {
+	for _, inst := range state.AgentInstances {
+		if inst == nil || !slices.Contains(inst.CurrentTasks, taskID) {
+			continue
+		}
+		inst.CurrentTasks = slices.DeleteFunc(inst.CurrentTasks, func(id int) bool { return id == taskID })
+		if len(inst.CurrentTasks) == 0 && inst.Status == "busy" {
+			inst.Status = "idle"
+		}
+	}
+}
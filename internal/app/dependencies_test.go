@@ -0,0 +1,149 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func dependencyState() *domain.CollabState {
+	state := domain.NewCollabState()
+	// 1 <- 2 <- 3, 1 <- 4
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "in_progress", AssignedTo: "claude-code"},
+		{ID: 2, Status: "pending", Dependencies: []int{1}},
+		{ID: 3, Status: "pending", Dependencies: []int{2}},
+		{ID: 4, Status: "in_progress", Dependencies: []int{1}, AssignedTo: "codex"},
+		{ID: 5, Status: "completed"},
+	}
+	state.AgentInstances["codex"] = &domain.AgentInstance{InstanceID: "codex", Status: "busy", CurrentTasks: []int{4}}
+	return state
+}
+
+func TestValidateDependencies(t *testing.T) {
+	state := dependencyState()
+	tests := []struct {
+		task    int
+		deps    []int
+		wantErr string
+	}{
+		{task: 6, deps: []int{1, 5}},
+		{task: 3, deps: []int{5}},
+		{task: 6, deps: []int{9}, wantErr: "#9 not found"},
+		{task: 2, deps: []int{2}, wantErr: "itself"},
+		{task: 1, deps: []int{3}, wantErr: "dependency cycle: #1 -> #3 -> #2 -> #1"},
+		{task: 2, deps: []int{4}},
+	}
+	for _, tt := range tests {
+		err := ValidateDependencies(state, tt.task, tt.deps)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("task %d deps %v: unexpected error %v", tt.task, tt.deps, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("task %d deps %v: error = %v, want %q", tt.task, tt.deps, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSyncDependencies(t *testing.T) {
+	state := dependencyState()
+	now := time.Now()
+
+	if released := SyncDependencies(state, now); len(released) != 0 {
+		t.Errorf("released = %v, want none", released)
+	}
+	for _, id := range []int{2, 3} {
+		if got := state.Tasks[id-1].Status; got != "waiting" {
+			t.Errorf("task %d status = %q, want waiting", id, got)
+		}
+	}
+	if got := state.Tasks[3].Status; got != "in_progress" {
+		t.Errorf("in-progress task status = %q, want unchanged", got)
+	}
+
+	state.Tasks[0].Status = "completed"
+	if released := SyncDependencies(state, now); !slices.Equal(released, []int{2}) {
+		t.Errorf("released = %v, want [2]", released)
+	}
+	if state.Tasks[2].Status != "waiting" {
+		t.Errorf("task 3 status = %q, want waiting (task 2 still open)", state.Tasks[2].Status)
+	}
+
+	// A dependency that left the live state counts as satisfied only when
+	// the archive says it completed.
+	state.Tasks = slices.Delete(state.Tasks, 1, 2)
+	if released := SyncDependencies(state, now); len(released) != 0 {
+		t.Errorf("released = %v, want none (dependency not in the archive)", released)
+	}
+	state.ArchivedDependencies = map[int]string{2: "cancelled"}
+	if released := SyncDependencies(state, now); len(released) != 0 {
+		t.Errorf("released = %v, want none (dependency archived as cancelled)", released)
+	}
+	state.ArchivedDependencies[2] = "completed"
+	if released := SyncDependencies(state, now); !slices.Equal(released, []int{3}) {
+		t.Errorf("released = %v, want [3]", released)
+	}
+}
+
+func TestArchiveTasks_KeepsDependentsWaiting(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "cancelled", UpdatedAt: now.Add(-72 * time.Hour)},
+		{ID: 2, Status: "waiting", Dependencies: []int{1}},
+	}
+	if n := ArchiveTasks(state, 1, now); n != 1 {
+		t.Fatalf("ArchiveTasks = %d, want 1", n)
+	}
+	if released := SyncDependencies(state, now); len(released) != 0 || state.Tasks[0].Status != "waiting" {
+		t.Errorf("released = %v, task 2 = %q; want it still waiting on the archived cancelled task", released, state.Tasks[0].Status)
+	}
+	if got := MissingDependencies(state); !slices.Equal(got, []int{1}) {
+		t.Errorf("MissingDependencies = %v, want [1]", got)
+	}
+}
+
+func TestResolveDependents(t *testing.T) {
+	now := time.Now()
+
+	state := dependencyState()
+	state.Tasks[0].Status = "cancelled"
+	changed, err := ResolveDependents(state, 1, DependentsCascade, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changed, []int{2, 3, 4}) {
+		t.Errorf("cascade changed %v, want [2 3 4]", changed)
+	}
+	for _, task := range state.Tasks[:4] {
+		if task.Status != "cancelled" {
+			t.Errorf("task %d status = %q, want cancelled", task.ID, task.Status)
+		}
+	}
+	if inst := state.AgentInstances["codex"]; len(inst.CurrentTasks) != 0 || inst.Status != "idle" {
+		t.Errorf("codex instance = %+v, want idle with no tasks", inst)
+	}
+
+	state = dependencyState()
+	state.Tasks[0].Status = "cancelled"
+	changed, err = ResolveDependents(state, 1, DependentsOrphan, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(changed, []int{2, 4}) {
+		t.Errorf("orphan changed %v, want [2 4]", changed)
+	}
+	if len(state.Tasks[1].Dependencies) != 0 || !slices.Equal(state.Tasks[2].Dependencies, []int{2}) {
+		t.Errorf("dependencies after orphan: #2 %v, #3 %v", state.Tasks[1].Dependencies, state.Tasks[2].Dependencies)
+	}
+	if released := SyncDependencies(state, now); len(released) != 0 || state.Tasks[1].Status != "pending" {
+		t.Errorf("task 2 status = %q after orphan, want pending", state.Tasks[1].Status)
+	}
+
+	if _, err := ResolveDependents(state, 1, "ignore", now); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,289 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// SessionExportFormat is the version of the SessionExport layout written by
+// ExportSession. ImportSession refuses files from a newer format. Format 2
+// added artifacts.
+const SessionExportFormat = 2
+
+// SessionExport is a portable collaboration session: the tasks, plans, work
+// contexts, notes, messages and result artifacts of one project (or all of
+// them), as written by `mcp-stringwork export`. Records keep their original IDs; ImportSession
+// renumbers them on the receiving side.
+type SessionExport struct {
+	Format       int                            `json:"format"`
+	ExportedAt   time.Time                      `json:"exported_at"`
+	Project      string                         `json:"project,omitempty"` // empty = all projects
+	Tasks        []domain.Task                  `json:"tasks"`
+	Plans        map[string]*domain.Plan        `json:"plans"`
+	WorkContexts map[string]*domain.WorkContext `json:"work_contexts"`
+	SessionNotes []domain.SessionNote           `json:"session_notes"`
+	Messages     []domain.Message               `json:"messages"`
+	Artifacts    []domain.Artifact              `json:"artifacts,omitempty"` // added by ExportArtifacts
+}
+
+// ExportSession copies the records of project out of state. An empty or
+// AllProjects project exports everything. Otherwise only records tagged with
+// project are exported, plus untagged ones (created before project scoping,
+// visible in every project) if includeUntagged is set. Work contexts are
+// included when their task is.
+func ExportSession(state *domain.CollabState, project string, includeUntagged bool) *SessionExport {
+	if project == AllProjects {
+		project = ""
+	}
+	match := func(recordProject string) bool {
+		return project == "" || recordProject == project || (includeUntagged && recordProject == "")
+	}
+	exp := &SessionExport{
+		Format:       SessionExportFormat,
+		ExportedAt:   time.Now(),
+		Project:      project,
+		Tasks:        []domain.Task{},
+		Plans:        make(map[string]*domain.Plan),
+		WorkContexts: make(map[string]*domain.WorkContext),
+		SessionNotes: []domain.SessionNote{},
+		Messages:     []domain.Message{},
+	}
+	taskIDs := make(map[int]bool)
+	for _, t := range state.Tasks {
+		if match(t.Project) {
+			exp.Tasks = append(exp.Tasks, t)
+			taskIDs[t.ID] = true
+		}
+	}
+	for id, wc := range state.WorkContexts {
+		if wc != nil && (project == "" || taskIDs[wc.TaskID]) {
+			exp.WorkContexts[id] = wc
+		}
+	}
+	for id, p := range state.Plans {
+		if p != nil && match(p.Project) {
+			exp.Plans[id] = p
+		}
+	}
+	for _, n := range state.SessionNotes {
+		if match(n.Project) {
+			exp.SessionNotes = append(exp.SessionNotes, n)
+		}
+	}
+	for _, m := range state.Messages {
+		if match(m.Project) {
+			exp.Messages = append(exp.Messages, m)
+		}
+	}
+	return exp
+}
+
+// ExportArtifacts adds the artifacts attached to the results of the exported
+// tasks to exp. Artifacts live in the repository, not in CollabState, so get
+// reads them (CollabService.Artifact). It returns how many listed artifacts
+// were not found.
+func ExportArtifacts(exp *SessionExport, get func(taskID int, name string) (*domain.Artifact, error)) (missing int, err error) {
+	for _, t := range exp.Tasks {
+		if t.Result == nil {
+			continue
+		}
+		for _, info := range t.Result.Artifacts {
+			a, err := get(t.ID, info.Name)
+			if errors.Is(err, ErrArtifactNotFound) {
+				missing++
+				continue
+			}
+			if err != nil {
+				return missing, fmt.Errorf("artifact %q of task #%d: %w", info.Name, t.ID, err)
+			}
+			exp.Artifacts = append(exp.Artifacts, *a)
+		}
+	}
+	return missing, nil
+}
+
+// ImportSummary reports what ImportSession added.
+type ImportSummary struct {
+	Tasks, Plans, WorkContexts, Notes, Messages, Artifacts int
+	SkippedPlans                                           []string // plan IDs that already existed
+	DroppedVerify                                          int      // tasks whose verify commands were dropped
+}
+
+func (s ImportSummary) String() string {
+	out := fmt.Sprintf("imported %d tasks, %d plans, %d work contexts, %d notes, %d messages, %d artifacts",
+		s.Tasks, s.Plans, s.WorkContexts, s.Notes, s.Messages, s.Artifacts)
+	if len(s.SkippedPlans) > 0 {
+		out += fmt.Sprintf("; skipped existing plans: %s", strings.Join(s.SkippedPlans, ", "))
+	}
+	if s.DroppedVerify > 0 {
+		out += fmt.Sprintf("; dropped the verify commands of %d tasks", s.DroppedVerify)
+	}
+	return out
+}
+
+// ImportSession adds the records of exp to state. Tasks, messages and notes
+// get fresh IDs from the state's counters, and task dependencies and work
+// context links are rewritten to match; dependencies on tasks that were not
+// exported are dropped. Plans whose ID already exists are skipped, and tasks
+// of a plan that was skipped or not exported lose their plan link; an invalid
+// plan ID (see ValidatePlanID) or artifact fails the import before anything is
+// added. Artifacts of tasks that were not exported are dropped; the others are
+// queued in state.PendingArtifacts for the repository to store. If
+// project is set, every project-scoped record is moved to it (e.g. when the
+// workspace lives at a different path on this machine). Task verify commands
+// run as shell commands on this server, so they are dropped unless keepVerify
+// is set.
+func ImportSession(state *domain.CollabState, exp *SessionExport, project string, keepVerify bool) (ImportSummary, error) {
+	var sum ImportSummary
+	if exp.Format > SessionExportFormat {
+		return sum, fmt.Errorf("export format %d is newer than this build supports (%d)", exp.Format, SessionExportFormat)
+	}
+	for id := range exp.Plans {
+		if err := ValidatePlanID(id); err != nil {
+			return sum, err
+		}
+	}
+	for _, a := range exp.Artifacts {
+		if err := ValidateArtifactName(a.Name); err != nil {
+			return sum, fmt.Errorf("artifact of task #%d: %w", a.TaskID, err)
+		}
+		if len(a.Content) > MaxArtifactSize {
+			return sum, fmt.Errorf("artifact %q of task #%d is %d bytes, limit is %d", a.Name, a.TaskID, len(a.Content), MaxArtifactSize)
+		}
+	}
+	EnsureStateMaps(state)
+	retarget := func(p string) string {
+		if p != "" && project != "" {
+			return project
+		}
+		return p
+	}
+
+	taskIDs := make(map[int]int, len(exp.Tasks))
+	for _, t := range exp.Tasks {
+		taskIDs[t.ID] = state.NextTaskID
+		state.NextTaskID++
+	}
+	planIDs := make(map[string]string, len(exp.Plans)) // exported ID -> stored ID
+	for id, p := range exp.Plans {
+		if p == nil {
+			continue
+		}
+		if _, exists := state.Plans[id]; !exists {
+			planIDs[id] = id
+		}
+	}
+	ctxIDs := make(map[string]string, len(exp.WorkContexts))
+	for id, wc := range exp.WorkContexts {
+		if wc == nil {
+			continue
+		}
+		newID := id
+		if _, exists := state.WorkContexts[id]; exists {
+			newID = fmt.Sprintf("ctx-%d-%d", taskIDs[wc.TaskID], time.Now().UnixNano())
+		}
+		ctxIDs[id] = newID
+	}
+
+	for _, t := range exp.Tasks {
+		t.ID = taskIDs[t.ID]
+		deps := t.Dependencies
+		t.Dependencies = nil
+		for _, d := range deps {
+			if nd, ok := taskIDs[d]; ok {
+				t.Dependencies = append(t.Dependencies, nd)
+			}
+		}
+		if t.ParentTaskID != 0 {
+			t.ParentTaskID = taskIDs[t.ParentTaskID] // 0 if the parent was not exported
+		}
+		if t.ScheduledFrom != 0 {
+			t.ScheduledFrom = taskIDs[t.ScheduledFrom]
+		}
+		if t.ContextID != "" {
+			t.ContextID = ctxIDs[t.ContextID]
+		}
+		if t.PlanID != "" {
+			if t.PlanID = planIDs[t.PlanID]; t.PlanID == "" {
+				t.PlanItemID = ""
+			}
+		}
+		if len(t.Verify) > 0 && !keepVerify {
+			t.Verify = nil
+			sum.DroppedVerify++
+		}
+		t.Project = retarget(t.Project)
+		state.Tasks = append(state.Tasks, t)
+		sum.Tasks++
+	}
+
+	ids := make([]string, 0, len(exp.WorkContexts))
+	for id := range ctxIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		wc := *exp.WorkContexts[id]
+		wc.ID = ctxIDs[id]
+		wc.TaskID = taskIDs[wc.TaskID]
+		if wc.ParentCtxID != "" {
+			if np, ok := ctxIDs[wc.ParentCtxID]; ok {
+				wc.ParentCtxID = np
+			}
+		}
+		state.WorkContexts[wc.ID] = &wc
+		sum.WorkContexts++
+	}
+
+	ids = ids[:0]
+	for id := range exp.Plans {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		p := exp.Plans[id]
+		if p == nil {
+			continue
+		}
+		newID, ok := planIDs[id]
+		if !ok {
+			sum.SkippedPlans = append(sum.SkippedPlans, id)
+			continue
+		}
+		plan := *p
+		plan.ID = newID
+		plan.Project = retarget(plan.Project)
+		state.Plans[newID] = &plan
+		sum.Plans++
+	}
+
+	for _, n := range exp.SessionNotes {
+		n.ID = state.NextNoteID
+		state.NextNoteID++
+		n.Project = retarget(n.Project)
+		state.SessionNotes = append(state.SessionNotes, n)
+		sum.Notes++
+	}
+	for _, m := range exp.Messages {
+		m.ID = state.NextMsgID
+		state.NextMsgID++
+		m.Project = retarget(m.Project)
+		state.Messages = append(state.Messages, m)
+		sum.Messages++
+	}
+	for _, a := range exp.Artifacts {
+		newID, ok := taskIDs[a.TaskID]
+		if !ok {
+			continue
+		}
+		a.TaskID = newID
+		state.PendingArtifacts = append(state.PendingArtifacts, a)
+		sum.Artifacts++
+	}
+	return sum, nil
+}
@@ -142,10 +142,7 @@ func EnsureAgentInstances(state *domain.CollabState, orch *policy.OrchestrationC
 			LastHeartbeat: now,
 		}
 		for _, w := range orch.Workers {
-			n := w.Instances
-			if n <= 0 {
-				n = 1
-			}
+			n := w.InstanceCount()
 			maxTasks := w.MaxConcurrentTasks
 			if maxTasks <= 0 {
 				maxTasks = 1
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// ErrNoJournal is returned by CollabService.Events when the repository keeps
+// no event journal.
+var ErrNoJournal = errors.New("event journal not available for this state backend")
+
+// RecordEvent queues an explicit journal entry (e.g. worker_spawned,
+// watchdog_recovered) for the current mutation. Changes visible in the state
+// itself are journaled automatically by CollabService.Run.
+func RecordEvent(state *domain.CollabState, e domain.Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	state.PendingEvents = append(state.PendingEvents, e)
+}
+
+// FilterEvents returns the events matching f, oldest first, keeping the most
+// recent f.Limit. events must be in ID order. It is the reference semantics of
+// EventFilter for journals that keep events in memory.
+func FilterEvents(events []domain.Event, f EventFilter) []domain.Event {
+	var out []domain.Event
+	for _, e := range events {
+		if f.matches(e) {
+			out = append(out, e)
+		}
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+func (f EventFilter) matches(e domain.Event) bool {
+	switch {
+	case f.TaskID != 0 && e.TaskID != f.TaskID:
+		return false
+	case f.Agent != "" && e.Actor != f.Agent && e.Target != f.Agent:
+		return false
+	case len(f.Types) > 0 && !slices.Contains(f.Types, e.Type):
+		return false
+	case !InProject(e.Project, f.Project):
+		return false
+	case !f.Since.IsZero() && e.Timestamp.Before(f.Since):
+		return false
+	case f.AfterID > 0 && e.ID <= f.AfterID:
+		return false
+	}
+	return true
+}
+
+// stateDigest is the part of CollabState that Run compares before and after
+// a mutation to derive journal events.
+type stateDigest struct {
+	nextMsgID  int
+	nextNoteID int
+	tasks      map[int]taskDigest
+	locks      map[string]domain.FileLock // FileLocks key -> lock
+	plans      map[string]map[string]string
+	presence   map[string][2]string // agent -> {status, workspace}
+	instances  map[string]string    // instance ID -> status
+}
+
+type taskDigest struct {
+	status, assignedTo, project string
+}
+
+func digestOf(state *domain.CollabState) stateDigest {
+	d := stateDigest{
+		nextMsgID:  state.NextMsgID,
+		nextNoteID: state.NextNoteID,
+		tasks:      make(map[int]taskDigest, len(state.Tasks)),
+		locks:      make(map[string]domain.FileLock, len(state.FileLocks)),
+		plans:      make(map[string]map[string]string, len(state.Plans)),
+		presence:   make(map[string][2]string, len(state.Presence)),
+		instances:  make(map[string]string, len(state.AgentInstances)),
+	}
+	for _, t := range state.Tasks {
+		d.tasks[t.ID] = taskDigest{t.Status, t.AssignedTo, t.Project}
+	}
+	for key, l := range state.FileLocks {
+		if l != nil {
+			d.locks[key] = *l
+		}
+	}
+	for id, p := range state.Plans {
+		if p == nil {
+			continue
+		}
+		items := make(map[string]string, len(p.Items))
+		for _, it := range p.Items {
+			items[it.ID] = it.Status
+		}
+		d.plans[id] = items
+	}
+	for name, p := range state.Presence {
+		if p != nil {
+			d.presence[name] = [2]string{p.Status, p.Workspace}
+		}
+	}
+	for id, inst := range state.AgentInstances {
+		if inst != nil {
+			d.instances[id] = inst.Status
+		}
+	}
+	return d
+}
+
+// diffEvents returns journal events for the changes between before and state.
+// actor is the agent that made the changes ("" if unknown); it is recorded on
+// task events whose state does not name who acted.
+func diffEvents(before stateDigest, state *domain.CollabState, actor string) []domain.Event {
+	now := time.Now()
+	var events []domain.Event
+	add := func(e domain.Event) {
+		e.Timestamp = now
+		events = append(events, e)
+	}
+
+	seen := make(map[int]bool, len(state.Tasks))
+	for _, t := range state.Tasks {
+		seen[t.ID] = true
+		old, existed := before.tasks[t.ID]
+		switch {
+		case !existed:
+			add(domain.Event{Type: domain.EventTaskCreated, Actor: t.CreatedBy, Target: t.AssignedTo, TaskID: t.ID, Project: t.Project,
+				Data: map[string]string{"title": t.Title, "status": t.Status}})
+		default:
+			if old.status != t.Status {
+				add(domain.Event{Type: domain.EventTaskStatusChanged, Actor: actor, Target: t.AssignedTo, TaskID: t.ID, Project: t.Project,
+					Data: map[string]string{"from": old.status, "to": t.Status}})
+			}
+			if old.assignedTo != t.AssignedTo {
+				add(domain.Event{Type: domain.EventTaskAssigned, Actor: actor, Target: t.AssignedTo, TaskID: t.ID, Project: t.Project,
+					Data: map[string]string{"from": old.assignedTo, "to": t.AssignedTo}})
+			}
+		}
+	}
+	archived := make(map[int]bool, len(state.PendingArchive))
+	for _, a := range state.PendingArchive {
+		archived[a.Task.ID] = true
+	}
+	for id, old := range before.tasks {
+		if !seen[id] {
+			typ := domain.EventTaskRemoved
+			if archived[id] {
+				typ = domain.EventTaskArchived
+			}
+			add(domain.Event{Type: typ, Actor: actor, Target: old.assignedTo, TaskID: id, Project: old.project,
+				Data: map[string]string{"status": old.status}})
+		}
+	}
+
+	first := len(state.Messages)
+	for first > 0 && state.Messages[first-1].ID >= before.nextMsgID {
+		first--
+	}
+	for _, m := range state.Messages[first:] {
+		add(domain.Event{Type: domain.EventMessageSent, Actor: m.From, Target: m.To, Project: m.Project,
+			Data: map[string]string{"message_id": strconv.Itoa(m.ID), "preview": Truncate(m.Content, 120)}})
+	}
+
+	first = len(state.SessionNotes)
+	for first > 0 && state.SessionNotes[first-1].ID >= before.nextNoteID {
+		first--
+	}
+	for _, n := range state.SessionNotes[first:] {
+		add(domain.Event{Type: domain.EventNoteAdded, Actor: n.Author, Project: n.Project,
+			Data: map[string]string{"category": n.Category, "preview": Truncate(n.Content, 120)}})
+	}
+
+	for key, l := range state.FileLocks {
+		if l == nil {
+			continue
+		}
+		if old, ok := before.locks[key]; !ok || old.LockedBy != l.LockedBy {
+			add(domain.Event{Type: domain.EventLockAcquired, Actor: l.LockedBy, Ref: l.Path, Project: l.Project,
+				Data: map[string]string{"reason": l.Reason}})
+		}
+	}
+	for key, old := range before.locks {
+		if l, ok := state.FileLocks[key]; !ok || l == nil || l.LockedBy != old.LockedBy {
+			add(domain.Event{Type: domain.EventLockReleased, Target: old.LockedBy, Ref: old.Path, Project: old.Project})
+		}
+	}
+
+	for id, p := range state.Plans {
+		if p == nil {
+			continue
+		}
+		oldItems, existed := before.plans[id]
+		if !existed {
+			add(domain.Event{Type: domain.EventPlanCreated, Actor: p.CreatedBy, Ref: id, Project: p.Project,
+				Data: map[string]string{"title": p.Title}})
+		}
+		for _, it := range p.Items {
+			if old, ok := oldItems[it.ID]; existed && ok && old != it.Status {
+				add(domain.Event{Type: domain.EventPlanItemStatusChanged, Actor: it.UpdatedBy, Target: it.Owner, Ref: id + "/" + it.ID, Project: p.Project,
+					Data: map[string]string{"from": old, "to": it.Status}})
+			}
+		}
+	}
+
+	for name, p := range state.Presence {
+		if p == nil {
+			continue
+		}
+		if old, ok := before.presence[name]; !ok || old != [2]string{p.Status, p.Workspace} {
+			add(domain.Event{Type: domain.EventPresenceChanged, Actor: name, TaskID: p.CurrentTaskID, Project: ProjectKey(p.Workspace),
+				Data: map[string]string{"status": p.Status, "workspace": p.Workspace}})
+		}
+	}
+
+	for id, inst := range state.AgentInstances {
+		if inst == nil {
+			continue
+		}
+		if old, ok := before.instances[id]; ok && old != inst.Status {
+			add(domain.Event{Type: domain.EventInstanceStatusChanged, Target: id,
+				Data: map[string]string{"from": old, "to": inst.Status}})
+		}
+	}
+	return events
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// journalTestRepo records the events passed to Save.
+type journalTestRepo struct {
+	notifierTestRepo
+	events []domain.Event
+}
+
+func (r *journalTestRepo) Save(state *domain.CollabState) error {
+	r.events = append(r.events, state.PendingEvents...)
+	return r.notifierTestRepo.Save(state)
+}
+
+func (r *journalTestRepo) Events(EventFilter) ([]domain.Event, error) { return r.events, nil }
+
+func eventTypes(events []domain.Event) map[string]int {
+	out := make(map[string]int)
+	for _, e := range events {
+		out[e.Type]++
+	}
+	return out
+}
+
+func TestRun_JournalsStateChanges(t *testing.T) {
+	repo := &journalTestRepo{notifierTestRepo: notifierTestRepo{state: domain.NewCollabState()}}
+	svc := NewCollabService(repo, testPolicy(), log.New(os.Stderr, "[test] ", 0))
+	now := time.Now()
+
+	_ = svc.Run(func(s *domain.CollabState) error {
+		s.Tasks = append(s.Tasks, domain.Task{ID: 1, Title: "T", Status: "pending", AssignedTo: "any", CreatedBy: "cursor", Project: "/p"})
+		s.NextTaskID = 2
+		s.Messages = append(s.Messages, domain.Message{ID: 1, From: "cursor", To: "claude-code", Content: "hi"})
+		s.NextMsgID = 2
+		s.FileLocks[FileLockKey("/p", "/p/a.go")] = &domain.FileLock{Path: "/p/a.go", LockedBy: "cursor", LockedAt: now, Project: "/p"}
+		return nil
+	})
+	got := eventTypes(repo.events)
+	if got[domain.EventTaskCreated] != 1 || got[domain.EventMessageSent] != 1 || got[domain.EventLockAcquired] != 1 {
+		t.Fatalf("first run events = %v", got)
+	}
+	if repo.events[0].Project != "/p" || repo.events[0].Actor != "cursor" {
+		t.Errorf("task_created event = %+v", repo.events[0])
+	}
+
+	repo.events = nil
+	_ = svc.RunAs("claude-code", func(s *domain.CollabState) error {
+		s.Tasks[0].Status = "in_progress"
+		s.Tasks[0].AssignedTo = "claude-code"
+		delete(s.FileLocks, FileLockKey("/p", "/p/a.go"))
+		RecordEvent(s, domain.Event{Type: domain.EventWatchdogRecovered, TaskID: 1})
+		return nil
+	})
+	got = eventTypes(repo.events)
+	if got[domain.EventTaskStatusChanged] != 1 || got[domain.EventTaskAssigned] != 1 ||
+		got[domain.EventLockReleased] != 1 || got[domain.EventWatchdogRecovered] != 1 || len(repo.events) != 4 {
+		t.Fatalf("second run events = %v", got)
+	}
+	for _, e := range repo.events {
+		if e.Type == domain.EventTaskStatusChanged && (e.Data["from"] != "pending" || e.Data["to"] != "in_progress") {
+			t.Errorf("status change data = %v", e.Data)
+		}
+		switch e.Type {
+		case domain.EventTaskStatusChanged, domain.EventTaskAssigned:
+			if e.Actor != "claude-code" {
+				t.Errorf("%s actor = %q, want the agent passed to RunAs", e.Type, e.Actor)
+			}
+		case domain.EventLockReleased:
+			if e.Project != "/p" || e.Target != "cursor" {
+				t.Errorf("lock_released event = %+v", e)
+			}
+		}
+	}
+
+	// Removed tasks keep their project and the acting agent.
+	repo.events = nil
+	_ = svc.RunAs("cursor", func(s *domain.CollabState) error {
+		s.Tasks = nil
+		return nil
+	})
+	if len(repo.events) != 1 || repo.events[0].Type != domain.EventTaskRemoved ||
+		repo.events[0].Project != "/p" || repo.events[0].Actor != "cursor" {
+		t.Errorf("removal events = %+v", repo.events)
+	}
+
+	// No changes, no events; pending events never leak into the next Run.
+	repo.events = nil
+	_ = svc.Run(func(s *domain.CollabState) error { return nil })
+	if len(repo.events) != 0 {
+		t.Errorf("expected no events for a no-op run, got %v", repo.events)
+	}
+
+	if _, err := svc.Events(EventFilter{}); err != nil {
+		t.Errorf("Events: %v", err)
+	}
+	if _, err := testService(nil).Events(EventFilter{}); err != ErrNoJournal {
+		t.Errorf("Events without journal: err = %v, want ErrNoJournal", err)
+	}
+}
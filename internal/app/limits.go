@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+// resourceLimitMarker starts the line runOnce appends to a worker's output
+// when a limit stopped the process; classifyWorkerError looks for it.
+const resourceLimitMarker = "stringwork: resource limit exceeded: "
+
+// limitMarker returns the output line reporting that limit stopped the worker.
+func limitMarker(limit string) string {
+	return "\n" + resourceLimitMarker + limit + "\n"
+}
+
+// describeMemoryLimit and friends name a limit the way acks and logs show it.
+func describeMemoryLimit(l policy.WorkerLimits) string {
+	return fmt.Sprintf("memory (max %d MB)", l.MaxMemoryMB)
+}
+
+func describeProcessLimit(l policy.WorkerLimits) string {
+	return fmt.Sprintf("processes (max %d)", l.MaxProcesses)
+}
+
+func describeOutputLimit(l policy.WorkerLimits) string {
+	return fmt.Sprintf("output (max %d bytes)", l.MaxOutputBytes)
+}
+
+// outputCap passes worker output through until max bytes, then drops the rest
+// and calls onExceed once so the process can be stopped.
+type outputCap struct {
+	w        io.Writer
+	max      int64
+	onExceed func()
+
+	mu       sync.Mutex
+	written  int64
+	exceeded bool
+}
+
+func (c *outputCap) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.exceeded {
+		return len(p), nil
+	}
+	if room := c.max - c.written; int64(len(p)) > room {
+		if room > 0 {
+			_, _ = c.w.Write(p[:room])
+		}
+		c.written = c.max
+		c.exceeded = true
+		c.onExceed()
+		return len(p), nil
+	}
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// Exceeded reports whether the output went over the cap.
+func (c *outputCap) Exceeded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.exceeded
+}
+
+// limitFromOutput recognizes a limit in the output of a worker that hit an
+// rlimit: the kernel refuses the allocation, fork or open rather than killing
+// the process, so only the resulting error message tells.
+func limitFromOutput(lower string) string {
+	switch {
+	case strings.Contains(lower, "too many open files"):
+		return "open files"
+	case strings.Contains(lower, "cannot allocate memory") || strings.Contains(lower, "out of memory"):
+		return "memory"
+	case strings.Contains(lower, "resource temporarily unavailable") && (strings.Contains(lower, "fork") || strings.Contains(lower, "spawn")):
+		return "processes"
+	}
+	return ""
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+const (
+	cgroupRoot   = "/sys/fs/cgroup"
+	serverCgroup = "stringwork-server" // leaf the server moves into, next to its workers
+)
+
+// workerCgroupParent is the cgroup the worker cgroups are created in, once
+// found: the server's original cgroup, which it has left for a leaf.
+var workerCgroupParent struct {
+	sync.Mutex
+	dir string
+}
+
+// processLimiter applies a worker type's OS-level limits to one process.
+// A nil limiter (no limits configured) does nothing.
+type processLimiter struct {
+	limits policy.WorkerLimits
+	logger *log.Logger
+	cgroup string // cgroup v2 directory of the process; "" when rlimits stand in
+	fd     int    // open cgroup directory for clone, -1 when unused
+}
+
+// newProcessLimiter prepares the limits for one spawn of instanceID. Memory,
+// CPU and process limits get a fresh cgroup v2 child of the server's own
+// cgroup (see cgroupParent); when that cannot be created they fall back to
+// rlimits.
+func newProcessLimiter(instanceID string, l *policy.WorkerLimits, logger *log.Logger) *processLimiter {
+	if l == nil {
+		return nil
+	}
+	p := &processLimiter{limits: *l, logger: logger, fd: -1}
+	if l.MaxMemoryMB == 0 && l.CPUShare == 0 && l.MaxProcesses == 0 {
+		return p
+	}
+	dir, err := createWorkerCgroup(instanceID, *l)
+	if err != nil {
+		if l.CPUShare > 0 {
+			logger.Printf("WorkerManager: %s cgroup v2 unavailable (%v) — using rlimits, cpu_share not enforced", instanceID, err)
+		} else {
+			logger.Printf("WorkerManager: %s cgroup v2 unavailable (%v) — using rlimits", instanceID, err)
+		}
+		return p
+	}
+	fd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		logger.Printf("WorkerManager: %s open cgroup %s: %v — using rlimits", instanceID, dir, err)
+		_ = os.Remove(dir)
+		return p
+	}
+	p.cgroup, p.fd = dir, fd
+	return p
+}
+
+// prepare makes the process start inside its cgroup. Call before cmd.Start.
+func (p *processLimiter) prepare(cmd *exec.Cmd) {
+	if p == nil || p.fd < 0 {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = p.fd
+}
+
+// started sets the rlimits of the process. Call right after cmd.Start; the
+// limits are inherited by everything the worker starts from then on.
+func (p *processLimiter) started(pid int) {
+	if p == nil {
+		return
+	}
+	set := func(name string, resource int, v uint64) {
+		if err := unix.Prlimit(pid, resource, &unix.Rlimit{Cur: v, Max: v}, nil); err != nil {
+			p.logger.Printf("WorkerManager: set %s limit of pid %d: %v", name, pid, err)
+		}
+	}
+	if p.limits.MaxOpenFiles > 0 {
+		set("open files", unix.RLIMIT_NOFILE, uint64(p.limits.MaxOpenFiles))
+	}
+	if p.cgroup != "" {
+		return
+	}
+	// RLIMIT_DATA, not RLIMIT_AS: V8 (Node-based CLIs) and Go reserve far
+	// more address space than they use and fail to start under RLIMIT_AS.
+	// RLIMIT_DATA only counts private writable memory, so file and shared
+	// mappings are not bounded.
+	if p.limits.MaxMemoryMB > 0 {
+		set("memory", unix.RLIMIT_DATA, uint64(p.limits.MaxMemoryMB)<<20)
+	}
+	if p.limits.MaxProcesses > 0 {
+		set("process", unix.RLIMIT_NPROC, uint64(p.limits.MaxProcesses))
+	}
+}
+
+// exceeded returns the limit the cgroup recorded stopping the process, or "".
+func (p *processLimiter) exceeded() string {
+	if p == nil || p.cgroup == "" {
+		return ""
+	}
+	if p.limits.MaxMemoryMB > 0 && cgroupEvent(p.cgroup, "memory.events", "oom_kill") > 0 {
+		return describeMemoryLimit(p.limits)
+	}
+	if p.limits.MaxProcesses > 0 && cgroupEvent(p.cgroup, "pids.events", "max") > 0 {
+		return describeProcessLimit(p.limits)
+	}
+	return ""
+}
+
+// release kills whatever the worker left behind in its cgroup and removes it.
+func (p *processLimiter) release() {
+	if p == nil || p.cgroup == "" {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(p.cgroup, "cgroup.kill"), []byte("1"), 0)
+	_ = syscall.Close(p.fd)
+	for i := 0; i < 10; i++ {
+		if err := os.Remove(p.cgroup); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	p.logger.Printf("WorkerManager: could not remove cgroup %s", p.cgroup)
+}
+
+// cgroupParent returns the cgroup to create worker cgroups in: the one the
+// server was started in. Controllers can only be enabled for the children of a
+// cgroup without processes of its own (except the root), so the processes in
+// it — the server and whatever started it inside the same scope — are first
+// moved to the leaf serverCgroup.
+func cgroupParent() (string, error) {
+	workerCgroupParent.Lock()
+	defer workerCgroupParent.Unlock()
+	if workerCgroupParent.dir != "" {
+		return workerCgroupParent.dir, nil
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	rel := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			rel = path
+			break
+		}
+	}
+	base := filepath.Join(cgroupRoot, rel)
+	if rel == "" {
+		return "", fmt.Errorf("not in a cgroup v2 hierarchy")
+	}
+	if _, err := os.Stat(filepath.Join(base, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 not mounted at %s", cgroupRoot)
+	}
+	if rel != "/" {
+		if err := moveToLeaf(base, serverCgroup); err != nil {
+			return "", err
+		}
+	}
+	workerCgroupParent.dir = base
+	return base, nil
+}
+
+// moveToLeaf moves every process of cgroup dir into its child leaf.
+func moveToLeaf(dir, leaf string) error {
+	procs, err := os.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+	pids := strings.Fields(string(procs))
+	if len(pids) == 0 {
+		return nil
+	}
+	if err := os.Mkdir(filepath.Join(dir, leaf), 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+	for _, pid := range pids {
+		if err := os.WriteFile(filepath.Join(dir, leaf, "cgroup.procs"), []byte(pid), 0); err != nil {
+			return fmt.Errorf("move pid %s to %s: %w", pid, leaf, err)
+		}
+	}
+	return nil
+}
+
+// createWorkerCgroup creates a cgroup v2 child of the server's cgroup with the
+// memory, CPU and process limits, enabling the controllers it needs.
+func createWorkerCgroup(instanceID string, l policy.WorkerLimits) (string, error) {
+	base, err := cgroupParent()
+	if err != nil {
+		return "", err
+	}
+
+	var files [][2]string
+	var controllers []string
+	if l.MaxMemoryMB > 0 {
+		controllers = append(controllers, "memory")
+		files = append(files, [2]string{"memory.max", strconv.FormatInt(int64(l.MaxMemoryMB)<<20, 10)})
+	}
+	if l.CPUShare > 0 {
+		const period = 100000
+		controllers = append(controllers, "cpu")
+		files = append(files, [2]string{"cpu.max", fmt.Sprintf("%d %d", max(int(l.CPUShare*period), 1000), period)})
+	}
+	if l.MaxProcesses > 0 {
+		controllers = append(controllers, "pids")
+		files = append(files, [2]string{"pids.max", strconv.Itoa(l.MaxProcesses)})
+	}
+	enabled, _ := os.ReadFile(filepath.Join(base, "cgroup.subtree_control"))
+	for _, c := range controllers {
+		if bytes.Contains(enabled, []byte(c)) {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(base, "cgroup.subtree_control"), []byte("+"+c), 0); err != nil {
+			return "", fmt.Errorf("enable %s controller: %w", c, err)
+		}
+	}
+
+	dir := filepath.Join(base, fmt.Sprintf("stringwork-worker-%s-%d", strings.ReplaceAll(instanceID, "/", "-"), time.Now().UnixNano()))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f[0]), []byte(f[1]), 0); err != nil {
+			_ = os.Remove(dir)
+			return "", fmt.Errorf("set %s: %w", f[0], err)
+		}
+	}
+	return dir, nil
+}
+
+// cgroupEvent returns the counter named key in a cgroup events file.
+func cgroupEvent(dir, file, key string) int {
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if k, v, ok := strings.Cut(sc.Text(), " "); ok && k == key {
+			n, _ := strconv.Atoi(v)
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestProcessLimiter_Rlimits(t *testing.T) {
+	p := newProcessLimiter("test-worker", &policy.WorkerLimits{MaxOpenFiles: 64}, log.New(io.Discard, "", 0))
+	defer p.release()
+
+	// The limit is set just after start, so give it a moment before reading it.
+	cmd := exec.Command("sh", "-c", "sleep 0.3; ulimit -n")
+	p.prepare(cmd)
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sh unavailable: %v", err)
+	}
+	p.started(cmd.Process.Pid)
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "64" {
+		t.Errorf("ulimit -n = %q, want 64", got)
+	}
+	if limit := p.exceeded(); limit != "" {
+		t.Errorf("exceeded = %q, want none", limit)
+	}
+}
+
+func TestProcessLimiter_MemoryRlimit(t *testing.T) {
+	p := newProcessLimiter("test-worker", &policy.WorkerLimits{MaxMemoryMB: 64}, log.New(io.Discard, "", 0))
+	defer p.release()
+	if p.cgroup != "" {
+		t.Skip("cgroup v2 available; the rlimit fallback is not used")
+	}
+
+	cmd := exec.Command("sh", "-c", "sleep 0.3; ulimit -d; ulimit -v")
+	p.prepare(cmd)
+	var out strings.Builder
+	cmd.Stdout = &out
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sh unavailable: %v", err)
+	}
+	p.started(cmd.Process.Pid)
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if got := strings.Fields(out.String()); len(got) != 2 || got[0] != "65536" || got[1] != "unlimited" {
+		t.Errorf("ulimit -d, -v = %q, want the data segment limited and the address space not", got)
+	}
+}
+
+func TestMoveToLeaf(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := moveToLeaf(dir, "server"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "server")); !os.IsNotExist(err) {
+		t.Errorf("leaf created for an empty cgroup: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte("4242\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := moveToLeaf(dir, "server"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "server", "cgroup.procs")); string(got) != "4242" {
+		t.Errorf("leaf cgroup.procs = %q, want the moved pid", got)
+	}
+}
+
+func TestNewProcessLimiter_NilLimits(t *testing.T) {
+	if p := newProcessLimiter("w", nil, log.New(io.Discard, "", 0)); p != nil {
+		t.Errorf("limiter without limits = %+v, want nil", p)
+	}
+}
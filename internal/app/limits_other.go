@@ -0,0 +1,29 @@
+//go:build !linux
+
+package app
+
+import (
+	"log"
+	"os/exec"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+// processLimiter is a no-op outside Linux: only max_output_bytes, which the
+// server enforces itself, applies there.
+type processLimiter struct{}
+
+func newProcessLimiter(instanceID string, l *policy.WorkerLimits, logger *log.Logger) *processLimiter {
+	if l != nil && (l.MaxMemoryMB > 0 || l.CPUShare > 0 || l.MaxProcesses > 0 || l.MaxOpenFiles > 0) {
+		logger.Printf("WorkerManager: %s limits other than max_output_bytes need Linux — not enforced", instanceID)
+	}
+	return nil
+}
+
+func (p *processLimiter) prepare(cmd *exec.Cmd) {}
+
+func (p *processLimiter) started(pid int) {}
+
+func (p *processLimiter) exceeded() string { return "" }
+
+func (p *processLimiter) release() {}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutputCap(t *testing.T) {
+	var buf bytes.Buffer
+	stops := 0
+	c := &outputCap{w: &buf, max: 10, onExceed: func() { stops++ }}
+
+	for _, chunk := range []string{"hello", "world!", "more"} {
+		if n, err := c.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if buf.String() != "helloworld" {
+		t.Errorf("passed through %q, want the first 10 bytes", buf.String())
+	}
+	if !c.Exceeded() || stops != 1 {
+		t.Errorf("exceeded = %v, stops = %d, want true and 1", c.Exceeded(), stops)
+	}
+}
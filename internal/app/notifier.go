@@ -42,6 +42,7 @@ type Notifier struct {
 	pollInterval time.Duration
 
 	spawnChecker SpawnChecker // optional; nil disables auto-spawn
+	workspace    string       // project of an agent without a workspace of its own
 
 	mu            sync.Mutex
 	lastPushedRev string
@@ -70,6 +71,14 @@ func WithWorkerManager(wm *WorkerManager) NotifierOption {
 	}
 }
 
+// WithNotifierWorkspace sets the workspace whose project the connected agent
+// is notified about when it has not set one (typically the WorkspaceRoot).
+func WithNotifierWorkspace(root string) NotifierOption {
+	return func(n *Notifier) {
+		n.workspace = root
+	}
+}
+
 // NewNotifier creates a notifier. getAgent returns the connected agent (e.g. "cursor"); if empty, push is skipped.
 // pushFunc is called with method "notifications/pair_update" and params PairUpdateParams when the agent has unread content.
 func NewNotifier(signalPath string, repo StateRepository, getAgent func() string, pushFunc func(method string, params any) error, logger *log.Logger, opts ...NotifierOption) *Notifier {
@@ -227,23 +236,10 @@ func (n *Notifier) checkAndPush() {
 		return
 	}
 
-	state, err := n.repo.Load()
+	unread, pending, err := AgentBacklog(QueriesFor(n.repo), agent, n.workspace)
 	if err != nil {
 		return
 	}
-
-	unread := 0
-	for _, m := range state.Messages {
-		if (m.To == agent || m.To == "all") && !m.Read {
-			unread++
-		}
-	}
-	pending := 0
-	for _, t := range state.Tasks {
-		if (t.AssignedTo == agent || t.AssignedTo == "any") && t.Status == "pending" {
-			pending++
-		}
-	}
 	if unread == 0 && pending == 0 {
 		n.mu.Lock()
 		n.lastPushedRev = rev
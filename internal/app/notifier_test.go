@@ -132,6 +132,31 @@ func TestNotifier_CheckOnce_PushWhenPendingTasks(t *testing.T) {
 	}
 }
 
+func TestNotifier_CheckOnce_OnlyCountsAgentProject(t *testing.T) {
+	dir := t.TempDir()
+	signalPath := filepath.Join(dir, ".stringwork-notify")
+	_ = TouchNotifySignal(signalPath)
+
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Title: "Here", AssignedTo: "cursor", Status: "pending", Project: "/work/alpha"},
+		{ID: 2, Title: "Elsewhere", AssignedTo: "cursor", Status: "pending", Project: "/work/beta"},
+	}
+	repo := &notifierTestRepo{state: state}
+
+	var pushParams PairUpdateParams
+	pushFunc := func(method string, params any) error {
+		pushParams, _ = params.(PairUpdateParams)
+		return nil
+	}
+	// cursor has no workspace of its own; the notifier's workspace applies.
+	n := NewNotifier(signalPath, repo, func() string { return "cursor" }, pushFunc, nil, WithNotifierWorkspace("/work/alpha"))
+	n.CheckOnce()
+	if pushParams.PendingTasks != 1 {
+		t.Errorf("PendingTasks = %d, want 1 (only /work/alpha)", pushParams.PendingTasks)
+	}
+}
+
 func TestNotifier_CheckOnce_SameRevisionPushedOnce(t *testing.T) {
 	dir := t.TempDir()
 	signalPath := filepath.Join(dir, ".stringwork-notify")
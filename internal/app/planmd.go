@@ -0,0 +1,417 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Plans are exported to and imported from markdown like this:
+//
+//	# Auth feature
+//
+//	- ID: auth
+//	- Goal: JWT auth with rate limiting
+//	- Status: completed
+//
+//	Context paragraphs.
+//
+//	## 1. JWT middleware @claude-code priority:1 depends:2,3 status:in_progress
+//
+//	Description paragraphs.
+//
+//	- Reasoning: Middleware keeps handlers clean
+//	- Acceptance:
+//	  - [ ] Validates signature
+//	  - [ ] 401 on invalid token
+//	- Constraints:
+//	  - No new dependencies
+//	- Blockers:
+//	  - Waiting on key rotation
+//	- Notes:
+//	  - [cursor] RS256 only
+//
+// Each "##" heading is an item: its ID, a period and its title, followed by
+// annotations for the owner, priority (default 2), dependencies and status
+// (default pending). Status is omitted for an active plan, as are empty
+// sections. Acceptance boxes are ticked when the item is completed; an item
+// without a status annotation whose boxes are all ticked imports as
+// completed. Checkboxes directly in an item body count as acceptance criteria
+// and a heading without "ID." gets its position as ID. Other lines are the
+// description (the context, before the first item). Creation and update
+// metadata is not exported.
+
+const defaultPlanItemPriority = 2
+
+var (
+	planItemStatuses = []string{"pending", "in_progress", "completed", "blocked", "cancelled"}
+	planStatuses     = []string{"active", "completed", "archived"}
+
+	mdBullet   = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	mdCheckbox = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	mdField    = regexp.MustCompile(`^([A-Za-z]+):\s*(.*)$`)
+	mdItemID   = regexp.MustCompile(`^(\S+)\.\s+(.+)$`)
+
+	planIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// ValidatePlanID checks that id can name a plan. Plan IDs end up in plan_file
+// paths, so they are limited to letters, digits, '.', '_' and '-' without "..".
+func ValidatePlanID(id string) error {
+	if !planIDPattern.MatchString(id) || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid plan ID %q: use letters, digits, '.', '_' and '-'", id)
+	}
+	return nil
+}
+
+// PlanMarkdown renders plan in the markdown format above.
+func PlanMarkdown(plan *domain.Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", oneLine(plan.Title))
+	fmt.Fprintf(&b, "- ID: %s\n", plan.ID)
+	fmt.Fprintf(&b, "- Goal: %s\n", oneLine(plan.Goal))
+	if plan.Status != "" && plan.Status != "active" {
+		fmt.Fprintf(&b, "- Status: %s\n", plan.Status)
+	}
+	if c := strings.TrimSpace(plan.Context); c != "" {
+		b.WriteString("\n" + c + "\n")
+	}
+	for _, it := range plan.Items {
+		b.WriteString("\n## " + it.ID + ". " + oneLine(it.Title))
+		if it.Owner != "" && it.Owner != "unassigned" {
+			b.WriteString(" @" + it.Owner)
+		}
+		if it.Priority != 0 && it.Priority != defaultPlanItemPriority {
+			fmt.Fprintf(&b, " priority:%d", it.Priority)
+		}
+		if len(it.Dependencies) > 0 {
+			b.WriteString(" depends:" + strings.Join(it.Dependencies, ","))
+		}
+		if it.Status != "" && it.Status != "pending" {
+			b.WriteString(" status:" + it.Status)
+		}
+		b.WriteString("\n")
+		if d := strings.TrimSpace(it.Description); d != "" {
+			b.WriteString("\n" + d + "\n")
+		}
+		var fields strings.Builder
+		if it.Reasoning != "" {
+			fields.WriteString("- Reasoning: " + oneLine(it.Reasoning) + "\n")
+		}
+		box := "[ ] "
+		if it.Status == "completed" {
+			box = "[x] "
+		}
+		writeMarkdownList(&fields, "Acceptance", box, it.Acceptance)
+		writeMarkdownList(&fields, "Constraints", "", it.Constraints)
+		writeMarkdownList(&fields, "Blockers", "", it.Blockers)
+		writeMarkdownList(&fields, "Notes", "", it.Notes)
+		if fields.Len() > 0 {
+			b.WriteString("\n" + fields.String())
+		}
+	}
+	return b.String()
+}
+
+func writeMarkdownList(b *strings.Builder, name, prefix string, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+	b.WriteString("- " + name + ":\n")
+	for _, e := range entries {
+		b.WriteString("  - " + prefix + oneLine(e) + "\n")
+	}
+}
+
+// oneLine joins the lines of s, which must fit on a heading or list line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ParsePlanMarkdown reads a plan in the markdown format above. The plan has
+// no project, creator or timestamps; ImportPlan fills them in.
+func ParsePlanMarkdown(text string) (*domain.Plan, error) {
+	plan := &domain.Plan{Status: "active", Items: []domain.PlanItem{}}
+	var (
+		context  []string // preamble lines that are not metadata
+		item     *domain.PlanItem
+		body     []string
+		list     *[]string // list section the indented lines belong to
+		ticked   int       // acceptance boxes ticked in the current item
+		hasState bool      // current item has a status annotation
+		inFence  bool
+	)
+	finish := func() {
+		if item == nil {
+			return
+		}
+		item.Description = strings.TrimSpace(strings.Join(body, "\n"))
+		if !hasState && len(item.Acceptance) > 0 && ticked == len(item.Acceptance) {
+			item.Status = "completed"
+		}
+		plan.Items = append(plan.Items, *item)
+	}
+
+	for n, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		switch {
+		case inFence || strings.HasPrefix(trimmed, "```"):
+		case plan.Title == "" && strings.HasPrefix(line, "# "):
+			plan.Title = strings.TrimSpace(line[2:])
+			continue
+		case strings.HasPrefix(line, "## "):
+			finish()
+			it, annotated, err := parsePlanItemHeading(strings.TrimSpace(line[3:]), len(plan.Items)+1)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			item, body, list, ticked, hasState = it, nil, nil, 0, annotated
+			continue
+		case item == nil:
+			if m := mdBullet.FindStringSubmatch(line); m != nil {
+				if f := mdField.FindStringSubmatch(m[1]); f != nil {
+					switch strings.ToLower(f[1]) {
+					case "id":
+						plan.ID = strings.TrimSpace(f[2])
+						continue
+					case "goal":
+						plan.Goal = strings.TrimSpace(f[2])
+						continue
+					case "status":
+						plan.Status = strings.TrimSpace(f[2])
+						if !slices.Contains(planStatuses, plan.Status) {
+							return nil, fmt.Errorf("line %d: invalid plan status %q (want %s)", n+1, plan.Status, strings.Join(planStatuses, ", "))
+						}
+						continue
+					}
+				}
+			}
+			context = append(context, line)
+			continue
+		default:
+			indented := line != strings.TrimLeft(line, " \t")
+			m := mdBullet.FindStringSubmatch(trimmed)
+			if m != nil && indented && list != nil {
+				entry := m[1]
+				if c := mdCheckbox.FindStringSubmatch(entry); c != nil {
+					entry = c[2]
+					if c[1] != " " && list == &item.Acceptance {
+						ticked++
+					}
+				}
+				*list = append(*list, strings.TrimSpace(entry))
+				continue
+			}
+			list = nil
+			if m != nil && !indented {
+				if c := mdCheckbox.FindStringSubmatch(m[1]); c != nil {
+					item.Acceptance = append(item.Acceptance, strings.TrimSpace(c[2]))
+					if c[1] != " " {
+						ticked++
+					}
+					continue
+				}
+				if f := mdField.FindStringSubmatch(m[1]); f != nil {
+					value := strings.TrimSpace(f[2])
+					switch strings.ToLower(f[1]) {
+					case "reasoning":
+						item.Reasoning = value
+						continue
+					case "acceptance":
+						list = &item.Acceptance
+					case "constraints":
+						list = &item.Constraints
+					case "blockers":
+						list = &item.Blockers
+					case "notes":
+						list = &item.Notes
+					}
+					if list != nil {
+						if value != "" {
+							*list = append(*list, value)
+						}
+						continue
+					}
+				}
+			}
+		}
+		if item == nil {
+			context = append(context, line)
+		} else {
+			body = append(body, line)
+		}
+	}
+	finish()
+
+	plan.Context = strings.TrimSpace(strings.Join(context, "\n"))
+	if plan.Title == "" {
+		return nil, fmt.Errorf("plan markdown needs a '# Title' heading")
+	}
+	seen := make(map[string]bool, len(plan.Items))
+	for _, it := range plan.Items {
+		if seen[it.ID] {
+			return nil, fmt.Errorf("item %q appears twice", it.ID)
+		}
+		seen[it.ID] = true
+	}
+	for _, it := range plan.Items {
+		for _, dep := range it.Dependencies {
+			if !seen[dep] {
+				return nil, fmt.Errorf("item %q depends on %q, which is not in the plan", it.ID, dep)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// parsePlanItemHeading parses "ID. Title @owner priority:N depends:a,b
+// status:S". pos is the item's position, its ID if the heading has none.
+// annotated reports whether the heading sets the status.
+func parsePlanItemHeading(heading string, pos int) (it *domain.PlanItem, annotated bool, err error) {
+	it = &domain.PlanItem{Status: "pending", Priority: defaultPlanItemPriority, Blockers: []string{}, Notes: []string{}}
+	words := strings.Fields(heading)
+annotations:
+	for len(words) > 0 {
+		w := words[len(words)-1]
+		switch {
+		case strings.HasPrefix(w, "@") && len(w) > 1:
+			it.Owner = w[1:]
+		case strings.HasPrefix(w, "priority:"):
+			p, err := strconv.Atoi(strings.TrimPrefix(w, "priority:"))
+			if err != nil || p < 1 || p > 3 {
+				return nil, false, fmt.Errorf("invalid %q (want priority:1 to priority:3)", w)
+			}
+			it.Priority = p
+		case strings.HasPrefix(w, "depends:"):
+			for _, d := range strings.Split(strings.TrimPrefix(w, "depends:"), ",") {
+				if d = strings.TrimSpace(d); d != "" {
+					it.Dependencies = append(it.Dependencies, d)
+				}
+			}
+		case strings.HasPrefix(w, "status:"):
+			it.Status = strings.TrimPrefix(w, "status:")
+			if !slices.Contains(planItemStatuses, it.Status) {
+				return nil, false, fmt.Errorf("invalid item status %q (want %s)", it.Status, strings.Join(planItemStatuses, ", "))
+			}
+			annotated = true
+		default:
+			break annotations
+		}
+		words = words[:len(words)-1]
+	}
+	rest := strings.Join(words, " ")
+	if m := mdItemID.FindStringSubmatch(rest); m != nil {
+		it.ID, it.Title = m[1], m[2]
+	} else {
+		it.ID, it.Title = strconv.Itoa(pos), rest
+	}
+	if it.Title == "" {
+		return nil, false, fmt.Errorf("item %q has no title", it.ID)
+	}
+	return it, annotated, nil
+}
+
+// ImportPlan adds parsed (from ParsePlanMarkdown) to the state as a plan of
+// project. A plan with the same ID is an error unless replace is set; then
+// its title, goal, context, status and items are replaced by parsed's, items
+// keep their update metadata unless they changed, and items missing from
+// parsed are dropped. Returns the stored plan and whether it is new.
+func ImportPlan(state *domain.CollabState, parsed *domain.Plan, project, by string, replace bool, now time.Time) (*domain.Plan, bool, error) {
+	if parsed.ID == "" || parsed.Goal == "" {
+		return nil, false, fmt.Errorf("plan needs an ID and a goal ('- ID: <id>' and '- Goal: <goal>' under the title)")
+	}
+	if err := ValidatePlanID(parsed.ID); err != nil {
+		return nil, false, err
+	}
+	existing, exists := state.Plans[parsed.ID]
+	if exists && existing != nil && !replace {
+		return nil, false, fmt.Errorf("plan %q already exists (use replace to update it)", parsed.ID)
+	}
+	items := make([]domain.PlanItem, len(parsed.Items))
+	for i, it := range parsed.Items {
+		it.UpdatedBy, it.UpdatedAt = by, now
+		if exists && existing != nil {
+			if old := findPlanItem(state, existing.ID, it.ID); old != nil && samePlanItem(old, &it) {
+				it.UpdatedBy, it.UpdatedAt = old.UpdatedBy, old.UpdatedAt
+			}
+		}
+		items[i] = it
+	}
+	if !exists || existing == nil {
+		plan := *parsed
+		plan.Items = items
+		plan.CreatedBy, plan.CreatedAt, plan.UpdatedAt = by, now, now
+		plan.Project = project
+		state.Plans[plan.ID] = &plan
+		return &plan, true, nil
+	}
+	existing.Title, existing.Goal, existing.Context = parsed.Title, parsed.Goal, parsed.Context
+	existing.Status = parsed.Status
+	existing.Items = items
+	existing.UpdatedAt = now
+	return existing, false, nil
+}
+
+// samePlanItem reports whether a and b have the same exported content.
+func samePlanItem(a, b *domain.PlanItem) bool {
+	norm := func(it *domain.PlanItem) string {
+		return PlanMarkdown(&domain.Plan{Items: []domain.PlanItem{*it}})
+	}
+	return norm(a) == norm(b)
+}
+
+// PlanFilePath returns the file the plan_file pattern keeps plan in: the
+// pattern with {id} replaced by the plan ID, relative to the plan's project.
+// Plans without a project have no file, and neither do plans whose path
+// would leave the project.
+func PlanFilePath(pattern string, plan *domain.Plan) string {
+	if pattern == "" || plan.Project == "" {
+		return ""
+	}
+	path := filepath.Join(plan.Project, strings.ReplaceAll(pattern, "{id}", plan.ID))
+	if rel, err := filepath.Rel(plan.Project, path); err != nil || rel == "." || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return path
+}
+
+// planFile is a plan file to write after a state change is saved.
+type planFile struct {
+	path, content string
+}
+
+// changedPlanFiles renders the plans updated at or after since for pattern.
+func changedPlanFiles(state *domain.CollabState, pattern string, since time.Time) []planFile {
+	var files []planFile
+	for _, p := range state.Plans {
+		if p == nil || p.UpdatedAt.Before(since) {
+			continue
+		}
+		if path := PlanFilePath(pattern, p); path != "" {
+			files = append(files, planFile{path: path, content: PlanMarkdown(p)})
+		}
+	}
+	return files
+}
+
+// writePlanFile writes f unless the file already has its content.
+func writePlanFile(f planFile) error {
+	if old, err := os.ReadFile(f.path); err == nil && string(old) == f.content {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, []byte(f.content), 0o644)
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestPlanMarkdown_RoundTrip(t *testing.T) {
+	plan := &domain.Plan{
+		ID: "auth", Title: "Auth feature", Goal: "JWT auth with rate limiting", Status: "active",
+		Context: "Sessions are going away.\n\nKeep the login page.",
+		Items: []domain.PlanItem{
+			{ID: "1", Title: "JWT middleware", Description: "Validate tokens.\n\n```go\n## not a heading\n```", Status: "completed",
+				Owner: "claude-code", Priority: 1, Reasoning: "Keeps handlers clean",
+				Acceptance: []string{"Validates signature", "401 on invalid token"}, Constraints: []string{"No new deps"},
+				Blockers: []string{}, Notes: []string{"[cursor] RS256 only"}},
+			{ID: "auth-2", Title: "Rate limiter", Status: "blocked", Priority: 2, Dependencies: []string{"1"},
+				Blockers: []string{"Waiting on Redis"}, Notes: []string{}},
+			{ID: "3", Title: "Docs", Status: "pending", Priority: 3, Blockers: []string{}, Notes: []string{}},
+		},
+	}
+	text := PlanMarkdown(plan)
+	for _, want := range []string{
+		"## 1. JWT middleware @claude-code priority:1 status:completed\n",
+		"## auth-2. Rate limiter depends:1 status:blocked\n",
+		"  - [x] Validates signature\n",
+		"- Reasoning: Keeps handlers clean\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, text)
+		}
+	}
+
+	parsed, err := ParsePlanMarkdown(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, plan) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", parsed, plan)
+	}
+	if again := PlanMarkdown(parsed); again != text {
+		t.Errorf("re-export differs:\n%s\nvs\n%s", again, text)
+	}
+}
+
+func TestParsePlanMarkdown_Checklist(t *testing.T) {
+	plan, err := ParsePlanMarkdown(`# Cleanup
+- id: cleanup
+- goal: Remove dead code
+
+## Drop v1 API @codex
+- [x] Routes removed
+- [X] Clients migrated
+
+## Update changelog
+- [ ] Entry added
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Items) != 2 {
+		t.Fatalf("items = %+v", plan.Items)
+	}
+	first, second := plan.Items[0], plan.Items[1]
+	if first.ID != "1" || first.Title != "Drop v1 API" || first.Owner != "codex" || first.Status != "completed" || len(first.Acceptance) != 2 {
+		t.Errorf("first item = %+v", first)
+	}
+	if second.ID != "2" || second.Status != "pending" || second.Acceptance[0] != "Entry added" {
+		t.Errorf("second item = %+v", second)
+	}
+}
+
+func TestParsePlanMarkdown_Errors(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"no title", "- ID: x\n", "Title"},
+		{"bad status", "# T\n## 1. A status:done\n", "invalid item status"},
+		{"bad priority", "# T\n## 1. A priority:9\n", "priority"},
+		{"duplicate", "# T\n## 1. A\n## 1. B\n", "appears twice"},
+		{"unknown dependency", "# T\n## 1. A depends:7\n", "not in the plan"},
+		{"no item title", "# T\n## @codex\n", "no title"},
+	}
+	for _, tt := range tests {
+		if _, err := ParsePlanMarkdown(tt.text); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestImportPlan(t *testing.T) {
+	state := domain.NewCollabState()
+	earlier := time.Now().Add(-time.Hour)
+	now := time.Now()
+	parsed, err := ParsePlanMarkdown("# API\n- ID: api\n- Goal: Ship it\n\n## 1. Store\n\n## 2. Handlers depends:1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, created, err := ImportPlan(state, parsed, "/work/alpha", "cursor", false, earlier)
+	if err != nil || !created || plan.Project != "/work/alpha" || plan.CreatedBy != "cursor" || state.Plans["api"] != plan {
+		t.Fatalf("import = %+v, %v, %v", plan, created, err)
+	}
+	if _, _, err := ImportPlan(state, parsed, "/work/alpha", "cursor", false, now); err == nil {
+		t.Error("expected error importing an existing plan without replace")
+	}
+	for _, id := range []string{"../../etc/cron.d/x", "a/b", "..", "x y"} {
+		bad := *parsed
+		bad.ID = id
+		if _, _, err := ImportPlan(state, &bad, "/work/alpha", "cursor", false, now); err == nil || state.Plans[id] != nil {
+			t.Errorf("import of plan ID %q: err = %v", id, err)
+		}
+	}
+
+	// Replace: item 1 is unchanged and keeps its metadata, item 2 is
+	// updated, item 3 is new.
+	parsed, _ = ParsePlanMarkdown("# API v2\n- ID: api\n- Goal: Ship it\n\n## 1. Store\n\n## 2. Handlers depends:1 status:in_progress\n\n## 3. Docs\n")
+	plan, created, err = ImportPlan(state, parsed, "/elsewhere", "codex", true, now)
+	if err != nil || created || plan.Title != "API v2" || plan.Project != "/work/alpha" || len(plan.Items) != 3 {
+		t.Fatalf("replace = %+v, %v, %v", plan, created, err)
+	}
+	if it := plan.Items[0]; it.UpdatedBy != "cursor" || !it.UpdatedAt.Equal(earlier) {
+		t.Errorf("unchanged item updated by %s at %v", it.UpdatedBy, it.UpdatedAt)
+	}
+	if it := plan.Items[1]; it.UpdatedBy != "codex" || it.Status != "in_progress" {
+		t.Errorf("changed item = %+v", it)
+	}
+}
+
+func TestRun_WritesPlanFile(t *testing.T) {
+	dir := t.TempDir()
+	state := domain.NewCollabState()
+	state.Plans["api"] = &domain.Plan{ID: "api", Title: "API", Goal: "Ship it", Status: "active", Project: dir}
+	state.Plans["old"] = &domain.Plan{ID: "old", Title: "Old", Goal: "Done", Status: "completed", Project: dir}
+	pol := policy.New(&policy.Config{WorkspaceRoot: dir, Orchestration: policy.DefaultOrchestration(), PlanFile: "plans/{id}.md"})
+	svc := NewCollabService(&notifierTestRepo{state: state}, pol, log.New(os.Stderr, "[test] ", 0))
+
+	if err := svc.Run(func(state *domain.CollabState) error {
+		p := state.Plans["api"]
+		p.Items = append(p.Items, domain.PlanItem{ID: "1", Title: "Store", Status: "pending", Priority: 2})
+		p.UpdatedAt = time.Now()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "plans", "api.md"))
+	if err != nil || !strings.Contains(string(data), "## 1. Store\n") {
+		t.Errorf("plan file = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "plans", "old.md")); !os.IsNotExist(err) {
+		t.Errorf("unchanged plan was written: %v", err)
+	}
+}
+
+func TestPlanFilePath(t *testing.T) {
+	tests := []struct {
+		pattern, id, want string
+	}{
+		{"PLAN.md", "api", "/work/alpha/PLAN.md"},
+		{"plans/{id}.md", "api", "/work/alpha/plans/api.md"},
+		{"/etc/{id}", "api", "/work/alpha/etc/api"},
+		{"../{id}.md", "api", ""},
+		{"{id}", "..", ""},
+		{"plans/{id}", "../../../etc/passwd", ""},
+	}
+	for _, tt := range tests {
+		plan := &domain.Plan{ID: tt.id, Project: "/work/alpha"}
+		if got := PlanFilePath(tt.pattern, plan); got != tt.want {
+			t.Errorf("PlanFilePath(%q, %q) = %q, want %q", tt.pattern, tt.id, got, tt.want)
+		}
+	}
+	if got := PlanFilePath("PLAN.md", &domain.Plan{ID: "api"}); got != "" {
+		t.Errorf("plan without project: %q", got)
+	}
+}
@@ -0,0 +1,277 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// ExecutePlan turns plan items into tasks (Task.PlanID and PlanItemID point
+// back at the item). From then on CollabService.Run keeps the two in step:
+// a status change on either side is copied to the other, and a task's
+// assignee becomes its item's owner.
+
+// PlanItemTask returns the live task created for item itemID of plan planID, or nil.
+func PlanItemTask(state *domain.CollabState, planID, itemID string) *domain.Task {
+	for i := range state.Tasks {
+		if t := &state.Tasks[i]; t.PlanID == planID && t.PlanItemID == itemID {
+			return t
+		}
+	}
+	return nil
+}
+
+// PlanExecution reports what ExecutePlan did with each item.
+type PlanExecution struct {
+	Created []PlanItemTaskRef // new tasks, in creation order
+	Skipped []PlanItemTaskRef // items that already have a task (TaskID set) or are finished
+}
+
+// PlanItemTaskRef pairs a plan item with its task.
+type PlanItemTaskRef struct {
+	ItemID string
+	Title  string
+	TaskID int    // 0 if the item has no task
+	Reason string // why a skipped item was skipped
+}
+
+// ExecutePlan creates a task for each item of plan in itemIDs (all items if
+// empty), plus the unfinished items they depend on. Items that already have a
+// task, and completed or cancelled items, are skipped. Item dependencies
+// become task dependencies, the owner becomes the assignee ("any" if none),
+// and the plan's goal, the item's reasoning, constraints and acceptance
+// criteria go into the task's work context. A task starts pending (waiting
+// while dependencies are open), blocked for a blocked item, and in progress
+// for an item its owner is working on. New tasks are appended to state.Tasks.
+func ExecutePlan(state *domain.CollabState, plan *domain.Plan, itemIDs []string, createdBy string, now time.Time) (PlanExecution, error) {
+	items := make(map[string]*domain.PlanItem, len(plan.Items))
+	for i := range plan.Items {
+		items[plan.Items[i].ID] = &plan.Items[i]
+	}
+	for _, id := range itemIDs {
+		if items[id] == nil {
+			return PlanExecution{}, fmt.Errorf("item %q not found in plan %q", id, plan.ID)
+		}
+	}
+	if len(itemIDs) == 0 {
+		for _, it := range plan.Items {
+			itemIDs = append(itemIDs, it.ID)
+		}
+	}
+
+	// Visit items depth-first so that dependencies get their tasks (and
+	// lower IDs) first; a dependency cycle is an error.
+	var exec PlanExecution
+	taskOf := make(map[string]int)
+	const (
+		visiting = 1
+		done     = 2
+	)
+	mark := make(map[string]int)
+	var order []*domain.PlanItem
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		it := items[id]
+		switch mark[id] {
+		case visiting:
+			return fmt.Errorf("plan %q has a dependency cycle: %s", plan.ID, strings.Join(append(path, id), " -> "))
+		case done:
+			return nil
+		}
+		mark[id] = visiting
+		if t := PlanItemTask(state, plan.ID, id); t != nil {
+			taskOf[id] = t.ID
+			exec.Skipped = append(exec.Skipped, PlanItemTaskRef{ItemID: id, Title: it.Title, TaskID: t.ID, Reason: "has a task"})
+		} else if it.Status == "completed" || it.Status == "cancelled" {
+			exec.Skipped = append(exec.Skipped, PlanItemTaskRef{ItemID: id, Title: it.Title, Reason: it.Status})
+		} else {
+			for _, dep := range it.Dependencies {
+				if items[dep] == nil {
+					return fmt.Errorf("item %q depends on %q, which is not in plan %q", id, dep, plan.ID)
+				}
+				if err := visit(dep, append(path, id)); err != nil {
+					return err
+				}
+			}
+			order = append(order, it)
+			taskOf[id] = state.NextTaskID + len(order) - 1
+		}
+		mark[id] = done
+		return nil
+	}
+	for _, id := range itemIDs {
+		if err := visit(id, nil); err != nil {
+			return PlanExecution{}, err
+		}
+	}
+
+	for _, it := range order {
+		t := domain.Task{
+			ID:          state.NextTaskID,
+			Title:       it.Title,
+			Description: it.Description,
+			Status:      "pending",
+			AssignedTo:  it.Owner,
+			CreatedBy:   createdBy,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Priority:    planTaskPriority(it.Priority),
+			Project:     plan.Project,
+			PlanID:      plan.ID,
+			PlanItemID:  it.ID,
+		}
+		state.NextTaskID++
+		if t.AssignedTo == "" || t.AssignedTo == "unassigned" {
+			t.AssignedTo = "any"
+		}
+		for _, dep := range it.Dependencies {
+			if id, ok := taskOf[dep]; ok && !slices.Contains(t.Dependencies, id) {
+				t.Dependencies = append(t.Dependencies, id)
+			}
+		}
+		switch {
+		case it.Status == "blocked":
+			t.Status = "blocked"
+			t.BlockedBy = strings.Join(it.Blockers, "; ")
+			if t.BlockedBy == "" {
+				t.BlockedBy = "plan item blocked"
+			}
+		case len(OpenDependencies(state, &t)) > 0:
+			t.Status = "waiting"
+		case it.Status == "in_progress" && t.AssignedTo != "any":
+			t.Status = "in_progress" // the owner is already on it
+		}
+		t.ContextID = planItemContext(state, plan, it, t.ID, now)
+		state.Tasks = append(state.Tasks, t)
+		exec.Created = append(exec.Created, PlanItemTaskRef{ItemID: it.ID, Title: it.Title, TaskID: t.ID})
+	}
+	if len(order) > 0 {
+		plan.UpdatedAt = now
+	}
+	return exec, nil
+}
+
+// planTaskPriority maps a plan item priority (1-3, 1 = highest) to a task
+// priority (1-4, 1 = critical): plan items are never critical.
+func planTaskPriority(p int) int {
+	if p < 1 || p > 3 {
+		return 3
+	}
+	return p + 1
+}
+
+// planItemContext creates the work context of the task for plan item it.
+func planItemContext(state *domain.CollabState, plan *domain.Plan, it *domain.PlanItem, taskID int, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan %q (%s): %s", plan.ID, plan.Title, plan.Goal)
+	if it.Reasoning != "" {
+		b.WriteString("\n\nReasoning: " + it.Reasoning)
+	}
+	if len(it.Acceptance) > 0 {
+		b.WriteString("\n\nAcceptance criteria:")
+		for _, a := range it.Acceptance {
+			b.WriteString("\n- " + a)
+		}
+	}
+	wc := &domain.WorkContext{
+		ID:          fmt.Sprintf("ctx-%d-%d", taskID, now.UnixNano()),
+		TaskID:      taskID,
+		Background:  b.String(),
+		Constraints: slices.Clone(it.Constraints),
+		SharedNotes: make(map[string]string),
+	}
+	if len(it.Acceptance) > 0 {
+		wc.SharedNotes["acceptance"] = strings.Join(it.Acceptance, "\n")
+	}
+	state.WorkContexts[wc.ID] = wc
+	return wc.ID
+}
+
+// planItemStatus is the plan item status matching task status s.
+func planItemStatus(s string) string {
+	switch s {
+	case "scheduled", "waiting":
+		return "pending"
+	}
+	return s
+}
+
+// syncTasksFromPlanItems copies plan item status changes made in this
+// mutation to the items' tasks. Runs before dependencies are released, so
+// completing an item releases the tasks waiting on its task.
+func syncTasksFromPlanItems(before stateDigest, state *domain.CollabState, now time.Time) {
+	for i := range state.Tasks {
+		t := &state.Tasks[i]
+		if t.PlanItemID == "" {
+			continue
+		}
+		it := findPlanItem(state, t.PlanID, t.PlanItemID)
+		if it == nil || before.plans[t.PlanID][it.ID] == it.Status || planItemStatus(t.Status) == it.Status {
+			continue
+		}
+		switch it.Status {
+		case "pending":
+			t.Status = "pending"
+			if len(OpenDependencies(state, t)) > 0 {
+				t.Status = "waiting"
+			}
+		case "blocked":
+			t.Status = "blocked"
+			if len(it.Blockers) > 0 {
+				t.BlockedBy = strings.Join(it.Blockers, "; ")
+			}
+		case "in_progress":
+			if it.Owner != "" && it.Owner != "unassigned" {
+				t.AssignedTo = it.Owner
+			} else if t.AssignedTo == "any" {
+				continue // nobody to run it; the item goes back to the task's status
+			}
+			t.Status = "in_progress"
+		default: // completed, cancelled
+			t.Status = it.Status
+		}
+		t.UpdatedAt = now
+	}
+}
+
+// syncPlanItemsFromTasks makes each plan item's status and owner match its
+// task. Runs last in a mutation, after item changes were copied to tasks.
+func syncPlanItemsFromTasks(state *domain.CollabState, now time.Time) {
+	for i := range state.Tasks {
+		t := &state.Tasks[i]
+		if t.PlanItemID == "" {
+			continue
+		}
+		it := findPlanItem(state, t.PlanID, t.PlanItemID)
+		if it == nil {
+			continue
+		}
+		status, owner := planItemStatus(t.Status), it.Owner
+		if t.AssignedTo != "any" && t.AssignedTo != "" {
+			owner = t.AssignedTo
+		}
+		if it.Status == status && it.Owner == owner {
+			continue
+		}
+		it.Status, it.Owner = status, owner
+		it.UpdatedBy = fmt.Sprintf("task #%d", t.ID)
+		it.UpdatedAt = now
+		state.Plans[t.PlanID].UpdatedAt = now
+	}
+}
+
+func findPlanItem(state *domain.CollabState, planID, itemID string) *domain.PlanItem {
+	p, ok := state.Plans[planID]
+	if !ok || p == nil {
+		return nil
+	}
+	for i := range p.Items {
+		if p.Items[i].ID == itemID {
+			return &p.Items[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func planFixture() *domain.Plan {
+	return &domain.Plan{
+		ID: "api", Title: "API", Goal: "Ship the public API", Status: "active", Project: "/work/alpha",
+		Items: []domain.PlanItem{
+			{ID: "1", Title: "Schema", Status: "completed", Priority: 1},
+			{ID: "2", Title: "Handlers", Status: "pending", Owner: "claude-code", Priority: 1, Dependencies: []string{"1", "3"},
+				Reasoning: "Handlers need the store", Acceptance: []string{"all routes return JSON"}, Constraints: []string{"no new deps"}},
+			{ID: "3", Title: "Store", Status: "in_progress", Owner: "codex", Priority: 2},
+			{ID: "4", Title: "Docs", Status: "blocked", Blockers: []string{"waiting on review"}, Priority: 3},
+		},
+	}
+}
+
+func TestExecutePlan(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.NextTaskID = 10
+	plan := planFixture()
+	state.Plans[plan.ID] = plan
+
+	exec, err := ExecutePlan(state, plan, []string{"2", "4"}, "cursor", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created []string
+	for _, c := range exec.Created {
+		created = append(created, c.ItemID)
+	}
+	// Item 3 is pulled in as a dependency of 2 and created first.
+	if !slices.Equal(created, []string{"3", "2", "4"}) {
+		t.Fatalf("created items = %v, want [3 2 4]", created)
+	}
+	if len(exec.Skipped) != 1 || exec.Skipped[0].ItemID != "1" || exec.Skipped[0].Reason != "completed" {
+		t.Errorf("skipped = %+v, want item 1 (completed)", exec.Skipped)
+	}
+
+	store, handlers, docs := state.Tasks[0], state.Tasks[1], state.Tasks[2]
+	if store.ID != 10 || store.Status != "in_progress" || store.AssignedTo != "codex" || store.Priority != 3 {
+		t.Errorf("store task = %+v", store)
+	}
+	if handlers.Status != "waiting" || handlers.AssignedTo != "claude-code" || !slices.Equal(handlers.Dependencies, []int{10}) ||
+		handlers.PlanID != "api" || handlers.PlanItemID != "2" || handlers.Project != "/work/alpha" || handlers.Priority != 2 {
+		t.Errorf("handlers task = %+v", handlers)
+	}
+	if docs.Status != "blocked" || docs.BlockedBy != "waiting on review" || docs.AssignedTo != "any" {
+		t.Errorf("docs task = %+v", docs)
+	}
+	wc := state.WorkContexts[handlers.ContextID]
+	if wc == nil || !strings.Contains(wc.Background, "Ship the public API") || !strings.Contains(wc.Background, "Handlers need the store") ||
+		!slices.Equal(wc.Constraints, []string{"no new deps"}) || wc.SharedNotes["acceptance"] != "all routes return JSON" {
+		t.Errorf("handlers work context = %+v", wc)
+	}
+
+	// Running it again only reports the existing tasks.
+	exec, err = ExecutePlan(state, plan, nil, "cursor", now)
+	if err != nil || len(exec.Created) != 0 || len(exec.Skipped) != 4 {
+		t.Errorf("second run = %+v, %v; want nothing created", exec, err)
+	}
+}
+
+func TestExecutePlan_Errors(t *testing.T) {
+	state := domain.NewCollabState()
+	plan := planFixture()
+	if _, err := ExecutePlan(state, plan, []string{"9"}, "cursor", time.Now()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unknown item: error = %v", err)
+	}
+
+	plan.Items[0].Status = "pending"
+	plan.Items[0].Dependencies = []string{"2"}
+	if _, err := ExecutePlan(state, plan, nil, "cursor", time.Now()); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cycle: error = %v", err)
+	}
+	if len(state.Tasks) != 0 {
+		t.Errorf("failed execution created %d tasks", len(state.Tasks))
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	state := domain.NewCollabState()
+	state.NextTaskID = 1
+	plan := planFixture()
+	state.Plans[plan.ID] = plan
+	svc := testService(state)
+
+	if err := svc.Run(func(state *domain.CollabState) error {
+		_, err := ExecutePlan(state, state.Plans["api"], []string{"2"}, "cursor", time.Now())
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store, handlers := &state.Tasks[0], &state.Tasks[1]
+
+	// Completing the item completes its task and releases the dependent task.
+	if err := svc.Run(func(state *domain.CollabState) error {
+		findPlanItem(state, "api", "3").Status = "completed"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if store.Status != "completed" || handlers.Status != "pending" {
+		t.Fatalf("after item completed: store %s, handlers %s; want completed, pending", store.Status, handlers.Status)
+	}
+
+	// Claiming the task moves the item along and makes the claimer its owner.
+	if err := svc.Run(func(state *domain.CollabState) error {
+		state.Tasks[1].Status = "in_progress"
+		state.Tasks[1].AssignedTo = "gemini"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	item := findPlanItem(state, "api", "2")
+	if item.Status != "in_progress" || item.Owner != "gemini" || item.UpdatedBy != "task #2" {
+		t.Errorf("item after task claimed = %s, owner %s, by %s", item.Status, item.Owner, item.UpdatedBy)
+	}
+}
@@ -7,6 +7,7 @@ import "github.com/jaakkos/stringwork/internal/policy"
 type Policy interface {
 	MessageRetentionMax() int
 	MessageRetentionDays() int
+	TaskRetentionDays() int
 	PresenceTTLSeconds() int
 	StateFile() string
 	SignalFilePath() string
@@ -15,4 +16,8 @@ type Policy interface {
 	IsToolEnabled(name string) bool
 	ValidatePath(path string) (string, error)
 	Orchestration() *policy.OrchestrationConfig
+	ToolPermissions(role string, agents ...string) policy.ToolPermissions
+	TaskTemplates() map[string]policy.TaskTemplate
+	Verification() policy.VerificationConfig
+	PlanFile() string
 }
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// AllProjects is the project filter value that disables project scoping.
+const AllProjects = "all"
+
+// ProjectKey normalizes a workspace path into the project identifier stored on
+// tasks, messages, notes, plans and locks. Paths inside a worker worktree
+// (<workspace>/.stringwork/...) map to the owning workspace, so workers and the
+// driver share one project.
+func ProjectKey(workspace string) string {
+	if workspace == "" {
+		return ""
+	}
+	p := filepath.Clean(workspace)
+	sep := string(filepath.Separator)
+	if i := strings.Index(p, sep+".stringwork"+sep); i > 0 {
+		p = p[:i]
+	}
+	return p
+}
+
+// InProject reports whether a record tagged with recordProject is visible in
+// project. Untagged records (created before project scoping) are visible
+// everywhere, and an empty or AllProjects filter matches every record.
+func InProject(recordProject, project string) bool {
+	return recordProject == "" || project == "" || project == AllProjects || recordProject == project
+}
+
+// FileLockKey returns the CollabState.FileLocks key of the lock on path in
+// project. Locks are per project, so two projects may lock the same path;
+// untagged locks are keyed by path alone. NUL cannot occur in either part.
+func FileLockKey(project, path string) string {
+	if project == "" {
+		return path
+	}
+	return project + "\x00" + path
+}
+
+// FindFileLock returns the key and lock on path visible in project (see
+// InProject): the project's own lock, else an untagged one. Without a project
+// filter the lock of any project matches.
+func FindFileLock(state *domain.CollabState, project, path string) (string, *domain.FileLock) {
+	for _, key := range []string{FileLockKey(project, path), path} {
+		if l := state.FileLocks[key]; l != nil && InProject(l.Project, project) {
+			return key, l
+		}
+	}
+	if project == "" || project == AllProjects {
+		for key, l := range state.FileLocks {
+			if l != nil && l.Path == path {
+				return key, l
+			}
+		}
+	}
+	return "", nil
+}
+
+// AgentProject returns the project an agent is working in: its presence
+// workspace, else its registered workspace, else fallback (typically the
+// policy's WorkspaceRoot).
+func AgentProject(state *domain.CollabState, agent, fallback string) string {
+	if state != nil && agent != "" {
+		if p, ok := state.Presence[agent]; ok && p != nil && p.Workspace != "" {
+			return ProjectKey(p.Workspace)
+		}
+		if ra, ok := state.RegisteredAgents[agent]; ok && ra != nil && ra.Workspace != "" {
+			return ProjectKey(ra.Workspace)
+		}
+	}
+	return ProjectKey(fallback)
+}
+
+// ProjectSummary describes one project found in the shared state.
+type ProjectSummary struct {
+	Project      string    `json:"project"`
+	Name         string    `json:"name"`
+	OpenTasks    int       `json:"open_tasks"`
+	Tasks        int       `json:"tasks"`
+	Messages     int       `json:"messages"`
+	Plans        int       `json:"plans"`
+	Agents       []string  `json:"agents,omitempty"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// ListProjects summarizes every project referenced by records or agent
+// workspaces, most recently active first. Untagged records are not counted.
+func ListProjects(state *domain.CollabState) []ProjectSummary {
+	byKey := make(map[string]*ProjectSummary)
+	get := func(key string) *ProjectSummary {
+		ps, ok := byKey[key]
+		if !ok {
+			ps = &ProjectSummary{Project: key, Name: filepath.Base(key)}
+			byKey[key] = ps
+		}
+		return ps
+	}
+	touch := func(ps *ProjectSummary, t time.Time) {
+		if t.After(ps.LastActivity) {
+			ps.LastActivity = t
+		}
+	}
+	for _, t := range state.Tasks {
+		if t.Project == "" {
+			continue
+		}
+		ps := get(t.Project)
+		ps.Tasks++
+		if t.Status != "completed" && t.Status != "cancelled" {
+			ps.OpenTasks++
+		}
+		touch(ps, t.UpdatedAt)
+	}
+	for _, m := range state.Messages {
+		if m.Project == "" {
+			continue
+		}
+		ps := get(m.Project)
+		ps.Messages++
+		touch(ps, m.Timestamp)
+	}
+	for _, p := range state.Plans {
+		if p == nil || p.Project == "" {
+			continue
+		}
+		ps := get(p.Project)
+		ps.Plans++
+		touch(ps, p.UpdatedAt)
+	}
+	for name, p := range state.Presence {
+		if p == nil || p.Workspace == "" {
+			continue
+		}
+		ps := get(ProjectKey(p.Workspace))
+		ps.Agents = append(ps.Agents, name)
+		touch(ps, p.LastSeen)
+	}
+	out := make([]ProjectSummary, 0, len(byKey))
+	for _, ps := range byKey {
+		sort.Strings(ps.Agents)
+		out = append(out, *ps)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].LastActivity.Equal(out[j].LastActivity) {
+			return out[i].LastActivity.After(out[j].LastActivity)
+		}
+		return out[i].Project < out[j].Project
+	})
+	return out
+}
+
+// ActivePlanID returns the active plan for project: the global active plan if
+// it belongs to project, otherwise the most recently updated active plan there.
+func ActivePlanID(state *domain.CollabState, project string) string {
+	if p, ok := state.Plans[state.ActivePlanID]; ok && p != nil && InProject(p.Project, project) {
+		return state.ActivePlanID
+	}
+	var best *domain.Plan
+	for _, p := range state.Plans {
+		if p == nil || p.Status != "active" || p.Project == "" || !InProject(p.Project, project) {
+			continue
+		}
+		if best == nil || p.UpdatedAt.After(best.UpdatedAt) {
+			best = p
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.ID
+}
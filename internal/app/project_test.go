@@ -0,0 +1,83 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestProjectKey(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"/work/alpha", "/work/alpha"},
+		{"/work/alpha/", "/work/alpha"},
+		{"/work/alpha/.stringwork/worktrees/claude-code-1", "/work/alpha"},
+		{"/work/alpha/.stringworkish", "/work/alpha/.stringworkish"},
+	}
+	for _, tc := range tests {
+		if got := ProjectKey(tc.in); got != tc.want {
+			t.Errorf("ProjectKey(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestInProject(t *testing.T) {
+	tests := []struct {
+		record, project string
+		want            bool
+	}{
+		{"", "/a", true},
+		{"/a", "", true},
+		{"/a", AllProjects, true},
+		{"/a", "/a", true},
+		{"/a", "/b", false},
+	}
+	for _, tc := range tests {
+		if got := InProject(tc.record, tc.project); got != tc.want {
+			t.Errorf("InProject(%q, %q) = %v, want %v", tc.record, tc.project, got, tc.want)
+		}
+	}
+}
+
+func TestAgentProject(t *testing.T) {
+	state := domain.NewCollabState()
+	state.Presence["cursor"] = &domain.Presence{Agent: "cursor", Workspace: "/a/"}
+	state.RegisteredAgents["bot"] = &domain.RegisteredAgent{Name: "bot", Workspace: "/b"}
+
+	if got := AgentProject(state, "cursor", "/root"); got != "/a" {
+		t.Errorf("presence workspace: got %q, want /a", got)
+	}
+	if got := AgentProject(state, "bot", "/root"); got != "/b" {
+		t.Errorf("registered workspace: got %q, want /b", got)
+	}
+	if got := AgentProject(state, "codex", "/root"); got != "/root" {
+		t.Errorf("fallback: got %q, want /root", got)
+	}
+}
+
+func TestListProjects(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "pending", UpdatedAt: now.Add(-time.Hour), Project: "/a"},
+		{ID: 2, Status: "completed", UpdatedAt: now.Add(-time.Hour), Project: "/a"},
+		{ID: 3, Status: "pending", UpdatedAt: now},
+	}
+	state.Messages = []domain.Message{{ID: 1, Timestamp: now.Add(-time.Minute), Project: "/b"}}
+	state.Presence["cursor"] = &domain.Presence{Agent: "cursor", Workspace: "/a", LastSeen: now.Add(-2 * time.Hour)}
+
+	got := ListProjects(state)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 projects, got %+v", got)
+	}
+	if got[0].Project != "/b" || got[0].Messages != 1 {
+		t.Errorf("first project = %+v, want /b with 1 message", got[0])
+	}
+	a := got[1]
+	if a.Project != "/a" || a.Name != "a" || a.Tasks != 2 || a.OpenTasks != 1 || len(a.Agents) != 1 {
+		t.Errorf("second project = %+v", a)
+	}
+}
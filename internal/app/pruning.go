@@ -53,6 +53,13 @@ func EnsureStateMaps(state *domain.CollabState) {
 	if state.FileLocks == nil {
 		state.FileLocks = make(map[string]*domain.FileLock)
 	}
+	// State saved before locks were keyed per project used the bare path.
+	for key, l := range state.FileLocks {
+		if l != nil && key != FileLockKey(l.Project, l.Path) {
+			delete(state.FileLocks, key)
+			state.FileLocks[FileLockKey(l.Project, l.Path)] = l
+		}
+	}
 	if state.RegisteredAgents == nil {
 		state.RegisteredAgents = make(map[string]*domain.RegisteredAgent)
 	}
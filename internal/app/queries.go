@@ -0,0 +1,151 @@
+package app
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// QueriesFor returns repo as a StateQueries when it implements the interface
+// natively, otherwise an adapter that answers each query from a full Load.
+func QueriesFor(repo StateRepository) StateQueries {
+	if q, ok := repo.(StateQueries); ok {
+		return q
+	}
+	return loadQueries{repo: repo}
+}
+
+// loadQueries implements StateQueries by scanning a freshly loaded state.
+// Used for repositories without indexed queries (e.g. test doubles).
+type loadQueries struct {
+	repo StateRepository
+}
+
+func (q loadQueries) UnreadCount(agent, project string) (int, error) {
+	state, err := q.repo.Load()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range state.Messages {
+		if (m.To == agent || m.To == "all") && !m.Read && InProject(m.Project, project) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q loadQueries) UnreadByRecipient(project string) (map[string]Backlog, error) {
+	state, err := q.repo.Load()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Backlog)
+	for _, m := range state.Messages {
+		if !m.Read && InProject(m.Project, project) {
+			out[m.To] = addToBacklog(out[m.To], m.Timestamp)
+		}
+	}
+	return out, nil
+}
+
+func (q loadQueries) PendingByAssignee(project string) (map[string]Backlog, error) {
+	state, err := q.repo.Load()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Backlog)
+	for _, t := range state.Tasks {
+		if t.Status == "pending" && InProject(t.Project, project) {
+			out[t.AssignedTo] = addToBacklog(out[t.AssignedTo], t.CreatedAt)
+		}
+	}
+	return out, nil
+}
+
+func (q loadQueries) TaskStatusCounts(project string, assignees ...string) (map[string]int, error) {
+	state, err := q.repo.Load()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int)
+	for _, t := range state.Tasks {
+		if (len(assignees) == 0 || slices.Contains(assignees, t.AssignedTo)) && InProject(t.Project, project) {
+			out[t.Status]++
+		}
+	}
+	return out, nil
+}
+
+func (q loadQueries) AgentWorkspace(agent string) (string, error) {
+	state, err := q.repo.Load()
+	if err != nil {
+		return "", err
+	}
+	if p, ok := state.Presence[agent]; ok && p != nil && p.Workspace != "" {
+		return p.Workspace, nil
+	}
+	if ra, ok := state.RegisteredAgents[agent]; ok && ra != nil && ra.Workspace != "" {
+		return ra.Workspace, nil
+	}
+	return "", nil
+}
+
+func (q loadQueries) TasksByStatus(statuses ...string) ([]domain.Task, error) {
+	state, err := q.repo.Load()
+	if err != nil {
+		return nil, err
+	}
+	var out []domain.Task
+	for _, t := range state.Tasks {
+		if slices.Contains(statuses, t.Status) {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// addToBacklog counts one more item created at ts.
+func addToBacklog(b Backlog, ts time.Time) Backlog {
+	b.Count++
+	if ts.After(b.Latest) {
+		b.Latest = ts
+	}
+	return b
+}
+
+// QueryAgentProject is AgentProject answered through q: the project of
+// agent's workspace, else of fallback.
+func QueryAgentProject(q StateQueries, agent, fallback string) (string, error) {
+	if agent != "" {
+		ws, err := q.AgentWorkspace(agent)
+		if err != nil {
+			return "", err
+		}
+		if ws != "" {
+			return ProjectKey(ws), nil
+		}
+	}
+	return ProjectKey(fallback), nil
+}
+
+// AgentBacklog returns the unread message and pending task counts the given
+// agent should be notified about (including broadcast messages and "any"
+// tasks) in its project; fallback is the workspace of agents without one.
+func AgentBacklog(q StateQueries, agent, fallback string) (unread, pending int, err error) {
+	project, err := QueryAgentProject(q, agent, fallback)
+	if err != nil {
+		return 0, 0, err
+	}
+	if unread, err = q.UnreadCount(agent, project); err != nil {
+		return 0, 0, err
+	}
+	counts, err := q.TaskStatusCounts(project, agent, "any")
+	if err != nil {
+		return 0, 0, err
+	}
+	return unread, counts["pending"], nil
+}
@@ -2,12 +2,104 @@
 package app
 
 import (
+	"time"
+
 	"github.com/jaakkos/stringwork/internal/domain"
 )
 
 // StateRepository loads and saves the full collaboration state.
+// Implementations may persist Save incrementally (only rows changed since the
+// last Load or Save), so callers should always Save a state obtained from Load.
 // Implementation: internal/repository/sqlite.
 type StateRepository interface {
 	Load() (*domain.CollabState, error)
 	Save(*domain.CollabState) error
 }
+
+// Backlog summarizes outstanding items addressed to one recipient.
+type Backlog struct {
+	Count  int
+	Latest time.Time // newest item (message timestamp or task creation time)
+}
+
+// StateQueries answers hot-path read questions (banners, notifier ticks,
+// worker spawning) without deserializing the whole CollabState.
+// Implementation: internal/repository/sqlite. Use QueriesFor to obtain one
+// from any StateRepository.
+//
+// Methods with a project argument only count records visible in that project
+// (see InProject); "" or AllProjects counts every project.
+type StateQueries interface {
+	// UnreadCount returns unread messages addressed to agent or "all".
+	UnreadCount(agent, project string) (int, error)
+	// UnreadByRecipient returns unread messages keyed by recipient (agent, instance ID or "all").
+	UnreadByRecipient(project string) (map[string]Backlog, error)
+	// PendingByAssignee returns pending tasks keyed by assignee (agent, instance ID or "any").
+	PendingByAssignee(project string) (map[string]Backlog, error)
+	// TaskStatusCounts returns task counts by status for tasks assigned to any
+	// of assignees, or for all tasks when assignees is empty.
+	TaskStatusCounts(project string, assignees ...string) (map[string]int, error)
+	// AgentWorkspace returns agent's presence workspace, else its registered
+	// workspace, or "" (see AgentProject).
+	AgentWorkspace(agent string) (string, error)
+	// TasksByStatus returns tasks whose status is one of statuses, ordered by ID.
+	TasksByStatus(statuses ...string) ([]domain.Task, error)
+}
+
+// AtomicUpdater is implemented by repositories that can run a whole
+// load-modify-save cycle as one transaction that is safe across processes
+// (e.g. two standalone servers sharing a state file). CollabService.Run
+// prefers it over separate Load and Save calls.
+// Implementation: internal/repository/sqlite.
+type AtomicUpdater interface {
+	// Update loads the current state, runs fn on it and, if fn returns nil,
+	// saves the result together with its PendingEvents. No other writer can
+	// change the state in between.
+	Update(fn func(*domain.CollabState) error) error
+}
+
+// EventJournal reads the append-only event history. Repositories that
+// implement it also persist CollabState.PendingEvents on Save.
+// Implementation: internal/repository/sqlite.
+type EventJournal interface {
+	Events(filter EventFilter) ([]domain.Event, error)
+}
+
+// TaskArchive reads tasks moved out of the live state by ArchiveTasks.
+// Repositories that implement it also persist CollabState.PendingArchive on
+// Save and fill CollabState.ArchivedDependencies on Load; CollabService.Run
+// refuses to archive into a repository without one.
+// Implementations: internal/repository/sqlite, memory, jsonfile.
+type TaskArchive interface {
+	ArchivedTasks(filter ArchiveFilter) ([]domain.ArchivedTask, error)
+}
+
+// ArtifactStore reads the artifacts attached to task results. Repositories
+// that implement it also persist CollabState.PendingArtifacts on Save;
+// CollabService.Run refuses to attach artifacts in a repository without one.
+// Artifacts outlive archiving, so results of archived tasks stay readable.
+// Implementations: internal/repository/sqlite, memory, jsonfile.
+type ArtifactStore interface {
+	// Artifact returns the artifact name of taskID, or ErrArtifactNotFound.
+	Artifact(taskID int, name string) (*domain.Artifact, error)
+}
+
+// ArchiveFilter selects archived tasks. Zero fields do not filter.
+type ArchiveFilter struct {
+	TaskID     int
+	Status     string
+	AssignedTo string // matches the assignee, and tasks assigned to "any"
+	Project    string // AllProjects or "" for every project; untagged tasks always match
+	Limit      int    // the Limit highest task IDs, still returned in ID order
+}
+
+// EventFilter selects journal entries. Zero fields do not filter.
+type EventFilter struct {
+	TaskID  int
+	Agent   string   // matches Actor or Target
+	Types   []string // event types
+	Project string   // AllProjects or "" for every project; untagged events always match
+	Since   time.Time
+	AfterID int64
+	Limit   int // most recent Limit matches, still returned oldest first
+}
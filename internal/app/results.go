@@ -0,0 +1,109 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Test outcomes reported in TaskResult.Tests.
+const (
+	TestsPassed = "passed"
+	TestsFailed = "failed"
+	TestsNotRun = "not_run"
+)
+
+// Limits on artifacts attached to one task result.
+const (
+	MaxArtifactSize       = 1 << 20 // bytes per artifact
+	maxArtifactsPerResult = 20
+	maxArtifactName       = 128
+)
+
+var (
+	// ErrNoArtifacts is returned when the repository keeps no artifacts.
+	ErrNoArtifacts = errors.New("artifacts not available for this state backend")
+	// ErrArtifactNotFound is returned for an unknown task or artifact name.
+	ErrArtifactNotFound = errors.New("artifact not found")
+)
+
+// ValidateArtifactName checks that name can be stored and served as a file
+// name: non-empty, at most 128 bytes and without path separators.
+func ValidateArtifactName(name string) error {
+	switch {
+	case name == "" || name == "." || name == "..":
+		return fmt.Errorf("invalid artifact name %q", name)
+	case len(name) > maxArtifactName:
+		return fmt.Errorf("artifact name %q is longer than %d bytes", name, maxArtifactName)
+	case strings.ContainsAny(name, "/\\\x00"):
+		return fmt.Errorf("artifact name %q must not contain path separators", name)
+	}
+	return nil
+}
+
+// ArtifactContentType returns contentType, or one guessed from the name's
+// extension and whether content is text.
+func ArtifactContentType(name, contentType string, content []byte) string {
+	if contentType != "" {
+		return contentType
+	}
+	if ext := filepath.Ext(name); ext == ".patch" || ext == ".diff" {
+		return "text/x-diff; charset=utf-8"
+	} else if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	if utf8.Valid(content) {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// RecordResult stores result on task, replacing any earlier result, and
+// queues artifacts for the repository. The summary also becomes
+// task.ResultSummary. Artifacts are validated first; on error nothing changes.
+func RecordResult(state *domain.CollabState, task *domain.Task, result domain.TaskResult, artifacts []domain.Artifact, now time.Time) error {
+	if len(artifacts) > maxArtifactsPerResult {
+		return fmt.Errorf("at most %d artifacts per result, got %d", maxArtifactsPerResult, len(artifacts))
+	}
+	seen := make(map[string]bool, len(artifacts))
+	for _, a := range artifacts {
+		if err := ValidateArtifactName(a.Name); err != nil {
+			return err
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("duplicate artifact name %q", a.Name)
+		}
+		seen[a.Name] = true
+		if len(a.Content) > MaxArtifactSize {
+			return fmt.Errorf("artifact %q is %d bytes, limit is %d", a.Name, len(a.Content), MaxArtifactSize)
+		}
+	}
+	switch result.Tests {
+	case "", TestsPassed, TestsFailed, TestsNotRun:
+	default:
+		return fmt.Errorf("invalid test outcome %q (want %s, %s or %s)", result.Tests, TestsPassed, TestsFailed, TestsNotRun)
+	}
+
+	result.ReportedAt = now
+	result.Artifacts = nil
+	for _, a := range artifacts {
+		a.TaskID = task.ID
+		a.ContentType = ArtifactContentType(a.Name, a.ContentType, a.Content)
+		a.CreatedBy = result.ReportedBy
+		a.CreatedAt = now
+		state.PendingArtifacts = append(state.PendingArtifacts, a)
+		result.Artifacts = append(result.Artifacts, domain.ArtifactInfo{Name: a.Name, ContentType: a.ContentType, Size: len(a.Content)})
+	}
+	task.Result = &result
+	if result.Summary != "" {
+		task.ResultSummary = result.Summary
+	}
+	task.UpdatedAt = now
+	return nil
+}
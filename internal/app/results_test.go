@@ -0,0 +1,70 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestArtifactContentType(t *testing.T) {
+	tests := []struct {
+		name, contentType string
+		content           []byte
+		want              string
+	}{
+		{"fix.patch", "", []byte("+x"), "text/x-diff; charset=utf-8"},
+		{"report.json", "", []byte("{}"), "application/json"},
+		{"notes", "", []byte("plain"), "text/plain; charset=utf-8"},
+		{"dump", "", []byte{0xff, 0xfe}, "application/octet-stream"},
+		{"report.md", "text/markdown", nil, "text/markdown"},
+	}
+	for _, tt := range tests {
+		if got := ArtifactContentType(tt.name, tt.contentType, tt.content); got != tt.want {
+			t.Errorf("ArtifactContentType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordResult(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{{ID: 7, Status: "completed", ResultSummary: "old"}}
+	task := &state.Tasks[0]
+
+	err := RecordResult(state, task, domain.TaskResult{Summary: "new", Tests: TestsPassed, ReportedBy: "codex"},
+		[]domain.Artifact{{Name: "a.txt", Content: []byte("hello")}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ResultSummary != "new" || task.Result.ReportedAt != now || len(task.Result.Artifacts) != 1 {
+		t.Fatalf("task = %+v, result = %+v", task, task.Result)
+	}
+	if info := task.Result.Artifacts[0]; info.Size != 5 || info.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("artifact info = %+v", info)
+	}
+	if len(state.PendingArtifacts) != 1 || state.PendingArtifacts[0].TaskID != 7 || state.PendingArtifacts[0].CreatedBy != "codex" {
+		t.Errorf("pending artifacts = %+v", state.PendingArtifacts)
+	}
+
+	for _, tt := range []struct {
+		result    domain.TaskResult
+		artifacts []domain.Artifact
+		wantErr   string
+	}{
+		{domain.TaskResult{Tests: "green"}, nil, "invalid test outcome"},
+		{domain.TaskResult{}, []domain.Artifact{{Name: "a/b"}}, "path separators"},
+		{domain.TaskResult{}, []domain.Artifact{{Name: ".."}}, "invalid artifact name"},
+		{domain.TaskResult{}, []domain.Artifact{{Name: strings.Repeat("n", 129)}}, "longer than"},
+		{domain.TaskResult{}, make([]domain.Artifact, 21), "at most 20"},
+	} {
+		err := RecordResult(state, task, tt.result, tt.artifacts, now)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("error = %v, want %q", err, tt.wantErr)
+		}
+	}
+	if task.Result.Summary != "new" || len(state.PendingArtifacts) != 1 {
+		t.Error("a rejected result changed the task")
+	}
+}
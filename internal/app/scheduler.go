@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Delayed and recurring tasks are held in the "scheduled" status, where
+// claim_next, the orchestrator and the worker manager ignore them. A delayed
+// task becomes pending at its NotBefore time. A recurring task (one with a
+// Schedule) never runs itself: at each NotBefore it spawns a pending copy,
+// its run, and NotBefore moves to the schedule's next time. Cancelling the
+// recurring task stops further runs.
+
+// defaultSchedulerInterval is how often the scheduler looks for due tasks.
+const defaultSchedulerInterval = 30 * time.Second
+
+// TaskDue reports whether a scheduled task's time has come.
+func TaskDue(t *domain.Task, now time.Time) bool {
+	return t.Status == "scheduled" && !t.NotBefore.After(now)
+}
+
+// MaterializeDueTasks makes scheduled tasks that are due pending and spawns
+// the due runs of recurring tasks. A recurring task whose previous run is
+// still unfinished skips the run instead of piling up copies. Returns the IDs
+// of the tasks that became pending.
+func MaterializeDueTasks(state *domain.CollabState, now time.Time) []int {
+	var ready []int
+	for i := 0; i < len(state.Tasks); i++ {
+		t := &state.Tasks[i]
+		if !TaskDue(t, now) {
+			continue
+		}
+		if t.Schedule == "" {
+			t.Status = "pending"
+			t.NotBefore = time.Time{}
+			t.UpdatedAt = now
+			ready = append(ready, t.ID)
+			continue
+		}
+		sched, err := ParseSchedule(t.Schedule)
+		if err != nil {
+			t.Status = "blocked"
+			t.BlockedBy = err.Error()
+			t.UpdatedAt = now
+			continue
+		}
+		if !hasOpenRun(state, t.ID) {
+			ready = append(ready, spawnRun(state, t, now))
+			t = &state.Tasks[i] // spawnRun appended to state.Tasks
+		}
+		t.NotBefore = sched.Next(now)
+		t.UpdatedAt = now
+		if t.NotBefore.IsZero() {
+			t.Status = "completed"
+			t.ResultSummary = "schedule has no further runs"
+		}
+	}
+	return ready
+}
+
+// hasOpenRun reports whether a run of the recurring task id is unfinished.
+func hasOpenRun(state *domain.CollabState, id int) bool {
+	for _, t := range state.Tasks {
+		if t.ScheduledFrom == id && t.Status != "completed" && t.Status != "cancelled" {
+			return true
+		}
+	}
+	return false
+}
+
+// spawnRun appends a pending copy of the recurring task rt, with a copy of
+// its work context, and returns the new task's ID.
+func spawnRun(state *domain.CollabState, rt *domain.Task, now time.Time) int {
+	id := state.NextTaskID
+	state.NextTaskID++
+	run := domain.Task{
+		ID:                  id,
+		Title:               rt.Title,
+		Description:         rt.Description,
+		Status:              "pending",
+		AssignedTo:          rt.AssignedTo,
+		CreatedBy:           rt.CreatedBy,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+		Priority:            rt.Priority,
+		Dependencies:        slices.Clone(rt.Dependencies),
+		WorkerType:          rt.WorkerType,
+		Capabilities:        slices.Clone(rt.Capabilities),
+		Project:             rt.Project,
+		Labels:              slices.Clone(rt.Labels),
+		PlanID:              rt.PlanID,
+		Verify:              slices.Clone(rt.Verify),
+		MaxAttempts:         rt.MaxAttempts,
+		ExpectedDurationSec: rt.ExpectedDurationSec,
+		ScheduledFrom:       rt.ID,
+	}
+	if wc, ok := state.WorkContexts[rt.ContextID]; ok && rt.ContextID != "" {
+		c := *wc
+		c.ID = fmt.Sprintf("ctx-%d-%d", id, now.UnixNano())
+		c.TaskID = id
+		c.RelevantFiles = slices.Clone(wc.RelevantFiles)
+		c.Constraints = slices.Clone(wc.Constraints)
+		c.SharedNotes = make(map[string]string)
+		state.WorkContexts[c.ID] = &c
+		run.ContextID = c.ID
+	}
+	state.Tasks = append(state.Tasks, run)
+	return id
+}
+
+// UpcomingRun is a scheduled task and when it next becomes pending.
+type UpcomingRun struct {
+	TaskID   int
+	Title    string
+	Schedule string // empty for a one-off delayed task
+	At       time.Time
+}
+
+// UpcomingRuns returns the scheduled tasks in project, soonest first.
+func UpcomingRuns(state *domain.CollabState, project string) []UpcomingRun {
+	var out []UpcomingRun
+	for _, t := range state.Tasks {
+		if t.Status != "scheduled" || !InProject(t.Project, project) {
+			continue
+		}
+		out = append(out, UpcomingRun{TaskID: t.ID, Title: t.Title, Schedule: t.Schedule, At: t.NotBefore})
+	}
+	slices.SortFunc(out, func(a, b UpcomingRun) int { return a.At.Compare(b.At) })
+	return out
+}
+
+// Scheduler periodically materializes due scheduled tasks and triggers the
+// notifier so that idle workers pick them up.
+type Scheduler struct {
+	svc      *CollabService
+	logger   *log.Logger
+	interval time.Duration
+	notifier Triggerable
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// SchedulerOption configures the scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerInterval sets how often the scheduler looks for due tasks.
+func WithSchedulerInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) { s.interval = d }
+}
+
+// WithSchedulerNotifier sets the notifier to trigger when tasks become pending.
+func WithSchedulerNotifier(n Triggerable) SchedulerOption {
+	return func(s *Scheduler) { s.notifier = n }
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler(svc *CollabService, logger *log.Logger, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		svc:      svc,
+		logger:   logger,
+		interval: defaultSchedulerInterval,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Start begins the scheduler loop. Returns when ctx is cancelled or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	defer close(s.doneCh)
+	s.logger.Printf("Scheduler: started (interval=%s)", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Println("Scheduler: stopped (context cancelled)")
+			return
+		case <-s.stopCh:
+			s.logger.Println("Scheduler: stopped")
+			return
+		case <-ticker.C:
+			s.CheckOnce()
+		}
+	}
+}
+
+// Stop signals the scheduler to stop.
+func (s *Scheduler) Stop() {
+	close(s.stopCh)
+	<-s.doneCh
+}
+
+// CheckOnce materializes the tasks that are due now.
+func (s *Scheduler) CheckOnce() {
+	// Look before writing: most cycles have nothing to do.
+	scheduled, err := s.svc.Queries().TasksByStatus("scheduled")
+	if err != nil {
+		s.logger.Printf("Scheduler: %v", err)
+		return
+	}
+	now := time.Now()
+	if !slices.ContainsFunc(scheduled, func(t domain.Task) bool { return TaskDue(&t, now) }) {
+		return
+	}
+	var ready []int
+	if err := s.svc.Run(func(state *domain.CollabState) error {
+		ready = MaterializeDueTasks(state, time.Now())
+		return nil
+	}); err != nil {
+		s.logger.Printf("Scheduler: %v", err)
+		return
+	}
+	if len(ready) == 0 {
+		return
+	}
+	s.logger.Printf("Scheduler: tasks now pending: %v", ready)
+	if s.notifier != nil {
+		s.notifier.Trigger()
+	}
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"log"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestMaterializeDueTasks(t *testing.T) {
+	now := time.Date(2026, 1, 14, 3, 0, 10, 0, time.Local)
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Title: "Re-check", Status: "scheduled", NotBefore: now.Add(-time.Minute)},
+		{ID: 2, Title: "Later", Status: "scheduled", NotBefore: now.AddDate(0, 1, 0)},
+		{ID: 3, Title: "Nightly sweep", Status: "scheduled", Schedule: "0 3 * * *", NotBefore: now.Add(-10 * time.Second),
+			AssignedTo: "any", Priority: 2, WorkerType: "claude-code", Capabilities: []string{"testing"}, Project: "/work/alpha", ContextID: "ctx-3",
+			Labels: []string{"maintenance"}, PlanID: "ops", Verify: []string{"go test ./..."}},
+	}
+	state.NextTaskID = 4
+	state.WorkContexts["ctx-3"] = &domain.WorkContext{ID: "ctx-3", TaskID: 3, Background: "flaky tests", SharedNotes: map[string]string{"x": "y"}}
+
+	ready := MaterializeDueTasks(state, now)
+	if len(ready) != 2 || ready[0] != 1 || ready[1] != 4 {
+		t.Fatalf("ready = %v, want [1 4]", ready)
+	}
+	if one := state.Tasks[0]; one.Status != "pending" || !one.NotBefore.IsZero() {
+		t.Errorf("delayed task = %s, not before %v; want pending, cleared", one.Status, one.NotBefore)
+	}
+	if later := state.Tasks[1]; later.Status != "scheduled" {
+		t.Errorf("task not yet due = %s, want scheduled", later.Status)
+	}
+	recurring := state.Tasks[2]
+	if want := time.Date(2026, 1, 15, 3, 0, 0, 0, time.Local); recurring.Status != "scheduled" || !recurring.NotBefore.Equal(want) {
+		t.Errorf("recurring task = %s, next %v; want scheduled, %v", recurring.Status, recurring.NotBefore, want)
+	}
+	run := state.Tasks[3]
+	if run.ID != 4 || run.Status != "pending" || run.ScheduledFrom != 3 || run.Title != "Nightly sweep" ||
+		run.Priority != 2 || run.WorkerType != "claude-code" || run.Project != "/work/alpha" || run.Schedule != "" {
+		t.Errorf("run = %+v", run)
+	}
+	if !slices.Equal(run.Labels, []string{"maintenance"}) || run.PlanID != "ops" || !slices.Equal(run.Verify, []string{"go test ./..."}) {
+		t.Errorf("run labels, plan and verify = %v, %q, %v", run.Labels, run.PlanID, run.Verify)
+	}
+	state.Tasks[2].Labels[0], state.Tasks[2].Verify[0] = "changed", "changed"
+	if run.Labels[0] != "maintenance" || run.Verify[0] != "go test ./..." {
+		t.Error("run shares its labels or verify commands with the recurring task")
+	}
+	wc := state.WorkContexts[run.ContextID]
+	if run.ContextID == "ctx-3" || wc == nil || wc.TaskID != 4 || wc.Background != "flaky tests" || len(wc.SharedNotes) != 0 {
+		t.Errorf("run work context %q = %+v", run.ContextID, wc)
+	}
+	if state.NextTaskID != 5 {
+		t.Errorf("next task ID = %d, want 5", state.NextTaskID)
+	}
+
+	// The next night's run is skipped while this one is unfinished.
+	next := recurring.NotBefore.Add(time.Second)
+	if ready := MaterializeDueTasks(state, next); len(ready) != 0 {
+		t.Errorf("ready with open run = %v, want none", ready)
+	}
+	if len(state.Tasks) != 4 || !state.Tasks[2].NotBefore.After(next) {
+		t.Errorf("skipped run should only advance the schedule: %d tasks, next %v", len(state.Tasks), state.Tasks[2].NotBefore)
+	}
+
+	state.Tasks[3].Status = "completed"
+	next = state.Tasks[2].NotBefore
+	if ready := MaterializeDueTasks(state, next); len(ready) != 1 || ready[0] != 5 {
+		t.Errorf("ready after run completed = %v, want [5]", ready)
+	}
+}
+
+func TestMaterializeDueTasks_InvalidSchedule(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{{ID: 1, Status: "scheduled", Schedule: "bogus", NotBefore: now}}
+	state.NextTaskID = 2
+
+	if ready := MaterializeDueTasks(state, now); len(ready) != 0 {
+		t.Errorf("ready = %v, want none", ready)
+	}
+	if got := state.Tasks[0]; got.Status != "blocked" || got.BlockedBy == "" {
+		t.Errorf("task = %s (%q), want blocked with a reason", got.Status, got.BlockedBy)
+	}
+}
+
+func TestScheduler_CheckOnce(t *testing.T) {
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Title: "Re-check", Status: "scheduled", NotBefore: time.Now().Add(-time.Second)},
+		{ID: 2, Title: "Waits", Status: "scheduled", NotBefore: time.Now().Add(time.Hour)},
+	}
+	state.NextTaskID = 3
+	svc := testService(state)
+	triggered := 0
+	s := NewScheduler(svc, log.New(os.Stderr, "[test] ", 0),
+		WithSchedulerNotifier(&mockTriggerable{fn: func() { triggered++ }}),
+	)
+
+	s.CheckOnce()
+	s.CheckOnce()
+
+	if state.Tasks[0].Status != "pending" || state.Tasks[1].Status != "scheduled" {
+		t.Errorf("statuses = %s, %s; want pending, scheduled", state.Tasks[0].Status, state.Tasks[1].Status)
+	}
+	if triggered != 1 {
+		t.Errorf("notifier triggered %d times, want 1", triggered)
+	}
+}
+
+// saveCountingRepo counts the saves of a notifierTestRepo.
+type saveCountingRepo struct {
+	notifierTestRepo
+	saves int
+}
+
+func (r *saveCountingRepo) Save(state *domain.CollabState) error {
+	r.saves++
+	return r.notifierTestRepo.Save(state)
+}
+
+func TestScheduler_CheckOnce_SkipsWriteWhenNothingDue(t *testing.T) {
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{{ID: 1, Title: "Later", Status: "scheduled", NotBefore: time.Now().Add(time.Hour)}}
+	repo := &saveCountingRepo{notifierTestRepo: notifierTestRepo{state: state}}
+	s := NewScheduler(NewCollabService(repo, testPolicy(), log.New(os.Stderr, "[test] ", 0)), log.New(os.Stderr, "[test] ", 0))
+
+	s.CheckOnce()
+	if repo.saves != 0 {
+		t.Errorf("saves = %d, want 0 with nothing due", repo.saves)
+	}
+	state.Tasks[0].NotBefore = time.Now().Add(-time.Second)
+	s.CheckOnce()
+	if repo.saves != 1 || state.Tasks[0].Status != "pending" {
+		t.Errorf("saves = %d, status = %s; want 1 save making the task pending", repo.saves, state.Tasks[0].Status)
+	}
+}
+
+func TestUpcomingRuns(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Title: "Late", Status: "scheduled", NotBefore: now.Add(2 * time.Hour), Project: "/work/alpha"},
+		{ID: 2, Title: "Nightly", Status: "scheduled", Schedule: "@daily", NotBefore: now.Add(time.Hour), Project: "/work/alpha"},
+		{ID: 3, Title: "Other project", Status: "scheduled", NotBefore: now, Project: "/work/beta"},
+		{ID: 4, Title: "Pending", Status: "pending", Project: "/work/alpha"},
+	}
+
+	runs := UpcomingRuns(state, "/work/alpha")
+	if len(runs) != 2 || runs[0].TaskID != 2 || runs[0].Schedule != "@daily" || runs[1].TaskID != 1 {
+		t.Errorf("upcoming = %+v, want #2 then #1", runs)
+	}
+}
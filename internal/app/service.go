@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/jaakkos/stringwork/internal/domain"
 )
@@ -34,25 +35,75 @@ func (s *CollabService) SetNotifier(n Triggerable) {
 }
 
 // Run loads state, runs fn, then saves. Caller must not retain state after fn returns.
-// On successful save, touches the notify signal file so other agent processes can push updates.
+// Changes made by fn are journaled as events (see journal.go) and saved with the state.
+// After fn, subtask progress is rolled up into parents, dependency statuses are synced
+// and task attempts are tracked (see RollupSubtasks, SyncDependencies, trackAttempts).
+// When the repository implements AtomicUpdater the whole cycle is one transaction, so
+// other server processes sharing the state file cannot interleave and lose writes; the
+// mutex only serializes callers within this process.
+// On successful save, touches the notify signal file so other agent processes can push updates
+// and rewrites the plan_file of each plan fn changed (see PlanFilePath).
 // If the database cannot be loaded, the error is returned immediately — we never fall back to
 // an empty state for writes, because Save() would overwrite the database with nothing.
 func (s *CollabService) Run(fn func(*domain.CollabState) error) error {
+	return s.RunAs("", fn)
+}
+
+// RunAs is Run on behalf of actor: task status, assignment and removal events
+// derived from fn's changes name actor as the agent that made them.
+func (s *CollabService) RunAs(actor string, fn func(*domain.CollabState) error) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	state, err := s.repo.Load()
-	if err != nil {
-		return fmt.Errorf("state load: %w", err)
+	var planFiles []planFile
+	mutate := func(state *domain.CollabState) error {
+		EnsureStateMaps(state)
+		EnsureAgentInstances(state, s.policy.Orchestration())
+		before := digestOf(state)
+		start := time.Now()
+		if err := fn(state); err != nil {
+			return err
+		}
+		now := time.Now()
+		syncTasksFromPlanItems(before, state, now)
+		RollupSubtasks(state, s.policy.Verification(), now)
+		SyncDependencies(state, now)
+		trackAttempts(before, state, now)
+		syncPlanItemsFromTasks(state, now)
+		if _, ok := s.repo.(TaskArchive); !ok && len(state.PendingArchive) > 0 {
+			return ErrNoArchive
+		}
+		if _, ok := s.repo.(ArtifactStore); !ok && len(state.PendingArtifacts) > 0 {
+			return ErrNoArtifacts
+		}
+		state.PendingEvents = append(diffEvents(before, state, actor), state.PendingEvents...)
+		if pattern := s.policy.PlanFile(); pattern != "" {
+			planFiles = changedPlanFiles(state, pattern, start)
+		}
+		return nil
 	}
-	EnsureStateMaps(state)
-	EnsureAgentInstances(state, s.policy.Orchestration())
-	if err := fn(state); err != nil {
-		return err
-	}
-	if err := s.repo.Save(state); err != nil {
-		return err
+	if u, ok := s.repo.(AtomicUpdater); ok {
+		if err := u.Update(mutate); err != nil {
+			return err
+		}
+	} else {
+		state, err := s.repo.Load()
+		if err != nil {
+			return fmt.Errorf("state load: %w", err)
+		}
+		defer func() { state.PendingEvents, state.PendingArchive, state.PendingArtifacts = nil, nil, nil }()
+		if err := mutate(state); err != nil {
+			return err
+		}
+		if err := s.repo.Save(state); err != nil {
+			return err
+		}
 	}
 	_ = TouchNotifySignal(s.policy.SignalFilePath())
+	for _, f := range planFiles {
+		if err := writePlanFile(f); err != nil {
+			s.logger.Printf("Warning: plan file %s: %v", f.path, err)
+		}
+	}
 	if s.notifier != nil {
 		s.notifier.Trigger()
 	}
@@ -74,5 +125,39 @@ func (s *CollabService) Query(fn func(*domain.CollabState) error) error {
 	return fn(state)
 }
 
+// Queries returns indexed read queries over the repository. Unlike Query, it
+// neither loads the full state nor takes the service lock.
+func (s *CollabService) Queries() StateQueries { return QueriesFor(s.repo) }
+
+// Events returns journal entries matching f, or ErrNoJournal when the
+// repository keeps no journal.
+func (s *CollabService) Events(f EventFilter) ([]domain.Event, error) {
+	j, ok := s.repo.(EventJournal)
+	if !ok {
+		return nil, ErrNoJournal
+	}
+	return j.Events(f)
+}
+
+// ArchivedTasks returns archived tasks matching f, or ErrNoArchive when the
+// repository keeps no archive.
+func (s *CollabService) ArchivedTasks(f ArchiveFilter) ([]domain.ArchivedTask, error) {
+	a, ok := s.repo.(TaskArchive)
+	if !ok {
+		return nil, ErrNoArchive
+	}
+	return a.ArchivedTasks(f)
+}
+
+// Artifact returns the artifact name attached to taskID's result,
+// ErrArtifactNotFound, or ErrNoArtifacts when the repository keeps none.
+func (s *CollabService) Artifact(taskID int, name string) (*domain.Artifact, error) {
+	a, ok := s.repo.(ArtifactStore)
+	if !ok {
+		return nil, ErrNoArtifacts
+	}
+	return a.Artifact(taskID, name)
+}
+
 // Policy returns the policy for use in handlers that need retention etc.
 func (s *CollabService) Policy() Policy { return s.policy }
@@ -7,11 +7,14 @@ import (
 
 // SessionRegistry tracks connected MCP client sessions and their associated
 // agent names. Multiple sessions can be active (SSE and Streamable HTTP).
+// A session that authenticated as an agent is bound to that identity for its
+// lifetime; the agent name it reports with SetAgent is only a display hint.
 type SessionRegistry struct {
 	mu           sync.RWMutex
 	sessions     map[string]string    // sessionID → agentName
 	agents       map[string]string    // agentName → sessionID (reverse lookup)
 	lastActivity map[string]time.Time // sessionID → last activity timestamp
+	identities   map[string]string    // sessionID → authenticated agent
 	dashboardURL string               // set after HTTP listener binds
 }
 
@@ -21,6 +24,7 @@ func NewSessionRegistry() *SessionRegistry {
 		sessions:     make(map[string]string),
 		agents:       make(map[string]string),
 		lastActivity: make(map[string]time.Time),
+		identities:   make(map[string]string),
 	}
 }
 
@@ -40,6 +44,26 @@ func (r *SessionRegistry) SetAgent(sessionID, agent string) {
 	r.lastActivity[sessionID] = time.Now()
 }
 
+// BindIdentity binds a session to the agent it authenticated as. It returns
+// false if the session is already bound to a different agent.
+func (r *SessionRegistry) BindIdentity(sessionID, agent string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if bound, ok := r.identities[sessionID]; ok {
+		return bound == agent
+	}
+	r.identities[sessionID] = agent
+	return true
+}
+
+// Identity returns the authenticated agent of a session, or "" if the session
+// never authenticated.
+func (r *SessionRegistry) Identity(sessionID string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.identities[sessionID]
+}
+
 // GetAgent returns the agent name for a session, or "" if unknown.
 func (r *SessionRegistry) GetAgent(sessionID string) string {
 	r.mu.RLock()
@@ -104,6 +128,7 @@ func (r *SessionRegistry) RemoveSession(sessionID string) {
 	}
 	delete(r.sessions, sessionID)
 	delete(r.lastActivity, sessionID)
+	delete(r.identities, sessionID)
 }
 
 // AgentCount returns the number of connected agents.
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+// Subtasks split a task into children (Task.ParentTaskID). A parent's
+// progress and status are rolled up from its children: progress is the mean
+// over non-cancelled children (completed counts as 100%), the parent starts
+// when a child starts, and completes when every non-cancelled child has -
+// unless it has verification commands, which only update_task runs: such a
+// parent waits in_progress for its assignee to complete it.
+
+// ValidateParent checks that parentID may get new subtasks: it must exist
+// and not be finished.
+func ValidateParent(state *domain.CollabState, parentID int) (*domain.Task, error) {
+	parent := taskIndex(state)[parentID]
+	if parent == nil {
+		return nil, fmt.Errorf("parent task #%d not found", parentID)
+	}
+	if parent.Status == "completed" || parent.Status == "cancelled" {
+		return nil, fmt.Errorf("parent task #%d is %s", parentID, parent.Status)
+	}
+	return parent, nil
+}
+
+// Subtasks returns the direct subtasks of taskID in ID order.
+func Subtasks(state *domain.CollabState, taskID int) []*domain.Task {
+	var out []*domain.Task
+	for i := range state.Tasks {
+		if state.Tasks[i].ParentTaskID == taskID {
+			out = append(out, &state.Tasks[i])
+		}
+	}
+	slices.SortFunc(out, func(a, b *domain.Task) int { return a.ID - b.ID })
+	return out
+}
+
+// OpenSubtasks returns the IDs of the direct subtasks of taskID that are
+// neither completed nor cancelled.
+func OpenSubtasks(state *domain.CollabState, taskID int) []int {
+	var open []int
+	for _, t := range Subtasks(state, taskID) {
+		if t.Status != "completed" && t.Status != "cancelled" {
+			open = append(open, t.ID)
+		}
+	}
+	return open
+}
+
+// RollupSubtasks updates every parent task's progress and status from its
+// subtasks, deepest level first. CollabService.Run calls it after every
+// mutation. Parents that are finished or blocked keep their status; parents
+// with verification commands (see VerifyCommands) are not completed.
+func RollupSubtasks(state *domain.CollabState, verify policy.VerificationConfig, now time.Time) {
+	children := make(map[int][]*domain.Task)
+	for i := range state.Tasks {
+		if p := state.Tasks[i].ParentTaskID; p != 0 {
+			children[p] = append(children[p], &state.Tasks[i])
+		}
+	}
+	if len(children) == 0 {
+		return
+	}
+	idx := taskIndex(state)
+	done := make(map[int]bool)
+	var roll func(id int)
+	roll = func(id int) {
+		if done[id] {
+			return
+		}
+		done[id] = true
+		for _, c := range children[id] {
+			roll(c.ID)
+		}
+		if parent := idx[id]; parent != nil {
+			rollupParent(state, parent, children[id], verify, now)
+		}
+	}
+	ids := make([]int, 0, len(children))
+	for id := range children {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		roll(id)
+	}
+}
+
+func rollupParent(state *domain.CollabState, parent *domain.Task, children []*domain.Task, verify policy.VerificationConfig, now time.Time) {
+	total, completed, started, sum := 0, 0, 0, 0
+	for _, c := range children {
+		switch c.Status {
+		case "cancelled":
+			continue
+		case "completed":
+			completed++
+			sum += 100
+		case "in_progress":
+			started++
+			sum += c.ProgressPercent
+		default:
+			sum += c.ProgressPercent
+		}
+		total++
+	}
+	if total == 0 {
+		return
+	}
+	switch parent.Status {
+	case "completed", "cancelled", "blocked":
+		return
+	}
+
+	percent := sum / total
+	desc := fmt.Sprintf("%d/%d subtasks completed", completed, total)
+	gated := completed == total && len(VerifyCommands(parent, verify)) > 0
+	if gated {
+		desc += "; complete the task to run its verification"
+	}
+	if percent != parent.ProgressPercent || desc != parent.ProgressDescription {
+		parent.ProgressPercent = percent
+		parent.ProgressDescription = desc
+		parent.LastProgressAt = now
+	}
+
+	switch {
+	case completed == total && !gated:
+		parent.Status = "completed"
+		if parent.ResultSummary == "" {
+			parent.ResultSummary = fmt.Sprintf("All %d subtasks completed", total)
+		}
+		parent.UpdatedAt = now
+		releaseInstanceTask(state, parent.ID)
+	case parent.Status == "pending" && started+completed > 0:
+		parent.Status = "in_progress"
+		parent.UpdatedAt = now
+	}
+}
+
+// TaskTreeEntry is a task and its depth in the subtask tree.
+type TaskTreeEntry struct {
+	Task  domain.Task
+	Depth int
+}
+
+// TaskTree orders tasks so that each subtask follows its parent. Tasks whose
+// parent is not among tasks are roots; roots and siblings keep their order in
+// tasks.
+func TaskTree(tasks []domain.Task) []TaskTreeEntry {
+	present := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		present[t.ID] = true
+	}
+	children := make(map[int][]domain.Task)
+	var roots []domain.Task
+	for _, t := range tasks {
+		if t.ParentTaskID != 0 && present[t.ParentTaskID] && t.ParentTaskID != t.ID {
+			children[t.ParentTaskID] = append(children[t.ParentTaskID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+	out := make([]TaskTreeEntry, 0, len(tasks))
+	seen := make(map[int]bool, len(tasks))
+	var walk func(t domain.Task, depth int)
+	walk = func(t domain.Task, depth int) {
+		if seen[t.ID] {
+			return
+		}
+		seen[t.ID] = true
+		out = append(out, TaskTreeEntry{Task: t, Depth: depth})
+		for _, c := range children[t.ID] {
+			walk(c, depth+1)
+		}
+	}
+	for _, t := range roots {
+		walk(t, 0)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestRollupSubtasks(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "pending", AssignedTo: "claude-code"},
+		{ID: 2, Status: "in_progress", ParentTaskID: 1, ProgressPercent: 50},
+		{ID: 3, Status: "pending", ParentTaskID: 1},
+		{ID: 4, Status: "cancelled", ParentTaskID: 1, ProgressPercent: 90},
+		{ID: 5, Status: "completed", ParentTaskID: 3},
+		{ID: 6, Status: "pending", ParentTaskID: 3},
+	}
+	state.AgentInstances["claude-code"] = &domain.AgentInstance{InstanceID: "claude-code", Status: "busy", CurrentTasks: []int{1}}
+
+	RollupSubtasks(state, policy.VerificationConfig{}, now)
+	// #3 is half done through #5; #1 averages #2 (50) and #3 (50), ignoring cancelled #4.
+	if p := state.Tasks[2]; p.Status != "in_progress" || p.ProgressPercent != 50 || p.ProgressDescription != "1/2 subtasks completed" {
+		t.Errorf("task 3 = %s %d%% %q", p.Status, p.ProgressPercent, p.ProgressDescription)
+	}
+	if p := state.Tasks[0]; p.Status != "in_progress" || p.ProgressPercent != 50 || p.ProgressDescription != "0/2 subtasks completed" {
+		t.Errorf("task 1 = %s %d%% %q", p.Status, p.ProgressPercent, p.ProgressDescription)
+	}
+
+	state.Tasks[1].Status = "completed"
+	state.Tasks[5].Status = "completed"
+	RollupSubtasks(state, policy.VerificationConfig{}, now)
+	for _, id := range []int{3, 1} {
+		if p := state.Tasks[id-1]; p.Status != "completed" || p.ProgressPercent != 100 || p.ResultSummary != "All 2 subtasks completed" {
+			t.Errorf("task %d = %s %d%% %q", id, p.Status, p.ProgressPercent, p.ResultSummary)
+		}
+	}
+	if inst := state.AgentInstances["claude-code"]; len(inst.CurrentTasks) != 0 || inst.Status != "idle" {
+		t.Errorf("instance = %+v, want idle", inst)
+	}
+
+	// Blocked parents keep their status.
+	state.Tasks[0].Status = "blocked"
+	state.Tasks[1].Status = "in_progress"
+	RollupSubtasks(state, policy.VerificationConfig{}, now)
+	if state.Tasks[0].Status != "blocked" {
+		t.Errorf("blocked parent status = %q", state.Tasks[0].Status)
+	}
+}
+
+func TestRollupSubtasks_VerificationGate(t *testing.T) {
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{
+		{ID: 1, Status: "in_progress", Verify: []string{"go test ./..."}},
+		{ID: 2, Status: "completed", ParentTaskID: 1},
+		{ID: 3, Status: "pending"},
+		{ID: 4, Status: "completed", ParentTaskID: 3},
+	}
+	RollupSubtasks(state, policy.VerificationConfig{}, now)
+	if p := state.Tasks[0]; p.Status != "in_progress" || p.ProgressPercent != 100 || !strings.Contains(p.ProgressDescription, "run its verification") {
+		t.Errorf("parent with verify = %s %d%% %q, want in_progress awaiting completion", p.Status, p.ProgressPercent, p.ProgressDescription)
+	}
+	if p := state.Tasks[2]; p.Status != "completed" {
+		t.Errorf("parent without verify = %s, want completed", p.Status)
+	}
+
+	// The configured default gates parents too.
+	state.Tasks[2].Status = "in_progress"
+	RollupSubtasks(state, policy.VerificationConfig{Commands: []string{"make check"}}, now)
+	if p := state.Tasks[2]; p.Status != "in_progress" {
+		t.Errorf("parent under default verification = %s, want in_progress", p.Status)
+	}
+}
+
+func TestTaskTree(t *testing.T) {
+	tasks := []domain.Task{
+		{ID: 5, ParentTaskID: 2},
+		{ID: 4},
+		{ID: 3, ParentTaskID: 9}, // parent not listed
+		{ID: 2, ParentTaskID: 1},
+		{ID: 1},
+	}
+	var got []int
+	var depths []int
+	for _, e := range TaskTree(tasks) {
+		got = append(got, e.Task.ID)
+		depths = append(depths, e.Depth)
+	}
+	wantIDs, wantDepths := []int{4, 3, 1, 2, 5}, []int{0, 0, 0, 1, 2}
+	for i := range wantIDs {
+		if len(got) != len(wantIDs) || got[i] != wantIDs[i] || depths[i] != wantDepths[i] {
+			t.Fatalf("tree = %v depths %v, want %v depths %v", got, depths, wantIDs, wantDepths)
+		}
+	}
+}
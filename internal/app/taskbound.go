@@ -0,0 +1,107 @@
+package app
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// TaskBinding is the task a task-bound worker process is launched for, with
+// the parts of its work context the command template can use.
+type TaskBinding struct {
+	ID            int
+	Title         string
+	Description   string
+	RelevantFiles []string
+	Constraints   []string
+	Background    string
+}
+
+// templateReplacements returns the {task_*} placeholders and their values;
+// all expand to "" when b is nil.
+func (b *TaskBinding) templateReplacements() []string {
+	if b == nil {
+		b = &TaskBinding{}
+	}
+	id := ""
+	if b.ID > 0 {
+		id = strconv.Itoa(b.ID)
+	}
+	return []string{
+		"{task_id}", id,
+		"{task_title}", b.Title,
+		"{task_description}", b.Description,
+		"{relevant_files}", strings.Join(b.RelevantFiles, ", "),
+		"{constraints}", strings.Join(b.Constraints, "; "),
+		"{background}", b.Background,
+	}
+}
+
+// claimTask pre-claims a pending task for a task-bound worker instance before
+// it is launched: the task goes in_progress under the instance, which opens
+// its attempt. taskID 0 picks the most urgent task the instance may take;
+// otherwise only that task is claimed (used to re-claim it for a retry).
+// Only tasks visible in project are claimed. Returns nil if there was
+// nothing to claim.
+func (m *WorkerManager) claimTask(c WorkerSpawnConfig, taskID int, project string) *TaskBinding {
+	if m.stateMutator == nil {
+		return nil
+	}
+	var binding *TaskBinding
+	_ = m.stateMutator(func(s *domain.CollabState) error {
+		var best *domain.Task
+		for i := range s.Tasks {
+			t := &s.Tasks[i]
+			if taskID != 0 && t.ID != taskID || !taskClaimableBy(t, c) || !InProject(t.Project, project) {
+				continue
+			}
+			if best == nil || t.Priority < best.Priority || t.Priority == best.Priority && t.ID < best.ID {
+				best = t
+			}
+		}
+		if best == nil {
+			return nil
+		}
+		best.Status = "in_progress"
+		best.AssignedTo = c.InstanceID
+		best.UpdatedAt = time.Now()
+		if inst, ok := s.AgentInstances[c.InstanceID]; ok && inst != nil && !slices.Contains(inst.CurrentTasks, best.ID) {
+			inst.CurrentTasks = append(inst.CurrentTasks, best.ID)
+			inst.Status = "busy"
+		}
+		binding = &TaskBinding{ID: best.ID, Title: best.Title, Description: best.Description}
+		if wc, ok := s.WorkContexts[best.ContextID]; ok && wc != nil && best.ContextID != "" {
+			binding.RelevantFiles = wc.RelevantFiles
+			binding.Constraints = wc.Constraints
+			binding.Background = wc.Background
+		}
+		return nil
+	})
+	return binding
+}
+
+// taskClaimableBy reports whether a pending task is addressed to the worker
+// instance, its type, or "any" worker whose type and capabilities fit.
+func taskClaimableBy(t *domain.Task, c WorkerSpawnConfig) bool {
+	if t.Status != "pending" {
+		return false
+	}
+	switch t.AssignedTo {
+	case c.InstanceID, c.AgentType:
+		return true
+	case "any":
+		if t.WorkerType != "" && t.WorkerType != c.AgentType {
+			return false
+		}
+		for _, need := range t.Capabilities {
+			if !slices.Contains(c.Capabilities, need) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
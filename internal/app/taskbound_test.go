@@ -0,0 +1,74 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestClaimTask(t *testing.T) {
+	state := domain.NewCollabState()
+	state.AgentInstances["codex-2"] = &domain.AgentInstance{InstanceID: "codex-2", AgentType: "codex", Role: domain.RoleWorker, Status: "offline"}
+	state.Tasks = []domain.Task{
+		{ID: 1, Title: "Needs GPU", Status: "pending", AssignedTo: "any", Priority: 1, Capabilities: []string{"gpu"}},
+		{ID: 2, Title: "Other type", Status: "pending", AssignedTo: "claude-code", Priority: 1},
+		{ID: 3, Title: "Later", Status: "pending", AssignedTo: "any", Priority: 3},
+		{ID: 4, Title: "Fix parser", Description: "Handle empty input", Status: "pending", AssignedTo: "codex", Priority: 2, ContextID: "ctx-4"},
+	}
+	state.WorkContexts["ctx-4"] = &domain.WorkContext{ID: "ctx-4", TaskID: 4, RelevantFiles: []string{"parser.go", "lexer.go"},
+		Constraints: []string{"no new deps"}, Background: "Crashes on empty files"}
+	svc := testService(state)
+	wm := &WorkerManager{stateMutator: svc.Run}
+	c := WorkerSpawnConfig{InstanceID: "codex-2", AgentType: "codex", TaskBound: true, Capabilities: []string{"code-edit"}}
+
+	b := wm.claimTask(c, 0, "")
+	if b == nil || b.ID != 4 {
+		t.Fatalf("claimed %+v, want task #4 (most urgent the instance may take)", b)
+	}
+	if b.Background != "Crashes on empty files" || len(b.RelevantFiles) != 2 || b.Constraints[0] != "no new deps" {
+		t.Errorf("binding = %+v, want work context fields", b)
+	}
+	task := state.Tasks[3]
+	if task.Status != "in_progress" || task.AssignedTo != "codex-2" || len(task.Attempts) != 1 {
+		t.Errorf("task #4 = %s/%s with %d attempt(s), want in_progress/codex-2 with 1", task.Status, task.AssignedTo, len(task.Attempts))
+	}
+	if inst := state.AgentInstances["codex-2"]; inst.Status != "busy" || len(inst.CurrentTasks) != 1 {
+		t.Errorf("instance = %+v", inst)
+	}
+
+	if b := wm.claimTask(c, 4, ""); b != nil {
+		t.Errorf("re-claimed in-progress task: %+v", b)
+	}
+	if b := wm.claimTask(c, 0, ""); b == nil || b.ID != 3 {
+		t.Errorf("second claim = %+v, want task #3", b)
+	}
+	if b := wm.claimTask(c, 0, ""); b != nil {
+		t.Errorf("third claim = %+v, want nothing left", b)
+	}
+
+	// Only tasks of the project workers are spawned into are claimed.
+	state.Tasks = append(state.Tasks,
+		domain.Task{ID: 5, Title: "Elsewhere", Status: "pending", AssignedTo: "codex", Project: "/work/beta"},
+		domain.Task{ID: 6, Title: "Here", Status: "pending", AssignedTo: "codex", Project: "/work/alpha", Priority: 4})
+	if b := wm.claimTask(c, 0, "/work/alpha"); b == nil || b.ID != 6 {
+		t.Errorf("claim in /work/alpha = %+v, want task #6", b)
+	}
+}
+
+func TestExpandWorkerTemplates_Task(t *testing.T) {
+	task := &TaskBinding{ID: 7, Title: "Fix parser", Description: "Handle empty input",
+		RelevantFiles: []string{"parser.go", "lexer.go"}, Constraints: []string{"no new deps", "keep API"}, Background: "Crashes"}
+	args := []string{"claude", "-p", "{agent} in {workspace}: #{task_id} {task_title} — {task_description}. Files: {relevant_files}. Constraints: {constraints}. {background}"}
+
+	got := expandWorkerTemplates(args, "claude-code-1", "/ws", task)[2]
+	want := "claude-code-1 in /ws: #7 Fix parser — Handle empty input. Files: parser.go, lexer.go. Constraints: no new deps; keep API. Crashes"
+	if got != want {
+		t.Errorf("expanded = %q\nwant      %q", got, want)
+	}
+
+	got = expandWorkerTemplates(args, "claude-code-1", "/ws", nil)[2]
+	if strings.Contains(got, "{task_") || strings.Contains(got, "{background}") {
+		t.Errorf("placeholders left without a task: %q", got)
+	}
+}
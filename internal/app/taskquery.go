@@ -0,0 +1,250 @@
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Task sort orders for TaskQuery.Sort. Ties are broken by task ID.
+const (
+	TaskSortID       = "id"
+	TaskSortPriority = "priority" // most urgent first
+	TaskSortCreated  = "created"
+	TaskSortUpdated  = "updated"
+)
+
+// Page sizes for QueryTasks.
+const (
+	DefaultTaskPageSize = 50
+	MaxTaskPageSize     = 500
+)
+
+// TaskQuery selects, orders and pages tasks. Zero fields do not filter.
+type TaskQuery struct {
+	Project       string // InProject semantics
+	Status        string // "all" is the same as ""
+	AssignedTo    string // also matches tasks assigned to "any"
+	CreatedBy     string
+	Labels        []string // a task must carry all of them (case-insensitive)
+	Priority      int
+	PlanID        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	Text          string // every word must occur in the title or description (case-insensitive)
+	Sort          string // TaskSortID by default
+	Desc          bool
+	Cursor        string // NextCursor of the previous page
+	Limit         int    // 0 = DefaultTaskPageSize; capped at MaxTaskPageSize
+}
+
+// TaskPage is one page of QueryTasks results.
+type TaskPage struct {
+	Tasks      []domain.Task
+	Total      int    // tasks matching the filters, on all pages
+	NextCursor string // empty on the last page
+}
+
+// MatchTask reports whether t passes q's filters.
+func MatchTask(t *domain.Task, q TaskQuery) bool {
+	switch {
+	case !InProject(t.Project, q.Project):
+	case q.Status != "" && q.Status != "all" && t.Status != q.Status:
+	case q.AssignedTo != "" && t.AssignedTo != q.AssignedTo && t.AssignedTo != "any":
+	case q.CreatedBy != "" && t.CreatedBy != q.CreatedBy:
+	case q.Priority != 0 && t.Priority != q.Priority:
+	case q.PlanID != "" && t.PlanID != q.PlanID:
+	case !q.CreatedAfter.IsZero() && t.CreatedAt.Before(q.CreatedAfter):
+	case !q.CreatedBefore.IsZero() && !t.CreatedAt.Before(q.CreatedBefore):
+	case !q.UpdatedAfter.IsZero() && t.UpdatedAt.Before(q.UpdatedAfter):
+	case !q.UpdatedBefore.IsZero() && !t.UpdatedAt.Before(q.UpdatedBefore):
+	case !hasLabels(t, q.Labels):
+	case !matchText(t, q.Text):
+	default:
+		return true
+	}
+	return false
+}
+
+func hasLabels(t *domain.Task, labels []string) bool {
+	for _, l := range labels {
+		if !slices.ContainsFunc(t.Labels, func(have string) bool { return strings.EqualFold(have, l) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchText(t *domain.Task, text string) bool {
+	if text == "" {
+		return true
+	}
+	haystack := strings.ToLower(t.Title + "\n" + t.Description)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryTasks filters tasks with q, sorts them and returns the page after
+// q.Cursor. Cursors hold the sort key of the last task returned, so pages
+// stay consistent while tasks are added or removed.
+func QueryTasks(tasks []domain.Task, q TaskQuery) (TaskPage, error) {
+	if q.Sort == "" {
+		q.Sort = TaskSortID
+	}
+	if !slices.Contains([]string{TaskSortID, TaskSortPriority, TaskSortCreated, TaskSortUpdated}, q.Sort) {
+		return TaskPage{}, fmt.Errorf("invalid sort %q (want %s, %s, %s or %s)", q.Sort, TaskSortID, TaskSortPriority, TaskSortCreated, TaskSortUpdated)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultTaskPageSize
+	}
+	limit = min(limit, MaxTaskPageSize)
+	var after *taskKey
+	if q.Cursor != "" {
+		k, err := decodeTaskCursor(q.Cursor, q.sortName())
+		if err != nil {
+			return TaskPage{}, err
+		}
+		after = &k
+	}
+
+	var matched []domain.Task
+	for i := range tasks {
+		if MatchTask(&tasks[i], q) {
+			matched = append(matched, tasks[i])
+		}
+	}
+	page := TaskPage{Total: len(matched)}
+	cmp := func(a, b taskKey) int {
+		c := a.compare(b)
+		if q.Desc {
+			return -c
+		}
+		return c
+	}
+	slices.SortStableFunc(matched, func(a, b domain.Task) int { return cmp(sortKey(&a, q.Sort), sortKey(&b, q.Sort)) })
+	start := 0
+	if after != nil {
+		start = len(matched)
+		for i := range matched {
+			if cmp(sortKey(&matched[i], q.Sort), *after) > 0 {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+limit, len(matched))
+	page.Tasks = matched[start:end]
+	if end < len(matched) {
+		page.NextCursor = encodeTaskCursor(q.sortName(), sortKey(&matched[end-1], q.Sort))
+	}
+	return page, nil
+}
+
+// sortName identifies the sort order a cursor belongs to.
+func (q TaskQuery) sortName() string {
+	if q.Desc {
+		return q.Sort + "-desc"
+	}
+	return q.Sort
+}
+
+// taskKey is a task's position in a sort order.
+type taskKey struct {
+	primary int64
+	id      int
+}
+
+func (a taskKey) compare(b taskKey) int {
+	switch {
+	case a.primary != b.primary:
+		if a.primary < b.primary {
+			return -1
+		}
+		return 1
+	case a.id != b.id:
+		if a.id < b.id {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func sortKey(t *domain.Task, sort string) taskKey {
+	k := taskKey{id: t.ID}
+	switch sort {
+	case TaskSortPriority:
+		k.primary = int64(t.Priority)
+	case TaskSortCreated:
+		k.primary = t.CreatedAt.UnixNano()
+	case TaskSortUpdated:
+		k.primary = t.UpdatedAt.UnixNano()
+	default:
+		k.primary = int64(t.ID)
+	}
+	return k
+}
+
+func encodeTaskCursor(sort string, k taskKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d:%d", sort, k.primary, k.id)))
+}
+
+func decodeTaskCursor(cursor, sort string) (taskKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	parts := strings.Split(string(raw), ":")
+	if err != nil || len(parts) != 3 {
+		return taskKey{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	if parts[0] != sort {
+		return taskKey{}, fmt.Errorf("cursor is for sort %q, not %q; start again without a cursor", parts[0], sort)
+	}
+	primary, err1 := strconv.ParseInt(parts[1], 10, 64)
+	id, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil {
+		return taskKey{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return taskKey{primary: primary, id: id}, nil
+}
+
+// NormalizeLabels trims labels and drops empty ones and case-insensitive
+// duplicates, keeping the first spelling. Labels may not contain commas.
+func NormalizeLabels(labels []string) ([]string, error) {
+	var out []string
+	for _, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if strings.Contains(l, ",") {
+			return nil, fmt.Errorf("label %q must not contain commas", l)
+		}
+		if !slices.ContainsFunc(out, func(have string) bool { return strings.EqualFold(have, l) }) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+// ParseQueryTime parses a date-range bound: an RFC3339 time or a date
+// (YYYY-MM-DD, midnight local time).
+func ParseQueryTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: want RFC3339 or YYYY-MM-DD", s)
+}
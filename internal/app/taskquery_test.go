@@ -0,0 +1,151 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func queryFixture() []domain.Task {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []domain.Task{
+		{ID: 1, Title: "Fix login redirect", Description: "Users land on /home", Status: "completed", AssignedTo: "claude-code", CreatedBy: "cursor",
+			Priority: 2, Labels: []string{"bug", "Frontend"}, CreatedAt: base, UpdatedAt: base.Add(5 * time.Hour), Project: "/work/alpha"},
+		{ID: 2, Title: "Add rate limiting", Description: "Token bucket per IP", Status: "pending", AssignedTo: "any", CreatedBy: "cursor",
+			Priority: 3, PlanID: "api", CreatedAt: base.Add(time.Hour), UpdatedAt: base.Add(time.Hour), Project: "/work/alpha"},
+		{ID: 3, Title: "Flaky login test", Status: "in_progress", AssignedTo: "codex", CreatedBy: "claude-code",
+			Priority: 1, Labels: []string{"bug", "tests"}, CreatedAt: base.Add(2 * time.Hour), UpdatedAt: base.Add(3 * time.Hour), Project: "/work/alpha"},
+		{ID: 4, Title: "Document API", Status: "pending", AssignedTo: "claude-code", CreatedBy: "cursor",
+			Priority: 3, PlanID: "api", CreatedAt: base.Add(3 * time.Hour), UpdatedAt: base.Add(4 * time.Hour), Project: "/work/beta"},
+	}
+}
+
+func taskIDs(tasks []domain.Task) []int {
+	var ids []int
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestQueryTasks_Filters(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		q    TaskQuery
+		want []int
+	}{
+		{"all", TaskQuery{}, []int{1, 2, 3, 4}},
+		{"project", TaskQuery{Project: "/work/alpha"}, []int{1, 2, 3}},
+		{"status", TaskQuery{Status: "pending"}, []int{2, 4}},
+		{"assignee includes any", TaskQuery{AssignedTo: "claude-code"}, []int{1, 2, 4}},
+		{"creator", TaskQuery{CreatedBy: "claude-code"}, []int{3}},
+		{"label case-insensitive", TaskQuery{Labels: []string{"frontend"}}, []int{1}},
+		{"all labels", TaskQuery{Labels: []string{"bug", "tests"}}, []int{3}},
+		{"priority", TaskQuery{Priority: 3}, []int{2, 4}},
+		{"plan", TaskQuery{PlanID: "api"}, []int{2, 4}},
+		{"created range", TaskQuery{CreatedAfter: base.Add(time.Hour), CreatedBefore: base.Add(3 * time.Hour)}, []int{2, 3}},
+		{"updated after", TaskQuery{UpdatedAfter: base.Add(4 * time.Hour)}, []int{1, 4}},
+		{"updated before", TaskQuery{UpdatedBefore: base.Add(2 * time.Hour)}, []int{2}},
+		{"text in title", TaskQuery{Text: "LOGIN"}, []int{1, 3}},
+		{"text words in title and description", TaskQuery{Text: "login home"}, []int{1}},
+		{"combined", TaskQuery{Labels: []string{"bug"}, Status: "in_progress"}, []int{3}},
+		{"no match", TaskQuery{Text: "kubernetes"}, nil},
+	}
+	for _, tt := range tests {
+		page, err := QueryTasks(queryFixture(), tt.q)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := taskIDs(page.Tasks); !slices.Equal(got, tt.want) || page.Total != len(tt.want) {
+			t.Errorf("%s: got %v (total %d), want %v", tt.name, got, page.Total, tt.want)
+		}
+	}
+}
+
+func TestQueryTasks_Sort(t *testing.T) {
+	tests := []struct {
+		sort string
+		desc bool
+		want []int
+	}{
+		{"", false, []int{1, 2, 3, 4}},
+		{TaskSortID, true, []int{4, 3, 2, 1}},
+		{TaskSortPriority, false, []int{3, 1, 2, 4}},
+		{TaskSortPriority, true, []int{4, 2, 1, 3}},
+		{TaskSortUpdated, true, []int{1, 4, 3, 2}},
+		{TaskSortCreated, false, []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		page, err := QueryTasks(queryFixture(), TaskQuery{Sort: tt.sort, Desc: tt.desc})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.sort, err)
+		}
+		if got := taskIDs(page.Tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("sort %q desc=%v: got %v, want %v", tt.sort, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestQueryTasks_Pagination(t *testing.T) {
+	tasks := queryFixture()
+	q := TaskQuery{Sort: TaskSortPriority, Limit: 2}
+
+	page, err := QueryTasks(tasks, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskIDs(page.Tasks); !slices.Equal(got, []int{3, 1}) || page.Total != 4 || page.NextCursor == "" {
+		t.Fatalf("first page = %v, total %d, cursor %q", got, page.Total, page.NextCursor)
+	}
+
+	// A task added between pages lands where it sorts; the next page
+	// continues after the last task seen.
+	tasks = append(tasks, domain.Task{ID: 5, Title: "Urgent", Status: "pending", Priority: 1})
+	q.Cursor = page.NextCursor
+	page, err = QueryTasks(tasks, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskIDs(page.Tasks); !slices.Equal(got, []int{2, 4}) || page.NextCursor != "" {
+		t.Errorf("second page = %v, cursor %q; want [2 4], last page", got, page.NextCursor)
+	}
+
+	q.Sort = TaskSortID
+	if _, err := QueryTasks(tasks, q); err == nil || !strings.Contains(err.Error(), "cursor is for sort") {
+		t.Errorf("cursor with another sort: error = %v", err)
+	}
+	q.Cursor = "not-a-cursor"
+	if _, err := QueryTasks(tasks, q); err == nil || !strings.Contains(err.Error(), "invalid cursor") {
+		t.Errorf("bad cursor: error = %v", err)
+	}
+	if _, err := QueryTasks(tasks, TaskQuery{Sort: "title"}); err == nil || !strings.Contains(err.Error(), "invalid sort") {
+		t.Errorf("bad sort: error = %v", err)
+	}
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	got, err := NormalizeLabels([]string{" bug ", "", "Bug", "frontend"})
+	if err != nil || !slices.Equal(got, []string{"bug", "frontend"}) {
+		t.Errorf("NormalizeLabels = %v, %v; want [bug frontend]", got, err)
+	}
+	if _, err := NormalizeLabels([]string{"a,b"}); err == nil {
+		t.Error("expected error for a label with a comma")
+	}
+}
+
+func TestParseQueryTime(t *testing.T) {
+	if got, err := ParseQueryTime("2026-03-01T12:00:00Z"); err != nil || !got.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC3339 = %v, %v", got, err)
+	}
+	if got, err := ParseQueryTime("2026-03-01"); err != nil || !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("date = %v, %v", got, err)
+	}
+	if _, err := ParseQueryTime("yesterday"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+var (
+	// templateVar matches a {name} variable reference in a task template.
+	templateVar = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	// shellSafe matches values sh passes through as one word unchanged.
+	shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:@%+=,-]+$`)
+)
+
+// shellQuote quotes s as a single sh word.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// TemplatedTask is a task template with its variables filled in.
+type TemplatedTask struct {
+	Title               string
+	Description         string
+	Capabilities        []string
+	WorkerType          string
+	Constraints         []string
+	ExpectedDurationSec int
+	Priority            int // 0 = not set by the template
+	Verify              []string
+}
+
+// TemplateVars returns the variables tpl references, sorted.
+func TemplateVars(tpl policy.TaskTemplate) []string {
+	seen := make(map[string]bool)
+	for _, text := range templateTexts(tpl) {
+		for _, m := range templateVar.FindAllStringSubmatch(text, -1) {
+			seen[m[1]] = true
+		}
+	}
+	vars := make([]string, 0, len(seen))
+	for v := range seen {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+func templateTexts(tpl policy.TaskTemplate) []string {
+	texts := []string{tpl.Title, tpl.Description}
+	texts = append(texts, tpl.Constraints...)
+	texts = append(texts, tpl.Verify...)
+	return append(texts, tpl.Acceptance...)
+}
+
+// ExpandTaskTemplate fills the variables of tpl from vars, falling back to
+// the template's defaults. Every referenced variable must get a value, and
+// vars may only name variables the template references or defines.
+// Acceptance criteria are appended to the description. Values are
+// shell-quoted in verify commands, which run with sh -c: a variable is always
+// one word there (policy.LoadConfig rejects placeholders inside quotes).
+func ExpandTaskTemplate(name string, tpl policy.TaskTemplate, vars map[string]string) (TemplatedTask, error) {
+	referenced := TemplateVars(tpl)
+	for v := range vars {
+		if _, ok := tpl.Vars[v]; !ok && !slices.Contains(referenced, v) {
+			return TemplatedTask{}, fmt.Errorf("template %q has no variable %q (variables: %s)", name, v, strings.Join(referenced, ", "))
+		}
+	}
+	values := make(map[string]string, len(referenced))
+	var missing []string
+	for _, v := range referenced {
+		if val, ok := vars[v]; ok {
+			values[v] = val
+		} else if val, ok := tpl.Vars[v]; ok {
+			values[v] = val
+		} else {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return TemplatedTask{}, fmt.Errorf("template %q needs vars: %s", name, strings.Join(missing, ", "))
+	}
+	expandWith := func(s string, quote func(string) string) string {
+		return templateVar.ReplaceAllStringFunc(s, func(ref string) string {
+			return quote(values[ref[1:len(ref)-1]])
+		})
+	}
+	expand := func(s string) string {
+		return expandWith(s, func(v string) string { return v })
+	}
+	expandAll := func(in []string) []string {
+		var out []string
+		for _, s := range in {
+			out = append(out, expand(s))
+		}
+		return out
+	}
+	var verify []string
+	for _, c := range tpl.Verify {
+		verify = append(verify, expandWith(c, shellQuote))
+	}
+
+	t := TemplatedTask{
+		Title:               expand(tpl.Title),
+		Description:         expand(tpl.Description),
+		Capabilities:        slices.Clone(tpl.Capabilities),
+		WorkerType:          tpl.WorkerType,
+		Constraints:         expandAll(tpl.Constraints),
+		ExpectedDurationSec: tpl.ExpectedDurationSec,
+		Priority:            tpl.Priority,
+		Verify:              verify,
+	}
+	if len(tpl.Acceptance) > 0 {
+		var b strings.Builder
+		b.WriteString(strings.TrimRight(t.Description, "\n"))
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("Acceptance criteria:")
+		for _, a := range expandAll(tpl.Acceptance) {
+			b.WriteString("\n- " + a)
+		}
+		t.Description = b.String()
+	}
+	return t, nil
+}
+
+// TaskTemplateNames returns the names of templates, sorted.
+func TaskTemplateNames(templates map[string]policy.TaskTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestExpandTaskTemplate(t *testing.T) {
+	tpl := policy.TaskTemplate{
+		Title:        "Add tests for {package}",
+		Description:  "Cover {package}.",
+		Vars:         map[string]string{"coverage": "80"},
+		Capabilities: []string{"testing"},
+		Constraints:  []string{"keep {package} API stable"},
+		Acceptance:   []string{"coverage >= {coverage}%", "go test ./{package}/... passes"},
+		Verify:       []string{"go test ./{package}/..."},
+	}
+	if got := TemplateVars(tpl); !slices.Equal(got, []string{"coverage", "package"}) {
+		t.Errorf("TemplateVars = %v", got)
+	}
+
+	task, err := ExpandTaskTemplate("add-tests", tpl, map[string]string{"package": "internal/app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Title != "Add tests for internal/app" {
+		t.Errorf("Title = %q", task.Title)
+	}
+	wantDesc := "Cover internal/app.\n\nAcceptance criteria:\n- coverage >= 80%\n- go test ./internal/app/... passes"
+	if task.Description != wantDesc {
+		t.Errorf("Description = %q, want %q", task.Description, wantDesc)
+	}
+	if !slices.Equal(task.Constraints, []string{"keep internal/app API stable"}) {
+		t.Errorf("Constraints = %v", task.Constraints)
+	}
+	if !slices.Equal(task.Verify, []string{"go test ./internal/app/..."}) {
+		t.Errorf("Verify = %v", task.Verify)
+	}
+
+	task, err = ExpandTaskTemplate("add-tests", tpl, map[string]string{"package": "x", "coverage": "95"})
+	if err != nil || !strings.Contains(task.Description, "coverage >= 95%") {
+		t.Errorf("vars should override defaults: %q, %v", task.Description, err)
+	}
+
+	if _, err := ExpandTaskTemplate("add-tests", tpl, nil); err == nil || !strings.Contains(err.Error(), "needs vars: package") {
+		t.Errorf("missing var: err = %v", err)
+	}
+	if _, err := ExpandTaskTemplate("add-tests", tpl, map[string]string{"package": "x", "pkg": "y"}); err == nil || !strings.Contains(err.Error(), `no variable "pkg"`) {
+		t.Errorf("unknown var: err = %v", err)
+	}
+}
+
+func TestExpandTaskTemplate_QuotesVerifyVars(t *testing.T) {
+	tpl := policy.TaskTemplate{Title: "Test {package}", Verify: []string{"go test ./{package}/..."}}
+	task, err := ExpandTaskTemplate("add-tests", tpl, map[string]string{"package": "x; touch pwned #'"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Title != "Test x; touch pwned #'" {
+		t.Errorf("Title = %q, want the value as is", task.Title)
+	}
+	// The value reaches the command as one word; nothing in it runs.
+	dir := t.TempDir()
+	v := RunVerification(context.Background(), dir, []string{strings.Replace(task.Verify[0], "go test", "echo", 1)}, time.Minute)
+	if !v.Passed || v.Checks[0].Output != "./x; touch pwned #'/..." {
+		t.Errorf("verify ran as %+v", v.Checks)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); !os.IsNotExist(err) {
+		t.Error("template var was executed by the shell")
+	}
+}
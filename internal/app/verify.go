@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+// A task with verification commands (its own, or the configured default)
+// can only be completed when they all succeed. update_task runs them in the
+// completing worker's directory before it changes the status; a failure
+// leaves the task as it was, records the outcome on the task and returns the
+// output to the worker.
+
+const (
+	defaultVerifyTimeout = 10 * time.Minute
+	verifyOutputTail     = 8 << 10 // bytes of output kept per command
+	verifyWaitDelay      = 5 * time.Second
+)
+
+// VerifyCommands returns the commands that must succeed before task can be
+// completed: its own, or the configured default. Empty means no gate.
+func VerifyCommands(task *domain.Task, cfg policy.VerificationConfig) []string {
+	if len(task.Verify) > 0 {
+		return task.Verify
+	}
+	return cfg.Commands
+}
+
+// VerifyTimeout returns the per-command timeout of cfg.
+func VerifyTimeout(cfg policy.VerificationConfig) time.Duration {
+	if cfg.TimeoutSeconds > 0 {
+		return time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return defaultVerifyTimeout
+}
+
+// VerificationDir returns the directory agent works in, for running the
+// verification of task when the worker manager has no process directory for
+// it: the agent's instance or presence workspace, else the task's project,
+// else fallback.
+func VerificationDir(state *domain.CollabState, task *domain.Task, agent, fallback string) string {
+	if inst, ok := state.AgentInstances[agent]; ok && inst != nil && inst.Workspace != "" {
+		return inst.Workspace
+	}
+	if p, ok := state.Presence[agent]; ok && p != nil && p.Workspace != "" {
+		return p.Workspace
+	}
+	if task.Project != "" {
+		return task.Project
+	}
+	return fallback
+}
+
+// RunVerification runs commands one after another with sh -c in dir,
+// stopping at the first one that fails, and reports the outcome. Each
+// command gets timeout; the output kept is its tail.
+func RunVerification(ctx context.Context, dir string, commands []string, timeout time.Duration) domain.Verification {
+	v := domain.Verification{Passed: true, Dir: dir, RanAt: time.Now()}
+	for _, c := range commands {
+		check := runCheck(ctx, dir, c, timeout)
+		v.Checks = append(v.Checks, check)
+		if check.ExitCode != 0 {
+			v.Passed = false
+			break
+		}
+	}
+	return v
+}
+
+func runCheck(ctx context.Context, dir, command string, timeout time.Duration) domain.VerificationCheck {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out := newTailBuffer(verifyOutputTail)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Kill the whole process group on timeout: test runners spawn children.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = verifyWaitDelay // don't hang on children that keep the output open
+
+	start := time.Now()
+	err := cmd.Run()
+	check := domain.VerificationCheck{Command: command, DurationMs: time.Since(start).Milliseconds()}
+	output := strings.TrimRight(out.String(), "\n")
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		check.ExitCode = -1
+		check.TimedOut = true
+	case errors.As(err, &exitErr):
+		check.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		check.ExitCode = -1
+		output = strings.TrimSpace(output + "\n" + err.Error())
+	}
+	check.Output = output
+	return check
+}
+
+// FailedCheck returns the check that failed verification v, if any.
+func FailedCheck(v *domain.Verification) *domain.VerificationCheck {
+	if v == nil || v.Passed {
+		return nil
+	}
+	if i := slices.IndexFunc(v.Checks, func(c domain.VerificationCheck) bool { return c.ExitCode != 0 }); i >= 0 {
+		return &v.Checks[i]
+	}
+	return nil
+}
+
+// RecordVerification stores v as task's latest verification outcome and
+// journals it.
+func RecordVerification(state *domain.CollabState, task *domain.Task, v domain.Verification) {
+	task.Verification = &v
+	data := map[string]string{"passed": strconv.FormatBool(v.Passed), "dir": v.Dir}
+	if c := FailedCheck(&v); c != nil {
+		data["command"] = c.Command
+		data["exit_code"] = strconv.Itoa(c.ExitCode)
+	}
+	RecordEvent(state, domain.Event{
+		Type:      domain.EventTaskVerified,
+		Actor:     v.RanBy,
+		TaskID:    task.ID,
+		Project:   task.Project,
+		Data:      data,
+		Timestamp: v.RanAt,
+	})
+}
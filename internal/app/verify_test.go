@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestRunVerification(t *testing.T) {
+	dir := t.TempDir()
+	v := RunVerification(context.Background(), dir, []string{"pwd", "echo fail >&2; exit 2", "echo never"}, time.Minute)
+	if v.Passed || v.Dir != dir || len(v.Checks) != 2 {
+		t.Fatalf("verification = %+v, want failed after 2 checks", v)
+	}
+	if c := v.Checks[0]; c.ExitCode != 0 || !strings.HasSuffix(c.Output, dir[strings.LastIndex(dir, "/"):]) {
+		t.Errorf("first check = %+v, want success in %s", c, dir)
+	}
+	if c := FailedCheck(&v); c == nil || c.ExitCode != 2 || c.Output != "fail" {
+		t.Errorf("failed check = %+v", c)
+	}
+
+	v = RunVerification(context.Background(), dir, []string{"true", "exit 0"}, time.Minute)
+	if !v.Passed || len(v.Checks) != 2 || FailedCheck(&v) != nil {
+		t.Errorf("verification = %+v, want passed", v)
+	}
+}
+
+func TestRunVerification_Timeout(t *testing.T) {
+	v := RunVerification(context.Background(), t.TempDir(), []string{"sleep 5"}, 100*time.Millisecond)
+	if c := FailedCheck(&v); c == nil || !c.TimedOut || c.ExitCode != -1 {
+		t.Errorf("verification = %+v, want timed out", v)
+	}
+}
+
+func TestVerifyCommands(t *testing.T) {
+	cfg := policy.VerificationConfig{Commands: []string{"make test"}}
+	if got := VerifyCommands(&domain.Task{}, cfg); !slices.Equal(got, []string{"make test"}) {
+		t.Errorf("default = %v", got)
+	}
+	if got := VerifyCommands(&domain.Task{Verify: []string{"go vet ./..."}}, cfg); !slices.Equal(got, []string{"go vet ./..."}) {
+		t.Errorf("task's own = %v", got)
+	}
+	if VerifyTimeout(cfg) != defaultVerifyTimeout || VerifyTimeout(policy.VerificationConfig{TimeoutSeconds: 30}) != 30*time.Second {
+		t.Error("unexpected timeout")
+	}
+}
+
+func TestVerificationDir(t *testing.T) {
+	state := domain.NewCollabState()
+	state.AgentInstances["codex"] = &domain.AgentInstance{InstanceID: "codex", Workspace: "/work/codex"}
+	state.Presence["cursor"] = &domain.Presence{Agent: "cursor", Workspace: "/work/cursor"}
+	task := &domain.Task{Project: "/work/alpha"}
+	for agent, want := range map[string]string{"codex": "/work/codex", "cursor": "/work/cursor", "claude-code": "/work/alpha"} {
+		if got := VerificationDir(state, task, agent, "/root"); got != want {
+			t.Errorf("%s: dir = %q, want %q", agent, got, want)
+		}
+	}
+	if got := VerificationDir(state, &domain.Task{}, "claude-code", "/root"); got != "/root" {
+		t.Errorf("fallback dir = %q", got)
+	}
+}
+
+func TestRecordVerification(t *testing.T) {
+	state := domain.NewCollabState()
+	state.Tasks = []domain.Task{{ID: 1, Project: "/work/alpha"}}
+	v := domain.Verification{Dir: "/work/alpha", RanBy: "codex", RanAt: time.Now(),
+		Checks: []domain.VerificationCheck{{Command: "make test", ExitCode: 2}}}
+	RecordVerification(state, &state.Tasks[0], v)
+	if state.Tasks[0].Verification == nil {
+		t.Fatal("verification not stored")
+	}
+	if len(state.PendingEvents) != 1 {
+		t.Fatalf("events = %+v", state.PendingEvents)
+	}
+	e := state.PendingEvents[0]
+	if e.Type != domain.EventTaskVerified || e.Actor != "codex" || e.TaskID != 1 || e.Data["passed"] != "false" || e.Data["command"] != "make test" {
+		t.Errorf("event = %+v", e)
+	}
+}
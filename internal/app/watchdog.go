@@ -199,7 +199,7 @@ func (w *Watchdog) check() {
 	prunedSessions = w.pruneStaleSessions()
 
 	// Phase 2: Recover stuck agents and tasks in a single state mutation.
-	err := w.svc.Run(func(state *domain.CollabState) error {
+	err := w.svc.RunAs("watchdog", func(state *domain.CollabState) error {
 		now := time.Now()
 
 		// Find dead agents: instances with no recent activity from any source.
@@ -256,6 +256,21 @@ func (w *Watchdog) check() {
 				t.ID, t.Title, t.AssignedTo, reason)
 
 			oldAssignee := t.AssignedTo
+			RecordEvent(state, domain.Event{
+				Type:    domain.EventWatchdogRecovered,
+				Actor:   "watchdog",
+				Target:  oldAssignee,
+				TaskID:  t.ID,
+				Project: t.Project,
+				Data: map[string]string{
+					"reason":          reason,
+					"previous_status": t.Status,
+					"idle_for":        now.Sub(t.UpdatedAt).Round(time.Second).String(),
+				},
+			})
+			if a := CloseAttempt(t, AttemptStalled, now); a != nil {
+				a.Error = reason
+			}
 			t.Status = "pending"
 			t.UpdatedAt = now
 			if t.ResultSummary == "" {
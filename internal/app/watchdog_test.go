@@ -115,6 +115,43 @@ func TestWatchdog_RecoverStuckTasks(t *testing.T) {
 	})
 }
 
+func TestWatchdog_JournalsRecovery(t *testing.T) {
+	state := domain.NewCollabState()
+	staleTime := time.Now().Add(-15 * time.Minute)
+	state.AgentInstances["claude-code"] = &domain.AgentInstance{
+		InstanceID: "claude-code", AgentType: "claude-code", Role: domain.RoleWorker,
+		Status: "busy", CurrentTasks: []int{1}, LastHeartbeat: staleTime,
+	}
+	state.Tasks = append(state.Tasks, domain.Task{ID: 1, Title: "Stuck task", Status: "in_progress", AssignedTo: "claude-code", UpdatedAt: staleTime})
+	state.NextTaskID = 2
+	state.NextMsgID = 1
+
+	repo := &journalTestRepo{notifierTestRepo: notifierTestRepo{state: state}}
+	logger := log.New(os.Stderr, "[test] ", 0)
+	svc := NewCollabService(repo, testPolicy(), logger)
+	wd := NewWatchdog(svc, NewSessionRegistry(), logger,
+		WithHeartbeatThreshold(1*time.Minute),
+		WithTaskStuckThreshold(5*time.Minute),
+	)
+	wd.CheckOnce()
+
+	var recovered, statusChanged *domain.Event
+	for i, e := range repo.events {
+		switch e.Type {
+		case domain.EventWatchdogRecovered:
+			recovered = &repo.events[i]
+		case domain.EventTaskStatusChanged:
+			statusChanged = &repo.events[i]
+		}
+	}
+	if recovered == nil || recovered.TaskID != 1 || recovered.Target != "claude-code" || recovered.Data["reason"] == "" {
+		t.Errorf("watchdog_recovered event = %+v", recovered)
+	}
+	if statusChanged == nil || statusChanged.Data["from"] != "in_progress" || statusChanged.Data["to"] != "pending" {
+		t.Errorf("task_status_changed event = %+v", statusChanged)
+	}
+}
+
 func TestWatchdog_DoesNotRecoverDriverTasks(t *testing.T) {
 	state := domain.NewCollabState()
 	staleTime := time.Now().Add(-15 * time.Minute)
@@ -175,6 +175,32 @@ func TestBuildWorkerEnv_MissingExpansionEmpty(t *testing.T) {
 	}
 }
 
+func TestBuildWorkerEnv_Token(t *testing.T) {
+	c := WorkerSpawnConfig{
+		InstanceID: "claude-code-1",
+		AgentType:  "claude-code",
+		InheritEnv: []string{"none"},
+		Env:        map[string]string{"STRINGWORK_TOKEN": "from-config"},
+	}
+
+	if envMap := envToMap(buildWorkerEnv(c, "/tmp/workspace")); envMap["STRINGWORK_TOKEN"] != "from-config" {
+		t.Errorf("without an issued token, config env should pass through, got %q", envMap["STRINGWORK_TOKEN"])
+	}
+
+	c.Token = "swk_issued"
+	if envMap := envToMap(buildWorkerEnv(c, "/tmp/workspace")); envMap["STRINGWORK_TOKEN"] != "swk_issued" {
+		t.Errorf("STRINGWORK_TOKEN = %q, want the issued token", envMap["STRINGWORK_TOKEN"])
+	}
+}
+
+func TestBuildWorkerEnv_TaskID(t *testing.T) {
+	c := WorkerSpawnConfig{InstanceID: "codex", InheritEnv: []string{"none"}, Task: &TaskBinding{ID: 12},
+		Env: map[string]string{"STRINGWORK_TASK_ID": "99"}}
+	if env := envToMap(buildWorkerEnv(c, "/ws")); env["STRINGWORK_TASK_ID"] != "12" {
+		t.Errorf("STRINGWORK_TASK_ID = %q, want 12", env["STRINGWORK_TASK_ID"])
+	}
+}
+
 func TestMatchEnvGlob(t *testing.T) {
 	tests := []struct {
 		pattern string
@@ -32,6 +32,8 @@ const (
 	failureBackoffBase     = 1 * time.Minute
 	failureBackoffMax      = 10 * time.Minute
 	failureBackoffMaxCount = 10 // stop auto-retrying after this many consecutive full failures
+	defaultIdleTimeout     = 5 * time.Minute
+	idleReapInterval       = 30 * time.Second
 )
 
 // WorkerSpawnConfig is a single spawnable worker (one instance).
@@ -45,6 +47,20 @@ type WorkerSpawnConfig struct {
 	MaxRetries int
 	Env        map[string]string // additional env vars for this worker
 	InheritEnv []string          // glob patterns for env var names to inherit (empty = all)
+	Token      string            // bearer token for the stringwork MCP server, issued per spawn
+	// Autoscaled instances start for type-wide work only while the queue needs
+	// them (see policy.WorkerConfig.Autoscaled).
+	Autoscaled   bool
+	MinInstances int           // autoscaling: instances started on any work
+	MaxInstances int           // autoscaling: upper bound
+	MaxTasks     int           // task slots per instance
+	IdleTimeout  time.Duration // autoscaling: stop after this long without a task or output
+	// TaskBound instances are launched for one pre-claimed task each; Task is
+	// that task for the current spawn (nil when woken by messages only).
+	TaskBound    bool
+	Capabilities []string
+	Task         *TaskBinding
+	Limits       *policy.WorkerLimits // resource limits per process; nil = none
 }
 
 // MCPServerEntry is a single MCP server configuration for worker CLI registration.
@@ -54,6 +70,9 @@ type MCPServerEntry struct {
 	Command string            // command-based server
 	Args    []string          // command arguments
 	Env     map[string]string // command environment
+	// BearerTokenEnv names an env var of the worker whose value the CLI sends
+	// as "Authorization: Bearer <value>" (URL-based servers only).
+	BearerTokenEnv string
 }
 
 // WorkerManager spawns and tracks worker instances from orchestration config (instance IDs, e.g. claude-code-1, claude-code-2).
@@ -88,6 +107,16 @@ type WorkerManager struct {
 	// backoffUntil holds an explicit "do not retry before" deadline, set when the
 	// worker output contains a parseable retry-after (e.g., quota reset time).
 	backoffUntil map[string]time.Time
+	// credentials issues the per-spawn tokens workers authenticate with.
+	credentials *AgentCredentials
+	// maxWorkers caps the worker processes running at once across all types (0 = no limit).
+	maxWorkers int
+	// active holds the instances with a spawn in flight, running or waiting between retries.
+	active map[string]struct{}
+	// scaledDown marks instances stopped for idleness, so spawn does not retry them.
+	scaledDown map[string]bool
+	// runLog records every run with its full output; nil = legacy per-instance log files.
+	runLog *WorkerRunLog
 }
 
 // ProcessInfo holds runtime process metadata for a worker instance.
@@ -102,12 +131,11 @@ type ProcessInfo struct {
 // NewWorkerManager creates a WorkerManager from orchestration config. Workers are built from orch.Workers only.
 func NewWorkerManager(orch *policy.OrchestrationConfig, getAgent func() string, repo StateRepository, stateMutator func(func(*domain.CollabState) error) error, fallbackDir string, logger *log.Logger) *WorkerManager {
 	var configs []WorkerSpawnConfig
+	maxWorkers := 0
 	if orch != nil {
+		maxWorkers = orch.MaxWorkers
 		for _, w := range orch.Workers {
-			n := w.Instances
-			if n <= 0 {
-				n = 1
-			}
+			n := w.InstanceCount()
 			cooldown := defaultWorkerCooldown
 			if w.CooldownSeconds > 0 {
 				cooldown = time.Duration(w.CooldownSeconds) * time.Second
@@ -124,21 +152,37 @@ func NewWorkerManager(orch *policy.OrchestrationConfig, getAgent func() string,
 			if w.MaxRetries > 0 {
 				maxRetries = w.MaxRetries
 			}
+			maxTasks := w.MaxConcurrentTasks
+			if maxTasks <= 0 {
+				maxTasks = 1
+			}
+			idleTimeout := defaultIdleTimeout
+			if w.IdleTimeoutSeconds > 0 {
+				idleTimeout = time.Duration(w.IdleTimeoutSeconds) * time.Second
+			}
 			for i := 0; i < n; i++ {
 				instanceID := w.Type
 				if n > 1 {
 					instanceID = fmt.Sprintf("%s-%d", w.Type, i+1)
 				}
 				configs = append(configs, WorkerSpawnConfig{
-					InstanceID: instanceID,
-					AgentType:  w.Type,
-					Command:    w.Command,
-					Cooldown:   cooldown,
-					Timeout:    timeout,
-					RetryDelay: retryDelay,
-					MaxRetries: maxRetries,
-					Env:        w.Env,
-					InheritEnv: w.InheritEnv,
+					InstanceID:   instanceID,
+					AgentType:    w.Type,
+					Command:      w.Command,
+					Cooldown:     cooldown,
+					Timeout:      timeout,
+					RetryDelay:   retryDelay,
+					MaxRetries:   maxRetries,
+					Env:          w.Env,
+					InheritEnv:   w.InheritEnv,
+					Autoscaled:   w.Autoscaled(),
+					MinInstances: w.MinInstances,
+					MaxInstances: w.MaxInstances,
+					MaxTasks:     maxTasks,
+					IdleTimeout:  idleTimeout,
+					TaskBound:    w.TaskBound,
+					Capabilities: w.Capabilities,
+					Limits:       w.Limits,
 				})
 			}
 		}
@@ -157,6 +201,9 @@ func NewWorkerManager(orch *policy.OrchestrationConfig, getAgent func() string,
 		consecutiveFailures: make(map[string]int),
 		lastFailure:         make(map[string]time.Time),
 		backoffUntil:        make(map[string]time.Time),
+		maxWorkers:          maxWorkers,
+		active:              make(map[string]struct{}),
+		scaledDown:          make(map[string]bool),
 	}
 }
 
@@ -171,6 +218,50 @@ func (m *WorkerManager) SetMCPServerURL(url string) {
 	m.mcpServerURL = strings.TrimSuffix(url, "/")
 }
 
+// SetCredentials enables agent authentication: every configured instance name
+// is protected right away, and each spawn gets a fresh token in STRINGWORK_TOKEN
+// that is revoked when the process exits.
+func (m *WorkerManager) SetCredentials(c *AgentCredentials) {
+	m.mu.Lock()
+	m.credentials = c
+	m.mu.Unlock()
+	for _, cfg := range m.configs {
+		c.Protect(cfg.InstanceID, instanceAliases(cfg)...)
+	}
+}
+
+// SetRunLog records each worker run, with its full output, in l instead of
+// appending to a stringwork-worker-<id>.log file per instance.
+func (m *WorkerManager) SetRunLog(l *WorkerRunLog) {
+	m.mu.Lock()
+	m.runLog = l
+	m.mu.Unlock()
+}
+
+// RunLog returns the run history set with SetRunLog, or nil.
+func (m *WorkerManager) RunLog() *WorkerRunLog {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runLog
+}
+
+// logLocation tells where the output of instanceID's runs can be read.
+func (m *WorkerManager) logLocation(instanceID string) string {
+	if m.RunLog() != nil {
+		return "get_worker_log instance=" + instanceID
+	}
+	return filepath.Join(policy.GlobalStateDir(), fmt.Sprintf("stringwork-worker-%s.log", strings.ReplaceAll(instanceID, "/", "-")))
+}
+
+// instanceAliases returns the names besides its instance ID a worker may act
+// under: its agent type, which single-instance configs use as the ID anyway.
+func instanceAliases(c WorkerSpawnConfig) []string {
+	if c.AgentType == "" || c.AgentType == c.InstanceID {
+		return nil
+	}
+	return []string{c.AgentType}
+}
+
 // GetProcessInfo returns process activity info for all running workers.
 func (m *WorkerManager) GetProcessInfo() map[string]ProcessInfo {
 	m.mu.Lock()
@@ -252,6 +343,7 @@ const (
 	workerErrorQuotaExhausted                         // API rate limit / quota exhausted
 	workerErrorAuth                                   // authentication / API key failure
 	workerErrorNotFound                               // binary not found or config error
+	workerErrorResourceLimit                          // stopped by a configured resource limit
 )
 
 // workerErrorInfo holds the classification result for a failed worker process.
@@ -269,14 +361,17 @@ func (e workerErrorClass) String() string {
 		return "auth_failure"
 	case workerErrorNotFound:
 		return "not_found"
+	case workerErrorResourceLimit:
+		return "resource_limit"
 	default:
 		return "transient"
 	}
 }
 
 // Terminal returns true if retrying the same command is pointless.
+// A resource limit is not: the next run may well stay within it.
 func (e workerErrorClass) Terminal() bool {
-	return e != workerErrorTransient
+	return e != workerErrorTransient && e != workerErrorResourceLimit
 }
 
 var quotaResetRe = regexp.MustCompile(`(?i)quota will reset after\s+(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?`)
@@ -286,6 +381,15 @@ var quotaResetRe = regexp.MustCompile(`(?i)quota will reset after\s+(?:(\d+)h)?(
 func classifyWorkerError(output string) workerErrorInfo {
 	lower := strings.ToLower(output)
 
+	// Resource limits: reported by runOnce, or recognized from rlimit errors
+	if _, after, ok := strings.Cut(output, resourceLimitMarker); ok {
+		limit, _, _ := strings.Cut(after, "\n")
+		return workerErrorInfo{
+			Class:   workerErrorResourceLimit,
+			Summary: "resource limit exceeded: " + strings.TrimSpace(limit),
+		}
+	}
+
 	// Quota / rate limit
 	if strings.Contains(lower, "quotaerror") ||
 		strings.Contains(lower, "quota") && strings.Contains(lower, "exhausted") ||
@@ -338,6 +442,13 @@ func classifyWorkerError(output string) workerErrorInfo {
 		}
 	}
 
+	if limit := limitFromOutput(lower); limit != "" {
+		return workerErrorInfo{
+			Class:   workerErrorResourceLimit,
+			Summary: "resource limit likely exceeded: " + limit,
+		}
+	}
+
 	return workerErrorInfo{Class: workerErrorTransient}
 }
 
@@ -477,6 +588,8 @@ func (m *WorkerManager) StartupCheck() {
 }
 
 // Check examines state and spawns workers for instances that have unread messages or pending tasks.
+// Workers are spawned into the connected agent's workspace, so only work in its
+// project counts (all projects when no agent with a workspace is connected).
 // In HTTP mode, skips spawning if the MCP endpoint is not reachable.
 func (m *WorkerManager) Check() {
 	if len(m.configs) == 0 {
@@ -486,12 +599,19 @@ func (m *WorkerManager) Check() {
 		return
 	}
 	connected := m.getAgent()
-	state, err := m.repo.Load()
+	q := QueriesFor(m.repo)
+	project, err := QueryAgentProject(q, connected, "")
+	if err != nil {
+		return
+	}
+	unreadBy, err := q.UnreadByRecipient(project)
+	if err != nil {
+		return
+	}
+	pendingBy, err := q.PendingByAssignee(project)
 	if err != nil {
 		return
 	}
-	EnsureStateMaps(state)
-	EnsureAgentInstances(state, nil)
 
 	unreadFor := make(map[string]int)
 	pendingFor := make(map[string]int)
@@ -500,51 +620,61 @@ func (m *WorkerManager) Check() {
 	for _, c := range m.configs {
 		agentTypes[c.AgentType] = struct{}{}
 	}
-	for _, msg := range state.Messages {
-		if msg.Read {
-			continue
+	note := func(who string, ts time.Time) {
+		if ts.After(latestUnread[who]) {
+			latestUnread[who] = ts
 		}
-		if msg.To == "all" {
+	}
+	for to, b := range unreadBy {
+		if to == "all" {
 			for typ := range agentTypes {
-				unreadFor[typ]++
-				if msg.Timestamp.After(latestUnread[typ]) {
-					latestUnread[typ] = msg.Timestamp
-				}
+				unreadFor[typ] += b.Count
+				note(typ, b.Latest)
 			}
 			continue
 		}
-		unreadFor[msg.To]++
-		if msg.Timestamp.After(latestUnread[msg.To]) {
-			latestUnread[msg.To] = msg.Timestamp
-		}
+		unreadFor[to] += b.Count
+		note(to, b.Latest)
 	}
-	for _, t := range state.Tasks {
-		if t.Status != "pending" {
-			continue
-		}
-		if t.AssignedTo == "any" {
+	for assignee, b := range pendingBy {
+		if assignee == "any" {
 			for typ := range agentTypes {
-				pendingFor[typ]++
-				if t.CreatedAt.After(latestUnread[typ]) {
-					latestUnread[typ] = t.CreatedAt
-				}
+				pendingFor[typ] += b.Count
+				note(typ, b.Latest)
 			}
 			continue
 		}
-		pendingFor[t.AssignedTo]++
-		if t.CreatedAt.After(latestUnread[t.AssignedTo]) {
-			latestUnread[t.AssignedTo] = t.CreatedAt
-		}
+		pendingFor[assignee] += b.Count
+		note(assignee, b.Latest)
+	}
+
+	// Autoscaled types may only start as many new instances for type-wide
+	// work as the queue needs; maxWorkers bounds all types together.
+	budget := m.scaleBudgets(q, unreadFor, pendingFor)
+	slots := -1
+	if m.maxWorkers > 0 {
+		slots = m.maxWorkers - m.activeCount()
 	}
 
-	workspace := m.resolveWorkspace(state)
+	// The workspace needs presence data, so only load the full state once a
+	// worker is actually about to be spawned.
+	workspace := ""
+	workspaceResolved := false
 
 	for _, c := range m.configs {
 		if c.InstanceID == connected || c.AgentType == connected {
 			continue
 		}
-		hasWork := (unreadFor[c.AgentType] > 0 || pendingFor[c.AgentType] > 0) || (unreadFor[c.InstanceID] > 0 || pendingFor[c.InstanceID] > 0)
-		if !hasWork {
+		ownWork := unreadFor[c.InstanceID] > 0 || pendingFor[c.InstanceID] > 0
+		typeWork := c.InstanceID != c.AgentType && (unreadFor[c.AgentType] > 0 || pendingFor[c.AgentType] > 0)
+		if !ownWork && !typeWork {
+			continue
+		}
+		scaleUp := c.Autoscaled && !ownWork
+		if scaleUp && budget[c.AgentType] <= 0 {
+			continue
+		}
+		if m.isActive(c.InstanceID) {
 			continue
 		}
 		if m.sessionChecker != nil && (m.sessionChecker(c.InstanceID) || m.sessionChecker(c.AgentType)) {
@@ -574,12 +704,40 @@ func (m *WorkerManager) Check() {
 				continue
 			}
 		}
+		if slots == 0 {
+			m.logger.Printf("WorkerManager: %s not spawned — max_workers (%d) reached", c.InstanceID, m.maxWorkers)
+			continue
+		}
 		if !m.acquireLock(c.InstanceID) {
 			continue
 		}
+		if c.TaskBound {
+			c.Task = m.claimTask(c, 0, project)
+			if c.Task == nil && unreadFor[c.AgentType]+unreadFor[c.InstanceID] == 0 {
+				// Another instance claimed the pending work first.
+				m.releaseLock(c.InstanceID)
+				continue
+			}
+		}
+		if scaleUp {
+			budget[c.AgentType]--
+		}
+		if slots > 0 {
+			slots--
+		}
+		m.setActive(c.InstanceID, true)
 		unread := unreadFor[c.AgentType] + unreadFor[c.InstanceID]
 		pending := pendingFor[c.AgentType] + pendingFor[c.InstanceID]
 
+		if !workspaceResolved {
+			workspace = m.fallbackDir
+			if state, err := m.repo.Load(); err == nil {
+				EnsureStateMaps(state)
+				workspace = m.resolveWorkspace(state)
+			}
+			workspaceResolved = true
+		}
+
 		// Use worktree isolation if configured and workspace is a git repo
 		spawnDir := workspace
 		if m.worktreeManager != nil {
@@ -591,7 +749,11 @@ func (m *WorkerManager) Check() {
 			}
 		}
 
-		m.logger.Printf("WorkerManager: spawning %s (%d unread, %d pending, workspace=%s)", c.InstanceID, unread, pending, spawnDir)
+		if c.Task != nil {
+			m.logger.Printf("WorkerManager: spawning %s for task #%d (workspace=%s)", c.InstanceID, c.Task.ID, spawnDir)
+		} else {
+			m.logger.Printf("WorkerManager: spawning %s (%d unread, %d pending, workspace=%s)", c.InstanceID, unread, pending, spawnDir)
+		}
 		m.sendAck(c.InstanceID, connected, unread, pending)
 		go m.spawn(c, spawnDir)
 	}
@@ -790,6 +952,7 @@ func (m *WorkerManager) resolveWorkspace(state *domain.CollabState) string {
 
 func (m *WorkerManager) spawn(c WorkerSpawnConfig, workspaceDir string) {
 	defer m.releaseLock(c.InstanceID)
+	defer m.setActive(c.InstanceID, false)
 	retryDelay := c.RetryDelay
 	var lastResult runResult
 	attempts := 0
@@ -801,10 +964,21 @@ func (m *WorkerManager) spawn(c WorkerSpawnConfig, workspaceDir string) {
 			if retryDelay > 2*time.Minute {
 				retryDelay = 2 * time.Minute
 			}
+			// The failed run released its task; take it back unless it moved on.
+			if c.Task != nil {
+				if c.Task = m.claimTask(c, c.Task.ID, ""); c.Task == nil {
+					m.logger.Printf("WorkerManager: %s not retried — its task was taken or finished meanwhile", c.InstanceID)
+					return
+				}
+			}
 		}
 		lastResult = m.runOnce(c, workspaceDir, attempt)
 		attempts = attempt + 1
-		if lastResult.Err == nil {
+		m.mu.Lock()
+		scaledDown := m.scaledDown[c.InstanceID]
+		delete(m.scaledDown, c.InstanceID)
+		m.mu.Unlock()
+		if lastResult.Err == nil || scaledDown {
 			m.mu.Lock()
 			m.lastSpawn[c.InstanceID] = time.Now()
 			m.consecutiveFailures[c.InstanceID] = 0
@@ -836,13 +1010,13 @@ func (m *WorkerManager) spawn(c WorkerSpawnConfig, workspaceDir string) {
 	m.mu.Unlock()
 
 	nextBackoff := m.failureBackoff(c.InstanceID)
-	logPath := filepath.Join(policy.GlobalStateDir(), fmt.Sprintf("stringwork-worker-%s.log", strings.ReplaceAll(c.InstanceID, "/", "-")))
+	logPath := m.logLocation(c.InstanceID)
 	if failures >= failureBackoffMaxCount {
 		m.logger.Printf("WorkerManager: %s failed %d consecutive times, giving up (manual restart required; full log: %s)", c.InstanceID, failures, logPath)
 	} else {
 		m.logger.Printf("WorkerManager: %s failed after %d attempts (%d consecutive failures, next retry in %s; full log: %s)", c.InstanceID, c.MaxRetries+1, failures, nextBackoff.Round(time.Second), logPath)
 	}
-	m.sendFailureAck(c.InstanceID, lastResult.Err, attempts)
+	m.sendFailureAck(c.InstanceID, lastResult.Err, classifyWorkerError(lastResult.Output), attempts)
 }
 
 // recordTerminalFailure sets the backoff state for a terminal error.
@@ -911,10 +1085,12 @@ func (m *WorkerManager) sendTerminalFailureAck(instanceID string, info workerErr
 }
 
 // buildWorkerEnv constructs the environment for a spawned worker process.
-// It handles three layers:
+// It handles four layers:
 //  1. Base: inherited from parent process (filtered by InheritEnv patterns if set)
 //  2. STRINGWORK_AGENT and STRINGWORK_WORKSPACE always injected
 //  3. Config env vars merged on top (with ${VAR} expansion from parent env)
+//  4. STRINGWORK_TOKEN when a token was issued and STRINGWORK_TASK_ID for a
+//     task-bound spawn; config cannot override them
 func buildWorkerEnv(c WorkerSpawnConfig, workspaceDir string) []string {
 	parentEnv := os.Environ()
 	parentMap := make(map[string]string, len(parentEnv))
@@ -961,6 +1137,13 @@ func buildWorkerEnv(c WorkerSpawnConfig, workspaceDir string) []string {
 		base = setEnvVar(base, k, expanded)
 	}
 
+	if c.Token != "" {
+		base = setEnvVar(base, WorkerTokenEnv, c.Token)
+	}
+	if c.Task != nil {
+		base = setEnvVar(base, "STRINGWORK_TASK_ID", strconv.Itoa(c.Task.ID))
+	}
+
 	return base
 }
 
@@ -983,8 +1166,13 @@ func matchEnvGlob(pattern, name string) bool {
 	return matched
 }
 
-func expandWorkerTemplates(args []string, agent, workspace string) []string {
-	replacer := strings.NewReplacer("{workspace}", workspace, "{agent}", agent)
+// expandWorkerTemplates fills the {workspace} and {agent} placeholders of a
+// worker command, and the {task_*}, {relevant_files}, {constraints} and
+// {background} placeholders from the task a task-bound spawn was launched for.
+// Values are not escaped: each argument reaches the worker as is, which is
+// safe unless a shell parses it (policy.LoadConfig rejects such commands).
+func expandWorkerTemplates(args []string, agent, workspace string, task *TaskBinding) []string {
+	replacer := strings.NewReplacer(append([]string{"{workspace}", workspace, "{agent}", agent}, task.templateReplacements()...)...)
 	out := make([]string, len(args))
 	for i, a := range args {
 		out[i] = replacer.Replace(a)
@@ -1087,10 +1275,14 @@ func (m *WorkerManager) mcpServerEntries() []MCPServerEntry {
 	seen := make(map[string]struct{})
 
 	if m.mcpServerURL != "" {
-		entries = append(entries, MCPServerEntry{
+		entry := MCPServerEntry{
 			Name: "stringwork",
 			URL:  m.mcpServerURL,
-		})
+		}
+		if m.credentials != nil {
+			entry.BearerTokenEnv = WorkerTokenEnv
+		}
+		entries = append(entries, entry)
 		seen["stringwork"] = struct{}{}
 	}
 	for _, s := range m.mcpServers {
@@ -1131,6 +1323,12 @@ func isClaudeMCPConfigured(name string, entry MCPServerEntry) bool {
 		if existingURL == "" {
 			return false
 		}
+		if entry.BearerTokenEnv != "" {
+			headers, _ := serverCfg["headers"].(map[string]interface{})
+			if auth, _ := headers["Authorization"].(string); auth != claudeBearerHeader(entry.BearerTokenEnv) {
+				return false
+			}
+		}
 		// Exact URL match required. Different paths (e.g. /mcp vs /sse) use different
 		// protocols — Codex's rmcp only supports streamable HTTP (/mcp), not SSE.
 		return strings.TrimSuffix(existingURL, "/") == strings.TrimSuffix(entry.URL, "/")
@@ -1193,6 +1391,9 @@ func isCodexMCPConfigured(name string, entry MCPServerEntry) bool {
 		sectionBody = sectionBody[:nextSect]
 	}
 	if entry.URL != "" {
+		if entry.BearerTokenEnv != "" && !strings.Contains(sectionBody, fmt.Sprintf(`bearer_token_env_var = "%s"`, entry.BearerTokenEnv)) {
+			return false
+		}
 		// Exact URL match required. Different paths (e.g. /mcp vs /sse) use different
 		// protocols — Codex's rmcp only supports streamable HTTP (/mcp), not SSE.
 		return strings.Contains(sectionBody, fmt.Sprintf(`url = "%s"`, entry.URL))
@@ -1217,6 +1418,9 @@ func registerMCPViaClaude(exe string, entry MCPServerEntry, logger *log.Logger)
 	if entry.URL != "" {
 		cfg["type"] = "http"
 		cfg["url"] = entry.URL
+		if entry.BearerTokenEnv != "" {
+			cfg["headers"] = map[string]string{"Authorization": claudeBearerHeader(entry.BearerTokenEnv)}
+		}
 	} else {
 		cfg["type"] = "stdio"
 		cfg["command"] = entry.Command
@@ -1263,6 +1467,9 @@ func registerMCPViaCodex(exe string, entry MCPServerEntry, logger *log.Logger) e
 	args := []string{"mcp", "add", entry.Name}
 	if entry.URL != "" {
 		args = append(args, "--url", entry.URL)
+		if entry.BearerTokenEnv != "" {
+			args = append(args, "--bearer-token-env-var", entry.BearerTokenEnv)
+		}
 	} else {
 		args = append(args, "--", entry.Command)
 		args = append(args, entry.Args...)
@@ -1297,6 +1504,12 @@ func isGeminiMCPConfigured(name string, entry MCPServerEntry) bool {
 	}
 	if entry.URL != "" {
 		existingURL, _ := serverCfg["url"].(string)
+		if entry.BearerTokenEnv != "" {
+			headers, _ := serverCfg["headers"].(map[string]interface{})
+			if auth, _ := headers["Authorization"].(string); auth != geminiBearerHeader(entry.BearerTokenEnv) {
+				return false
+			}
+		}
 		return strings.TrimSuffix(existingURL, "/") == strings.TrimSuffix(entry.URL, "/")
 	}
 	if entry.Command != "" {
@@ -1306,6 +1519,18 @@ func isGeminiMCPConfigured(name string, entry MCPServerEntry) bool {
 	return false
 }
 
+// claudeBearerHeader is the Authorization header value Claude Code expands
+// from the worker's environment; an unset variable sends an empty token.
+func claudeBearerHeader(env string) string {
+	return "Bearer ${" + env + ":-}"
+}
+
+// geminiBearerHeader is the Authorization header value Gemini CLI expands
+// from the worker's environment.
+func geminiBearerHeader(env string) string {
+	return "Bearer $" + env
+}
+
 // registerMCPViaGemini uses "gemini mcp add" to register a server.
 func registerMCPViaGemini(exe string, entry MCPServerEntry, logger *log.Logger) error {
 	// Remove existing entry (ignore errors — may not exist)
@@ -1320,6 +1545,9 @@ func registerMCPViaGemini(exe string, entry MCPServerEntry, logger *log.Logger)
 	var args []string
 	if entry.URL != "" {
 		args = []string{"mcp", "add", "-s", "user", "--transport", "http", entry.Name, entry.URL}
+		if entry.BearerTokenEnv != "" {
+			args = append(args, "--header", "Authorization: "+geminiBearerHeader(entry.BearerTokenEnv))
+		}
 	} else {
 		args = []string{"mcp", "add", "-s", "user", entry.Name, entry.Command}
 		args = append(args, "--")
@@ -1357,12 +1585,19 @@ func (m *WorkerManager) runOnce(c WorkerSpawnConfig, workspaceDir string, attemp
 		delete(m.runningWorkers, c.InstanceID)
 		m.mu.Unlock()
 	}()
-	args := expandWorkerTemplates(c.Command, c.InstanceID, workspaceDir)
+	args := expandWorkerTemplates(c.Command, c.InstanceID, workspaceDir, c.Task)
 	if len(args) == 0 {
 		return runResult{Err: fmt.Errorf("empty command")}
 	}
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 	cmd.Dir = workspaceDir
+	m.mu.Lock()
+	creds := m.credentials
+	m.mu.Unlock()
+	if creds != nil {
+		c.Token = creds.Issue(c.InstanceID, instanceAliases(c)...)
+		defer creds.Revoke(c.InstanceID)
+	}
 	env := buildWorkerEnv(c, workspaceDir)
 	// Ensure the worker's CLI tool has configured MCP servers registered (claude/codex).
 	if m.mcpServerURL != "" || len(m.mcpServers) > 0 {
@@ -1372,8 +1607,6 @@ func (m *WorkerManager) runOnce(c WorkerSpawnConfig, workspaceDir string, attemp
 	}
 	cmd.Env = env
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	logPath := filepath.Join(policy.GlobalStateDir(), fmt.Sprintf("stringwork-worker-%s.log", strings.ReplaceAll(c.InstanceID, "/", "-")))
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 
 	// Set up process activity tracking
 	pInfo := &ProcessInfo{
@@ -1384,6 +1617,7 @@ func (m *WorkerManager) runOnce(c WorkerSpawnConfig, workspaceDir string, attemp
 	}
 	m.mu.Lock()
 	m.processActivity[c.InstanceID] = pInfo
+	runLog := m.runLog
 	m.mu.Unlock()
 	defer func() {
 		m.mu.Lock()
@@ -1393,53 +1627,118 @@ func (m *WorkerManager) runOnce(c WorkerSpawnConfig, workspaceDir string, attemp
 
 	tail := newTailBuffer(4096)
 
+	// Output goes to the run history when there is one, else to the
+	// instance's log file, else to stderr.
+	var run WorkerRun
+	var logFile *os.File
+	var err error
+	if runLog != nil {
+		run = WorkerRun{InstanceID: c.InstanceID, AgentType: c.AgentType, Attempt: attempt + 1, Command: args, Dir: workspaceDir}
+		if c.Task != nil {
+			run.TaskID = c.Task.ID
+		}
+		if run, logFile, err = runLog.Start(run); err != nil {
+			m.logger.Printf("WorkerManager: record run of %s: %v", c.InstanceID, err)
+			runLog = nil
+		}
+	}
+	if runLog == nil {
+		logPath := filepath.Join(policy.GlobalStateDir(), fmt.Sprintf("stringwork-worker-%s.log", strings.ReplaceAll(c.InstanceID, "/", "-")))
+		if logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			label := "spawn"
+			if attempt > 0 {
+				label = fmt.Sprintf("retry-%d", attempt)
+			}
+			fmt.Fprintf(logFile, "\n=== Worker %s [%s] at %s (dir=%s) ===\n", c.InstanceID, label, time.Now().Format(time.RFC3339), workspaceDir)
+			fmt.Fprintf(logFile, "Command: %v\n", args)
+		}
+	}
+
+	var out io.Writer
 	if err != nil {
-		mw := io.MultiWriter(os.Stderr, tail)
-		cmd.Stdout = mw
-		cmd.Stderr = mw
+		out = io.MultiWriter(os.Stderr, tail)
 	} else {
 		defer logFile.Close()
-		label := "spawn"
-		if attempt > 0 {
-			label = fmt.Sprintf("retry-%d", attempt)
-		}
-		fmt.Fprintf(logFile, "\n=== Worker %s [%s] at %s (dir=%s) ===\n", c.InstanceID, label, time.Now().Format(time.RFC3339), workspaceDir)
-		fmt.Fprintf(logFile, "Command: %v\n", args)
 		aw := &activityWriter{inner: logFile, mu: &m.mu, info: pInfo}
-		mw := io.MultiWriter(aw, tail)
-		cmd.Stdout = mw
-		cmd.Stderr = mw
+		out = io.MultiWriter(aw, tail)
 	}
+	var capped *outputCap
+	if c.Limits != nil && c.Limits.MaxOutputBytes > 0 {
+		capped = &outputCap{w: out, max: c.Limits.MaxOutputBytes, onExceed: cancel}
+		cmd.Stdout, cmd.Stderr = capped, capped
+	} else {
+		cmd.Stdout, cmd.Stderr = out, out
+	}
+	limiter := newProcessLimiter(c.InstanceID, c.Limits, m.logger)
+	defer limiter.release()
+	limiter.prepare(cmd)
 	start := time.Now()
-	if err := cmd.Run(); err != nil {
+	m.recordWorkerEvent(domain.Event{
+		Type:   domain.EventWorkerSpawned,
+		Target: c.InstanceID,
+		Data:   map[string]string{"attempt": strconv.Itoa(attempt + 1), "workspace": workspaceDir},
+	})
+	runErr := cmd.Start()
+	if runErr == nil {
+		limiter.started(cmd.Process.Pid)
+		runErr = cmd.Wait()
+	}
+	limit := limiter.exceeded()
+	if capped != nil && capped.Exceeded() {
+		limit = describeOutputLimit(*c.Limits)
+	}
+	if limit != "" {
+		fmt.Fprint(out, limitMarker(limit))
+	}
+	exited := domain.Event{
+		Type:   domain.EventWorkerExited,
+		Target: c.InstanceID,
+		Data:   map[string]string{"duration": time.Since(start).Round(time.Second).String()},
+	}
+	if runErr != nil {
+		exited.Data["error"] = runErr.Error()
+	}
+	m.recordWorkerEvent(exited)
+	var res runResult
+	if err := runErr; err != nil {
 		elapsed := time.Since(start).Round(time.Millisecond)
-		output := strings.TrimSpace(tail.String())
-		if ctx.Err() == context.DeadlineExceeded {
-			if output != "" {
-				return runResult{Err: fmt.Errorf("timed out after %s", c.Timeout), Output: output}
-			}
-			return runResult{Err: fmt.Errorf("timed out after %s", c.Timeout)}
+		res.Output = strings.TrimSpace(tail.String())
+		if limit != "" {
+			res.Err = fmt.Errorf("stopped by resource limit %s after %s", limit, elapsed)
+		} else if ctx.Err() == context.DeadlineExceeded {
+			res.Err = fmt.Errorf("timed out after %s", c.Timeout)
+		} else {
+			res.Err = fmt.Errorf("exited after %s: %w", elapsed, err)
 		}
-		if output != "" {
-			return runResult{
-				Err:    fmt.Errorf("exited after %s: %w", elapsed, err),
-				Output: output,
-			}
+	} else {
+		m.logger.Printf("WorkerManager: %s completed in %s", c.InstanceID, time.Since(start).Round(time.Millisecond))
+	}
+	if runLog != nil {
+		logFile.Close()
+		run.ExitCode = -1
+		if cmd.ProcessState != nil {
+			run.ExitCode = cmd.ProcessState.ExitCode()
+		}
+		if res.Err != nil {
+			run.Error = res.Err.Error()
+			run.ErrorClass = classifyWorkerError(res.Output).Class.String()
+		}
+		if err := runLog.Finish(run); err != nil {
+			m.logger.Printf("WorkerManager: record end of run #%d of %s: %v", run.ID, c.InstanceID, err)
 		}
-		return runResult{Err: fmt.Errorf("exited after %s: %w", elapsed, err)}
 	}
-	m.logger.Printf("WorkerManager: %s completed in %s", c.InstanceID, time.Since(start).Round(time.Millisecond))
-	m.reconcileAfterExit(c)
-	return runResult{}
+	m.reconcileAfterExit(c, res, tail.String())
+	return res
 }
 
 // reconcileAfterExit checks for tasks stuck in "in_progress" after a worker exits.
-// If a worker couldn't communicate back (e.g. sandbox blocks MCP), this ensures
-// tasks don't stay orphaned. Stuck tasks are reset to "pending" for driver review.
-// Also cleans up worktrees if the strategy is "on_exit".
-func (m *WorkerManager) reconcileAfterExit(c WorkerSpawnConfig) {
+// If a worker couldn't communicate back (e.g. sandbox blocks MCP) or failed, this
+// ensures tasks don't stay orphaned. Stuck tasks are reset to "pending" for driver
+// review, and their attempt records the exit with logTail as excerpt.
+// After a successful run, also cleans up worktrees if the strategy is "on_exit".
+func (m *WorkerManager) reconcileAfterExit(c WorkerSpawnConfig, res runResult, logTail string) {
 	// Cleanup worktree if strategy is "on_exit"
-	if m.worktreeManager != nil && m.worktreeManager.CleanupStrategy() == "on_exit" {
+	if res.Err == nil && m.worktreeManager != nil && m.worktreeManager.CleanupStrategy() == "on_exit" {
 		if err := m.worktreeManager.CleanupWorktree(c.InstanceID, m.fallbackDir); err != nil {
 			m.logger.Printf("WorkerManager: worktree cleanup on exit for %s: %v", c.InstanceID, err)
 		}
@@ -1448,8 +1747,13 @@ func (m *WorkerManager) reconcileAfterExit(c WorkerSpawnConfig) {
 	if m.stateMutator == nil {
 		return
 	}
+	outcome, exited := AttemptWorkerExited, "exited"
+	if res.Err != nil {
+		outcome, exited = AttemptWorkerFailed, "failed"
+	}
 	_ = m.stateMutator(func(s *domain.CollabState) error {
 		reconciled := 0
+		now := time.Now()
 		for i := range s.Tasks {
 			t := &s.Tasks[i]
 			if t.Status != "in_progress" {
@@ -1458,12 +1762,19 @@ func (m *WorkerManager) reconcileAfterExit(c WorkerSpawnConfig) {
 			if t.AssignedTo != c.InstanceID && t.AssignedTo != c.AgentType {
 				continue
 			}
+			if a := CloseAttempt(t, outcome, now); a != nil {
+				a.LogExcerpt = LogExcerpt(strings.TrimSpace(logTail))
+				if res.Err != nil {
+					a.Error = res.Err.Error()
+					a.ExitClass = classifyWorkerError(res.Output).Class.String()
+				}
+			}
 			// Mark as "pending" (not "completed") so the driver can re-assign or verify.
 			// We don't know if the worker actually finished the work.
 			t.Status = "pending"
-			t.UpdatedAt = time.Now()
+			t.UpdatedAt = now
 			if t.ResultSummary == "" {
-				t.ResultSummary = fmt.Sprintf("Worker %s exited without updating status. Check worker log for details.", c.InstanceID)
+				t.ResultSummary = fmt.Sprintf("Worker %s %s without updating status. Check worker log for details.", c.InstanceID, exited)
 			}
 			// Clean up the worker instance's task list
 			if inst, ok := s.AgentInstances[c.InstanceID]; ok && inst != nil {
@@ -1488,7 +1799,7 @@ func (m *WorkerManager) reconcileAfterExit(c WorkerSpawnConfig) {
 				ID:        s.NextMsgID,
 				From:      "system",
 				To:        driver,
-				Content:   fmt.Sprintf("⚠️ **%s** exited with %d task(s) still in-progress — reset to pending for review. Check worker log for details.", c.InstanceID, reconciled),
+				Content:   fmt.Sprintf("⚠️ **%s** %s with %d task(s) still in-progress — reset to pending for review. Check get_task_history or the worker log for details.", c.InstanceID, exited, reconciled),
 				Timestamp: time.Now(),
 			})
 			s.NextMsgID++
@@ -1497,6 +1808,18 @@ func (m *WorkerManager) reconcileAfterExit(c WorkerSpawnConfig) {
 	})
 }
 
+// recordWorkerEvent journals a worker process lifecycle event.
+func (m *WorkerManager) recordWorkerEvent(e domain.Event) {
+	if m.stateMutator == nil {
+		return
+	}
+	e.Project = ProjectKey(m.fallbackDir)
+	_ = m.stateMutator(func(s *domain.CollabState) error {
+		RecordEvent(s, e)
+		return nil
+	})
+}
+
 func (m *WorkerManager) sendAck(instanceID, recipient string, unread, pending int) {
 	if recipient == "" || m.stateMutator == nil {
 		return
@@ -1523,11 +1846,14 @@ func (m *WorkerManager) sendAck(instanceID, recipient string, unread, pending in
 	})
 }
 
-func (m *WorkerManager) sendFailureAck(instanceID string, lastErr error, attempts int) {
+func (m *WorkerManager) sendFailureAck(instanceID string, lastErr error, info workerErrorInfo, attempts int) {
 	if m.stateMutator == nil {
 		return
 	}
 	content := fmt.Sprintf("❌ **%s** failed to respond after %d attempt(s): %v", instanceID, attempts, lastErr)
+	if info.Class == workerErrorResourceLimit {
+		content = fmt.Sprintf("🧱 **%s** failed after %d attempt(s), %s: %v. Raise the worker's limits in config if the work needs more.", instanceID, attempts, info.Summary, lastErr)
+	}
 	_ = m.stateMutator(func(s *domain.CollabState) error {
 		recipient := ""
 		for i := len(s.Messages) - 1; i >= 0; i-- {
@@ -104,6 +104,27 @@ func TestIsClaudeMCPConfigured(t *testing.T) {
 		}
 	})
 
+	t.Run("bearer token header is required when configured", func(t *testing.T) {
+		authed := target
+		authed.BearerTokenEnv = WorkerTokenEnv
+		if isClaudeMCPConfigured(authed.Name, authed) {
+			t.Error("expected false when the Authorization header is missing")
+		}
+		cfg := map[string]interface{}{
+			"mcpServers": map[string]interface{}{
+				"stringwork": map[string]interface{}{
+					"type":    "http",
+					"url":     "http://localhost:8943/mcp",
+					"headers": map[string]string{"Authorization": "Bearer ${STRINGWORK_TOKEN:-}"},
+				},
+			},
+		}
+		writeJSON(t, filepath.Join(tmpHome, ".claude.json"), cfg)
+		if !isClaudeMCPConfigured(authed.Name, authed) {
+			t.Error("expected true when URL and Authorization header match")
+		}
+	})
+
 	t.Run("config with different path (/sse) is NOT a match", func(t *testing.T) {
 		cfg := map[string]interface{}{
 			"mcpServers": map[string]interface{}{
@@ -212,6 +233,24 @@ url = "http://localhost:8943/sse"
 		}
 	})
 
+	t.Run("bearer token env var is required when configured", func(t *testing.T) {
+		authed := target
+		authed.BearerTokenEnv = WorkerTokenEnv
+		toml := `[mcp_servers.stringwork]
+url = "http://localhost:8943/mcp"
+`
+		os.WriteFile(configPath, []byte(toml), 0644)
+		if isCodexMCPConfigured(authed.Name, authed) {
+			t.Error("expected false without bearer_token_env_var")
+		}
+		toml += `bearer_token_env_var = "STRINGWORK_TOKEN"
+`
+		os.WriteFile(configPath, []byte(toml), 0644)
+		if !isCodexMCPConfigured(authed.Name, authed) {
+			t.Error("expected true with bearer_token_env_var")
+		}
+	})
+
 	t.Run("config with different server", func(t *testing.T) {
 		toml := `[mcp_servers.stringwork]
 url = "http://other-host:9000/sse"
@@ -281,6 +320,14 @@ func TestMCPServerEntries(t *testing.T) {
 	if entries[1].Name != "local-stdio" || entries[1].Command != "npx" {
 		t.Fatalf("unexpected second entry: %+v", entries[1])
 	}
+	if entries[0].BearerTokenEnv != "" {
+		t.Errorf("no credentials set, but stringwork entry sends %s", entries[0].BearerTokenEnv)
+	}
+
+	wm.SetCredentials(NewAgentCredentials())
+	if got := wm.mcpServerEntries()[0].BearerTokenEnv; got != WorkerTokenEnv {
+		t.Errorf("with credentials, stringwork BearerTokenEnv = %q, want %q", got, WorkerTokenEnv)
+	}
 }
 
 func TestTailBuffer_ShortWrite(t *testing.T) {
@@ -498,6 +545,24 @@ func TestClassifyWorkerError_NotFound(t *testing.T) {
 	}
 }
 
+func TestClassifyWorkerError_ResourceLimit(t *testing.T) {
+	info := classifyWorkerError("building...\nKilled" + limitMarker("memory (max 512 MB)"))
+	if info.Class != workerErrorResourceLimit || info.Summary != "resource limit exceeded: memory (max 512 MB)" {
+		t.Errorf("marker: got %s %q", info.Class, info.Summary)
+	}
+	for output, limit := range map[string]string{
+		"open /tmp/x: too many open files":                     "open files",
+		"fatal error: runtime: cannot allocate memory":         "memory",
+		"sh: fork: retry: Resource temporarily unavailable":    "processes",
+		"Error: spawn EAGAIN resource temporarily unavailable": "processes",
+	} {
+		info := classifyWorkerError(output)
+		if info.Class != workerErrorResourceLimit || !strings.HasSuffix(info.Summary, limit) {
+			t.Errorf("%q: got %s %q, want %s limit", output, info.Class, info.Summary, limit)
+		}
+	}
+}
+
 func TestClassifyWorkerError_Transient(t *testing.T) {
 	output := `some random error that doesn't match any pattern`
 	info := classifyWorkerError(output)
@@ -607,6 +672,9 @@ func TestWorkerErrorClass_Terminal(t *testing.T) {
 	if !workerErrorNotFound.Terminal() {
 		t.Error("not_found should be terminal")
 	}
+	if workerErrorResourceLimit.Terminal() {
+		t.Error("resource_limit should not be terminal")
+	}
 }
 
 func writeJSON(t *testing.T, path string, v interface{}) {
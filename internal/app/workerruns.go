@@ -0,0 +1,420 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRunHistory   = 200
+	defaultRunHistoryMB = 100
+	runIndexFile        = "runs.json"
+	runLockFile         = "runs.lock"
+)
+
+// ErrWorkerRunNotFound is returned by WorkerRunLog.ReadLog when no stored run
+// matches the query.
+var ErrWorkerRunNotFound = errors.New("worker run not found")
+
+// WorkerRun is one execution of a worker process, as recorded by runOnce.
+type WorkerRun struct {
+	ID          int64     `json:"id"`
+	InstanceID  string    `json:"instance_id"`
+	AgentType   string    `json:"agent_type"`
+	TaskID      int       `json:"task_id,omitempty"` // task-bound spawns only
+	Attempt     int       `json:"attempt"`           // 1 for the spawn, 2+ for retries
+	Command     []string  `json:"command"`
+	Dir         string    `json:"dir"`
+	StartedAt   time.Time `json:"started_at"`
+	EndedAt     time.Time `json:"ended_at,omitzero"`
+	ExitCode    int       `json:"exit_code"` // -1 when killed by a signal or never started
+	ErrorClass  string    `json:"error_class,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	OutputBytes int64     `json:"output_bytes"`
+	Owner       string    `json:"owner,omitempty"` // owners/<name> lock of the recording server
+}
+
+// Running reports whether the run has not ended yet.
+func (r WorkerRun) Running() bool {
+	return r.EndedAt.IsZero()
+}
+
+// WorkerLogQuery selects the output lines of a stored run.
+type WorkerLogQuery struct {
+	RunID    int64  // run to read; 0 = most recent run of Instance (or of any worker)
+	Instance string // instance ID or agent type
+	Tail     int    // keep the last Tail lines; 0 = all
+	Grep     string // regular expression the lines must match (optional)
+}
+
+// WorkerLog is the result of ReadLog.
+type WorkerLog struct {
+	Run     WorkerRun
+	Lines   []string
+	Matched int // lines matching Grep (all lines without it), before Tail
+}
+
+// WorkerRunLog keeps the history of worker runs in a directory: an index of
+// run metadata in runs.json and the full output of each run in
+// runs/<id>.log. The oldest runs are removed once there are more than
+// maxRuns or their output exceeds maxBytes.
+//
+// Several server processes may share the directory. Every access re-reads
+// runs.json under an flock on runs.lock, so run IDs stay unique and no
+// process overwrites the entries of another. Each log also holds a lock on
+// owners/<name> while it is open; a run whose owner lock is free was left
+// open by a server that is gone.
+type WorkerRunLog struct {
+	dir      string
+	maxRuns  int
+	maxBytes int64
+	owner    string
+	ownerF   *os.File
+
+	mu     sync.Mutex
+	runs   []WorkerRun // oldest first; reloaded from runs.json on every access
+	nextID int64
+}
+
+type runIndex struct {
+	NextID int64       `json:"next_id"`
+	Runs   []WorkerRun `json:"runs"`
+}
+
+// NewWorkerRunLog opens (or creates) the run history in dir. Zero bounds use
+// the defaults. Runs left open by a server process that has stopped are
+// closed as interrupted.
+func NewWorkerRunLog(dir string, maxRuns int, maxBytes int64) (*WorkerRunLog, error) {
+	if maxRuns <= 0 {
+		maxRuns = defaultRunHistory
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultRunHistoryMB << 20
+	}
+	for _, sub := range []string{"runs", "owners"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	l := &WorkerRunLog{dir: dir, maxRuns: maxRuns, maxBytes: maxBytes, owner: fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())}
+	err := l.update(func() error {
+		f, err := os.OpenFile(l.ownerPath(l.owner), os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			return fmt.Errorf("lock %s: %w", f.Name(), err)
+		}
+		l.ownerF = f
+
+		gone := map[string]bool{}
+		for i := range l.runs {
+			r := &l.runs[i]
+			if !r.Running() || r.Owner == l.owner {
+				continue
+			}
+			if _, ok := gone[r.Owner]; !ok {
+				gone[r.Owner] = l.ownerGone(r.Owner)
+			}
+			if !gone[r.Owner] {
+				continue
+			}
+			r.EndedAt = r.StartedAt
+			if fi, err := os.Stat(l.logPath(r.ID)); err == nil {
+				r.EndedAt, r.OutputBytes = fi.ModTime(), fi.Size()
+			}
+			r.ExitCode = -1
+			r.Error = "interrupted: server stopped during the run"
+		}
+		if entries, err := os.ReadDir(filepath.Join(l.dir, "owners")); err == nil {
+			for _, e := range entries {
+				if e.Name() != l.owner && l.ownerGone(e.Name()) {
+					_ = os.Remove(l.ownerPath(e.Name()))
+				}
+			}
+		}
+		return l.save()
+	})
+	if err != nil {
+		if l.ownerF != nil {
+			l.ownerF.Close()
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+// Close releases the owner lock. Runs still open are closed as interrupted
+// by the next server that opens the directory.
+func (l *WorkerRunLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ownerF == nil {
+		return nil
+	}
+	_ = os.Remove(l.ownerPath(l.owner))
+	err := l.ownerF.Close()
+	l.ownerF = nil
+	return err
+}
+
+func (l *WorkerRunLog) logPath(id int64) string {
+	return filepath.Join(l.dir, "runs", fmt.Sprintf("%d.log", id))
+}
+
+func (l *WorkerRunLog) ownerPath(name string) string {
+	return filepath.Join(l.dir, "owners", name)
+}
+
+// ownerGone reports whether no open log holds the owner lock name. Runs
+// recorded before owners existed have no owner and count as gone.
+func (l *WorkerRunLog) ownerGone(name string) bool {
+	if name == "" || name != filepath.Base(name) {
+		return true
+	}
+	f, err := os.OpenFile(l.ownerPath(name), os.O_RDWR, 0)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) == nil
+}
+
+// update runs fn on the current index with runs.lock held exclusively; fn
+// saves its changes itself.
+func (l *WorkerRunLog) update(fn func() error) error {
+	return l.withIndex(syscall.LOCK_EX, fn)
+}
+
+// view runs fn on the current index with runs.lock held shared.
+func (l *WorkerRunLog) view(fn func() error) error {
+	return l.withIndex(syscall.LOCK_SH, fn)
+}
+
+func (l *WorkerRunLog) withIndex(how int, fn func() error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(filepath.Join(l.dir, runLockFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // releases the lock
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		return fmt.Errorf("lock %s: %w", runLockFile, err)
+	}
+	if err := l.load(); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// load reads the index from disk. Caller holds l.mu and runs.lock.
+func (l *WorkerRunLog) load() error {
+	l.runs, l.nextID = nil, 1
+	data, err := os.ReadFile(filepath.Join(l.dir, runIndexFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		var idx runIndex
+		if err := json.Unmarshal(data, &idx); err != nil {
+			return fmt.Errorf("decode %s: %w", runIndexFile, err)
+		}
+		l.runs, l.nextID = idx.Runs, max(idx.NextID, 1)
+	}
+	return nil
+}
+
+// Start records the beginning of run and creates its output file. The
+// returned run carries the assigned ID and start time; pass it to Finish.
+func (l *WorkerRunLog) Start(run WorkerRun) (WorkerRun, *os.File, error) {
+	var f *os.File
+	err := l.update(func() error {
+		run.ID = l.nextID
+		run.StartedAt = time.Now()
+		run.EndedAt = time.Time{}
+		run.Owner = l.owner
+		var err error
+		for {
+			// O_EXCL: never write into the output of another run, such as one
+			// left behind by a server that died before saving the index.
+			f, err = os.OpenFile(l.logPath(run.ID), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+			if !os.IsExist(err) {
+				break
+			}
+			run.ID++
+		}
+		if err != nil {
+			return err
+		}
+		l.nextID = run.ID + 1
+		l.runs = append(l.runs, run)
+		if err := l.save(); err != nil {
+			f.Close()
+			_ = os.Remove(l.logPath(run.ID))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return run, nil, err
+	}
+	return run, f, nil
+}
+
+// Finish records the end of run (ExitCode, ErrorClass and Error as set by the
+// caller), takes its output size from the output file, and drops old runs.
+func (l *WorkerRunLog) Finish(run WorkerRun) error {
+	if run.EndedAt.IsZero() {
+		run.EndedAt = time.Now()
+	}
+	if fi, err := os.Stat(l.logPath(run.ID)); err == nil {
+		run.OutputBytes = fi.Size()
+	}
+	return l.update(func() error {
+		i := slices.IndexFunc(l.runs, func(r WorkerRun) bool { return r.ID == run.ID })
+		if i < 0 {
+			return fmt.Errorf("%w: #%d", ErrWorkerRunNotFound, run.ID)
+		}
+		l.runs[i] = run
+		l.rotate()
+		return l.save()
+	})
+}
+
+// rotate removes the oldest ended runs until at most maxRuns remain and their
+// output fits in maxBytes. Running runs are kept.
+func (l *WorkerRunLog) rotate() {
+	var total int64
+	for _, r := range l.runs {
+		total += r.OutputBytes
+	}
+	count := len(l.runs)
+	l.runs = slices.DeleteFunc(l.runs, func(r WorkerRun) bool {
+		if r.Running() || (count <= l.maxRuns && total <= l.maxBytes) {
+			return false
+		}
+		_ = os.Remove(l.logPath(r.ID))
+		count--
+		total -= r.OutputBytes
+		return true
+	})
+}
+
+// save rewrites the index atomically. Caller holds l.mu and runs.lock.
+func (l *WorkerRunLog) save() error {
+	data, err := json.MarshalIndent(runIndex{NextID: l.nextID, Runs: l.runs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, runIndexFile)
+	tmp := path + "." + l.owner + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Runs returns the stored runs of instance (an instance ID or agent type; ""
+// for all workers), newest first, at most limit of them (0 = all).
+func (l *WorkerRunLog) Runs(instance string, limit int) []WorkerRun {
+	var out []WorkerRun
+	_ = l.view(func() error {
+		out = l.find(instance, limit)
+		return nil
+	})
+	return out
+}
+
+// find is Runs on the loaded index. Caller holds l.mu.
+func (l *WorkerRunLog) find(instance string, limit int) []WorkerRun {
+	var out []WorkerRun
+	for i := len(l.runs) - 1; i >= 0; i-- {
+		r := l.runs[i]
+		if instance != "" && r.InstanceID != instance && r.AgentType != instance {
+			continue
+		}
+		out = append(out, r)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// ReadLog returns the output lines of the run selected by q.
+func (l *WorkerRunLog) ReadLog(q WorkerLogQuery) (WorkerLog, error) {
+	var re *regexp.Regexp
+	if q.Grep != "" {
+		var err error
+		if re, err = regexp.Compile(q.Grep); err != nil {
+			return WorkerLog{}, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+
+	var run WorkerRun
+	found := false
+	err := l.view(func() error {
+		if q.RunID > 0 {
+			i := slices.IndexFunc(l.runs, func(r WorkerRun) bool { return r.ID == q.RunID })
+			if i >= 0 {
+				run, found = l.runs[i], true
+			}
+		} else if runs := l.find(q.Instance, 1); len(runs) > 0 {
+			run, found = runs[0], true
+		}
+		return nil
+	})
+	if err != nil {
+		return WorkerLog{}, err
+	}
+	if found && q.RunID > 0 && q.Instance != "" && run.InstanceID != q.Instance && run.AgentType != q.Instance {
+		return WorkerLog{}, fmt.Errorf("%w: #%d is a run of %s, not %s", ErrWorkerRunNotFound, q.RunID, run.InstanceID, q.Instance)
+	}
+	if !found {
+		if q.RunID > 0 {
+			return WorkerLog{}, fmt.Errorf("%w: #%d (it may have been rotated out)", ErrWorkerRunNotFound, q.RunID)
+		}
+		return WorkerLog{}, ErrWorkerRunNotFound
+	}
+
+	f, err := os.Open(l.logPath(run.ID))
+	if err != nil {
+		return WorkerLog{}, err
+	}
+	defer f.Close()
+	res := WorkerLog{Run: run}
+	r := bufio.NewReader(f)
+	for {
+		raw, err := r.ReadString('\n')
+		if line := strings.TrimRight(raw, "\r\n"); raw != "" && (re == nil || re.MatchString(line)) {
+			res.Matched++
+			res.Lines = append(res.Lines, line)
+			if q.Tail > 0 && len(res.Lines) > 2*q.Tail {
+				res.Lines = append(res.Lines[:0], res.Lines[len(res.Lines)-q.Tail:]...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return WorkerLog{}, fmt.Errorf("read run #%d: %w", run.ID, err)
+		}
+	}
+	if q.Tail > 0 && len(res.Lines) > q.Tail {
+		res.Lines = res.Lines[len(res.Lines)-q.Tail:]
+	}
+	return res, nil
+}
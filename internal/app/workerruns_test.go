@@ -0,0 +1,205 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// recordRun stores a finished run of instance with output and returns it.
+func recordRun(t *testing.T, l *WorkerRunLog, instance, output string) WorkerRun {
+	t.Helper()
+	run, f, err := l.Start(WorkerRun{InstanceID: instance, AgentType: strings.TrimRight(instance, "-0123456789"), Attempt: 1, Command: []string{"claude", "-p", "go"}, Dir: "/ws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(f, output)
+	f.Close()
+	if err := l.Finish(run); err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+func TestWorkerRunLog(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewWorkerRunLog(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := recordRun(t, l, "claude-code-1", "starting\nerror: boom\ndone\n")
+	run, f, err := l.Start(WorkerRun{InstanceID: "codex", AgentType: "codex", Attempt: 2, TaskID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(f, "line 1\nline 2\nline 3\nline 4")
+	f.Close()
+	run.ExitCode, run.Error, run.ErrorClass = 1, "exited after 1s: exit status 1", "transient"
+	if err := l.Finish(run); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs := l.Runs("", 0); len(runs) != 2 || runs[0].ID != run.ID || runs[1].ID != first.ID {
+		t.Fatalf("runs = %+v, want newest first", runs)
+	}
+	if runs := l.Runs("claude-code", 0); len(runs) != 1 || runs[0].InstanceID != "claude-code-1" || runs[0].OutputBytes != 26 {
+		t.Errorf("runs of type claude-code = %+v", runs)
+	}
+
+	wl, err := l.ReadLog(WorkerLogQuery{Instance: "codex", Tail: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wl.Run.ID != run.ID || wl.Run.ErrorClass != "transient" || wl.Matched != 4 || strings.Join(wl.Lines, ",") != "line 3,line 4" {
+		t.Errorf("tail of latest codex run = %+v", wl)
+	}
+	wl, err = l.ReadLog(WorkerLogQuery{RunID: first.ID, Grep: "error|done"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(wl.Lines, ",") != "error: boom,done" {
+		t.Errorf("grep lines = %q", wl.Lines)
+	}
+	if _, err := l.ReadLog(WorkerLogQuery{RunID: first.ID, Instance: "codex"}); !errors.Is(err, ErrWorkerRunNotFound) {
+		t.Errorf("run of another instance: err = %v", err)
+	}
+	if _, err := l.ReadLog(WorkerLogQuery{Grep: "("}); err == nil {
+		t.Error("expected an error for an invalid grep pattern")
+	}
+
+	// The history survives a restart; IDs keep counting.
+	l, err = NewWorkerRunLog(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := recordRun(t, l, "codex", ""); next.ID != run.ID+1 {
+		t.Errorf("ID after reopen = %d, want %d", next.ID, run.ID+1)
+	}
+}
+
+func TestWorkerRunLog_InterruptedRun(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := NewWorkerRunLog(dir, 0, 0)
+	run, f, err := l.Start(WorkerRun{InstanceID: "codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(f, "partial")
+	f.Close()
+	l.Close() // the server stops
+
+	l, err = NewWorkerRunLog(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := l.Runs("codex", 1)[0]
+	if got.ID != run.ID || got.Running() || got.ExitCode != -1 || got.OutputBytes != 7 || !strings.Contains(got.Error, "interrupted") {
+		t.Errorf("run left open = %+v, want closed as interrupted", got)
+	}
+}
+
+func TestWorkerRunLog_SharedDir(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := NewWorkerRunLog(dir, 0, 0)
+	b, _ := NewWorkerRunLog(dir, 0, 0)
+	ra, fa, err := a.Start(WorkerRun{InstanceID: "codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, fb, err := b.Start(WorkerRun{InstanceID: "claude-code"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ra.ID == rb.ID {
+		t.Fatalf("both servers got run #%d", ra.ID)
+	}
+	fmt.Fprint(fa, "from a")
+	fmt.Fprint(fb, "from b")
+	fa.Close()
+	fb.Close()
+
+	// A third server must not close runs of servers that are still up.
+	c, _ := NewWorkerRunLog(dir, 0, 0)
+	if runs := c.Runs("", 0); len(runs) != 2 || !runs[0].Running() || !runs[1].Running() {
+		t.Fatalf("runs after a third open = %+v, want both still running", runs)
+	}
+	if err := a.Finish(ra); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Finish(rb); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []WorkerRun{ra, rb} {
+		wl, err := c.ReadLog(WorkerLogQuery{RunID: r.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wl.Run.Running() || len(wl.Lines) != 1 || wl.Lines[0] != map[int64]string{ra.ID: "from a", rb.ID: "from b"}[r.ID] {
+			t.Errorf("run #%d = %+v", r.ID, wl)
+		}
+	}
+}
+
+func TestWorkerRunLog_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := NewWorkerRunLog(dir, 3, 0)
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, recordRun(t, l, "codex", "out\n").ID)
+	}
+	runs := l.Runs("", 0)
+	if len(runs) != 3 || runs[2].ID != ids[2] {
+		t.Fatalf("runs = %+v, want the newest 3", runs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "runs", fmt.Sprintf("%d.log", ids[0]))); !os.IsNotExist(err) {
+		t.Errorf("output of rotated run still on disk: %v", err)
+	}
+	if _, err := l.ReadLog(WorkerLogQuery{RunID: ids[0]}); !errors.Is(err, ErrWorkerRunNotFound) {
+		t.Errorf("rotated run: err = %v", err)
+	}
+
+	// The byte budget drops old runs too, but keeps running ones.
+	l, _ = NewWorkerRunLog(t.TempDir(), 100, 10)
+	open, f, _ := l.Start(WorkerRun{InstanceID: "codex"})
+	defer f.Close()
+	recordRun(t, l, "codex", "0123456789")
+	last := recordRun(t, l, "codex", "0123456789")
+	runs = l.Runs("", 0)
+	if len(runs) != 2 || runs[0].ID != last.ID || runs[1].ID != open.ID {
+		t.Errorf("runs = %+v, want the running one and the newest", runs)
+	}
+}
+
+func TestRunOnce_RecordsRun(t *testing.T) {
+	runs, _ := NewWorkerRunLog(t.TempDir(), 0, 0)
+	wm := autoscaleTestManager(domain.NewCollabState())
+	wm.SetRunLog(runs)
+	c := WorkerSpawnConfig{InstanceID: "codex", AgentType: "codex", Timeout: time.Minute, InheritEnv: []string{"PATH"},
+		Command: []string{"sh", "-c", "echo working on {agent}; echo 'permission denied' >&2; exit 3"}}
+
+	res := wm.runOnce(c, t.TempDir(), 1)
+	if res.Err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	got := runs.Runs("codex", 0)
+	if len(got) != 1 {
+		t.Fatalf("runs = %+v, want 1", got)
+	}
+	r := got[0]
+	if r.Attempt != 2 || r.ExitCode != 3 || r.ErrorClass != classifyWorkerError(res.Output).Class.String() || r.Error != res.Err.Error() || r.OutputBytes != 35 || r.Running() {
+		t.Errorf("recorded run = %+v", r)
+	}
+	wl, err := runs.ReadLog(WorkerLogQuery{RunID: r.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(wl.Lines, "\n") != "working on codex\npermission denied" {
+		t.Errorf("stored output = %q", wl.Lines)
+	}
+}
@@ -4,8 +4,14 @@ package dashboard
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"net/url"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jaakkos/stringwork/internal/app"
@@ -16,10 +22,12 @@ import (
 type StateSnapshot struct {
 	Timestamp    string             `json:"timestamp"`
 	Workspace    string             `json:"workspace"`
+	Project      string             `json:"project"`
 	Agents       []AgentSnapshot    `json:"agents"`
 	Tasks        []TaskSnapshot     `json:"tasks"`
 	Messages     []MessageSnapshot  `json:"messages"`
 	Plans        []PlanSnapshot     `json:"plans,omitempty"`
+	Upcoming     []UpcomingSnapshot `json:"upcoming,omitempty"`
 	Workers      []WorkerSnapshot   `json:"workers,omitempty"`
 	SessionNotes []NoteSnapshot     `json:"session_notes,omitempty"`
 	FileLocks    []FileLockSnapshot `json:"file_locks,omitempty"`
@@ -44,19 +52,62 @@ type AgentSnapshot struct {
 
 // TaskSnapshot is a per-task summary.
 type TaskSnapshot struct {
-	ID                  int    `json:"id"`
-	Title               string `json:"title"`
-	Status              string `json:"status"`
-	AssignedTo          string `json:"assigned_to"`
-	CreatedBy           string `json:"created_by"`
-	Priority            int    `json:"priority"`
-	Age                 string `json:"age"`
-	ResultSummary       string `json:"result_summary,omitempty"`
-	ProgressDescription string `json:"progress_description,omitempty"`
-	ProgressPercent     int    `json:"progress_percent,omitempty"`
-	LastProgressAge     string `json:"last_progress_age,omitempty"`
-	ExpectedDurationSec int    `json:"expected_duration_sec,omitempty"`
-	SLAStatus           string `json:"sla_status,omitempty"`
+	ID                  int               `json:"id"`
+	Title               string            `json:"title"`
+	Status              string            `json:"status"`
+	AssignedTo          string            `json:"assigned_to"`
+	CreatedBy           string            `json:"created_by"`
+	Priority            int               `json:"priority"`
+	Age                 string            `json:"age"`
+	ResultSummary       string            `json:"result_summary,omitempty"`
+	ProgressDescription string            `json:"progress_description,omitempty"`
+	ProgressPercent     int               `json:"progress_percent,omitempty"`
+	LastProgressAge     string            `json:"last_progress_age,omitempty"`
+	ExpectedDurationSec int               `json:"expected_duration_sec,omitempty"`
+	SLAStatus           string            `json:"sla_status,omitempty"`
+	ParentTaskID        int               `json:"parent_task_id,omitempty"`
+	Depth               int               `json:"depth,omitempty"` // nesting level under ParentTaskID
+	MaxAttempts         int               `json:"max_attempts,omitempty"`
+	Attempts            []AttemptSnapshot `json:"attempts,omitempty"`
+	Tests               string            `json:"tests,omitempty"` // reported test outcome
+	Artifacts           []ArtifactLink    `json:"artifacts,omitempty"`
+	Labels              []string          `json:"labels,omitempty"`
+	PlanID              string            `json:"plan_id,omitempty"`
+	Verification        string            `json:"verification,omitempty"`         // "passed" or "failed"
+	VerificationFailure string            `json:"verification_failure,omitempty"` // failed command
+}
+
+// TaskListResponse is one page of /api/tasks.
+type TaskListResponse struct {
+	Project    string         `json:"project"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Tasks      []TaskSnapshot `json:"tasks"`
+}
+
+// ArtifactLink points at a downloadable task result artifact.
+type ArtifactLink struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	URL  string `json:"url"`
+}
+
+// AttemptSnapshot is a summary of one claim of a task.
+type AttemptSnapshot struct {
+	Number    int    `json:"number"`
+	Agent     string `json:"agent"`
+	Outcome   string `json:"outcome"` // "running" while in progress
+	ExitClass string `json:"exit_class,omitempty"`
+	Duration  string `json:"duration"`
+}
+
+// UpcomingSnapshot is a scheduled task's next run.
+type UpcomingSnapshot struct {
+	TaskID   int    `json:"task_id"`
+	Title    string `json:"title"`
+	Schedule string `json:"schedule,omitempty"` // cron spec; empty for a one-off delayed task
+	At       string `json:"at"`
+	In       string `json:"in"`
 }
 
 // MessageSnapshot is a per-message summary.
@@ -126,11 +177,39 @@ type WorkerController interface {
 	RunningWorkers() []string
 }
 
+// WorkerRunStore is implemented by app.WorkerRunLog. It gives the dashboard
+// the recorded worker runs and their output.
+type WorkerRunStore interface {
+	Runs(instance string, limit int) []app.WorkerRun
+	ReadLog(q app.WorkerLogQuery) (app.WorkerLog, error)
+}
+
+// WorkerRunSnapshot is one recorded worker run, as listed by /api/worker-runs.
+type WorkerRunSnapshot struct {
+	ID          int64  `json:"id"`
+	InstanceID  string `json:"instance_id"`
+	AgentType   string `json:"agent_type"`
+	TaskID      int    `json:"task_id,omitempty"`
+	Attempt     int    `json:"attempt"`
+	Command     string `json:"command"`
+	Dir         string `json:"dir"`
+	StartedAt   string `json:"started_at"`
+	Age         string `json:"age"`
+	Duration    string `json:"duration"`
+	Running     bool   `json:"running"`
+	ExitCode    int    `json:"exit_code"`
+	ErrorClass  string `json:"error_class,omitempty"`
+	Error       string `json:"error,omitempty"`
+	OutputBytes int64  `json:"output_bytes"`
+	LogURL      string `json:"log_url"`
+}
+
 // Handler holds dependencies for dashboard HTTP handlers.
 type Handler struct {
 	svc      *app.CollabService
 	registry *app.SessionRegistry
 	workers  WorkerController // optional; nil when no orchestration configured
+	runs     WorkerRunStore   // optional; nil when worker runs are not recorded
 }
 
 // NewHandler creates a dashboard handler.
@@ -150,12 +229,23 @@ func WithWorkerController(wc WorkerController) HandlerOption {
 	return func(h *Handler) { h.workers = wc }
 }
 
+// WithWorkerRuns sets the WorkerRunStore for the worker run history endpoints.
+func WithWorkerRuns(rs WorkerRunStore) HandlerOption {
+	return func(h *Handler) { h.runs = rs }
+}
+
 // RegisterRoutes adds dashboard routes to the given mux.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/state", h.handleAPIState)
 	mux.HandleFunc("/api/reset", h.handleAPIReset)
 	mux.HandleFunc("/api/restart-workers", h.handleAPIRestartWorkers)
 	mux.HandleFunc("/api/switch-project", h.handleAPISwitchProject)
+	mux.HandleFunc("/api/projects", h.handleAPIProjects)
+	mux.HandleFunc("/api/events", h.handleAPIEvents)
+	mux.HandleFunc("/api/artifact", h.handleAPIArtifact)
+	mux.HandleFunc("/api/tasks", h.handleAPITasks)
+	mux.HandleFunc("/api/worker-runs", h.handleAPIWorkerRuns)
+	mux.HandleFunc("/api/worker-log", h.handleAPIWorkerLog)
 	mux.HandleFunc("/dashboard", h.handleDashboard)
 	mux.HandleFunc("/dashboard/", h.handleDashboard)
 }
@@ -299,20 +389,9 @@ func (h *Handler) handleAPISwitchProject(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	// Step 2: Clear working scope (tasks, messages, plans, notes, locks)
+	// Step 2: Move agents to the new project. Records of the previous project
+	// are kept; they are scoped by project and reappear when switching back.
 	err := h.svc.Run(func(state *domain.CollabState) error {
-		state.Tasks = []domain.Task{}
-		state.Messages = []domain.Message{}
-		state.SessionNotes = []domain.SessionNote{}
-		state.Plans = make(map[string]*domain.Plan)
-		state.ActivePlanID = ""
-		state.FileLocks = make(map[string]*domain.FileLock)
-		state.WorkContexts = make(map[string]*domain.WorkContext)
-		state.AgentContexts = make(map[string]*domain.AgentContext)
-		state.NextTaskID = 1
-		state.NextMsgID = 1
-		state.NextNoteID = 1
-
 		// Reset agent instance task lists but keep agents registered
 		for _, inst := range state.AgentInstances {
 			if inst != nil {
@@ -335,7 +414,7 @@ func (h *Handler) handleAPISwitchProject(w http.ResponseWriter, r *http.Request)
 		w.Write([]byte(`{"error":"` + err.Error() + `"}`))
 		return
 	}
-	steps = append(steps, "cleared working scope")
+	steps = append(steps, "moved agents to "+app.ProjectKey(workspace))
 
 	// Step 3: Update workspace root in policy
 	h.svc.Policy().SetWorkspaceRoot(workspace)
@@ -351,6 +430,322 @@ func (h *Handler) handleAPISwitchProject(w http.ResponseWriter, r *http.Request)
 	_ = enc.Encode(resp)
 }
 
+func (h *Handler) handleAPIProjects(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	resp := map[string]any{
+		"current":  app.ProjectKey(h.svc.Policy().WorkspaceRoot()),
+		"projects": []app.ProjectSummary{},
+	}
+	if err := h.svc.Query(func(state *domain.CollabState) error {
+		resp["projects"] = app.ListProjects(state)
+		return nil
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"` + err.Error() + `"}`))
+		return
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(resp)
+}
+
+// handleAPIEvents serves the event journal. Query parameters: task_id, agent,
+// type (comma-separated), since (RFC3339), after_id, limit (default 100, max
+// 500) and project (default: current workspace; "all" for every project).
+func (h *Handler) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	q := r.URL.Query()
+	f := app.EventFilter{
+		Agent:   q.Get("agent"),
+		Project: app.ProjectKey(h.svc.Policy().WorkspaceRoot()),
+		Limit:   100,
+	}
+	if v := q.Get("task_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"task_id must be an integer"}`))
+			return
+		}
+		f.TaskID = id
+	}
+	if v := q.Get("type"); v != "" {
+		f.Types = strings.Split(v, ",")
+	}
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"since must be an RFC3339 time"}`))
+			return
+		}
+		f.Since = t
+	}
+	if v := q.Get("after_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"after_id must be an integer"}`))
+			return
+		}
+		f.AfterID = id
+	}
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		f.Limit = min(v, 500)
+	}
+	if v := q.Get("project"); v == app.AllProjects {
+		f.Project = v
+	} else if v != "" {
+		f.Project = app.ProjectKey(v)
+	}
+
+	events, err := h.svc.Events(f)
+	if errors.Is(err, app.ErrNoJournal) {
+		w.WriteHeader(http.StatusNotImplemented)
+		w.Write([]byte(`{"error":"` + err.Error() + `"}`))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"` + err.Error() + `"}`))
+		return
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(map[string]any{"project": f.Project, "events": events})
+}
+
+// handleAPITasks lists tasks with filtering, sorting and cursor pagination.
+// Query parameters: status, assigned_to, created_by, label (repeatable or
+// comma-separated; all must match), priority, plan_id, created_after,
+// created_before, updated_after, updated_before (RFC3339 or YYYY-MM-DD),
+// q (text search), sort (id, priority, created, updated), order (asc, desc),
+// limit (default 50, max 500), cursor and project (default: current
+// workspace; "all" for every project).
+func (h *Handler) handleAPITasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	v := r.URL.Query()
+	q := app.TaskQuery{
+		Project:    app.ProjectKey(h.svc.Policy().WorkspaceRoot()),
+		Status:     v.Get("status"),
+		AssignedTo: v.Get("assigned_to"),
+		CreatedBy:  v.Get("created_by"),
+		PlanID:     v.Get("plan_id"),
+		Text:       v.Get("q"),
+		Sort:       v.Get("sort"),
+		Desc:       v.Get("order") == "desc",
+		Cursor:     v.Get("cursor"),
+	}
+	if p := v.Get("project"); p == app.AllProjects {
+		q.Project = p
+	} else if p != "" {
+		q.Project = app.ProjectKey(p)
+	}
+	for _, l := range v["label"] {
+		q.Labels = append(q.Labels, strings.Split(l, ",")...)
+	}
+	var err error
+	if s := v.Get("priority"); s != "" {
+		if q.Priority, err = strconv.Atoi(s); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "priority must be an integer")
+			return
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "limit must be an integer")
+			return
+		}
+	}
+	for key, dst := range map[string]*time.Time{
+		"created_after":  &q.CreatedAfter,
+		"created_before": &q.CreatedBefore,
+		"updated_after":  &q.UpdatedAfter,
+		"updated_before": &q.UpdatedBefore,
+	} {
+		if s := v.Get(key); s != "" {
+			if *dst, err = app.ParseQueryTime(s); err != nil {
+				writeAPIError(w, http.StatusBadRequest, key+": "+err.Error())
+				return
+			}
+		}
+	}
+
+	var page app.TaskPage
+	var queryErr error
+	if err := h.svc.Query(func(state *domain.CollabState) error {
+		page, queryErr = app.QueryTasks(state.Tasks, q)
+		return nil
+	}); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if queryErr != nil {
+		writeAPIError(w, http.StatusBadRequest, queryErr.Error())
+		return
+	}
+	now := time.Now()
+	resp := TaskListResponse{Project: q.Project, Total: page.Total, NextCursor: page.NextCursor, Tasks: []TaskSnapshot{}}
+	for _, t := range page.Tasks {
+		resp.Tasks = append(resp.Tasks, taskSnapshot(t, 0, now))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(resp)
+}
+
+// writeAPIError writes {"error": msg} with status.
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// artifactURL returns the /api/artifact download URL for an artifact.
+func artifactURL(taskID int, name string) string {
+	return "/api/artifact?" + url.Values{"task_id": {strconv.Itoa(taskID)}, "name": {name}}.Encode()
+}
+
+// handleAPIArtifact downloads a task result artifact. Query parameters:
+// task_id and name.
+func (h *Handler) handleAPIArtifact(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	q := r.URL.Query()
+	taskID, err := strconv.Atoi(q.Get("task_id"))
+	if err != nil {
+		http.Error(w, "task_id must be an integer", http.StatusBadRequest)
+		return
+	}
+	name := q.Get("name")
+	if err := app.ValidateArtifactName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a, err := h.svc.Artifact(taskID, name)
+	switch {
+	case errors.Is(err, app.ErrArtifactNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, app.ErrNoArtifacts):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(a.Content)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = w.Write(a.Content)
+}
+
+// handleAPIWorkerRuns lists recorded worker runs, newest first. Query
+// parameters: instance (instance ID or agent type) and limit (default 50, max
+// 200).
+func (h *Handler) handleAPIWorkerRuns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+	if h.runs == nil {
+		writeAPIError(w, http.StatusNotFound, "worker runs are not recorded by this server")
+		return
+	}
+	q := r.URL.Query()
+	limit := 50
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		limit = min(v, 200)
+	}
+	now := time.Now()
+	runs := []WorkerRunSnapshot{}
+	for _, run := range h.runs.Runs(q.Get("instance"), limit) {
+		end := now
+		if !run.Running() {
+			end = run.EndedAt
+		}
+		runs = append(runs, WorkerRunSnapshot{
+			ID:          run.ID,
+			InstanceID:  run.InstanceID,
+			AgentType:   run.AgentType,
+			TaskID:      run.TaskID,
+			Attempt:     run.Attempt,
+			Command:     strings.Join(run.Command, " "),
+			Dir:         run.Dir,
+			StartedAt:   run.StartedAt.Format(time.RFC3339),
+			Age:         relTime(run.StartedAt, now),
+			Duration:    end.Sub(run.StartedAt).Round(time.Second).String(),
+			Running:     run.Running(),
+			ExitCode:    run.ExitCode,
+			ErrorClass:  run.ErrorClass,
+			Error:       run.Error,
+			OutputBytes: run.OutputBytes,
+			LogURL:      "/api/worker-log?run=" + strconv.FormatInt(run.ID, 10),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(map[string]any{"runs": runs})
+}
+
+// handleAPIWorkerLog returns the output of a worker run as plain text. Query
+// parameters: run (default: the latest run of instance, or of any worker),
+// instance, tail (number of lines; default all) and grep (regular expression).
+func (h *Handler) handleAPIWorkerLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if h.runs == nil {
+		http.Error(w, "worker runs are not recorded by this server", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	lq := app.WorkerLogQuery{Instance: q.Get("instance"), Grep: q.Get("grep")}
+	if v := q.Get("run"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "run must be an integer", http.StatusBadRequest)
+			return
+		}
+		lq.RunID = id
+	}
+	if v := q.Get("tail"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "tail must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		lq.Tail = n
+	}
+	if _, err := regexp.Compile(lq.Grep); err != nil {
+		http.Error(w, "invalid grep pattern: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	wl, err := h.runs.ReadLog(lq)
+	switch {
+	case errors.Is(err, app.ErrWorkerRunNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	for _, line := range wl.Lines {
+		_, _ = w.Write([]byte(line + "\n"))
+	}
+}
+
 func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -361,6 +756,14 @@ func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 		Timestamp: now.Format(time.RFC3339),
 		Workspace: h.svc.Policy().WorkspaceRoot(),
 	}
+	// Records are filtered to ?project= (default: the current workspace);
+	// project=all shows every project.
+	snap.Project = app.ProjectKey(snap.Workspace)
+	if p := r.URL.Query().Get("project"); p == app.AllProjects {
+		snap.Project = p
+	} else if p != "" {
+		snap.Project = app.ProjectKey(p)
+	}
 
 	connectedAgents := make(map[string]bool)
 	for _, a := range h.registry.ConnectedAgents() {
@@ -425,48 +828,37 @@ func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 			return snap.Agents[i].Name < snap.Agents[j].Name
 		})
 
-		// ── Tasks (most recent first, limit 50) ──
-		start := 0
-		if len(state.Tasks) > 50 {
-			start = len(state.Tasks) - 50
-		}
-		for i := len(state.Tasks) - 1; i >= start; i-- {
-			t := state.Tasks[i]
-			ts := TaskSnapshot{
-				ID:                  t.ID,
-				Title:               truncate(t.Title, 80),
-				Status:              t.Status,
-				AssignedTo:          t.AssignedTo,
-				CreatedBy:           t.CreatedBy,
-				Priority:            t.Priority,
-				Age:                 relTime(t.CreatedAt, now),
-				ResultSummary:       truncate(t.ResultSummary, 120),
-				ProgressDescription: truncate(t.ProgressDescription, 120),
-				ProgressPercent:     t.ProgressPercent,
-				ExpectedDurationSec: t.ExpectedDurationSec,
-			}
-			if !t.LastProgressAt.IsZero() {
-				ts.LastProgressAge = relTime(t.LastProgressAt, now)
+		// ── Tasks (most recent first, limit 50; subtasks under their parent) ──
+		var recent []domain.Task
+		for i := len(state.Tasks) - 1; i >= 0 && len(recent) < 50; i-- {
+			if app.InProject(state.Tasks[i].Project, snap.Project) {
+				recent = append(recent, state.Tasks[i])
 			}
-			if t.ExpectedDurationSec > 0 && t.Status == "in_progress" {
-				expected := time.Duration(t.ExpectedDurationSec) * time.Second
-				actual := now.Sub(t.UpdatedAt)
-				if actual > expected {
-					ts.SLAStatus = "over"
-				} else {
-					ts.SLAStatus = "ok"
-				}
+		}
+		for _, e := range app.TaskTree(recent) {
+			snap.Tasks = append(snap.Tasks, taskSnapshot(e.Task, e.Depth, now))
+		}
+
+		// ── Upcoming scheduled runs (soonest first, limit 20) ──
+		for _, u := range app.UpcomingRuns(state, snap.Project) {
+			if len(snap.Upcoming) == 20 {
+				break
 			}
-			snap.Tasks = append(snap.Tasks, ts)
+			snap.Upcoming = append(snap.Upcoming, UpcomingSnapshot{
+				TaskID:   u.TaskID,
+				Title:    truncate(u.Title, 80),
+				Schedule: u.Schedule,
+				At:       u.At.Format("Jan 2 15:04"),
+				In:       untilTime(u.At, now),
+			})
 		}
 
 		// ── Messages (most recent first, limit 30) ──
-		msgStart := 0
-		if len(state.Messages) > 30 {
-			msgStart = len(state.Messages) - 30
-		}
-		for i := len(state.Messages) - 1; i >= msgStart; i-- {
+		for i := len(state.Messages) - 1; i >= 0 && len(snap.Messages) < 30; i-- {
 			m := state.Messages[i]
+			if !app.InProject(m.Project, snap.Project) {
+				continue
+			}
 			snap.Messages = append(snap.Messages, MessageSnapshot{
 				ID:        m.ID,
 				From:      m.From,
@@ -486,7 +878,7 @@ func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 		sort.Strings(planIDs)
 		for _, id := range planIDs {
 			plan := state.Plans[id]
-			if plan == nil {
+			if plan == nil || !app.InProject(plan.Project, snap.Project) {
 				continue
 			}
 			ps := PlanSnapshot{
@@ -532,12 +924,11 @@ func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 		})
 
 		// ── Session notes (most recent first, limit 20) ──
-		noteStart := 0
-		if len(state.SessionNotes) > 20 {
-			noteStart = len(state.SessionNotes) - 20
-		}
-		for i := len(state.SessionNotes) - 1; i >= noteStart; i-- {
+		for i := len(state.SessionNotes) - 1; i >= 0 && len(snap.SessionNotes) < 20; i-- {
 			n := state.SessionNotes[i]
+			if !app.InProject(n.Project, snap.Project) {
+				continue
+			}
 			snap.SessionNotes = append(snap.SessionNotes, NoteSnapshot{
 				ID:       n.ID,
 				Author:   n.Author,
@@ -555,7 +946,7 @@ func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 		sort.Strings(lockPaths)
 		for _, p := range lockPaths {
 			fl := state.FileLocks[p]
-			if fl == nil {
+			if fl == nil || !app.InProject(fl.Project, snap.Project) {
 				continue
 			}
 			expires := "never"
@@ -583,6 +974,64 @@ func (h *Handler) handleAPIState(w http.ResponseWriter, r *http.Request) {
 	_ = enc.Encode(snap)
 }
 
+// taskSnapshot summarizes t, nested depth levels under its parent.
+func taskSnapshot(t domain.Task, depth int, now time.Time) TaskSnapshot {
+	ts := TaskSnapshot{
+		ID:                  t.ID,
+		Title:               truncate(t.Title, 80),
+		Status:              t.Status,
+		AssignedTo:          t.AssignedTo,
+		CreatedBy:           t.CreatedBy,
+		Priority:            t.Priority,
+		Age:                 relTime(t.CreatedAt, now),
+		ResultSummary:       truncate(t.ResultSummary, 120),
+		ProgressDescription: truncate(t.ProgressDescription, 120),
+		ProgressPercent:     t.ProgressPercent,
+		ExpectedDurationSec: t.ExpectedDurationSec,
+		ParentTaskID:        t.ParentTaskID,
+		Depth:               depth,
+		MaxAttempts:         t.MaxAttempts,
+		Labels:              t.Labels,
+		PlanID:              t.PlanID,
+	}
+	for _, a := range t.Attempts {
+		as := AttemptSnapshot{Number: a.Number, Agent: a.Agent, Outcome: a.Outcome, ExitClass: a.ExitClass}
+		if a.EndedAt.IsZero() {
+			as.Outcome = "running"
+			as.Duration = now.Sub(a.StartedAt).Round(time.Second).String()
+		} else {
+			as.Duration = a.EndedAt.Sub(a.StartedAt).Round(time.Second).String()
+		}
+		ts.Attempts = append(ts.Attempts, as)
+	}
+	if r := t.Result; r != nil {
+		ts.Tests = r.Tests
+		for _, a := range r.Artifacts {
+			ts.Artifacts = append(ts.Artifacts, ArtifactLink{Name: a.Name, Size: a.Size, URL: artifactURL(t.ID, a.Name)})
+		}
+	}
+	if v := t.Verification; v != nil {
+		ts.Verification = "passed"
+		if c := app.FailedCheck(v); c != nil {
+			ts.Verification = "failed"
+			ts.VerificationFailure = truncate(c.Command, 120)
+		}
+	}
+	if !t.LastProgressAt.IsZero() {
+		ts.LastProgressAge = relTime(t.LastProgressAt, now)
+	}
+	if t.ExpectedDurationSec > 0 && t.Status == "in_progress" {
+		expected := time.Duration(t.ExpectedDurationSec) * time.Second
+		actual := now.Sub(t.UpdatedAt)
+		if actual > expected {
+			ts.SLAStatus = "over"
+		} else {
+			ts.SLAStatus = "ok"
+		}
+	}
+	return ts
+}
+
 func relTime(t time.Time, now time.Time) string {
 	if t.IsZero() {
 		return "never"
@@ -602,6 +1051,21 @@ func relTime(t time.Time, now time.Time) string {
 	}
 }
 
+// untilTime is relTime for a time in the future.
+func untilTime(t time.Time, now time.Time) string {
+	d := t.Sub(now)
+	switch {
+	case d < time.Minute:
+		return "due"
+	case d < time.Hour:
+		return "in " + itoa(int(d.Minutes())) + "m"
+	case d < 24*time.Hour:
+		return "in " + itoa(int(d.Hours())) + "h"
+	default:
+		return "in " + itoa(int(d.Hours()/24)) + "d"
+	}
+}
+
 func formatDuration(d time.Duration, unit string) string {
 	switch unit {
 	case "s":
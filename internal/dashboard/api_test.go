@@ -13,6 +13,7 @@ import (
 	"github.com/jaakkos/stringwork/internal/app"
 	"github.com/jaakkos/stringwork/internal/domain"
 	"github.com/jaakkos/stringwork/internal/policy"
+	"github.com/jaakkos/stringwork/internal/repository/memory"
 )
 
 type mockRepo struct {
@@ -39,6 +40,7 @@ type mockPolicy struct {
 
 func (p *mockPolicy) MessageRetentionMax() int                   { return 1000 }
 func (p *mockPolicy) MessageRetentionDays() int                  { return 30 }
+func (p *mockPolicy) TaskRetentionDays() int                     { return 0 }
 func (p *mockPolicy) PresenceTTLSeconds() int                    { return 300 }
 func (p *mockPolicy) StateFile() string                          { return "" }
 func (p *mockPolicy) SignalFilePath() string                     { return "" }
@@ -47,6 +49,12 @@ func (p *mockPolicy) SetWorkspaceRoot(root string)               { p.workspaceRo
 func (p *mockPolicy) IsToolEnabled(string) bool                  { return true }
 func (p *mockPolicy) ValidatePath(path string) (string, error)   { return path, nil }
 func (p *mockPolicy) Orchestration() *policy.OrchestrationConfig { return nil }
+func (p *mockPolicy) ToolPermissions(string, ...string) policy.ToolPermissions {
+	return policy.ToolPermissions{}
+}
+func (p *mockPolicy) TaskTemplates() map[string]policy.TaskTemplate { return nil }
+func (p *mockPolicy) Verification() policy.VerificationConfig       { return policy.VerificationConfig{} }
+func (p *mockPolicy) PlanFile() string                              { return "" }
 
 func newTestService() (*app.CollabService, *mockRepo) {
 	repo := &mockRepo{state: domain.NewCollabState()}
@@ -304,6 +312,42 @@ func TestAPIState_SLAOver(t *testing.T) {
 	}
 }
 
+func TestAPIState_Upcoming(t *testing.T) {
+	svc, repo := newTestService()
+	registry := app.NewSessionRegistry()
+	h := NewHandler(svc, registry)
+
+	now := time.Now()
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Re-check", Status: "scheduled", NotBefore: now.Add(90 * time.Minute), CreatedAt: now, UpdatedAt: now},
+		{ID: 2, Title: "Nightly sweep", Status: "scheduled", Schedule: "0 3 * * *", NotBefore: now.Add(30 * time.Minute), CreatedAt: now, UpdatedAt: now},
+		{ID: 3, Title: "Run", Status: "pending", ScheduledFrom: 2, CreatedAt: now, UpdatedAt: now},
+	}
+	repo.state.NextTaskID = 4
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	req := httptest.NewRequest("GET", "/api/state", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var snap StateSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &snap); err != nil {
+		t.Fatalf("json decode: %v", err)
+	}
+
+	if len(snap.Upcoming) != 2 {
+		t.Fatalf("expected 2 upcoming runs, got %+v", snap.Upcoming)
+	}
+	if u := snap.Upcoming[0]; u.TaskID != 2 || u.Schedule != "0 3 * * *" || u.In != "in 29m" && u.In != "in 30m" {
+		t.Errorf("first upcoming = %+v, want #2 in 30m", u)
+	}
+	if u := snap.Upcoming[1]; u.TaskID != 1 || u.Schedule != "" || u.In != "in 1h" {
+		t.Errorf("second upcoming = %+v, want #1 in 1h", u)
+	}
+}
+
 func TestAPIReset_ClearsState(t *testing.T) {
 	svc, repo := newTestService()
 	registry := app.NewSessionRegistry()
@@ -523,7 +567,7 @@ func TestDashboardPage_ServesHTML(t *testing.T) {
 	}
 }
 
-func TestAPISwitchProject_ClearsAndUpdatesWorkspace(t *testing.T) {
+func TestAPISwitchProject_KeepsRecordsAndUpdatesWorkspace(t *testing.T) {
 	svc, repo := newTestService()
 	registry := app.NewSessionRegistry()
 	wc := &mockWorkerController{running: []string{"claude-code"}}
@@ -531,10 +575,10 @@ func TestAPISwitchProject_ClearsAndUpdatesWorkspace(t *testing.T) {
 
 	now := time.Now()
 	repo.state.Tasks = []domain.Task{
-		{ID: 1, Title: "Old task", Status: "in_progress", AssignedTo: "claude-code", CreatedBy: "cursor", CreatedAt: now},
+		{ID: 1, Title: "Old task", Status: "in_progress", AssignedTo: "claude-code", CreatedBy: "cursor", CreatedAt: now, Project: "/old/project"},
 	}
 	repo.state.Messages = []domain.Message{
-		{ID: 1, From: "cursor", To: "claude-code", Content: "old msg", Timestamp: now},
+		{ID: 1, From: "cursor", To: "claude-code", Content: "old msg", Timestamp: now, Project: "/old/project"},
 	}
 	repo.state.Presence = map[string]*domain.Presence{
 		"cursor": {Agent: "cursor", Status: "working", Workspace: "/old/project", LastSeen: now},
@@ -551,12 +595,12 @@ func TestAPISwitchProject_ClearsAndUpdatesWorkspace(t *testing.T) {
 		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	// Tasks and messages cleared
-	if len(repo.state.Tasks) != 0 {
-		t.Errorf("expected 0 tasks, got %d", len(repo.state.Tasks))
+	// Records of the old project are kept
+	if len(repo.state.Tasks) != 1 {
+		t.Errorf("expected 1 task, got %d", len(repo.state.Tasks))
 	}
-	if len(repo.state.Messages) != 0 {
-		t.Errorf("expected 0 messages, got %d", len(repo.state.Messages))
+	if len(repo.state.Messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(repo.state.Messages))
 	}
 
 	// Presence workspace updated
@@ -591,3 +635,306 @@ func TestAPISwitchProject_RequiresWorkspace(t *testing.T) {
 		t.Fatalf("expected 400 without workspace, got %d", w.Code)
 	}
 }
+
+func TestAPIState_FiltersByProject(t *testing.T) {
+	svc, repo := newTestService()
+	registry := app.NewSessionRegistry()
+	h := NewHandler(svc, registry)
+
+	now := time.Now()
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Here", Status: "pending", CreatedAt: now, Project: "/tmp"},
+		{ID: 2, Title: "Elsewhere", Status: "pending", CreatedAt: now, Project: "/other"},
+		{ID: 3, Title: "Legacy", Status: "pending", CreatedAt: now},
+	}
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	ids := func(url string) []int {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var snap StateSnapshot
+		if err := json.Unmarshal(w.Body.Bytes(), &snap); err != nil {
+			t.Fatalf("json decode: %v", err)
+		}
+		var out []int
+		for _, ts := range snap.Tasks {
+			out = append(out, ts.ID)
+		}
+		return out
+	}
+
+	if got := ids("/api/state"); len(got) != 2 || got[0] != 3 || got[1] != 1 {
+		t.Errorf("default project: got tasks %v, want [3 1]", got)
+	}
+	if got := ids("/api/state?project=/other"); len(got) != 2 || got[0] != 3 || got[1] != 2 {
+		t.Errorf("project=/other: got tasks %v, want [3 2]", got)
+	}
+	if got := ids("/api/state?project=all"); len(got) != 3 {
+		t.Errorf("project=all: got tasks %v, want all 3", got)
+	}
+}
+
+func TestAPIProjects(t *testing.T) {
+	svc, repo := newTestService()
+	registry := app.NewSessionRegistry()
+	h := NewHandler(svc, registry)
+
+	now := time.Now()
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "A", Status: "pending", UpdatedAt: now, Project: "/tmp"},
+		{ID: 2, Title: "B", Status: "completed", UpdatedAt: now, Project: "/other"},
+	}
+
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	req := httptest.NewRequest("GET", "/api/projects", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp struct {
+		Current  string               `json:"current"`
+		Projects []app.ProjectSummary `json:"projects"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json decode: %v", err)
+	}
+	if resp.Current != "/tmp" {
+		t.Errorf("current = %q, want /tmp", resp.Current)
+	}
+	if len(resp.Projects) != 2 {
+		t.Fatalf("expected 2 projects, got %+v", resp.Projects)
+	}
+}
+
+// journalRepo adds an in-memory event journal to mockRepo.
+type journalRepo struct {
+	*mockRepo
+	events []domain.Event
+}
+
+func (j *journalRepo) Save(s *domain.CollabState) error {
+	for _, e := range s.PendingEvents {
+		e.ID = int64(len(j.events) + 1)
+		j.events = append(j.events, e)
+	}
+	return j.mockRepo.Save(s)
+}
+
+func (j *journalRepo) Events(f app.EventFilter) ([]domain.Event, error) {
+	var out []domain.Event
+	for _, e := range j.events {
+		if (f.TaskID == 0 || e.TaskID == f.TaskID) && e.ID > f.AfterID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestAPIEvents(t *testing.T) {
+	repo := &journalRepo{mockRepo: &mockRepo{state: domain.NewCollabState()}}
+	svc := app.NewCollabService(repo, &mockPolicy{workspaceRoot: "/tmp"}, log.New(io.Discard, "", 0))
+	h := NewHandler(svc, app.NewSessionRegistry())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	_ = svc.Run(func(s *domain.CollabState) error {
+		s.Tasks = append(s.Tasks,
+			domain.Task{ID: 1, Title: "A", Status: "pending", CreatedBy: "cursor"},
+			domain.Task{ID: 2, Title: "B", Status: "pending", CreatedBy: "cursor"})
+		return nil
+	})
+
+	get := func(url string) (int, []domain.Event) {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		var resp struct {
+			Events []domain.Event `json:"events"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Events
+	}
+
+	if code, events := get("/api/events"); code != 200 || len(events) != 2 {
+		t.Fatalf("GET /api/events = %d, %d events", code, len(events))
+	}
+	if _, events := get("/api/events?task_id=2"); len(events) != 1 || events[0].Type != domain.EventTaskCreated {
+		t.Errorf("task_id filter: got %+v", events)
+	}
+	if code, _ := get("/api/events?task_id=x"); code != http.StatusBadRequest {
+		t.Errorf("invalid task_id: got %d, want 400", code)
+	}
+
+	plain, _ := newTestService()
+	mux = http.NewServeMux()
+	NewHandler(plain, app.NewSessionRegistry()).RegisterRoutes(mux)
+	if code, _ := get("/api/events"); code != http.StatusNotImplemented {
+		t.Errorf("without journal: got %d, want 501", code)
+	}
+}
+
+func TestAPIArtifact(t *testing.T) {
+	svc := app.NewCollabService(memory.New(), &mockPolicy{workspaceRoot: "/tmp"}, log.New(io.Discard, "", 0))
+	h := NewHandler(svc, app.NewSessionRegistry())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	err := svc.Run(func(s *domain.CollabState) error {
+		s.Tasks = append(s.Tasks, domain.Task{ID: 1, Title: "Fix", Status: "completed", CreatedBy: "cursor", CreatedAt: time.Now()})
+		return app.RecordResult(s, &s.Tasks[0], domain.TaskResult{Summary: "fixed", Tests: app.TestsPassed, ReportedBy: "claude-code"},
+			[]domain.Artifact{{Name: "fix.patch", Content: []byte("+fixed\n")}}, time.Now())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var snap StateSnapshot
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/state", nil))
+	_ = json.Unmarshal(w.Body.Bytes(), &snap)
+	if len(snap.Tasks) != 1 || snap.Tasks[0].Tests != "passed" || len(snap.Tasks[0].Artifacts) != 1 {
+		t.Fatalf("task snapshot = %+v", snap.Tasks)
+	}
+	link := snap.Tasks[0].Artifacts[0]
+	if link.Name != "fix.patch" || link.Size != 7 || link.URL != "/api/artifact?name=fix.patch&task_id=1" {
+		t.Errorf("artifact link = %+v", link)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", link.URL, nil))
+	if w.Code != 200 || w.Body.String() != "+fixed\n" {
+		t.Fatalf("GET %s = %d %q", link.URL, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/x-diff; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != "attachment; filename=fix.patch" {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	for url, want := range map[string]int{
+		"/api/artifact?task_id=1&name=other.txt": http.StatusNotFound,
+		"/api/artifact?task_id=x&name=fix.patch": http.StatusBadRequest,
+		"/api/artifact?task_id=1&name=../etc":    http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != want {
+			t.Errorf("GET %s = %d, want %d", url, w.Code, want)
+		}
+	}
+}
+
+func TestAPITasks(t *testing.T) {
+	svc, repo := newTestService()
+	h := NewHandler(svc, app.NewSessionRegistry())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	now := time.Now()
+	for i := 1; i <= 4; i++ {
+		task := domain.Task{ID: i, Title: "Task", Status: "pending", AssignedTo: "any", CreatedBy: "cursor", Priority: 5 - i, CreatedAt: now, UpdatedAt: now}
+		if i != 2 {
+			task.Labels = []string{"bug", "frontend"}
+		}
+		repo.state.Tasks = append(repo.state.Tasks, task)
+	}
+	repo.state.NextTaskID = 5
+
+	get := func(query string) (int, TaskListResponse) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/tasks?"+query, nil))
+		var resp TaskListResponse
+		if w.Code == 200 {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("json decode: %v", err)
+			}
+		}
+		return w.Code, resp
+	}
+
+	code, resp := get("label=bug,frontend&sort=priority&limit=2")
+	if code != 200 || resp.Total != 3 || len(resp.Tasks) != 2 || resp.Tasks[0].ID != 4 || resp.Tasks[1].ID != 3 || resp.NextCursor == "" {
+		t.Fatalf("first page: code %d, %+v", code, resp)
+	}
+	if got := resp.Tasks[0].Labels; len(got) != 2 || got[0] != "bug" {
+		t.Errorf("labels = %v", got)
+	}
+	code, resp = get("label=bug&label=frontend&sort=priority&limit=2&cursor=" + resp.NextCursor)
+	if code != 200 || len(resp.Tasks) != 1 || resp.Tasks[0].ID != 1 || resp.NextCursor != "" {
+		t.Errorf("second page: code %d, %+v", code, resp)
+	}
+
+	for _, query := range []string{"sort=title", "priority=high", "created_after=soon", "cursor=bogus"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: code %d, want 400", query, code)
+		}
+	}
+}
+
+func TestAPIWorkerRuns(t *testing.T) {
+	svc := app.NewCollabService(memory.New(), &mockPolicy{workspaceRoot: "/tmp"}, log.New(io.Discard, "", 0))
+	mux := http.NewServeMux()
+	NewHandler(svc, app.NewSessionRegistry()).RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/worker-runs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("without a run store: status = %d, want 404", w.Code)
+	}
+
+	runs, err := app.NewWorkerRunLog(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, f, err := runs.Start(app.WorkerRun{InstanceID: "codex", AgentType: "codex", Attempt: 1, Command: []string{"codex", "exec"}, Dir: "/ws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("one\ntwo\nthree\n")
+	f.Close()
+	run.ExitCode, run.Error, run.ErrorClass = 2, "exited after 1s: exit status 2", "transient"
+	if err := runs.Finish(run); err != nil {
+		t.Fatal(err)
+	}
+	mux = http.NewServeMux()
+	NewHandler(svc, app.NewSessionRegistry(), WithWorkerRuns(runs)).RegisterRoutes(mux)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/worker-runs?instance=codex", nil))
+	var resp struct {
+		Runs []WorkerRunSnapshot `json:"runs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Runs) != 1 {
+		t.Fatalf("runs = %+v", resp.Runs)
+	}
+	got := resp.Runs[0]
+	if got.ID != run.ID || got.Command != "codex exec" || got.ExitCode != 2 || got.ErrorClass != "transient" || got.Running || got.OutputBytes != 14 || got.LogURL != "/api/worker-log?run=1" {
+		t.Errorf("run snapshot = %+v", got)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", got.LogURL+"&tail=2", nil))
+	if w.Code != 200 || w.Body.String() != "two\nthree\n" {
+		t.Errorf("GET log = %d %q", w.Code, w.Body.String())
+	}
+	for url, want := range map[string]int{
+		"/api/worker-log?run=9":        http.StatusNotFound,
+		"/api/worker-log?run=x":        http.StatusBadRequest,
+		"/api/worker-log?run=1&grep=(": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != want {
+			t.Errorf("GET %s = %d, want %d", url, w.Code, want)
+		}
+	}
+}
@@ -172,6 +172,8 @@ const dashboardHTML = `<!DOCTYPE html>
   .badge.pending { background: #1f2d3d; color: var(--accent); }
   .badge.in_progress { background: #2a1f0d; color: var(--yellow); }
   .badge.completed { background: #0d2818; color: var(--green); }
+  .badge.waiting { background: #1f2d3d; color: var(--text-dim); }
+  .badge.scheduled { background: #1f2d3d; color: var(--text-dim); }
   .badge.blocked { background: #2d1a1a; color: var(--red); }
   .badge.cancelled { background: #2d1a1a; color: var(--red); }
   .badge.active { background: #0d2818; color: var(--green); }
@@ -216,6 +218,12 @@ const dashboardHTML = `<!DOCTYPE html>
   }
   .sla-over { color: var(--red); font-weight: 600; }
   .sla-ok { color: var(--green); }
+  .tests.passed { color: var(--green); }
+  .tests.failed { color: var(--red); }
+  a.artifact { color: var(--accent); text-decoration: none; }
+  a.artifact:hover { text-decoration: underline; }
+  .run-error { color: var(--red); }
+  .label { display: inline-block; margin-left: 4px; padding: 0 6px; border-radius: 8px; font-size: 10px; background: #1f2d3d; color: var(--text-dim); }
 
   /* Messages */
   .msg-list { max-height: 400px; overflow-y: auto; }
@@ -472,11 +480,12 @@ const dashboardHTML = `<!DOCTYPE html>
 <div class="modal-overlay" id="switch-modal">
   <div class="modal">
     <h2 style="color:var(--accent)">Switch Project</h2>
-    <p>This will cancel all running workers, clear all tasks, messages, and plans, then set the new workspace. Agents stay registered.</p>
+    <p>This will cancel all running workers and set the new workspace. Tasks, messages, and plans of the current project are kept and shown again when you switch back.</p>
     <label class="modal-option" style="flex-direction:column;align-items:stretch;gap:4px">
       <span>New workspace path:</span>
-      <input type="text" id="switch-workspace" placeholder="/path/to/project" style="width:100%;padding:6px 10px;background:var(--bg);color:var(--text);border:1px solid var(--border);border-radius:6px;font-size:13px;font-family:monospace">
+      <input type="text" id="switch-workspace" list="known-projects" placeholder="/path/to/project" style="width:100%;padding:6px 10px;background:var(--bg);color:var(--text);border:1px solid var(--border);border-radius:6px;font-size:13px;font-family:monospace">
     </label>
+    <datalist id="known-projects"></datalist>
     <div class="modal-actions">
       <button class="btn btn-secondary" onclick="hideSwitchModal()">Cancel</button>
       <button class="btn btn-primary" id="switch-confirm-btn" onclick="doSwitchProject()">Switch Project</button>
@@ -497,13 +506,19 @@ const dashboardHTML = `<!DOCTYPE html>
     <div class="card-body"><div class="worker-list" id="workers"></div></div>
   </div>
 
-  <!-- Row 3: Tasks (full width) -->
+  <!-- Row 3: Worker runs (full width, only shown when runs are recorded) -->
+  <div class="card full-width" id="runs-card" style="display:none">
+    <div class="card-header">&#128220; Worker Runs <span class="count" id="runs-count">0</span></div>
+    <div class="card-body" id="runs"></div>
+  </div>
+
+  <!-- Row 4: Tasks (full width) -->
   <div class="card full-width" id="tasks-card">
     <div class="card-header">&#9745; Tasks <span class="count" id="tasks-count">0</span></div>
     <div class="card-body" id="tasks"></div>
   </div>
 
-  <!-- Row 4: Messages + Plans/Notes/Locks -->
+  <!-- Row 5: Messages + Plans/Notes/Locks -->
   <div class="card" id="messages-card">
     <div class="card-header">&#128172; Messages <span class="count" id="messages-count">0</span></div>
     <div class="card-body msg-list" id="messages"></div>
@@ -598,6 +613,47 @@ function renderWorkers(workers) {
   }).join('');
 }
 
+function renderRuns(runs) {
+  const card = document.getElementById('runs-card');
+  const el = document.getElementById('runs');
+  document.getElementById('runs-count').textContent = runs ? runs.length : 0;
+  if (!runs || runs.length === 0) {
+    card.style.display = 'none';
+    return;
+  }
+  card.style.display = '';
+  let html = '<table><thead><tr><th>Run</th><th>Worker</th><th>Attempt</th><th>Task</th><th>Result</th><th>Duration</th><th>Output</th><th>Started</th></tr></thead><tbody>';
+  runs.forEach(r => {
+    let result = '<span class="badge in_progress">running</span>';
+    if (!r.running) {
+      result = r.error
+        ? '<span class="run-error" title="' + escAttr(r.error) + '">exit ' + r.exit_code + (r.error_class ? ' (' + esc(r.error_class) + ')' : '') + '</span>'
+        : '<span class="tests passed">ok</span>';
+    }
+    html += '<tr>' +
+      '<td><a class="artifact" href="' + escAttr(r.log_url) + '" target="_blank" title="' + escAttr(r.command + '\n' + r.dir) + '">#' + r.id + '</a></td>' +
+      '<td>' + esc(r.instance_id) + '</td>' +
+      '<td>' + r.attempt + '</td>' +
+      '<td>' + (r.task_id ? '#' + r.task_id : '-') + '</td>' +
+      '<td>' + result + '</td>' +
+      '<td style="white-space:nowrap">' + esc(r.duration) + '</td>' +
+      '<td style="white-space:nowrap">' + r.output_bytes + ' B</td>' +
+      '<td style="white-space:nowrap;color:var(--text-dim)" title="' + escAttr(r.started_at) + '">' + esc(r.age) + '</td>' +
+    '</tr>';
+  });
+  html += '</tbody></table>';
+  el.innerHTML = html;
+}
+
+async function fetchRuns() {
+  try {
+    const resp = await fetch('/api/worker-runs?limit=20');
+    renderRuns(resp.ok ? (await resp.json()).runs : null);
+  } catch (e) {
+    renderRuns(null);
+  }
+}
+
 function renderTasks(tasks) {
   const el = document.getElementById('tasks');
   document.getElementById('tasks-count').textContent = tasks ? tasks.length : 0;
@@ -629,11 +685,28 @@ function renderTasks(tasks) {
     } else if (t.status === 'completed' && t.result_summary) {
       progressCol = '<span style="font-size:11px;color:var(--text-dim)">' + esc(t.result_summary) + '</span>';
     }
+    if (t.attempts && (t.attempts.length > 1 || t.max_attempts)) {
+      const history = t.attempts.map(a => '#' + a.number + ' ' + a.agent + ': ' + a.outcome + (a.exit_class ? ' (' + a.exit_class + ')' : '') + ', ' + a.duration).join('\n');
+      progressCol += '<div class="progress-text" title="' + escAttr(history) + '">Attempt ' + t.attempts.length + (t.max_attempts ? '/' + t.max_attempts : '') +
+        (t.attempts.length > 1 ? ' · last: ' + esc(t.attempts[t.attempts.length - 2].outcome) : '') + '</div>';
+    }
+    if (t.tests) {
+      progressCol += '<div class="progress-text">Tests: <span class="tests ' + esc(t.tests) + '">' + esc(t.tests) + '</span></div>';
+    }
+    if (t.verification) {
+      progressCol += '<div class="progress-text"' + (t.verification_failure ? ' title="' + escAttr(t.verification_failure) + '"' : '') +
+        '>Verification: <span class="tests ' + esc(t.verification) + '">' + esc(t.verification) + '</span></div>';
+    }
+    if (t.artifacts) {
+      progressCol += '<div class="progress-text">' + t.artifacts.map(a =>
+        '<a class="artifact" href="' + escAttr(a.url) + '" download title="' + a.size + ' bytes">' + esc(a.name) + '</a>').join(' · ') + '</div>';
+    }
 
     html += '<tr>' +
       '<td>#' + t.id + '</td>' +
       '<td><span class="priority p' + t.priority + '"></span></td>' +
-      '<td>' + esc(t.title) + '</td>' +
+      '<td>' + (t.depth ? '<span style="padding-left:' + (t.depth - 1) * 16 + 'px;color:var(--text-dim)">&#8627; </span>' : '') + esc(t.title) +
+        (t.labels || []).map(l => '<span class="label">' + esc(l) + '</span>').join('') + '</td>' +
       '<td><span class="badge ' + t.status + '">' + esc(t.status) + '</span></td>' +
       '<td>' + progressCol + '</td>' +
       '<td>' + esc(t.assigned_to || '-') + '</td>' +
@@ -691,6 +764,22 @@ function renderSide(data) {
     });
   }
 
+  // Upcoming scheduled runs
+  if (data.upcoming && data.upcoming.length > 0) {
+    sections.push('Upcoming');
+    html += '<div style="border-top:1px solid var(--border)">';
+    html += '<div style="padding:10px 14px 4px 14px;font-size:11px;font-weight:600;color:var(--text-dim);text-transform:uppercase">Upcoming Runs</div>';
+    html += '<div class="lock-list">';
+    data.upcoming.forEach(u => {
+      html += '<div class="lock-item">' +
+        '<span class="lock-path">#' + u.task_id + ' ' + esc(u.title) + '</span>' +
+        (u.schedule ? '<span class="lock-owner">' + esc(u.schedule) + '</span>' : '') +
+        '<span style="color:var(--text-dim);font-size:11px">' + esc(u.at) + ' · ' + esc(u.in) + '</span>' +
+      '</div>';
+    });
+    html += '</div></div>';
+  }
+
   // Session Notes
   if (data.session_notes && data.session_notes.length > 0) {
     sections.push('Notes');
@@ -727,7 +816,7 @@ function renderSide(data) {
   }
 
   header.innerHTML = '&#128203; ' + (sections.length > 0 ? sections.join(' / ') : 'Plans');
-  body.innerHTML = html || '<div class="empty">No plans, notes, or locks</div>';
+  body.innerHTML = html || '<div class="empty">No plans, upcoming runs, notes, or locks</div>';
 }
 
 function shortPath(p) {
@@ -769,6 +858,7 @@ async function fetchState() {
     renderTasks(data.tasks);
     renderMessages(data.messages);
     renderSide(data);
+    fetchRuns();
   } catch (e) {
     document.getElementById('updated').textContent = 'error';
     document.getElementById('updated').style.color = 'var(--red)';
@@ -784,6 +874,12 @@ function showSwitchModal() {
   if (current) input.value = current;
   input.focus();
   input.select();
+  fetch('/api/projects').then(r => r.json()).then(data => {
+    const list = document.getElementById('known-projects');
+    list.innerHTML = (data.projects || []).map(p =>
+      '<option value="' + escAttr(p.project) + '">' + esc(p.name) + ' (' + p.open_tasks + ' open)</option>'
+    ).join('');
+  }).catch(() => {});
 }
 function hideSwitchModal() {
   document.getElementById('switch-modal').classList.remove('open');
@@ -12,6 +12,7 @@ type Message struct {
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
 	Read      bool      `json:"read"`
+	Project   string    `json:"project,omitempty"` // workspace the message belongs to; empty = unscoped
 }
 
 // AgentRole is the role of an agent in the driver/worker model.
@@ -52,21 +53,34 @@ type WorkContext struct {
 
 // Task is a shared task.
 type Task struct {
-	ID            int       `json:"id"`
-	Title         string    `json:"title"`
-	Description   string    `json:"description"`
-	Status        string    `json:"status"` // pending, in_progress, completed, blocked, cancelled
-	AssignedTo    string    `json:"assigned_to"`
-	CreatedBy     string    `json:"created_by"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	Priority      int       `json:"priority"`
-	Dependencies  []int     `json:"dependencies"`
-	BlockedBy     string    `json:"blocked_by"`
-	ContextID     string    `json:"context_id,omitempty"`
-	WorkerType    string    `json:"worker_type,omitempty"`
-	Capabilities  []string  `json:"capabilities,omitempty"`
-	ResultSummary string    `json:"result_summary,omitempty"`
+	ID            int           `json:"id"`
+	Title         string        `json:"title"`
+	Description   string        `json:"description"`
+	Status        string        `json:"status"` // scheduled, waiting, pending, in_progress, completed, blocked, cancelled
+	AssignedTo    string        `json:"assigned_to"`
+	CreatedBy     string        `json:"created_by"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Priority      int           `json:"priority"`
+	Dependencies  []int         `json:"dependencies"`
+	BlockedBy     string        `json:"blocked_by"`
+	ContextID     string        `json:"context_id,omitempty"`
+	WorkerType    string        `json:"worker_type,omitempty"`
+	Capabilities  []string      `json:"capabilities,omitempty"`
+	ResultSummary string        `json:"result_summary,omitempty"`
+	Result        *TaskResult   `json:"result,omitempty"`         // structured result reported on completion
+	Project       string        `json:"project,omitempty"`        // workspace the task belongs to; empty = unscoped
+	ParentTaskID  int           `json:"parent_task_id,omitempty"` // task this one is a subtask of; 0 = top level
+	Attempts      []TaskAttempt `json:"attempts,omitempty"`       // one per claim, oldest first
+	MaxAttempts   int           `json:"max_attempts,omitempty"`   // block the task instead of retrying after this many; 0 = unlimited
+	NotBefore     time.Time     `json:"not_before,omitempty"`     // a scheduled task becomes pending at this time (the next run, if recurring)
+	Schedule      string        `json:"schedule,omitempty"`       // cron spec of a recurring task; each run is a new task
+	ScheduledFrom int           `json:"scheduled_from,omitempty"` // recurring task this run was created from; 0 = none
+	Labels        []string      `json:"labels,omitempty"`
+	PlanID        string        `json:"plan_id,omitempty"`      // plan the task belongs to; empty = none
+	PlanItemID    string        `json:"plan_item_id,omitempty"` // item of PlanID the task carries out; its status follows the task's
+	Verify        []string      `json:"verify,omitempty"`       // commands that must succeed to complete the task; empty = the configured default
+	Verification  *Verification `json:"verification,omitempty"` // outcome of the last verification run
 	// Progress monitoring fields
 	ExpectedDurationSec int       `json:"expected_duration_seconds,omitempty"` // SLA: expected task duration in seconds
 	ProgressDescription string    `json:"progress_description,omitempty"`      // latest progress report text
@@ -74,6 +88,73 @@ type Task struct {
 	LastProgressAt      time.Time `json:"last_progress_at,omitempty"`          // when progress was last reported
 }
 
+// TaskAttempt is one claim of a task: from entering in_progress under an
+// assignee until the task leaves in_progress or changes hands.
+type TaskAttempt struct {
+	Number          int       `json:"number"`
+	Agent           string    `json:"agent"`
+	StartedAt       time.Time `json:"started_at"`
+	EndedAt         time.Time `json:"ended_at,omitempty"`
+	Outcome         string    `json:"outcome,omitempty"`    // completed, cancelled, blocked, released, reassigned, worker_exited, worker_failed, stalled; "" while running
+	ExitClass       string    `json:"exit_class,omitempty"` // worker failure class: transient, quota_exhausted, auth_failure, not_found, resource_limit
+	Error           string    `json:"error,omitempty"`
+	ProgressPercent int       `json:"progress_percent,omitempty"` // last reported progress
+	LastProgress    string    `json:"last_progress,omitempty"`
+	LogExcerpt      string    `json:"log_excerpt,omitempty"` // tail of the worker log when a worker exit ended the attempt
+}
+
+// TaskResult is the structured outcome a worker reports with update_task.
+// Artifact contents are kept by the repository (see Artifact); the result
+// only lists them.
+type TaskResult struct {
+	Summary      string         `json:"summary,omitempty"`
+	FilesChanged []string       `json:"files_changed,omitempty"`
+	Commits      []string       `json:"commits,omitempty"`
+	Tests        string         `json:"tests,omitempty"` // passed, failed, not_run
+	TestDetails  string         `json:"test_details,omitempty"`
+	FollowUps    []string       `json:"follow_ups,omitempty"` // suggested follow-up work
+	Artifacts    []ArtifactInfo `json:"artifacts,omitempty"`
+	ReportedBy   string         `json:"reported_by"`
+	ReportedAt   time.Time      `json:"reported_at"`
+}
+
+// Verification is the outcome of running a task's verification commands
+// when it was marked completed. Commands run in order and stop at the first
+// failure.
+type Verification struct {
+	Passed bool                `json:"passed"`
+	Dir    string              `json:"dir"` // where the commands ran
+	Checks []VerificationCheck `json:"checks"`
+	RanBy  string              `json:"ran_by"` // agent that completed the task
+	RanAt  time.Time           `json:"ran_at"`
+}
+
+// VerificationCheck is one verification command and how it ended.
+type VerificationCheck struct {
+	Command    string `json:"command"`
+	ExitCode   int    `json:"exit_code"` // -1 if it could not be started or timed out
+	TimedOut   bool   `json:"timed_out,omitempty"`
+	Output     string `json:"output,omitempty"` // tail of stdout and stderr
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ArtifactInfo describes an artifact attached to a task result.
+type ArtifactInfo struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+// Artifact is a named file (a patch, a report) attached to a task result.
+type Artifact struct {
+	TaskID      int       `json:"task_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Content     []byte    `json:"content"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // Presence is an agent's current status.
 type Presence struct {
 	Agent         string    `json:"agent"`
@@ -91,6 +172,7 @@ type SessionNote struct {
 	Content   string    `json:"content"`
 	Category  string    `json:"category"` // decision, note, question, blocker
 	Timestamp time.Time `json:"timestamp"`
+	Project   string    `json:"project,omitempty"`
 }
 
 // PlanItem is a single item in a shared plan.
@@ -122,6 +204,7 @@ type Plan struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	Status    string     `json:"status"` // active, completed, archived
+	Project   string     `json:"project,omitempty"`
 }
 
 // AgentContext tracks what an agent has seen for notifications.
@@ -139,6 +222,7 @@ type FileLock struct {
 	Reason    string    `json:"reason"`
 	LockedAt  time.Time `json:"locked_at"`
 	ExpiresAt time.Time `json:"expires_at"`
+	Project   string    `json:"project,omitempty"`
 }
 
 // RegisteredAgent is an agent that has registered with the system.
@@ -152,6 +236,41 @@ type RegisteredAgent struct {
 	LastSeen     time.Time `json:"last_seen"`
 }
 
+// Event types recorded in the journal.
+const (
+	EventTaskCreated           = "task_created"
+	EventTaskStatusChanged     = "task_status_changed"
+	EventTaskAssigned          = "task_assigned"
+	EventTaskRemoved           = "task_removed"
+	EventTaskArchived          = "task_archived"
+	EventMessageSent           = "message_sent"
+	EventLockAcquired          = "lock_acquired"
+	EventLockReleased          = "lock_released"
+	EventNoteAdded             = "note_added"
+	EventPlanCreated           = "plan_created"
+	EventPlanItemStatusChanged = "plan_item_status_changed"
+	EventPresenceChanged       = "presence_changed"
+	EventInstanceStatusChanged = "instance_status_changed"
+	EventWorkerSpawned         = "worker_spawned"
+	EventWorkerExited          = "worker_exited"
+	EventWatchdogRecovered     = "watchdog_recovered"
+	EventToolDenied            = "tool_denied"
+	EventTaskVerified          = "task_verified"
+)
+
+// Event is one entry in the append-only journal of state changes.
+type Event struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	Actor     string            `json:"actor,omitempty"`   // who caused it, when known
+	Target    string            `json:"target,omitempty"`  // agent affected (recipient, assignee, instance)
+	TaskID    int               `json:"task_id,omitempty"` // related task, if any
+	Ref       string            `json:"ref,omitempty"`     // other subject: lock path, plan ID, plan item
+	Project   string            `json:"project,omitempty"`
+	Data      map[string]string `json:"data,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
 // ProjectInfo holds information about the current project/workspace.
 type ProjectInfo struct {
 	Name        string    `json:"name"`
@@ -162,6 +281,14 @@ type ProjectInfo struct {
 	LastUpdated time.Time `json:"last_updated"`
 }
 
+// ArchivedTask is a finished task moved out of the live state by retention,
+// together with its work context.
+type ArchivedTask struct {
+	Task        Task         `json:"task"`
+	WorkContext *WorkContext `json:"work_context,omitempty"`
+	ArchivedAt  time.Time    `json:"archived_at"`
+}
+
 // CollabState is the aggregate collaboration state.
 type CollabState struct {
 	Messages         []Message                   `json:"messages"`
@@ -180,6 +307,23 @@ type CollabState struct {
 	AgentInstances   map[string]*AgentInstance   `json:"agent_instances"`
 	WorkContexts     map[string]*WorkContext     `json:"work_contexts"`
 	DriverID         string                      `json:"driver_id"`
+
+	// PendingEvents are journal entries produced by the current mutation.
+	// Repositories that keep a journal append them on Save; they are never loaded.
+	PendingEvents []Event `json:"-"`
+
+	// PendingArchive holds tasks removed from Tasks by the current mutation to
+	// be archived. Repositories that keep an archive store them on Save.
+	PendingArchive []ArchivedTask `json:"-"`
+
+	// PendingArtifacts are task result artifacts attached by the current
+	// mutation. Repositories that keep artifacts store them on Save.
+	PendingArtifacts []Artifact `json:"-"`
+
+	// ArchivedDependencies holds the status of archived tasks that live tasks
+	// depend on, keyed by task ID. Repositories that keep an archive fill it on
+	// Load; ArchiveTasks adds the tasks it archives. It is never saved.
+	ArchivedDependencies map[int]string `json:"-"`
 }
 
 // NewCollabState returns an empty CollabState with maps and IDs initialized.
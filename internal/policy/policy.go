@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -25,10 +26,20 @@ func GlobalStateFile() string {
 	return filepath.Join(GlobalStateDir(), "state.sqlite")
 }
 
+// State backends selectable with the state_backend config key.
+const (
+	StateBackendSQLite = "sqlite" // default: shared SQLite file, safe across server processes
+	StateBackendMemory = "memory" // ephemeral, lost when the server exits
+	StateBackendJSON   = "json"   // human-readable JSON file, for a single server process
+)
+
 // WorkerConfig configures a worker type in the driver/worker orchestration model.
 type WorkerConfig struct {
 	Type               string   `yaml:"type"`                 // e.g. "claude-code", "codex"
 	Instances          int      `yaml:"instances"`            // max concurrent instances (default 1)
+	MinInstances       int      `yaml:"min_instances"`        // autoscaling: instances started on any work
+	MaxInstances       int      `yaml:"max_instances"`        // autoscaling: upper bound, enables autoscaling when set
+	IdleTimeoutSeconds int      `yaml:"idle_timeout_seconds"` // autoscaling: stop surplus idle instances (default 300)
 	Command            []string `yaml:"command"`              // spawn command
 	Capabilities       []string `yaml:"capabilities"`         // e.g. ["code-edit", "code-review"]
 	MaxConcurrentTasks int      `yaml:"max_concurrent_tasks"` // per instance (default 1)
@@ -36,6 +47,12 @@ type WorkerConfig struct {
 	TimeoutSeconds     int      `yaml:"timeout_seconds"`
 	RetryDelaySeconds  int      `yaml:"retry_delay_seconds"`
 	MaxRetries         int      `yaml:"max_retries"`
+	// TaskBound launches one process per task: a pending task is claimed for
+	// the instance before launch and the command can use {task_id},
+	// {task_title}, {task_description}, {relevant_files}, {constraints} and
+	// {background}. The text placeholders are filled in unescaped, so
+	// LoadConfig rejects them inside a shell script (sh -c '...').
+	TaskBound bool `yaml:"task_bound"`
 	// Env sets additional environment variables for the spawned worker process.
 	// Values can reference parent env vars with ${VAR} syntax (e.g. "home_dir: ${HOME}").
 	// These are merged on top of the inherited environment.
@@ -45,16 +62,59 @@ type WorkerConfig struct {
 	// ensure specific vars are passed (e.g. ["GH_*", "GITHUB_*", "SSH_AUTH_SOCK",
 	// "DOCKER_HOST"]). If set to ["none"], no env vars are inherited (clean environment).
 	InheritEnv []string `yaml:"inherit_env"`
+	// Limits bounds the resources of each spawned process (optional).
+	Limits *WorkerLimits `yaml:"limits"`
+}
+
+// WorkerLimits bounds a spawned worker process. On Linux, memory, CPU and
+// process limits use a cgroup v2 child of the server's cgroup when it can be
+// created (e.g. under a delegated systemd scope; the server first moves itself
+// into a leaf child, as cgroups with processes cannot enable controllers),
+// else rlimits; open files are always an rlimit. MaxOutputBytes is enforced by
+// the server on any platform. Zero means no limit.
+type WorkerLimits struct {
+	MaxMemoryMB    int     `yaml:"max_memory_mb"`    // cgroup memory.max, else RLIMIT_DATA
+	CPUShare       float64 `yaml:"cpu_share"`        // CPUs the worker may use, e.g. 1.5 (cgroup only)
+	MaxProcesses   int     `yaml:"max_processes"`    // cgroup pids.max, else RLIMIT_NPROC (counts all of the user's processes)
+	MaxOpenFiles   int     `yaml:"max_open_files"`   // RLIMIT_NOFILE
+	MaxOutputBytes int64   `yaml:"max_output_bytes"` // stdout+stderr; the worker is killed beyond this
 }
 
 // OrchestrationConfig holds driver/worker orchestration settings.
 type OrchestrationConfig struct {
-	Driver                   string          `yaml:"driver"` // agent type that is the driver, e.g. "cursor"
-	Workers                  []WorkerConfig  `yaml:"workers"`
-	AssignmentStrategy       string          `yaml:"assignment_strategy"` // least_loaded (default), capability_match, round_robin
-	HeartbeatIntervalSeconds int             `yaml:"heartbeat_interval_seconds"`
-	WorkerTimeoutSeconds     int             `yaml:"worker_timeout_seconds"`
-	Worktrees                *WorktreeConfig `yaml:"worktrees"` // optional git worktree isolation
+	Driver                   string            `yaml:"driver"` // agent type that is the driver, e.g. "cursor"
+	Workers                  []WorkerConfig    `yaml:"workers"`
+	AssignmentStrategy       string            `yaml:"assignment_strategy"` // least_loaded (default), capability_match, round_robin
+	HeartbeatIntervalSeconds int               `yaml:"heartbeat_interval_seconds"`
+	WorkerTimeoutSeconds     int               `yaml:"worker_timeout_seconds"`
+	Worktrees                *WorktreeConfig   `yaml:"worktrees"`   // optional git worktree isolation
+	MaxWorkers               int               `yaml:"max_workers"` // worker processes running at once, all types (0 = no limit)
+	RunHistory               *RunHistoryConfig `yaml:"run_history"` // optional bounds of the stored worker runs
+}
+
+// RunHistoryConfig bounds the worker runs (metadata and full output) kept in
+// the state directory for get_worker_log and the dashboard. The oldest runs
+// are dropped first when either bound is exceeded.
+type RunHistoryConfig struct {
+	MaxRuns    int `yaml:"max_runs"`     // runs kept (default 200)
+	MaxTotalMB int `yaml:"max_total_mb"` // output kept across all runs (default 100)
+}
+
+// InstanceCount returns how many instance IDs the worker type gets:
+// MaxInstances when autoscaling, else Instances (at least 1).
+func (w WorkerConfig) InstanceCount() int {
+	if w.MaxInstances > 0 {
+		return w.MaxInstances
+	}
+	return max(w.Instances, 1)
+}
+
+// Autoscaled reports whether the worker type scales with queue depth. Instances
+// beyond MinInstances then start only while pending tasks outnumber the free
+// task slots of running instances, and stop again after IdleTimeoutSeconds
+// without a task or output.
+func (w WorkerConfig) Autoscaled() bool {
+	return w.MaxInstances > 0
 }
 
 // MCPServerConfig describes an MCP server that should be auto-registered with
@@ -94,6 +154,123 @@ type DaemonConfig struct {
 	GracePeriodSecs int    `yaml:"grace_period_seconds"`
 }
 
+// AuthConfig controls agent authentication on the HTTP transport.
+type AuthConfig struct {
+	// RequireToken rejects MCP requests on the TCP port that carry no valid
+	// agent token. By default such clients may connect, but cannot act under
+	// the name of the driver or a configured worker.
+	RequireToken bool `yaml:"require_token"`
+}
+
+// Roles for tool authorization. The driver is the orchestration driver on its
+// own connection, workers are agents authenticated with a worker token, and
+// anonymous covers every client without credentials.
+const (
+	RoleDriver    = "driver"
+	RoleWorker    = "worker"
+	RoleAnonymous = "anonymous"
+)
+
+// ToolPermissions restricts which tools a role or agent may call.
+type ToolPermissions struct {
+	Allow         []string `yaml:"allow"`           // tool names or "*"; empty = all tools
+	Deny          []string `yaml:"deny"`            // tool names; wins over allow
+	OwnTasksOnly  bool     `yaml:"own_tasks_only"`  // update_task and create_subtasks only on tasks assigned to the caller (or claiming an 'any' task)
+	DenyAssignAny bool     `yaml:"deny_assign_any"` // create_task may not leave the task to 'any'
+}
+
+// Permits reports whether the tool may be called.
+func (t ToolPermissions) Permits(tool string) bool {
+	for _, d := range t.Deny {
+		if d == tool || d == "*" {
+			return false
+		}
+	}
+	if len(t.Allow) == 0 {
+		return true
+	}
+	for _, a := range t.Allow {
+		if a == tool || a == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationConfig assigns tool permissions per role, refined per agent.
+type AuthorizationConfig struct {
+	Roles  map[string]ToolPermissions `yaml:"roles"`  // keyed by RoleDriver, RoleWorker, RoleAnonymous
+	Agents map[string]ToolPermissions `yaml:"agents"` // keyed by agent instance ID or type
+}
+
+// DefaultAuthorization lets the driver call everything. Workers and anonymous
+// clients may not cancel agents or create, import or execute plans, may only
+// update their own tasks (or claim one left to 'any'), and must name an
+// assignee when creating a task.
+func DefaultAuthorization() *AuthorizationConfig {
+	restricted := ToolPermissions{
+		Deny:          []string{"cancel_agent", "create_plan", "import_plan", "execute_plan"},
+		OwnTasksOnly:  true,
+		DenyAssignAny: true,
+	}
+	return &AuthorizationConfig{
+		Roles: map[string]ToolPermissions{
+			RoleDriver:    {},
+			RoleWorker:    restricted,
+			RoleAnonymous: restricted,
+		},
+	}
+}
+
+// Permissions returns the permissions of role, refined by the entries of the
+// given agent names: an agent's allow list replaces the role's, deny lists add
+// up, and a restriction set on either applies.
+func (a *AuthorizationConfig) Permissions(role string, agents ...string) ToolPermissions {
+	if a == nil {
+		return ToolPermissions{}
+	}
+	p := a.Roles[role]
+	p.Deny = append([]string(nil), p.Deny...)
+	for _, name := range agents {
+		ap, ok := a.Agents[name]
+		if !ok {
+			continue
+		}
+		if len(ap.Allow) > 0 {
+			p.Allow = ap.Allow
+		}
+		p.Deny = append(p.Deny, ap.Deny...)
+		p.OwnTasksOnly = p.OwnTasksOnly || ap.OwnTasksOnly
+		p.DenyAssignAny = p.DenyAssignAny || ap.DenyAssignAny
+	}
+	return p
+}
+
+// TaskTemplate is a reusable task definition for create_task template='...'.
+// Title, Description, Constraints and Acceptance may reference variables as
+// {name}; create_task fills them from its vars, falling back to Vars.
+type TaskTemplate struct {
+	Title               string            `yaml:"title"` // title pattern, e.g. "Add tests for {package}"
+	Description         string            `yaml:"description"`
+	Vars                map[string]string `yaml:"vars"` // default values; variables without one are required
+	Capabilities        []string          `yaml:"capabilities"`
+	WorkerType          string            `yaml:"worker_type"`
+	Constraints         []string          `yaml:"constraints"`
+	ExpectedDurationSec int               `yaml:"expected_duration_seconds"`
+	Acceptance          []string          `yaml:"acceptance"` // acceptance criteria, appended to the description
+	Priority            int               `yaml:"priority"`   // 1-4; 0 = normal
+	Verify              []string          `yaml:"verify"`     // verification commands, e.g. "go test ./{package}/..."
+}
+
+// VerificationConfig sets the verification gate for completing tasks. The
+// commands run in the completing worker's workspace (its worktree, if any)
+// when a task without verification commands of its own is marked completed;
+// the task stays in_progress unless all of them succeed.
+type VerificationConfig struct {
+	Commands       []string `yaml:"commands"`        // shell commands, e.g. "go test ./..."; empty = no gate
+	TimeoutSeconds int      `yaml:"timeout_seconds"` // per command (default 600)
+}
+
 // FeaturesConfig groups optional feature flags.
 type FeaturesConfig struct {
 	Knowledge *KnowledgeConfig `yaml:"knowledge"`
@@ -104,10 +281,12 @@ type Config struct {
 	WorkspaceRoot string   `yaml:"workspace_root"`
 	EnabledTools  []string `yaml:"enabled_tools"`
 	StateFile     string   `yaml:"state_file"`
+	StateBackend  string   `yaml:"state_backend"` // sqlite (default), memory or json
 	LogFile       string   `yaml:"log_file"`
 
 	MessageRetentionMax  int `yaml:"message_retention_max"`
 	MessageRetentionDays int `yaml:"message_retention_days"`
+	TaskRetentionDays    int `yaml:"task_retention_days"` // archive finished tasks after this many days; 0 = never
 	PresenceTTLSeconds   int `yaml:"presence_ttl_seconds"`
 
 	HTTPPort      int                        `yaml:"http_port"`
@@ -115,6 +294,14 @@ type Config struct {
 	MCPServers    map[string]MCPServerConfig `yaml:"mcp_servers"`
 	Features      *FeaturesConfig            `yaml:"features"`
 	Daemon        *DaemonConfig              `yaml:"daemon"`
+	Auth          *AuthConfig                `yaml:"auth"`
+	Authorization *AuthorizationConfig       `yaml:"authorization"`
+	TaskTemplates map[string]TaskTemplate    `yaml:"task_templates"`
+	Verification  *VerificationConfig        `yaml:"verification"`
+
+	// PlanFile, if set, is a file in each plan's project (e.g. "PLAN.md" or
+	// "plans/{id}.md") rewritten as markdown whenever the plan changes.
+	PlanFile string `yaml:"plan_file"`
 }
 
 // DefaultConfig returns sensible defaults. Orchestration is always set (driver cursor, no workers).
@@ -127,6 +314,7 @@ func DefaultConfig() *Config {
 		PresenceTTLSeconds:   300,
 		StateFile:            "",
 		Orchestration:        DefaultOrchestration(),
+		Authorization:        DefaultAuthorization(),
 	}
 }
 
@@ -158,9 +346,140 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.Orchestration = DefaultOrchestration()
 	}
 
+	if o := cfg.Orchestration; o.MaxWorkers < 0 {
+		return nil, fmt.Errorf("orchestration.max_workers %d must not be negative", o.MaxWorkers)
+	}
+	if h := cfg.Orchestration.RunHistory; h != nil && (h.MaxRuns < 0 || h.MaxTotalMB < 0) {
+		return nil, fmt.Errorf("orchestration.run_history: limits must not be negative")
+	}
+	for _, w := range cfg.Orchestration.Workers {
+		if w.MinInstances < 0 || w.MaxInstances < 0 || w.IdleTimeoutSeconds < 0 {
+			return nil, fmt.Errorf("orchestration.workers %s: min_instances, max_instances and idle_timeout_seconds must not be negative", w.Type)
+		}
+		if w.MinInstances > 0 && w.MaxInstances == 0 {
+			return nil, fmt.Errorf("orchestration.workers %s: min_instances needs max_instances", w.Type)
+		}
+		if w.MinInstances > w.MaxInstances {
+			return nil, fmt.Errorf("orchestration.workers %s: min_instances %d is above max_instances %d", w.Type, w.MinInstances, w.MaxInstances)
+		}
+		if ph := shellScriptPlaceholder(w.Command); ph != "" {
+			return nil, fmt.Errorf("orchestration.workers %s: %s is inside a shell script, where task text would run as shell code; pass it to the script as an argument instead (sh -c '... \"$1\"' sh '%s')", w.Type, ph, ph)
+		}
+		if l := w.Limits; l != nil && (l.MaxMemoryMB < 0 || l.CPUShare < 0 || l.MaxProcesses < 0 || l.MaxOpenFiles < 0 || l.MaxOutputBytes < 0) {
+			return nil, fmt.Errorf("orchestration.workers %s: limits must not be negative", w.Type)
+		}
+	}
+
+	if a := cfg.Authorization; a != nil {
+		for role := range a.Roles {
+			switch role {
+			case RoleDriver, RoleWorker, RoleAnonymous:
+			default:
+				return nil, fmt.Errorf("authorization.roles: unknown role %q (want %s, %s or %s)", role, RoleDriver, RoleWorker, RoleAnonymous)
+			}
+		}
+	}
+
+	for name, tpl := range cfg.TaskTemplates {
+		if tpl.Title == "" {
+			return nil, fmt.Errorf("task_templates.%s: title is required", name)
+		}
+		if tpl.Priority < 0 || tpl.Priority > 4 {
+			return nil, fmt.Errorf("task_templates.%s: priority %d must be 1-4", name, tpl.Priority)
+		}
+		for _, c := range tpl.Verify {
+			if ph := quotedPlaceholder(c); ph != "" {
+				return nil, fmt.Errorf("task_templates.%s: verify: %s must not be quoted; variable values are shell-quoted when filled in", name, ph)
+			}
+		}
+	}
+
+	if v := cfg.Verification; v != nil && v.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("verification.timeout_seconds %d must not be negative", v.TimeoutSeconds)
+	}
+
+	switch cfg.StateBackend {
+	case "", StateBackendSQLite, StateBackendMemory, StateBackendJSON:
+	default:
+		return nil, fmt.Errorf("state_backend %q: must be %s, %s or %s", cfg.StateBackend, StateBackendSQLite, StateBackendMemory, StateBackendJSON)
+	}
+
 	return cfg, nil
 }
 
+// templatePlaceholder matches a {name} variable in a task template.
+var templatePlaceholder = regexp.MustCompile(`^\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// quotedPlaceholder returns the first template variable inside single or
+// double quotes (or escaped) in the shell command c, or "".
+func quotedPlaceholder(c string) string {
+	var quote byte
+	for i := 0; i < len(c); i++ {
+		switch ch := c[i]; {
+		case ch == '\\' && quote != '\'':
+			if ph := templatePlaceholder.FindString(c[i+1:]); ph != "" {
+				return ph
+			}
+			i++
+		case quote == 0 && (ch == '\'' || ch == '"'):
+			quote = ch
+		case quote != 0 && ch == quote:
+			quote = 0
+		case ch == '{' && quote != 0:
+			if ph := templatePlaceholder.FindString(c[i:]); ph != "" {
+				return ph
+			}
+		}
+	}
+	return ""
+}
+
+// taskTextPlaceholders are the worker command placeholders filled with text
+// that agents write (task title, description, work context).
+var taskTextPlaceholders = []string{"{task_title}", "{task_description}", "{relevant_files}", "{constraints}", "{background}"}
+
+// shellScriptPlaceholder returns the first task text placeholder inside the
+// script of a command that runs one (sh -c SCRIPT, cmd /c, powershell
+// -Command), or "". Placeholders in the script's own arguments ($1, ...) are
+// safe: the shell does not parse those.
+func shellScriptPlaceholder(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	exe := strings.TrimSuffix(strings.ToLower(filepath.Base(command[0])), ".exe")
+	var script []string
+	for i, a := range command[1:] {
+		rest := command[i+2:]
+		switch exe {
+		case "sh", "bash", "zsh", "dash", "ksh", "mksh", "ash", "fish":
+			if len(a) > 1 && a[0] == '-' && a[1] != '-' && strings.Contains(a, "c") && len(rest) > 0 {
+				script = rest[:1]
+			}
+		case "cmd":
+			if strings.EqualFold(a, "/c") || strings.EqualFold(a, "/k") {
+				script = rest
+			}
+		case "powershell", "pwsh":
+			if strings.EqualFold(a, "-c") || strings.EqualFold(a, "-command") {
+				script = rest
+			}
+		default:
+			return ""
+		}
+		if script != nil {
+			break
+		}
+	}
+	for _, s := range script {
+		for _, ph := range taskTextPlaceholders {
+			if strings.Contains(s, ph) {
+				return ph
+			}
+		}
+	}
+	return ""
+}
+
 // Policy enforces security rules
 type Policy struct {
 	config *Config
@@ -189,9 +508,18 @@ func (p *Policy) SetWorkspaceRoot(root string) {
 	p.config.WorkspaceRoot = root
 }
 
+// StateBackend returns the configured state backend (StateBackendSQLite if unset).
+func (p *Policy) StateBackend() string {
+	if p.config.StateBackend == "" {
+		return StateBackendSQLite
+	}
+	return p.config.StateBackend
+}
+
 // StateFile returns the configured state file path.
-// If unset, defaults to the global state file (~/.config/stringwork/state.sqlite)
-// so that all agents on the machine share the same state regardless of working directory.
+// If unset, defaults to the global state file (~/.config/stringwork/state.sqlite,
+// or state.json for the json backend) so that all agents on the machine share the
+// same state regardless of working directory.
 func (p *Policy) StateFile() string {
 	p.mu.RLock()
 	sf := p.config.StateFile
@@ -199,6 +527,9 @@ func (p *Policy) StateFile() string {
 	p.mu.RUnlock()
 
 	if sf == "" {
+		if p.StateBackend() == StateBackendJSON {
+			return filepath.Join(GlobalStateDir(), "state.json")
+		}
 		return GlobalStateFile()
 	}
 	if filepath.IsAbs(sf) {
@@ -277,6 +608,12 @@ func (p *Policy) MessageRetentionDays() int {
 	return p.config.MessageRetentionDays
 }
 
+// TaskRetentionDays returns how long completed and cancelled tasks stay in
+// the live state before they are archived (0 = never).
+func (p *Policy) TaskRetentionDays() int {
+	return p.config.TaskRetentionDays
+}
+
 // PresenceTTLSeconds returns the presence TTL in seconds
 func (p *Policy) PresenceTTLSeconds() int {
 	return p.config.PresenceTTLSeconds
@@ -346,3 +683,33 @@ func (p *Policy) DaemonGracePeriodSeconds() int {
 	}
 	return 10
 }
+
+// RequireAgentToken reports whether MCP requests on the TCP port must carry a
+// valid agent token.
+func (p *Policy) RequireAgentToken() bool {
+	return p.config.Auth != nil && p.config.Auth.RequireToken
+}
+
+// ToolPermissions returns the tool permissions of a caller with the given role
+// and agent names (see AuthorizationConfig.Permissions).
+func (p *Policy) ToolPermissions(role string, agents ...string) ToolPermissions {
+	return p.config.Authorization.Permissions(role, agents...)
+}
+
+// TaskTemplates returns the configured task templates keyed by name.
+func (p *Policy) TaskTemplates() map[string]TaskTemplate {
+	return p.config.TaskTemplates
+}
+
+// PlanFile returns the plan_file pattern; empty disables plan file sync.
+func (p *Policy) PlanFile() string {
+	return p.config.PlanFile
+}
+
+// Verification returns the verification gate configuration (never nil).
+func (p *Policy) Verification() VerificationConfig {
+	if p.config.Verification == nil {
+		return VerificationConfig{}
+	}
+	return *p.config.Verification
+}
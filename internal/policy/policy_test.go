@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -18,6 +19,10 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("expected message retention days 30, got %d", cfg.MessageRetentionDays)
 	}
 
+	if cfg.TaskRetentionDays != 0 {
+		t.Errorf("expected task archival off by default, got %d days", cfg.TaskRetentionDays)
+	}
+
 	if cfg.PresenceTTLSeconds != 300 {
 		t.Errorf("expected presence TTL 300s, got %d", cfg.PresenceTTLSeconds)
 	}
@@ -186,6 +191,37 @@ state_file: state/custom.sqlite
 	}
 }
 
+func TestStateBackend(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	if err := os.WriteFile(configPath, []byte("state_backend: json\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	pol := New(cfg)
+	if pol.StateBackend() != StateBackendJSON {
+		t.Errorf("expected json backend, got %q", pol.StateBackend())
+	}
+	if want := filepath.Join(GlobalStateDir(), "state.json"); pol.StateFile() != want {
+		t.Errorf("expected default state file %s, got %s", want, pol.StateFile())
+	}
+
+	if New(DefaultConfig()).StateBackend() != StateBackendSQLite {
+		t.Error("expected sqlite as the default backend")
+	}
+
+	if err := os.WriteFile(configPath, []byte("state_backend: postgres\n"), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("expected error for unknown state_backend")
+	}
+}
+
 func TestMCPServers_URLBased(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
@@ -400,3 +436,229 @@ mcp_servers: {}
 		t.Errorf("expected empty MCPServers map, got %v", servers)
 	}
 }
+
+func TestToolPermissions(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `
+authorization:
+  roles:
+    worker:
+      deny: [cancel_agent]
+  agents:
+    codex:
+      allow: [send_message, read_messages, update_task]
+      deny: [read_messages]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	p := New(cfg)
+
+	driver := p.ToolPermissions(RoleDriver, "cursor")
+	if !driver.Permits("cancel_agent") || driver.OwnTasksOnly || driver.DenyAssignAny {
+		t.Errorf("driver = %+v, want unrestricted", driver)
+	}
+
+	// Overriding the worker role replaces the default restrictions.
+	worker := p.ToolPermissions(RoleWorker, "claude-code-1", "claude-code")
+	if worker.Permits("cancel_agent") || !worker.Permits("create_plan") || worker.OwnTasksOnly {
+		t.Errorf("worker = %+v", worker)
+	}
+
+	codex := p.ToolPermissions(RoleWorker, "codex")
+	for tool, want := range map[string]bool{"send_message": true, "update_task": true, "read_messages": false, "cancel_agent": false, "create_task": false} {
+		if got := codex.Permits(tool); got != want {
+			t.Errorf("codex Permits(%s) = %v, want %v", tool, got, want)
+		}
+	}
+
+	// Anonymous keeps the default restrictions.
+	anon := p.ToolPermissions(RoleAnonymous)
+	if anon.Permits("cancel_agent") || !anon.OwnTasksOnly || !anon.DenyAssignAny {
+		t.Errorf("anonymous = %+v, want default restrictions", anon)
+	}
+
+	if err := os.WriteFile(configPath, []byte("authorization:\n  roles:\n    admin: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "unknown role") {
+		t.Errorf("LoadConfig with unknown role: err = %v", err)
+	}
+}
+
+func TestTaskTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `
+task_templates:
+  add-tests:
+    title: "Add tests for {package}"
+    description: "Cover {package} with table tests."
+    vars:
+      coverage: "80"
+    capabilities: [testing]
+    worker_type: claude-code
+    constraints: ["do not change {package} behavior"]
+    expected_duration_seconds: 900
+    acceptance: ["coverage of {package} >= {coverage}%"]
+    priority: 2
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	tpl, ok := New(cfg).TaskTemplates()["add-tests"]
+	if !ok {
+		t.Fatal("expected template add-tests")
+	}
+	if tpl.Title != "Add tests for {package}" || tpl.Vars["coverage"] != "80" || tpl.WorkerType != "claude-code" ||
+		tpl.ExpectedDurationSec != 900 || len(tpl.Acceptance) != 1 || tpl.Priority != 2 {
+		t.Errorf("template = %+v", tpl)
+	}
+
+	for _, bad := range []string{
+		"task_templates:\n  empty:\n    description: no title\n",
+		"task_templates:\n  urgent:\n    title: x\n    priority: 7\n",
+		"task_templates:\n  t:\n    title: x\n    verify: [\"go test './{package}/...'\"]\n",
+		"task_templates:\n  t:\n    title: x\n    verify: [\"echo \\\\{package}\"]\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "task_templates.") {
+			t.Errorf("LoadConfig(%q): err = %v", bad, err)
+		}
+	}
+}
+
+func TestVerificationConfig(t *testing.T) {
+	if v := New(DefaultConfig()).Verification(); len(v.Commands) != 0 {
+		t.Errorf("default verification = %+v, want no commands", v)
+	}
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := "verification:\n  commands: [\"go test ./...\", \"go vet ./...\"]\n  timeout_seconds: 120\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if v := New(cfg).Verification(); len(v.Commands) != 2 || v.TimeoutSeconds != 120 {
+		t.Errorf("verification = %+v", v)
+	}
+
+	if err := os.WriteFile(configPath, []byte("verification:\n  timeout_seconds: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "verification.timeout_seconds") {
+		t.Errorf("negative timeout: err = %v", err)
+	}
+}
+
+func TestWorkerAutoscalingConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `orchestration:
+  driver: cursor
+  max_workers: 3
+  run_history:
+    max_runs: 50
+  workers:
+    - type: claude-code
+      min_instances: 1
+      max_instances: 4
+      idle_timeout_seconds: 120
+    - type: codex
+      instances: 2
+      limits:
+        max_memory_mb: 2048
+        cpu_share: 1.5
+        max_output_bytes: 1048576
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	orch := cfg.Orchestration
+	if orch.MaxWorkers != 3 {
+		t.Errorf("max_workers = %d, want 3", orch.MaxWorkers)
+	}
+	if h := orch.RunHistory; h == nil || h.MaxRuns != 50 || h.MaxTotalMB != 0 {
+		t.Errorf("run_history = %+v", h)
+	}
+	if w := orch.Workers[0]; !w.Autoscaled() || w.InstanceCount() != 4 || w.IdleTimeoutSeconds != 120 {
+		t.Errorf("claude-code = %+v", w)
+	}
+	if w := orch.Workers[1]; w.Autoscaled() || w.InstanceCount() != 2 || w.Limits == nil || w.Limits.MaxMemoryMB != 2048 || w.Limits.CPUShare != 1.5 {
+		t.Errorf("codex = %+v", w)
+	}
+
+	for _, bad := range []string{
+		"orchestration:\n  workers:\n    - type: x\n      min_instances: 3\n      max_instances: 2\n",
+		"orchestration:\n  workers:\n    - type: x\n      min_instances: 1\n",
+		"orchestration:\n  max_workers: -1\n",
+		"orchestration:\n  run_history:\n    max_total_mb: -1\n",
+		"orchestration:\n  workers:\n    - type: x\n      limits:\n        max_memory_mb: -5\n",
+		"orchestration:\n  workers:\n    - type: x\n      task_bound: true\n      command: [sh, -c, \"claude -p '{task_title}'\"]\n",
+	} {
+		if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(configPath); err == nil {
+			t.Errorf("LoadConfig(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestShellScriptPlaceholder(t *testing.T) {
+	tests := []struct {
+		command []string
+		want    string
+	}{
+		{[]string{"claude", "-p", "Work on {task_title}: {task_description}"}, ""},
+		{[]string{"sh", "-c", "claude -p '{task_description}'"}, "{task_description}"},
+		{[]string{"/bin/bash", "--login", "-ec", "run {background}"}, "{background}"},
+		{[]string{"sh", "-c", "claude -p \"$1\"", "sh", "{task_title}"}, ""},
+		{[]string{"sh", "-c", "echo task {task_id}"}, ""},
+		{[]string{"cmd.exe", "/C", "claude", "-p", "{task_title}"}, "{task_title}"},
+		{[]string{"pwsh", "-Command", "claude -p '{constraints}'"}, "{constraints}"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := shellScriptPlaceholder(tt.command); got != tt.want {
+			t.Errorf("shellScriptPlaceholder(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestQuotedPlaceholder(t *testing.T) {
+	tests := []struct {
+		command, want string
+	}{
+		{"go test ./{package}/...", ""},
+		{`go test "./{package}/..."`, "{package}"},
+		{"go test './{package}'", "{package}"},
+		{`echo \{package}`, "{package}"},
+		{`echo "a" {b} 'c'`, ""},
+		{`echo "{}" {b}`, ""},
+	}
+	for _, tt := range tests {
+		if got := quotedPlaceholder(tt.command); got != tt.want {
+			t.Errorf("quotedPlaceholder(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/repository/sqlite"
+)
+
+// backupTimeFormat sorts chronologically as text.
+const backupTimeFormat = "20060102-150405"
+
+// BackupFiles snapshots each SQLite database in paths into dir as
+// <name>-<timestamp><ext> (e.g. state-20260102-150405.sqlite) and then deletes
+// all but the newest keep snapshots of that database. Databases that do not
+// exist yet are skipped. It returns the paths of the new snapshots.
+func BackupFiles(dir string, keep int, now time.Time, paths ...string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create backup dir: %w", err)
+	}
+	var written []string
+	for _, src := range paths {
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		ext := filepath.Ext(src)
+		stem := strings.TrimSuffix(filepath.Base(src), ext)
+		dst := filepath.Join(dir, stem+"-"+now.Format(backupTimeFormat)+ext)
+		if err := sqlite.Backup(src, dst); err != nil {
+			return written, err
+		}
+		written = append(written, dst)
+		if err := rotateBackups(dir, stem, ext, keep); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// rotateBackups removes all but the newest keep snapshots named
+// <stem>-<timestamp><ext> in dir. keep < 1 keeps everything.
+func rotateBackups(dir, stem, ext string, keep int) error {
+	if keep < 1 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var snapshots []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, stem+"-") || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, stem+"-"), ext)
+		if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+			continue
+		}
+		snapshots = append(snapshots, name)
+	}
+	sort.Strings(snapshots)
+	for len(snapshots) > keep {
+		if err := os.Remove(filepath.Join(dir, snapshots[0])); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
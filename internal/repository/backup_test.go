@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestBackupFiles_SnapshotsAndRotates(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.sqlite")
+	repo, err := NewStateRepository(policy.StateBackendSQLite, statePath)
+	if err != nil {
+		t.Fatalf("NewStateRepository: %v", err)
+	}
+	defer repo.(interface{ Close() error }).Close()
+	state := domain.NewCollabState()
+	state.Tasks = append(state.Tasks, domain.Task{ID: 1, Title: "Keep me", Status: "pending", CreatedAt: time.Now(), UpdatedAt: time.Now()})
+	state.NextTaskID = 2
+	if err := repo.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	backups := filepath.Join(dir, "backups")
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	var last []string
+	for i := 0; i < 4; i++ {
+		// knowledge.db does not exist and is skipped.
+		last, err = BackupFiles(backups, 2, start.Add(time.Duration(i)*time.Minute), statePath, filepath.Join(dir, "knowledge.db"))
+		if err != nil {
+			t.Fatalf("BackupFiles: %v", err)
+		}
+	}
+	if len(last) != 1 || filepath.Base(last[0]) != "state-20260102-150705.sqlite" {
+		t.Fatalf("written = %v", last)
+	}
+
+	entries, err := os.ReadDir(backups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != "state-20260102-150605.sqlite" || names[1] != "state-20260102-150705.sqlite" {
+		t.Errorf("after rotation = %v, want the two newest snapshots", names)
+	}
+
+	snap, err := NewStateRepository(policy.StateBackendSQLite, last[0])
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer snap.(interface{ Close() error }).Close()
+	got, err := snap.Load()
+	if err != nil {
+		t.Fatalf("Load snapshot: %v", err)
+	}
+	if len(got.Tasks) != 1 || got.Tasks[0].Title != "Keep me" {
+		t.Errorf("snapshot tasks = %+v", got.Tasks)
+	}
+}
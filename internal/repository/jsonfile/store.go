@@ -0,0 +1,198 @@
+// Package jsonfile implements a StateRepository that keeps the whole state,
+// its event journal, its task archive and result artifacts in one indented
+// JSON file. It is
+// meant for debugging (the file can be read and edited by hand) and for a
+// single server process: writes are atomic, but two processes sharing the
+// file can still overwrite each other's changes. Use the sqlite backend for
+// shared state.
+package jsonfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+var (
+	_ app.StateRepository = (*Store)(nil)
+	_ app.AtomicUpdater   = (*Store)(nil)
+	_ app.EventJournal    = (*Store)(nil)
+	_ app.TaskArchive     = (*Store)(nil)
+	_ app.ArtifactStore   = (*Store)(nil)
+)
+
+// fileFormat is the version of the file layout.
+const fileFormat = 1
+
+// file is the on-disk layout.
+type file struct {
+	Format    int                   `json:"format"`
+	State     *domain.CollabState   `json:"state"`
+	Events    []domain.Event        `json:"events"`
+	Archive   []domain.ArchivedTask `json:"archive,omitempty"`
+	Artifacts []domain.Artifact     `json:"artifacts,omitempty"`
+}
+
+// Store implements app.StateRepository on a JSON file.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New returns a Store for the JSON file at path, creating parent directories.
+// The file itself is created on the first Save.
+func New(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create state dir: %w", err)
+	}
+	s := &Store{path: path}
+	if _, err := s.read(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load implements app.StateRepository.
+func (s *Store) Load() (*domain.CollabState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return f.State, nil
+}
+
+// Save implements app.StateRepository.
+func (s *Store) Save(state *domain.CollabState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	return s.write(f, state)
+}
+
+// Update implements app.AtomicUpdater within this process.
+func (s *Store) Update(fn func(*domain.CollabState) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(f.State); err != nil {
+		return err
+	}
+	return s.write(f, f.State)
+}
+
+// Events implements app.EventJournal.
+func (s *Store) Events(filter app.EventFilter) ([]domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return app.FilterEvents(f.Events, filter), nil
+}
+
+// ArchivedTasks implements app.TaskArchive.
+func (s *Store) ArchivedTasks(filter app.ArchiveFilter) ([]domain.ArchivedTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return app.FilterArchived(f.Archive, filter), nil
+}
+
+// Artifact implements app.ArtifactStore.
+func (s *Store) Artifact(taskID int, name string) (*domain.Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range f.Artifacts {
+		if a := &f.Artifacts[i]; a.TaskID == taskID && a.Name == name {
+			return a, nil
+		}
+	}
+	return nil, app.ErrArtifactNotFound
+}
+
+// read parses the file; a missing file is an empty state.
+func (s *Store) read() (*file, error) {
+	f := &file{Format: fileFormat, State: domain.NewCollabState()}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	if f.Format > fileFormat {
+		return nil, fmt.Errorf("%s has format %d, newer than this build supports (%d)", s.path, f.Format, fileFormat)
+	}
+	if f.State == nil {
+		f.State = domain.NewCollabState()
+	}
+	app.EnsureStateMaps(f.State)
+	if missing := app.MissingDependencies(f.State); len(missing) > 0 {
+		f.State.ArchivedDependencies = make(map[int]string)
+		for _, a := range f.Archive {
+			if slices.Contains(missing, a.Task.ID) {
+				f.State.ArchivedDependencies[a.Task.ID] = a.Task.Status
+			}
+		}
+	}
+	return f, nil
+}
+
+// write stores state and appends its pending events, archived tasks and
+// artifacts to f, replacing the file atomically. An artifact replaces one
+// with the same task and name.
+func (s *Store) write(f *file, state *domain.CollabState) error {
+	next := int64(1)
+	if n := len(f.Events); n > 0 {
+		next = f.Events[n-1].ID + 1
+	}
+	for _, e := range state.PendingEvents {
+		e.ID = next
+		next++
+		f.Events = append(f.Events, e)
+	}
+	f.Archive = append(f.Archive, state.PendingArchive...)
+	for _, a := range state.PendingArtifacts {
+		f.Artifacts = slices.DeleteFunc(f.Artifacts, func(old domain.Artifact) bool {
+			return old.TaskID == a.TaskID && old.Name == a.Name
+		})
+		f.Artifacts = append(f.Artifacts, a)
+	}
+	f.Format = fileFormat
+	f.State = state
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,80 @@
+package jsonfile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) app.StateRepository {
+		store, err := New(filepath.Join(t.TempDir(), "state.json"))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return store
+	})
+}
+
+func TestStore_PersistsReadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	store, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	state := domain.NewCollabState()
+	state.Tasks = append(state.Tasks, domain.Task{ID: 1, Title: "Readable", Status: "pending", CreatedAt: time.Now(), UpdatedAt: time.Now()})
+	state.NextTaskID = 2
+	state.PendingEvents = []domain.Event{{Type: domain.EventTaskCreated, TaskID: 1, Timestamp: time.Now()}}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var raw struct {
+		Format int `json:"format"`
+		State  struct {
+			Tasks []struct {
+				Title string `json:"title"`
+			} `json:"tasks"`
+		} `json:"state"`
+		Events []json.RawMessage `json:"events"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("state file is not JSON: %v", err)
+	}
+	if raw.Format != fileFormat || len(raw.State.Tasks) != 1 || raw.State.Tasks[0].Title != "Readable" || len(raw.Events) != 1 {
+		t.Errorf("unexpected file contents:\n%s", data)
+	}
+
+	reopened, err := New(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got.Tasks) != 1 || got.NextTaskID != 2 {
+		t.Errorf("reopened state: %d tasks, next_task_id %d", len(got.Tasks), got.NextTaskID)
+	}
+}
+
+func TestNew_RejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path); err == nil {
+		t.Error("expected error for a corrupt state file")
+	}
+}
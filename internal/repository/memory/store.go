@@ -0,0 +1,142 @@
+// Package memory implements an in-memory StateRepository for ephemeral
+// sessions and tests. Nothing is written to disk; the state is lost when the
+// process exits.
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+var (
+	_ app.StateRepository = (*Store)(nil)
+	_ app.AtomicUpdater   = (*Store)(nil)
+	_ app.EventJournal    = (*Store)(nil)
+	_ app.TaskArchive     = (*Store)(nil)
+	_ app.ArtifactStore   = (*Store)(nil)
+)
+
+// Store implements app.StateRepository in memory. Load and Save copy the
+// state, so callers can never change the stored state without saving it.
+type Store struct {
+	mu        sync.Mutex
+	state     []byte // JSON of the saved CollabState; nil until the first Save
+	events    []domain.Event
+	archived  map[int]domain.ArchivedTask
+	artifacts map[artifactKey]domain.Artifact
+}
+
+type artifactKey struct {
+	taskID int
+	name   string
+}
+
+// New returns an empty in-memory store.
+func New() *Store {
+	return &Store{
+		archived:  make(map[int]domain.ArchivedTask),
+		artifacts: make(map[artifactKey]domain.Artifact),
+	}
+}
+
+// Load implements app.StateRepository.
+func (s *Store) Load() (*domain.CollabState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Save implements app.StateRepository.
+func (s *Store) Save(state *domain.CollabState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(state)
+}
+
+// Update implements app.AtomicUpdater.
+func (s *Store) Update(fn func(*domain.CollabState) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	return s.save(state)
+}
+
+// Events implements app.EventJournal.
+func (s *Store) Events(f app.EventFilter) ([]domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return app.FilterEvents(s.events, f), nil
+}
+
+// ArchivedTasks implements app.TaskArchive.
+func (s *Store) ArchivedTasks(f app.ArchiveFilter) ([]domain.ArchivedTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make([]domain.ArchivedTask, 0, len(s.archived))
+	for _, a := range s.archived {
+		all = append(all, a)
+	}
+	return app.FilterArchived(all, f), nil
+}
+
+// Artifact implements app.ArtifactStore.
+func (s *Store) Artifact(taskID int, name string) (*domain.Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.artifacts[artifactKey{taskID, name}]
+	if !ok {
+		return nil, app.ErrArtifactNotFound
+	}
+	a.Content = append([]byte(nil), a.Content...)
+	return &a, nil
+}
+
+func (s *Store) load() (*domain.CollabState, error) {
+	state := domain.NewCollabState()
+	if s.state == nil {
+		return state, nil
+	}
+	if err := json.Unmarshal(s.state, state); err != nil {
+		return nil, fmt.Errorf("memory state: %w", err)
+	}
+	app.EnsureStateMaps(state)
+	for _, id := range app.MissingDependencies(state) {
+		if a, ok := s.archived[id]; ok {
+			if state.ArchivedDependencies == nil {
+				state.ArchivedDependencies = make(map[int]string)
+			}
+			state.ArchivedDependencies[id] = a.Task.Status
+		}
+	}
+	return state, nil
+}
+
+func (s *Store) save(state *domain.CollabState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("memory state: %w", err)
+	}
+	s.state = data
+	for _, e := range state.PendingEvents {
+		e.ID = int64(len(s.events) + 1)
+		s.events = append(s.events, e)
+	}
+	for _, a := range state.PendingArchive {
+		s.archived[a.Task.ID] = a
+	}
+	for _, a := range state.PendingArtifacts {
+		a.Content = append([]byte(nil), a.Content...)
+		s.artifacts[artifactKey{a.TaskID, a.Name}] = a
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/repository/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) app.StateRepository { return New() })
+}
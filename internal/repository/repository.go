@@ -1,12 +1,34 @@
 package repository
 
 import (
+	"fmt"
+
 	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/policy"
+	"github.com/jaakkos/stringwork/internal/repository/jsonfile"
+	"github.com/jaakkos/stringwork/internal/repository/memory"
 	"github.com/jaakkos/stringwork/internal/repository/sqlite"
 )
 
-// NewStateRepository returns a StateRepository backed by SQLite at the given path.
-// The path is typically from policy.StateFile() (default ~/.config/stringwork/state.sqlite).
-func NewStateRepository(path string) (app.StateRepository, error) {
-	return sqlite.New(path)
+// NewStateRepository returns the StateRepository for backend (see the
+// policy.StateBackend* constants; "" means SQLite). path is the state file,
+// typically from policy.StateFile() (default ~/.config/stringwork/state.sqlite),
+// and is ignored by the memory backend.
+func NewStateRepository(backend, path string) (app.StateRepository, error) {
+	switch backend {
+	case "", policy.StateBackendSQLite:
+		return sqlite.New(path)
+	case policy.StateBackendMemory:
+		return memory.New(), nil
+	case policy.StateBackendJSON:
+		return jsonfile.New(path)
+	default:
+		return nil, fmt.Errorf("unknown state backend %q", backend)
+	}
+}
+
+// MigrateStateFile brings the state database at path up to the current schema
+// version, backing it up first. With dryRun set it only reports what would run.
+func MigrateStateFile(path string, dryRun bool) (*sqlite.MigrationReport, error) {
+	return sqlite.Migrate(path, dryRun)
 }
@@ -0,0 +1,509 @@
+// Package repotest is a conformance suite for app.StateRepository
+// implementations. Each backend runs it from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) app.StateRepository { ... })
+//	}
+//
+// Optional capabilities (app.AtomicUpdater, app.EventJournal, app.TaskArchive,
+// app.ArtifactStore) are tested when the repository implements them.
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// Run runs the suite. open must return a new, empty repository on every call
+// and arrange for it to be closed (e.g. with t.Cleanup).
+func Run(t *testing.T, open func(t *testing.T) app.StateRepository) {
+	t.Run("EmptyLoad", func(t *testing.T) { testEmptyLoad(t, open(t)) })
+	t.Run("RoundTrip", func(t *testing.T) { testRoundTrip(t, open(t)) })
+	t.Run("LoadReturnsCopy", func(t *testing.T) { testLoadReturnsCopy(t, open(t)) })
+	t.Run("SaveRemovesDeleted", func(t *testing.T) { testSaveRemovesDeleted(t, open(t)) })
+	t.Run("AtomicUpdate", func(t *testing.T) {
+		u, ok := open(t).(app.AtomicUpdater)
+		if !ok {
+			t.Skip("repository does not implement app.AtomicUpdater")
+		}
+		testAtomicUpdate(t, u)
+	})
+	t.Run("Journal", func(t *testing.T) {
+		repo := open(t)
+		if _, ok := repo.(app.EventJournal); !ok {
+			t.Skip("repository does not implement app.EventJournal")
+		}
+		testJournal(t, repo)
+	})
+	t.Run("Archive", func(t *testing.T) {
+		repo := open(t)
+		if _, ok := repo.(app.TaskArchive); !ok {
+			t.Skip("repository does not implement app.TaskArchive")
+		}
+		testArchive(t, repo)
+	})
+	t.Run("Artifacts", func(t *testing.T) {
+		repo := open(t)
+		if _, ok := repo.(app.ArtifactStore); !ok {
+			t.Skip("repository does not implement app.ArtifactStore")
+		}
+		testArtifacts(t, repo)
+	})
+}
+
+// at returns a fixed UTC time offset by d, with sub-second precision so
+// backends that truncate timestamps are caught.
+func at(d time.Duration) time.Time {
+	return time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC).Add(d)
+}
+
+// sampleState returns a state with every persisted collection populated.
+func sampleState() *domain.CollabState {
+	s := domain.NewCollabState()
+	s.Messages = []domain.Message{
+		{ID: 1, From: "cursor", To: "claude-code", Content: "hello", Timestamp: at(0), Read: true},
+		{ID: 2, From: "claude-code", To: "all", Content: "hi all", Timestamp: at(time.Second), Project: "/work/alpha"},
+	}
+	s.NextMsgID = 3
+	s.Tasks = []domain.Task{
+		{ID: 1, Title: "Design", Description: "Sketch the API", Status: "completed", AssignedTo: "claude-code",
+			CreatedBy: "cursor", CreatedAt: at(0), UpdatedAt: at(time.Minute), Priority: 2, Dependencies: []int{},
+			ContextID: "ctx-1", ResultSummary: "done", Project: "/work/alpha", Schedule: "0 3 * * *", NotBefore: at(time.Hour),
+			Labels: []string{"api", "design"}, PlanID: "alpha", PlanItemID: "1", Verify: []string{"go test ./..."},
+			Verification: &domain.Verification{Passed: true, Dir: "/work/alpha", RanBy: "claude-code", RanAt: at(time.Minute),
+				Checks: []domain.VerificationCheck{{Command: "go test ./...", Output: "ok", DurationMs: 1200}}},
+			Result: &domain.TaskResult{Summary: "done", FilesChanged: []string{"api.go"}, Commits: []string{"abc123"},
+				Tests: "passed", TestDetails: "12 passed", FollowUps: []string{"document the API"},
+				Artifacts:  []domain.ArtifactInfo{{Name: "api.patch", ContentType: "text/x-diff; charset=utf-8", Size: 42}},
+				ReportedBy: "claude-code", ReportedAt: at(time.Minute)}},
+		{ID: 2, Title: "Build", Status: "in_progress", AssignedTo: "claude-code-1", CreatedBy: "cursor",
+			CreatedAt: at(time.Minute), UpdatedAt: at(2 * time.Minute), Priority: 3, Dependencies: []int{1},
+			BlockedBy: "", WorkerType: "claude-code", Capabilities: []string{"code-edit"}, ExpectedDurationSec: 600,
+			ProgressDescription: "halfway", ProgressPercent: 50, LastProgressAt: at(90 * time.Second), Project: "/work/alpha", ParentTaskID: 1, MaxAttempts: 3,
+			NotBefore: at(30 * time.Second), ScheduledFrom: 1,
+			Attempts: []domain.TaskAttempt{
+				{Number: 1, Agent: "claude-code-1", StartedAt: at(time.Minute), EndedAt: at(80 * time.Second), Outcome: "worker_failed",
+					ExitClass: "quota_exhausted", Error: "exit status 1", ProgressPercent: 10, LastProgress: "started", LogExcerpt: "quota exhausted"},
+				{Number: 2, Agent: "claude-code-1", StartedAt: at(2 * time.Minute)},
+			}},
+	}
+	s.NextTaskID = 3
+	s.NextNoteID = 2
+	s.SessionNotes = []domain.SessionNote{
+		{ID: 1, Author: "cursor", Content: "Use REST", Category: "decision", Timestamp: at(0), Project: "/work/alpha"},
+	}
+	s.Presence["cursor"] = &domain.Presence{Agent: "cursor", Status: "working", CurrentTaskID: 1, Note: "reviewing",
+		Workspace: "/work/alpha", LastSeen: at(time.Minute)}
+	s.Plans["alpha"] = &domain.Plan{ID: "alpha", Title: "Alpha", Goal: "Ship", Context: "ctx", CreatedBy: "cursor",
+		CreatedAt: at(0), UpdatedAt: at(time.Minute), Status: "active", Project: "/work/alpha",
+		Items: []domain.PlanItem{
+			{ID: "1", Title: "API", Description: "d", Reasoning: "r", Acceptance: []string{"tests pass"},
+				Constraints: []string{"no deps"}, Status: "completed", Owner: "claude-code", Dependencies: []string{},
+				Blockers: []string{}, Notes: []string{"note"}, Priority: 1, UpdatedBy: "claude-code", UpdatedAt: at(time.Minute)},
+			{ID: "2", Title: "UI", Status: "pending", Dependencies: []string{"1"}, Blockers: []string{}, Notes: []string{},
+				Priority: 2, UpdatedBy: "cursor", UpdatedAt: at(0)},
+		}}
+	s.ActivePlanID = "alpha"
+	s.AgentContexts["cursor"] = &domain.AgentContext{Agent: "cursor", LastCheckedMsgID: 2, LastCheckedTaskID: 1, LastCheckTime: at(time.Minute)}
+	s.FileLocks[app.FileLockKey("/work/alpha", "/work/alpha/main.go")] = &domain.FileLock{Path: "/work/alpha/main.go", LockedBy: "claude-code-1",
+		Reason: "editing", LockedAt: at(0), ExpiresAt: at(time.Hour), Project: "/work/alpha"}
+	// Locks are per project: another project holds the same path.
+	s.FileLocks[app.FileLockKey("/work/beta", "/work/alpha/main.go")] = &domain.FileLock{Path: "/work/alpha/main.go", LockedBy: "codex",
+		Reason: "vendoring", LockedAt: at(0), ExpiresAt: at(time.Hour), Project: "/work/beta"}
+	s.RegisteredAgents["helper"] = &domain.RegisteredAgent{Name: "helper", DisplayName: "Helper", Capabilities: []string{"review"},
+		Workspace: "/work/alpha", Project: "/work/alpha", RegisteredAt: at(0), LastSeen: at(time.Minute)}
+	s.AgentInstances["claude-code-1"] = &domain.AgentInstance{InstanceID: "claude-code-1", AgentType: "claude-code",
+		Role: domain.RoleWorker, Capabilities: []string{"code-edit"}, MaxTasks: 1, Status: "busy", CurrentTasks: []int{2},
+		Workspace: "/work/alpha", LastHeartbeat: at(time.Minute), Progress: "building", ProgressStep: 2,
+		ProgressTotalSteps: 4, ProgressUpdatedAt: at(time.Minute)}
+	s.WorkContexts["ctx-1"] = &domain.WorkContext{ID: "ctx-1", TaskID: 1, RelevantFiles: []string{"main.go"},
+		Background: "why", Constraints: []string{"keep it small"}, SharedNotes: map[string]string{"k": "v"}}
+	s.DriverID = "cursor"
+	return s
+}
+
+func load(t *testing.T, repo app.StateRepository) *domain.CollabState {
+	t.Helper()
+	state, err := repo.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return state
+}
+
+func save(t *testing.T, repo app.StateRepository, state *domain.CollabState) {
+	t.Helper()
+	if err := repo.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
+
+// compare reports every collection of got that differs from want.
+func compare(t *testing.T, got, want *domain.CollabState) {
+	t.Helper()
+	check := func(name string, g, w any) {
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("%s differ:\n got %+v\nwant %+v", name, g, w)
+		}
+	}
+	check("messages", got.Messages, want.Messages)
+	check("tasks", got.Tasks, want.Tasks)
+	check("session notes", got.SessionNotes, want.SessionNotes)
+	check("presence", got.Presence, want.Presence)
+	check("plans", got.Plans, want.Plans)
+	check("agent contexts", got.AgentContexts, want.AgentContexts)
+	check("file locks", got.FileLocks, want.FileLocks)
+	check("registered agents", got.RegisteredAgents, want.RegisteredAgents)
+	check("agent instances", got.AgentInstances, want.AgentInstances)
+	check("work contexts", got.WorkContexts, want.WorkContexts)
+	check("counters", [3]int{got.NextMsgID, got.NextTaskID, got.NextNoteID}, [3]int{want.NextMsgID, want.NextTaskID, want.NextNoteID})
+	check("active plan", got.ActivePlanID, want.ActivePlanID)
+	check("driver", got.DriverID, want.DriverID)
+}
+
+func testEmptyLoad(t *testing.T, repo app.StateRepository) {
+	state := load(t, repo)
+	if state.NextMsgID != 1 || state.NextTaskID != 1 || state.NextNoteID != 1 {
+		t.Errorf("counters of empty state = %d/%d/%d, want 1/1/1", state.NextMsgID, state.NextTaskID, state.NextNoteID)
+	}
+	if len(state.Messages) != 0 || len(state.Tasks) != 0 || len(state.SessionNotes) != 0 {
+		t.Errorf("empty state has records: %+v", state)
+	}
+	if state.Presence == nil || state.Plans == nil || state.AgentContexts == nil || state.FileLocks == nil ||
+		state.RegisteredAgents == nil || state.AgentInstances == nil || state.WorkContexts == nil {
+		t.Error("empty state has nil maps")
+	}
+}
+
+func testRoundTrip(t *testing.T, repo app.StateRepository) {
+	save(t, repo, sampleState())
+	compare(t, load(t, repo), sampleState())
+
+	// A second save of a modified state is reflected too.
+	state := load(t, repo)
+	state.Tasks[1].Status = "completed"
+	state.Tasks[1].ResultSummary = "built"
+	state.Messages[1].Read = true
+	state.Plans["alpha"].Items[1].Status = "in_progress"
+	state.Presence["cursor"].Status = "idle"
+	save(t, repo, state)
+
+	want := sampleState()
+	want.Tasks[1].Status = "completed"
+	want.Tasks[1].ResultSummary = "built"
+	want.Messages[1].Read = true
+	want.Plans["alpha"].Items[1].Status = "in_progress"
+	want.Presence["cursor"].Status = "idle"
+	compare(t, load(t, repo), want)
+}
+
+func testLoadReturnsCopy(t *testing.T, repo app.StateRepository) {
+	save(t, repo, sampleState())
+	state := load(t, repo)
+	state.Tasks[0].Title = "changed"
+	state.Messages = append(state.Messages, domain.Message{ID: 3, From: "x", To: "y", Timestamp: at(0)})
+	state.Plans["alpha"].Items[0].Status = "blocked"
+	state.Presence["cursor"].Note = "changed"
+	compare(t, load(t, repo), sampleState())
+}
+
+func testSaveRemovesDeleted(t *testing.T, repo app.StateRepository) {
+	save(t, repo, sampleState())
+	state := load(t, repo)
+	state.Tasks = state.Tasks[:1]
+	state.Messages = state.Messages[1:]
+	state.SessionNotes = nil
+	state.Plans["alpha"].Items = state.Plans["alpha"].Items[:1]
+	delete(state.Presence, "cursor")
+	clear(state.FileLocks)
+	delete(state.RegisteredAgents, "helper")
+	delete(state.WorkContexts, "ctx-1")
+	delete(state.AgentContexts, "cursor")
+	save(t, repo, state)
+
+	got := load(t, repo)
+	if len(got.Tasks) != 1 || got.Tasks[0].ID != 1 {
+		t.Errorf("tasks = %+v, want only task 1", got.Tasks)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].ID != 2 {
+		t.Errorf("messages = %+v, want only message 2", got.Messages)
+	}
+	if len(got.SessionNotes) != 0 || len(got.Plans["alpha"].Items) != 1 {
+		t.Errorf("notes = %+v, plan items = %+v", got.SessionNotes, got.Plans["alpha"].Items)
+	}
+	if len(got.Presence) != 0 || len(got.FileLocks) != 0 || len(got.RegisteredAgents) != 0 ||
+		len(got.WorkContexts) != 0 || len(got.AgentContexts) != 0 {
+		t.Errorf("deleted map entries survived: presence %v locks %v agents %v contexts %v agent contexts %v",
+			got.Presence, got.FileLocks, got.RegisteredAgents, got.WorkContexts, got.AgentContexts)
+	}
+
+	delete(got.Plans, "alpha")
+	got.ActivePlanID = ""
+	save(t, repo, got)
+	if final := load(t, repo); len(final.Plans) != 0 || final.ActivePlanID != "" {
+		t.Errorf("plans = %v, active = %q after deleting the plan", final.Plans, final.ActivePlanID)
+	}
+}
+
+func testAtomicUpdate(t *testing.T, u app.AtomicUpdater) {
+	repo := u.(app.StateRepository)
+	save(t, repo, sampleState())
+
+	boom := errors.New("boom")
+	if err := u.Update(func(s *domain.CollabState) error {
+		s.Tasks = nil
+		s.NextMsgID = 100
+		return boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("Update error = %v, want boom", err)
+	}
+	compare(t, load(t, repo), sampleState())
+
+	const workers, writes = 4, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*writes)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				errs <- u.Update(func(s *domain.CollabState) error {
+					s.Messages = append(s.Messages, domain.Message{ID: s.NextMsgID, From: fmt.Sprintf("w%d", w), To: "all",
+						Content: fmt.Sprint(i), Timestamp: at(0)})
+					s.NextMsgID++
+					return nil
+				})
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+
+	got := load(t, repo)
+	if want := 2 + workers*writes; len(got.Messages) != want || got.NextMsgID != want+1 {
+		t.Errorf("after concurrent updates: %d messages, next_msg_id %d; want %d and %d", len(got.Messages), got.NextMsgID, want, want+1)
+	}
+	seen := make(map[int]bool)
+	for _, m := range got.Messages {
+		if seen[m.ID] {
+			t.Errorf("duplicate message ID %d", m.ID)
+		}
+		seen[m.ID] = true
+	}
+}
+
+func testJournal(t *testing.T, repo app.StateRepository) {
+	journal := repo.(app.EventJournal)
+	state := sampleState()
+	state.PendingEvents = []domain.Event{
+		{Type: domain.EventTaskCreated, Actor: "cursor", TaskID: 1, Project: "/work/alpha", Data: map[string]string{"title": "Design"}, Timestamp: at(0)},
+		{Type: domain.EventMessageSent, Actor: "cursor", Target: "claude-code", Timestamp: at(time.Second)},
+	}
+	save(t, repo, state)
+	if got := load(t, repo); len(got.PendingEvents) != 0 {
+		t.Errorf("Load returned pending events: %+v", got.PendingEvents)
+	}
+
+	if u, ok := repo.(app.AtomicUpdater); ok {
+		if err := u.Update(func(s *domain.CollabState) error {
+			s.PendingEvents = []domain.Event{{Type: domain.EventTaskStatusChanged, Target: "claude-code", TaskID: 1,
+				Project: "/work/beta", Data: map[string]string{"from": "pending", "to": "completed"}, Timestamp: at(time.Minute)}}
+			return nil
+		}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	} else {
+		state = load(t, repo)
+		state.PendingEvents = []domain.Event{{Type: domain.EventTaskStatusChanged, Target: "claude-code", TaskID: 1,
+			Project: "/work/beta", Data: map[string]string{"from": "pending", "to": "completed"}, Timestamp: at(time.Minute)}}
+		save(t, repo, state)
+	}
+
+	events := func(f app.EventFilter) []domain.Event {
+		t.Helper()
+		evs, err := journal.Events(f)
+		if err != nil {
+			t.Fatalf("Events(%+v): %v", f, err)
+		}
+		return evs
+	}
+	types := func(evs []domain.Event) []string {
+		var out []string
+		for _, e := range evs {
+			out = append(out, e.Type)
+		}
+		return out
+	}
+
+	all := events(app.EventFilter{})
+	if len(all) != 3 || !(all[0].ID < all[1].ID && all[1].ID < all[2].ID) {
+		t.Fatalf("events = %+v, want 3 in increasing ID order", all)
+	}
+	first := all[0]
+	if first.Type != domain.EventTaskCreated || first.Actor != "cursor" || first.TaskID != 1 || first.Project != "/work/alpha" ||
+		first.Data["title"] != "Design" || !first.Timestamp.Equal(at(0)) {
+		t.Errorf("first event = %+v", first)
+	}
+
+	if got := types(events(app.EventFilter{TaskID: 1})); !reflect.DeepEqual(got, []string{domain.EventTaskCreated, domain.EventTaskStatusChanged}) {
+		t.Errorf("task filter = %v", got)
+	}
+	if got := types(events(app.EventFilter{Agent: "claude-code"})); !reflect.DeepEqual(got, []string{domain.EventMessageSent, domain.EventTaskStatusChanged}) {
+		t.Errorf("agent filter = %v", got)
+	}
+	if got := types(events(app.EventFilter{Types: []string{domain.EventMessageSent}})); !reflect.DeepEqual(got, []string{domain.EventMessageSent}) {
+		t.Errorf("type filter = %v", got)
+	}
+	// Untagged events match every project.
+	if got := types(events(app.EventFilter{Project: "/work/alpha"})); !reflect.DeepEqual(got, []string{domain.EventTaskCreated, domain.EventMessageSent}) {
+		t.Errorf("project filter = %v", got)
+	}
+	if got := types(events(app.EventFilter{Since: at(time.Second)})); !reflect.DeepEqual(got, []string{domain.EventMessageSent, domain.EventTaskStatusChanged}) {
+		t.Errorf("since filter = %v", got)
+	}
+	if got := events(app.EventFilter{AfterID: all[1].ID}); len(got) != 1 || got[0].ID != all[2].ID {
+		t.Errorf("after_id filter = %+v", got)
+	}
+	if got := types(events(app.EventFilter{Limit: 2})); !reflect.DeepEqual(got, []string{domain.EventMessageSent, domain.EventTaskStatusChanged}) {
+		t.Errorf("limit keeps the most recent, oldest first: %v", got)
+	}
+}
+
+func testArchive(t *testing.T, repo app.StateRepository) {
+	archive := repo.(app.TaskArchive)
+	save(t, repo, sampleState())
+
+	state := load(t, repo)
+	if n := app.ArchiveTasks(state, 1, at(48*time.Hour)); n != 1 {
+		t.Fatalf("ArchiveTasks = %d, want 1 (only the completed task)", n)
+	}
+	save(t, repo, state)
+
+	got := load(t, repo)
+	if len(got.Tasks) != 1 || got.Tasks[0].ID != 2 {
+		t.Errorf("live tasks = %+v, want only task 2", got.Tasks)
+	}
+	if _, ok := got.WorkContexts["ctx-1"]; ok {
+		t.Error("work context of the archived task is still live")
+	}
+	if len(got.PendingArchive) != 0 {
+		t.Errorf("Load returned pending archive: %+v", got.PendingArchive)
+	}
+
+	archived, err := archive.ArchivedTasks(app.ArchiveFilter{})
+	if err != nil {
+		t.Fatalf("ArchivedTasks: %v", err)
+	}
+	want := sampleState()
+	if len(archived) != 1 || !reflect.DeepEqual(archived[0].Task, want.Tasks[0]) ||
+		!reflect.DeepEqual(archived[0].WorkContext, want.WorkContexts["ctx-1"]) || !archived[0].ArchivedAt.Equal(at(48*time.Hour)) {
+		t.Fatalf("archived = %+v", archived)
+	}
+
+	// Archive a second task directly to exercise the filters.
+	state = load(t, repo)
+	state.Tasks[0].Status = "cancelled"
+	state.Tasks[0].UpdatedAt = at(0)
+	state.Tasks[0].Project = "/work/beta"
+	state.Tasks[0].AssignedTo = "any"
+	app.ArchiveTasks(state, 1, at(48*time.Hour))
+	save(t, repo, state)
+
+	ids := func(f app.ArchiveFilter) []int {
+		t.Helper()
+		tasks, err := archive.ArchivedTasks(f)
+		if err != nil {
+			t.Fatalf("ArchivedTasks(%+v): %v", f, err)
+		}
+		var out []int
+		for _, a := range tasks {
+			out = append(out, a.Task.ID)
+		}
+		return out
+	}
+	checks := []struct {
+		name string
+		f    app.ArchiveFilter
+		want []int
+	}{
+		{"all", app.ArchiveFilter{}, []int{1, 2}},
+		{"task", app.ArchiveFilter{TaskID: 2}, []int{2}},
+		{"status", app.ArchiveFilter{Status: "completed"}, []int{1}},
+		{"assignee includes any", app.ArchiveFilter{AssignedTo: "claude-code"}, []int{1, 2}},
+		{"assignee", app.ArchiveFilter{AssignedTo: "codex"}, []int{2}},
+		{"project", app.ArchiveFilter{Project: "/work/beta"}, []int{2}},
+		{"all projects", app.ArchiveFilter{Project: app.AllProjects}, []int{1, 2}},
+		{"limit keeps highest IDs", app.ArchiveFilter{Limit: 1}, []int{2}},
+	}
+	for _, c := range checks {
+		if got := ids(c.f); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: archived IDs = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// Load reports the archived status of tasks that live tasks depend on.
+	state = load(t, repo)
+	state.Tasks = append(state.Tasks, domain.Task{ID: 3, Title: "Follow-up", Status: "waiting", Dependencies: []int{1, 2},
+		CreatedBy: "codex", AssignedTo: "any", CreatedAt: at(0), UpdatedAt: at(0)})
+	save(t, repo, state)
+	if got := load(t, repo).ArchivedDependencies; !reflect.DeepEqual(got, map[int]string{1: "completed", 2: "cancelled"}) {
+		t.Errorf("ArchivedDependencies = %v, want task 1 completed and task 2 cancelled", got)
+	}
+}
+
+func testArtifacts(t *testing.T, repo app.StateRepository) {
+	store := repo.(app.ArtifactStore)
+	state := sampleState()
+	state.PendingArtifacts = []domain.Artifact{
+		{TaskID: 1, Name: "api.patch", ContentType: "text/x-diff; charset=utf-8", Content: []byte("--- a/api.go\n+++ b/api.go\n"),
+			CreatedBy: "claude-code", CreatedAt: at(time.Minute)},
+		{TaskID: 1, Name: "blob.bin", ContentType: "application/octet-stream", Content: []byte{0, 1, 2, 0xff},
+			CreatedBy: "claude-code", CreatedAt: at(time.Minute)},
+	}
+	save(t, repo, state)
+
+	if got := load(t, repo); len(got.PendingArtifacts) != 0 {
+		t.Errorf("Load returned pending artifacts: %+v", got.PendingArtifacts)
+	}
+	for _, want := range state.PendingArtifacts {
+		got, err := store.Artifact(want.TaskID, want.Name)
+		if err != nil {
+			t.Fatalf("Artifact(%d, %q): %v", want.TaskID, want.Name, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("Artifact(%d, %q) = %+v, want %+v", want.TaskID, want.Name, *got, want)
+		}
+	}
+	if _, err := store.Artifact(1, "missing.txt"); !errors.Is(err, app.ErrArtifactNotFound) {
+		t.Errorf("missing artifact: err = %v, want ErrArtifactNotFound", err)
+	}
+	if _, err := store.Artifact(2, "api.patch"); !errors.Is(err, app.ErrArtifactNotFound) {
+		t.Errorf("artifact of another task: err = %v, want ErrArtifactNotFound", err)
+	}
+
+	// Reporting again replaces the artifact; archiving the task keeps it.
+	state = load(t, repo)
+	state.PendingArtifacts = []domain.Artifact{{TaskID: 1, Name: "api.patch", ContentType: "text/plain; charset=utf-8",
+		Content: []byte("v2"), CreatedBy: "cursor", CreatedAt: at(2 * time.Minute)}}
+	app.ArchiveTasks(state, 1, at(48*time.Hour))
+	save(t, repo, state)
+	got, err := store.Artifact(1, "api.patch")
+	if err != nil {
+		t.Fatalf("Artifact after replace: %v", err)
+	}
+	if string(got.Content) != "v2" || got.CreatedBy != "cursor" {
+		t.Errorf("replaced artifact = %+v", got)
+	}
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+var _ app.TaskArchive = (*Store)(nil)
+
+// archiveTasks stores archived tasks within tx. The whole ArchivedTask is kept
+// as JSON; the other columns exist for filtering.
+func archiveTasks(tx *sql.Tx, tasks []domain.ArchivedTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO archived_tasks (id, status, assigned_to, project, archived_at, data) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("archived_tasks: %w", err)
+	}
+	defer stmt.Close()
+	for _, a := range tasks {
+		if _, err := stmt.Exec(a.Task.ID, a.Task.Status, a.Task.AssignedTo, a.Task.Project,
+			formatTime(a.ArchivedAt), marshalJSON(a)); err != nil {
+			return fmt.Errorf("archived_tasks: %w", err)
+		}
+	}
+	return nil
+}
+
+// ArchivedTasks implements app.TaskArchive.
+func (s *Store) ArchivedTasks(f app.ArchiveFilter) ([]domain.ArchivedTask, error) {
+	var where []string
+	var args []any
+	if f.TaskID != 0 {
+		where = append(where, "id = ?")
+		args = append(args, f.TaskID)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.AssignedTo != "" {
+		where = append(where, "assigned_to IN (?, 'any')")
+		args = append(args, f.AssignedTo)
+	}
+	if f.Project != "" && f.Project != app.AllProjects {
+		where = append(where, "project IN ('', ?)")
+		args = append(args, f.Project)
+	}
+	q := "SELECT data FROM archived_tasks"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("archived_tasks: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.ArchivedTask
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var a domain.ArchivedTask
+		if err := json.Unmarshal([]byte(data), &a); err != nil {
+			return nil, fmt.Errorf("archived_tasks: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Highest IDs first from the query; return in ID order.
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// loadArchivedDependencies fills state.ArchivedDependencies with the status
+// of archived tasks that live tasks still depend on.
+func loadArchivedDependencies(q querier, state *domain.CollabState) error {
+	missing := app.MissingDependencies(state)
+	if len(missing) == 0 {
+		return nil
+	}
+	args := make([]any, len(missing))
+	for i, id := range missing {
+		args[i] = id
+	}
+	rows, err := q.Query("SELECT id, status FROM archived_tasks WHERE id IN (?"+strings.Repeat(", ?", len(missing)-1)+")", args...)
+	if err != nil {
+		return fmt.Errorf("archived_tasks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var status string
+		if err := rows.Scan(&id, &status); err != nil {
+			return fmt.Errorf("archived_tasks: %w", err)
+		}
+		if state.ArchivedDependencies == nil {
+			state.ArchivedDependencies = make(map[int]string)
+		}
+		state.ArchivedDependencies[id] = status
+	}
+	return rows.Err()
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+var _ app.ArtifactStore = (*Store)(nil)
+
+// storeArtifacts stores result artifacts within tx, replacing any with the
+// same task and name.
+func storeArtifacts(tx *sql.Tx, artifacts []domain.Artifact) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO artifacts (task_id, name, content_type, content, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("artifacts: %w", err)
+	}
+	defer stmt.Close()
+	for _, a := range artifacts {
+		content := a.Content
+		if content == nil {
+			content = []byte{}
+		}
+		if _, err := stmt.Exec(a.TaskID, a.Name, a.ContentType, content, a.CreatedBy, formatTime(a.CreatedAt)); err != nil {
+			return fmt.Errorf("artifacts: %w", err)
+		}
+	}
+	return nil
+}
+
+// Artifact implements app.ArtifactStore.
+func (s *Store) Artifact(taskID int, name string) (*domain.Artifact, error) {
+	a := domain.Artifact{TaskID: taskID, Name: name}
+	var createdAt string
+	err := s.db.QueryRow("SELECT content_type, content, created_by, created_at FROM artifacts WHERE task_id = ? AND name = ?", taskID, name).
+		Scan(&a.ContentType, &a.Content, &a.CreatedBy, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, app.ErrArtifactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("artifacts: %w", err)
+	}
+	if a.CreatedAt, err = parseTime(createdAt, "artifacts"); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
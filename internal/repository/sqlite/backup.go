@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	sqlitedriver "modernc.org/sqlite"
+)
+
+// backuper is the online backup API of the modernc.org/sqlite connection.
+type backuper interface {
+	NewBackup(dstURI string) (*sqlitedriver.Backup, error)
+}
+
+// Backup copies the SQLite database at src to dst with the online backup API,
+// so it is safe while servers are writing: the copy is a consistent snapshot
+// including any committed WAL content. dst is written to a temporary file and
+// renamed into place, replacing an existing file.
+func Backup(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", src+"?_pragma=busy_timeout(10000)")
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer conn.Close()
+
+	tmp := dst + ".tmp"
+	_ = os.Remove(tmp)
+	err = conn.Raw(func(dc any) error {
+		b, ok := dc.(backuper)
+		if !ok {
+			return errors.New("sqlite driver does not support online backup")
+		}
+		bk, err := b.NewBackup(tmp)
+		if err != nil {
+			return err
+		}
+		for more := true; more; {
+			if more, err = bk.Step(-1); err != nil {
+				_ = bk.Finish()
+				return err
+			}
+		}
+		return bk.Finish()
+	})
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("backup %s: %w", src, err)
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+// Environment for TestHelperProcessWriter, which runs in a child process.
+const (
+	helperStateEnv  = "STRINGWORK_TEST_STATE"
+	helperAgentEnv  = "STRINGWORK_TEST_AGENT"
+	helperWritesEnv = "STRINGWORK_TEST_WRITES"
+)
+
+// appendOne adds a message and a task using the state's ID counters, like
+// send_message and create_task do.
+func appendOne(state *domain.CollabState, agent string, i int) {
+	now := time.Now()
+	state.Messages = append(state.Messages, domain.Message{
+		ID: state.NextMsgID, From: agent, To: "all", Content: fmt.Sprintf("%s #%d", agent, i), Timestamp: now,
+	})
+	state.NextMsgID++
+	state.Tasks = append(state.Tasks, domain.Task{
+		ID: state.NextTaskID, Title: fmt.Sprintf("%s #%d", agent, i), Status: "pending", AssignedTo: "any",
+		CreatedBy: agent, CreatedAt: now, UpdatedAt: now, Priority: 3,
+	})
+	state.NextTaskID++
+}
+
+// TestHelperProcessWriter is not a real test: TestRun_ConcurrentProcesses
+// re-executes the test binary with this test selected to act as a separate
+// standalone server writing to the shared state file.
+func TestHelperProcessWriter(t *testing.T) {
+	path := os.Getenv(helperStateEnv)
+	if path == "" {
+		t.Skip("helper process for TestRun_ConcurrentProcesses")
+	}
+	agent := os.Getenv(helperAgentEnv)
+	writes, _ := strconv.Atoi(os.Getenv(helperWritesEnv))
+
+	repo, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer repo.(*Store).Close()
+	pol := policy.New(&policy.Config{StateFile: path, WorkspaceRoot: filepath.Dir(path)})
+	svc := app.NewCollabService(repo, pol, log.New(io.Discard, "", 0))
+
+	for i := 0; i < writes; i++ {
+		if err := svc.Run(func(state *domain.CollabState) error {
+			appendOne(state, agent, i)
+			return nil
+		}); err != nil {
+			t.Fatalf("Run %d: %v", i, err)
+		}
+	}
+}
+
+func TestRun_ConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("multi-process stress test")
+	}
+	const procs, writes = 4, 25
+	path := filepath.Join(t.TempDir(), "state.sqlite")
+	// Create and migrate the database up front so the children only race on state writes.
+	repo, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	repo.(*Store).Close()
+
+	var wg sync.WaitGroup
+	errs := make([]error, procs)
+	for p := 0; p < procs; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcessWriter$", "-test.count=1")
+			cmd.Env = append(os.Environ(),
+				helperStateEnv+"="+path,
+				helperAgentEnv+"="+fmt.Sprintf("agent-%d", p),
+				helperWritesEnv+"="+strconv.Itoa(writes),
+			)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs[p] = fmt.Errorf("process %d: %v\n%s", p, err, out)
+			}
+		}(p)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err = New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer repo.(*Store).Close()
+	state, err := repo.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	const total = procs * writes
+	if len(state.Messages) != total || len(state.Tasks) != total {
+		t.Fatalf("lost writes: %d messages and %d tasks, want %d each", len(state.Messages), len(state.Tasks), total)
+	}
+	msgIDs, taskIDs := make(map[int]bool), make(map[int]bool)
+	perAgent := make(map[string]int)
+	for _, m := range state.Messages {
+		if msgIDs[m.ID] {
+			t.Errorf("duplicate message ID %d", m.ID)
+		}
+		msgIDs[m.ID] = true
+		perAgent[m.From]++
+	}
+	for _, task := range state.Tasks {
+		if taskIDs[task.ID] {
+			t.Errorf("duplicate task ID %d", task.ID)
+		}
+		taskIDs[task.ID] = true
+	}
+	for p := 0; p < procs; p++ {
+		if n := perAgent[fmt.Sprintf("agent-%d", p)]; n != writes {
+			t.Errorf("agent-%d has %d messages, want %d", p, n, writes)
+		}
+	}
+	if state.NextMsgID != total+1 || state.NextTaskID != total+1 {
+		t.Errorf("counters: next_msg_id=%d next_task_id=%d, want %d", state.NextMsgID, state.NextTaskID, total+1)
+	}
+
+	events, err := repo.(*Store).Events(app.EventFilter{Types: []string{domain.EventTaskCreated}})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != total {
+		t.Errorf("journaled %d task_created events, want %d", len(events), total)
+	}
+}
+
+func TestUpdate_SeesOtherConnectionsWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.sqlite")
+	a, err := New(path)
+	if err != nil {
+		t.Fatalf("New a: %v", err)
+	}
+	defer a.(*Store).Close()
+	b, err := New(path)
+	if err != nil {
+		t.Fatalf("New b: %v", err)
+	}
+	defer b.(*Store).Close()
+
+	// Both stores hold a snapshot of the empty state; b's Update must still
+	// build on a's write rather than overwrite it.
+	if _, err := a.Load(); err != nil {
+		t.Fatalf("Load a: %v", err)
+	}
+	if _, err := b.Load(); err != nil {
+		t.Fatalf("Load b: %v", err)
+	}
+	if err := a.(*Store).Update(func(s *domain.CollabState) error { appendOne(s, "a", 0); return nil }); err != nil {
+		t.Fatalf("Update a: %v", err)
+	}
+	if err := b.(*Store).Update(func(s *domain.CollabState) error { appendOne(s, "b", 0); return nil }); err != nil {
+		t.Fatalf("Update b: %v", err)
+	}
+
+	boom := errors.New("boom")
+	if err := a.(*Store).Update(func(s *domain.CollabState) error {
+		appendOne(s, "a", 1)
+		return boom
+	}); !errors.Is(err, boom) {
+		t.Fatalf("Update error = %v, want boom", err)
+	}
+
+	state, err := a.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(state.Messages) != 2 || state.Messages[0].ID == state.Messages[1].ID || state.NextMsgID != 3 {
+		t.Errorf("messages = %+v, next_msg_id=%d; want two distinct and no write from the failed update", state.Messages, state.NextMsgID)
+	}
+}
@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func newTestStore(t testing.TB) *Store {
+	t.Helper()
+	store, err := New(filepath.Join(t.TempDir(), "state.sqlite"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	st := store.(*Store)
+	t.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+// bigState returns a state with the given number of messages and tasks.
+func bigState(msgs, tasks int) *domain.CollabState {
+	now := time.Now()
+	state := domain.NewCollabState()
+	for i := 1; i <= msgs; i++ {
+		state.Messages = append(state.Messages, domain.Message{
+			ID: i, From: "cursor", To: "claude-code", Content: fmt.Sprintf("message %d with some body text", i), Timestamp: now,
+		})
+	}
+	for i := 1; i <= tasks; i++ {
+		state.Tasks = append(state.Tasks, domain.Task{
+			ID: i, Title: fmt.Sprintf("task %d", i), Description: "desc", Status: "pending", AssignedTo: "any",
+			CreatedBy: "cursor", CreatedAt: now, UpdatedAt: now, Priority: 3,
+		})
+	}
+	state.NextMsgID = msgs + 1
+	state.NextTaskID = tasks + 1
+	return state
+}
+
+// countWrites installs triggers that count inserts and deletes on messages and tasks.
+func countWrites(t *testing.T, s *Store) func() int {
+	t.Helper()
+	stmts := []string{
+		"CREATE TABLE write_log (n INTEGER)",
+		"CREATE TRIGGER msg_ins AFTER INSERT ON messages BEGIN INSERT INTO write_log VALUES (1); END",
+		"CREATE TRIGGER msg_del AFTER DELETE ON messages BEGIN INSERT INTO write_log VALUES (1); END",
+		"CREATE TRIGGER task_ins AFTER INSERT ON tasks BEGIN INSERT INTO write_log VALUES (1); END",
+		"CREATE TRIGGER task_del AFTER DELETE ON tasks BEGIN INSERT INTO write_log VALUES (1); END",
+	}
+	for _, q := range stmts {
+		if _, err := s.db.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	return func() int {
+		var n int
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM write_log").Scan(&n); err != nil {
+			t.Fatalf("count writes: %v", err)
+		}
+		_, _ = s.db.Exec("DELETE FROM write_log")
+		return n
+	}
+}
+
+func TestSave_IncrementalWritesOnlyChangedRows(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Save(bigState(100, 20)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	writes := countWrites(t, s)
+
+	state, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	state.Messages[4].Read = true                           // update
+	state.Tasks = state.Tasks[1:]                           // delete task 1
+	state.Messages = append(state.Messages, domain.Message{ // insert
+		ID: state.NextMsgID, From: "a", To: "b", Content: "new", Timestamp: time.Now(),
+	})
+	state.NextMsgID++
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// One replaced message, one deleted task, one inserted message.
+	if got := writes(); got != 3 {
+		t.Errorf("row writes = %d, want 3", got)
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded.Messages) != 101 || len(loaded.Tasks) != 19 {
+		t.Fatalf("messages=%d tasks=%d, want 101, 19", len(loaded.Messages), len(loaded.Tasks))
+	}
+	if !loaded.Messages[4].Read {
+		t.Error("Messages[4].Read not persisted")
+	}
+	if loaded.Tasks[0].ID != 2 {
+		t.Errorf("Tasks[0].ID = %d, want 2", loaded.Tasks[0].ID)
+	}
+
+	// Saving an unchanged state writes nothing.
+	if err := s.Save(loaded); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got := writes(); got != 0 {
+		t.Errorf("row writes for unchanged state = %d, want 0", got)
+	}
+}
+
+func TestSave_IncrementalPlanItemsAndMaps(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Plans["p1"] = &domain.Plan{ID: "p1", Title: "Plan", Status: "active", CreatedAt: now, UpdatedAt: now,
+		Items: []domain.PlanItem{{ID: "1", Title: "one", Status: "pending", UpdatedAt: now}, {ID: "2", Title: "two", Status: "pending", UpdatedAt: now}}}
+	state.FileLocks["a.go"] = &domain.FileLock{Path: "a.go", LockedBy: "cursor", LockedAt: now, ExpiresAt: now}
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	state.Plans["p1"].Items = state.Plans["p1"].Items[1:]
+	state.Plans["p1"].Items[0].Status = "completed"
+	delete(state.FileLocks, "a.go")
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	items := loaded.Plans["p1"].Items
+	if len(items) != 1 || items[0].ID != "2" || items[0].Status != "completed" {
+		t.Errorf("plan items = %+v, want only item 2 completed", items)
+	}
+	if len(loaded.FileLocks) != 0 {
+		t.Errorf("file locks = %d, want 0", len(loaded.FileLocks))
+	}
+}
+
+// benchmarkHeartbeatSave measures a heartbeat-sized change (one presence row)
+// against a 10k-message / 2k-task state.
+func benchmarkHeartbeatSave(b *testing.B, full bool) {
+	s := newTestStore(b)
+	if err := s.Save(bigState(10000, 2000)); err != nil {
+		b.Fatalf("Save: %v", err)
+	}
+	state, err := s.Load()
+	if err != nil {
+		b.Fatalf("Load: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		state.Presence["cursor"] = &domain.Presence{Agent: "cursor", Status: "working", LastSeen: time.Now()}
+		if full {
+			s.snapshot = nil
+		}
+		if err := s.Save(state); err != nil {
+			b.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+func BenchmarkSave_Incremental(b *testing.B) { benchmarkHeartbeatSave(b, false) }
+
+func BenchmarkSave_FullReplace(b *testing.B) { benchmarkHeartbeatSave(b, true) }
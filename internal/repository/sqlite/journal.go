@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+var _ app.EventJournal = (*Store)(nil)
+
+// eventTimeFormat is fixed-width UTC so event timestamps compare correctly as
+// text (RFC3339Nano drops trailing zeros and does not).
+const eventTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// appendEvents inserts events into the journal within tx.
+func appendEvents(tx *sql.Tx, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare("INSERT INTO events (type, actor, target, task_id, ref, project, data, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("events: %w", err)
+	}
+	defer stmt.Close()
+	for _, e := range events {
+		data := "{}"
+		if len(e.Data) > 0 {
+			data = marshalJSON(e.Data)
+		}
+		if _, err := stmt.Exec(e.Type, e.Actor, e.Target, e.TaskID, e.Ref, e.Project, data,
+			e.Timestamp.UTC().Format(eventTimeFormat)); err != nil {
+			return fmt.Errorf("events: %w", err)
+		}
+	}
+	return nil
+}
+
+// Events implements app.EventJournal.
+func (s *Store) Events(f app.EventFilter) ([]domain.Event, error) {
+	var where []string
+	var args []any
+	if f.TaskID != 0 {
+		where = append(where, "task_id = ?")
+		args = append(args, f.TaskID)
+	}
+	if f.Agent != "" {
+		where = append(where, "(actor = ? OR target = ?)")
+		args = append(args, f.Agent, f.Agent)
+	}
+	if len(f.Types) > 0 {
+		where = append(where, "type IN ("+placeholders(len(f.Types))+")")
+		args = append(args, stringArgs(f.Types)...)
+	}
+	if f.Project != "" && f.Project != app.AllProjects {
+		where = append(where, "project IN ('', ?)")
+		args = append(args, f.Project)
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, f.Since.UTC().Format(eventTimeFormat))
+	}
+	if f.AfterID > 0 {
+		where = append(where, "id > ?")
+		args = append(args, f.AfterID)
+	}
+	q := "SELECT id, type, actor, target, task_id, ref, project, data, timestamp FROM events"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("events: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.Event
+	for rows.Next() {
+		var e domain.Event
+		var data, ts string
+		if err := rows.Scan(&e.ID, &e.Type, &e.Actor, &e.Target, &e.TaskID, &e.Ref, &e.Project, &data, &ts); err != nil {
+			return nil, err
+		}
+		if data != "{}" {
+			_ = json.Unmarshal([]byte(data), &e.Data)
+		}
+		if e.Timestamp, err = time.Parse(eventTimeFormat, ts); err != nil {
+			return nil, fmt.Errorf("event %d timestamp: %w", e.ID, err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Newest first from the query; return oldest first.
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
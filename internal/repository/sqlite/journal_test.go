@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestJournal_AppendAndFilter(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := domain.NewCollabState()
+	state.PendingEvents = []domain.Event{
+		{Type: domain.EventTaskCreated, Actor: "cursor", Target: "claude-code", TaskID: 1, Project: "/a", Timestamp: base,
+			Data: map[string]string{"title": "T"}},
+		{Type: domain.EventTaskStatusChanged, Target: "claude-code", TaskID: 1, Project: "/a", Timestamp: base.Add(100 * time.Millisecond)},
+		{Type: domain.EventMessageSent, Actor: "codex", Target: "cursor", Project: "/b", Timestamp: base.Add(120 * time.Millisecond)},
+		{Type: domain.EventWorkerSpawned, Target: "claude-code", Timestamp: base.Add(time.Second)},
+	}
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// Events are appended once; a second save of the same state adds nothing.
+	state.PendingEvents = nil
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	ids := func(f app.EventFilter) []int64 {
+		t.Helper()
+		events, err := s.Events(f)
+		if err != nil {
+			t.Fatalf("Events(%+v): %v", f, err)
+		}
+		var out []int64
+		for _, e := range events {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	eq := func(name string, got []int64, want ...int64) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+
+	eq("all", ids(app.EventFilter{}), 1, 2, 3, 4)
+	eq("task", ids(app.EventFilter{TaskID: 1}), 1, 2)
+	eq("agent", ids(app.EventFilter{Agent: "cursor"}), 1, 3)
+	eq("types", ids(app.EventFilter{Types: []string{domain.EventMessageSent, domain.EventWorkerSpawned}}), 3, 4)
+	eq("project", ids(app.EventFilter{Project: "/a"}), 1, 2, 4)
+	eq("since", ids(app.EventFilter{Since: base.Add(110 * time.Millisecond)}), 3, 4)
+	eq("after", ids(app.EventFilter{AfterID: 2}), 3, 4)
+	eq("limit", ids(app.EventFilter{Limit: 2}), 3, 4)
+
+	events, _ := s.Events(app.EventFilter{TaskID: 1, Limit: 1, Types: []string{domain.EventTaskCreated}})
+	if len(events) != 1 || events[0].Data["title"] != "T" || !events[0].Timestamp.Equal(base) || events[0].Actor != "cursor" {
+		t.Errorf("round-tripped event = %+v", events)
+	}
+}
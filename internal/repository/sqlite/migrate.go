@@ -0,0 +1,472 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// migration is one numbered schema change. Each migration runs in its own
+// transaction together with the schema_version row that records it, so a
+// failure leaves the database at the previous version.
+//
+// Databases created before schema versioning have no schema_version table and
+// may be at any point of the history, so migrations must tolerate objects that
+// already exist: use CREATE ... IF NOT EXISTS and addColumns.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations is the complete schema history, oldest first. Never edit or
+// renumber a released migration; append a new one instead.
+var migrations = []migration{
+	{1, "initial schema", func(tx *sql.Tx) error {
+		return execAll(tx, `
+CREATE TABLE IF NOT EXISTS messages (
+	id INTEGER PRIMARY KEY,
+	from_agent TEXT NOT NULL,
+	to_agent TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp TEXT NOT NULL,
+	read_flag INTEGER NOT NULL DEFAULT 0
+)`, `
+CREATE TABLE IF NOT EXISTS tasks (
+	id INTEGER PRIMARY KEY,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	status TEXT NOT NULL,
+	assigned_to TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 3,
+	blocked_by TEXT NOT NULL DEFAULT '',
+	dependencies TEXT NOT NULL DEFAULT '[]'
+)`, `
+CREATE TABLE IF NOT EXISTS presence (
+	agent TEXT PRIMARY KEY,
+	status TEXT NOT NULL,
+	current_task_id INTEGER NOT NULL DEFAULT 0,
+	note TEXT NOT NULL DEFAULT '',
+	last_seen TEXT NOT NULL
+)`, `
+CREATE TABLE IF NOT EXISTS session_notes (
+	id INTEGER PRIMARY KEY,
+	author TEXT NOT NULL,
+	content TEXT NOT NULL,
+	category TEXT NOT NULL,
+	timestamp TEXT NOT NULL
+)`, `
+CREATE TABLE IF NOT EXISTS plans (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	goal TEXT NOT NULL,
+	context TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	status TEXT NOT NULL
+)`, `
+CREATE TABLE IF NOT EXISTS plan_items (
+	plan_id TEXT NOT NULL,
+	item_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	reasoning TEXT NOT NULL DEFAULT '',
+	acceptance TEXT NOT NULL DEFAULT '[]',
+	constraints TEXT NOT NULL DEFAULT '[]',
+	status TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	dependencies TEXT NOT NULL DEFAULT '[]',
+	blockers TEXT NOT NULL DEFAULT '[]',
+	notes TEXT NOT NULL DEFAULT '[]',
+	priority INTEGER NOT NULL DEFAULT 2,
+	updated_by TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (plan_id, item_id),
+	FOREIGN KEY (plan_id) REFERENCES plans(id)
+)`, `
+CREATE TABLE IF NOT EXISTS agent_contexts (
+	agent TEXT PRIMARY KEY,
+	last_checked_msg_id INTEGER NOT NULL DEFAULT 0,
+	last_checked_task_id INTEGER NOT NULL DEFAULT 0,
+	last_check_time TEXT NOT NULL
+)`, `
+CREATE TABLE IF NOT EXISTS file_locks (
+	path TEXT PRIMARY KEY,
+	locked_by TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	locked_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL
+)`, `
+CREATE TABLE IF NOT EXISTS meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+)`,
+			"CREATE INDEX IF NOT EXISTS idx_messages_to_read ON messages(to_agent, read_flag)",
+			"CREATE INDEX IF NOT EXISTS idx_tasks_status_assigned ON tasks(status, assigned_to)",
+		)
+	}},
+	{2, "orchestration: workspaces, worker routing, instances, work contexts", func(tx *sql.Tx) error {
+		if err := addColumns(tx, "presence", "workspace TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := addColumns(tx, "tasks",
+			"context_id TEXT NOT NULL DEFAULT ''",
+			"worker_type TEXT NOT NULL DEFAULT ''",
+			"capabilities TEXT NOT NULL DEFAULT '[]'",
+		); err != nil {
+			return err
+		}
+		return execAll(tx, `
+CREATE TABLE IF NOT EXISTS agent_instances (
+	instance_id TEXT PRIMARY KEY,
+	agent_type TEXT NOT NULL,
+	role TEXT NOT NULL,
+	capabilities TEXT NOT NULL DEFAULT '[]',
+	max_tasks INTEGER NOT NULL DEFAULT 1,
+	status TEXT NOT NULL DEFAULT 'offline',
+	current_tasks TEXT NOT NULL DEFAULT '[]',
+	workspace TEXT NOT NULL DEFAULT '',
+	last_heartbeat TEXT NOT NULL
+)`, `
+CREATE TABLE IF NOT EXISTS work_contexts (
+	id TEXT PRIMARY KEY,
+	task_id INTEGER NOT NULL,
+	relevant_files TEXT NOT NULL DEFAULT '[]',
+	background TEXT NOT NULL DEFAULT '',
+	constraints TEXT NOT NULL DEFAULT '[]',
+	shared_notes TEXT NOT NULL DEFAULT '{}',
+	parent_ctx_id TEXT NOT NULL DEFAULT ''
+)`)
+	}},
+	{3, "task results and expected duration", func(tx *sql.Tx) error {
+		return addColumns(tx, "tasks",
+			"result_summary TEXT NOT NULL DEFAULT ''",
+			"expected_duration_sec INTEGER NOT NULL DEFAULT 0",
+		)
+	}},
+	{4, "progress reporting", func(tx *sql.Tx) error {
+		if err := addColumns(tx, "tasks",
+			"progress_description TEXT NOT NULL DEFAULT ''",
+			"progress_percent INTEGER NOT NULL DEFAULT 0",
+			"last_progress_at TEXT NOT NULL DEFAULT ''",
+		); err != nil {
+			return err
+		}
+		return addColumns(tx, "agent_instances",
+			"progress TEXT NOT NULL DEFAULT ''",
+			"progress_step INTEGER NOT NULL DEFAULT 0",
+			"progress_total_steps INTEGER NOT NULL DEFAULT 0",
+			"progress_updated_at TEXT NOT NULL DEFAULT ''",
+		)
+	}},
+	{5, "registered agents", func(tx *sql.Tx) error {
+		return execAll(tx, `
+CREATE TABLE IF NOT EXISTS registered_agents (
+	name TEXT PRIMARY KEY,
+	display_name TEXT NOT NULL DEFAULT '',
+	capabilities TEXT NOT NULL DEFAULT '[]',
+	workspace TEXT NOT NULL DEFAULT '',
+	project TEXT NOT NULL DEFAULT '',
+	registered_at TEXT NOT NULL,
+	last_seen TEXT NOT NULL
+)`)
+	}},
+	{6, "indexes for unread and pending queries", func(tx *sql.Tx) error {
+		return execAll(tx,
+			"CREATE INDEX IF NOT EXISTS idx_messages_unread ON messages(to_agent) WHERE read_flag = 0",
+			"CREATE INDEX IF NOT EXISTS idx_tasks_assigned_status ON tasks(assigned_to, status)",
+		)
+	}},
+	{7, "project scoping", func(tx *sql.Tx) error {
+		for _, t := range []string{"messages", "tasks", "session_notes", "plans", "file_locks"} {
+			if err := addColumns(tx, t, "project TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return execAll(tx, "CREATE INDEX IF NOT EXISTS idx_tasks_project ON tasks(project)")
+	}},
+	{8, "event journal", func(tx *sql.Tx) error {
+		return execAll(tx, `
+CREATE TABLE IF NOT EXISTS events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	actor TEXT NOT NULL DEFAULT '',
+	target TEXT NOT NULL DEFAULT '',
+	task_id INTEGER NOT NULL DEFAULT 0,
+	ref TEXT NOT NULL DEFAULT '',
+	project TEXT NOT NULL DEFAULT '',
+	data TEXT NOT NULL DEFAULT '{}',
+	timestamp TEXT NOT NULL
+)`,
+			"CREATE INDEX IF NOT EXISTS idx_events_task ON events(task_id) WHERE task_id > 0",
+			"CREATE INDEX IF NOT EXISTS idx_events_type ON events(type)",
+		)
+	}},
+	{9, "task archive", func(tx *sql.Tx) error {
+		return execAll(tx, `
+CREATE TABLE IF NOT EXISTS archived_tasks (
+	id INTEGER PRIMARY KEY,
+	status TEXT NOT NULL,
+	assigned_to TEXT NOT NULL DEFAULT '',
+	project TEXT NOT NULL DEFAULT '',
+	archived_at TEXT NOT NULL,
+	data TEXT NOT NULL
+)`,
+			"CREATE INDEX IF NOT EXISTS idx_archived_tasks_project ON archived_tasks(project)",
+		)
+	}},
+	{10, "subtasks", func(tx *sql.Tx) error {
+		if err := addColumns(tx, "tasks", "parent_task_id INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		return execAll(tx, "CREATE INDEX IF NOT EXISTS idx_tasks_parent ON tasks(parent_task_id) WHERE parent_task_id > 0")
+	}},
+	{11, "task attempts", func(tx *sql.Tx) error {
+		return addColumns(tx, "tasks",
+			"attempts TEXT NOT NULL DEFAULT '[]'",
+			"max_attempts INTEGER NOT NULL DEFAULT 0",
+		)
+	}},
+	{12, "task results", func(tx *sql.Tx) error {
+		if err := addColumns(tx, "tasks", "result TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		return execAll(tx, `
+CREATE TABLE IF NOT EXISTS artifacts (
+	task_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	content_type TEXT NOT NULL DEFAULT '',
+	content BLOB NOT NULL,
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (task_id, name)
+)`)
+	}},
+	{13, "scheduled tasks", func(tx *sql.Tx) error {
+		return addColumns(tx, "tasks",
+			"not_before TEXT NOT NULL DEFAULT ''",
+			"schedule TEXT NOT NULL DEFAULT ''",
+			"scheduled_from INTEGER NOT NULL DEFAULT 0",
+		)
+	}},
+	{14, "task labels", func(tx *sql.Tx) error {
+		return addColumns(tx, "tasks",
+			"labels TEXT NOT NULL DEFAULT '[]'",
+			"plan_id TEXT NOT NULL DEFAULT ''",
+		)
+	}},
+	{15, "task verification", func(tx *sql.Tx) error {
+		return addColumns(tx, "tasks",
+			"verify TEXT NOT NULL DEFAULT '[]'",
+			"verification TEXT NOT NULL DEFAULT ''",
+		)
+	}},
+	{16, "plan item tasks", func(tx *sql.Tx) error {
+		return addColumns(tx, "tasks", "plan_item_id TEXT NOT NULL DEFAULT ''")
+	}},
+	{17, "file locks per project", func(tx *sql.Tx) error {
+		return execAll(tx, `
+CREATE TABLE file_locks_new (
+	project TEXT NOT NULL DEFAULT '',
+	path TEXT NOT NULL,
+	locked_by TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	locked_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL,
+	PRIMARY KEY (project, path)
+)`,
+			"INSERT INTO file_locks_new (project, path, locked_by, reason, locked_at, expires_at) SELECT project, path, locked_by, reason, locked_at, expires_at FROM file_locks",
+			"DROP TABLE file_locks",
+			"ALTER TABLE file_locks_new RENAME TO file_locks",
+		)
+	}},
+}
+
+const schemaVersionTable = `
+CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TEXT NOT NULL
+)`
+
+// LatestSchemaVersion is the schema version this build migrates databases to.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// MigrationReport describes the outcome of Migrate.
+type MigrationReport struct {
+	From    int      // version before migrating; 0 for new and pre-versioning databases
+	To      int      // version after migrating; equals From on a dry run
+	Legacy  bool     // database predates schema versioning
+	Pending []string // migrations applied (or, on a dry run, to be applied) as "NNN name"
+	Backup  string   // copy of the database taken before migrating, if any
+}
+
+// Migrate brings the database at path up to LatestSchemaVersion, creating
+// it (and its parent directories) if needed. Before
+// changing an existing database it writes a backup next to it
+// (<path>.bak-v<from>-<timestamp>). With dryRun set it only reports the
+// pending migrations and leaves the file untouched.
+func Migrate(path string, dryRun bool) (*MigrationReport, error) {
+	if dryRun {
+		// Opening would create the file; report a new database instead.
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return migrateReport(0, false), nil
+		}
+	} else if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("sqlite mkdir: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite", path+"?_txlock=immediate&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("sqlite open: %w", err)
+	}
+	defer db.Close()
+	return migrate(db, path, dryRun)
+}
+
+func migrate(db *sql.DB, path string, dryRun bool) (*MigrationReport, error) {
+	from, legacy, err := currentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if latest := LatestSchemaVersion(); from > latest {
+		return nil, fmt.Errorf("sqlite schema version %d is newer than this build supports (%d); upgrade mcp-stringwork", from, latest)
+	}
+
+	report := migrateReport(from, legacy)
+	if dryRun || len(report.Pending) == 0 {
+		return report, nil
+	}
+
+	if from > 0 || legacy {
+		report.Backup = fmt.Sprintf("%s.bak-v%d-%s", path, from, time.Now().Format("20060102-150405"))
+		if _, err := db.Exec("VACUUM INTO ?", report.Backup); err != nil {
+			return report, fmt.Errorf("sqlite backup before migrating: %w", err)
+		}
+	}
+
+	if _, err := db.Exec(schemaVersionTable); err != nil {
+		return report, fmt.Errorf("sqlite schema_version: %w", err)
+	}
+	for _, m := range migrations {
+		if m.version <= from {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return report, fmt.Errorf("sqlite migration %03d (%s): %w", m.version, m.name, err)
+		}
+		report.To = m.version
+	}
+	return report, nil
+}
+
+// migrateReport returns a report listing the migrations after from.
+func migrateReport(from int, legacy bool) *MigrationReport {
+	report := &MigrationReport{From: from, To: from, Legacy: legacy}
+	for _, m := range migrations {
+		if m.version > from {
+			report.Pending = append(report.Pending, fmt.Sprintf("%03d %s", m.version, m.name))
+		}
+	}
+	return report
+}
+
+// applyMigration runs m and records it in one transaction. The transaction
+// takes the write lock up front (_txlock=immediate), so when two processes
+// open an old database at once the second sees the version row and skips.
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var applied int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM schema_version WHERE version = ?", m.version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// currentVersion returns the highest applied migration, and whether the
+// database has tables but no schema_version (created before versioning).
+func currentVersion(db *sql.DB) (version int, legacy bool, err error) {
+	var tables, versioned int
+	if err := db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(name = 'schema_version'), 0)
+		FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`).Scan(&tables, &versioned); err != nil {
+		return 0, false, fmt.Errorf("sqlite schema version: %w", err)
+	}
+	if versioned == 0 {
+		return 0, tables > 0, nil
+	}
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, false, fmt.Errorf("sqlite schema version: %w", err)
+	}
+	return version, false, nil
+}
+
+func execAll(tx *sql.Tx, stmts ...string) error {
+	for _, q := range stmts {
+		if _, err := tx.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumns adds each column definition ("name TYPE ...") to table unless a
+// column of that name already exists.
+func addColumns(tx *sql.Tx, table string, defs ...string) error {
+	existing := make(map[string]bool)
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	for _, def := range defs {
+		name, _, _ := strings.Cut(def, " ")
+		if existing[name] {
+			continue
+		}
+		if _, err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// loadFixture creates a database at a temp path from testdata/<name>.sql.
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", name+".sql"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "state.sqlite")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("apply fixture %s: %v", name, err)
+	}
+	return path
+}
+
+// schemaOf returns table -> column names, and the index names, of the database at path.
+func schemaOf(t *testing.T, path string) (map[string][]string, []string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	tables := make(map[string][]string)
+	var indexes []string
+	rows, err := db.Query(`SELECT type, name FROM sqlite_master
+		WHERE type IN ('table', 'index') AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		t.Fatalf("sqlite_master: %v", err)
+	}
+	var names []string
+	for rows.Next() {
+		var typ, name string
+		if err := rows.Scan(&typ, &name); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if typ == "index" {
+			indexes = append(indexes, name)
+		} else {
+			names = append(names, name)
+		}
+	}
+	rows.Close()
+	for _, table := range names {
+		cols, err := db.Query("SELECT name FROM pragma_table_info(?) ORDER BY name", table)
+		if err != nil {
+			t.Fatalf("table_info %s: %v", table, err)
+		}
+		for cols.Next() {
+			var c string
+			_ = cols.Scan(&c)
+			tables[table] = append(tables[table], c)
+		}
+		cols.Close()
+	}
+	return tables, indexes
+}
+
+func TestMigrate_NewDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.sqlite")
+	report, err := Migrate(path, false)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if report.From != 0 || report.To != LatestSchemaVersion() || report.Legacy || report.Backup != "" {
+		t.Errorf("unexpected report for new database: %+v", report)
+	}
+	if len(report.Pending) != len(migrations) {
+		t.Errorf("expected %d migrations applied, got %v", len(migrations), report.Pending)
+	}
+
+	// Re-running is a no-op.
+	report, err = Migrate(path, false)
+	if err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	if report.From != LatestSchemaVersion() || len(report.Pending) != 0 || report.Backup != "" {
+		t.Errorf("expected no-op on up-to-date database, got %+v", report)
+	}
+}
+
+func TestMigrate_UpgradesReleasedSchemas(t *testing.T) {
+	fresh := filepath.Join(t.TempDir(), "fresh.sqlite")
+	if _, err := Migrate(fresh, false); err != nil {
+		t.Fatalf("Migrate fresh: %v", err)
+	}
+	wantTables, wantIndexes := schemaOf(t, fresh)
+
+	for v := 1; v <= 5; v++ {
+		name := fmt.Sprintf("legacy_v%d", v)
+		t.Run(name, func(t *testing.T) {
+			path := loadFixture(t, name)
+			report, err := Migrate(path, false)
+			if err != nil {
+				t.Fatalf("Migrate: %v", err)
+			}
+			if !report.Legacy || report.From != 0 || report.To != LatestSchemaVersion() {
+				t.Errorf("unexpected report: %+v", report)
+			}
+			if report.Backup == "" {
+				t.Error("expected a backup of the legacy database")
+			} else if _, err := os.Stat(report.Backup); err != nil {
+				t.Errorf("backup missing: %v", err)
+			}
+
+			gotTables, gotIndexes := schemaOf(t, path)
+			if !reflect.DeepEqual(gotTables, wantTables) {
+				t.Errorf("upgraded schema differs from fresh schema:\n got %v\nwant %v", gotTables, wantTables)
+			}
+			if !reflect.DeepEqual(gotIndexes, wantIndexes) {
+				t.Errorf("upgraded indexes = %v, want %v", gotIndexes, wantIndexes)
+			}
+
+			store, err := New(path)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer store.(*Store).Close()
+			state, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if len(state.Messages) != 2 || len(state.Tasks) != 1 || len(state.SessionNotes) != 1 ||
+				len(state.FileLocks) != 1 || state.Plans["plan-1"] == nil || len(state.Plans["plan-1"].Items) != 1 {
+				t.Errorf("fixture data not preserved: %d messages, %d tasks, %d notes, %d locks, plans %v",
+					len(state.Messages), len(state.Tasks), len(state.SessionNotes), len(state.FileLocks), state.Plans)
+			}
+			if state.Tasks[0].Title != "Fix login" || state.Tasks[0].Project != "" {
+				t.Errorf("task = %+v", state.Tasks[0])
+			}
+			if state.NextMsgID != 3 || state.ActivePlanID != "plan-1" {
+				t.Errorf("meta not preserved: next_msg_id=%d active_plan=%q", state.NextMsgID, state.ActivePlanID)
+			}
+			if err := store.Save(state); err != nil {
+				t.Errorf("Save after upgrade: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrate_DryRunLeavesDatabaseUntouched(t *testing.T) {
+	path := loadFixture(t, "legacy_v3")
+	before, _ := schemaOf(t, path)
+
+	report, err := Migrate(path, true)
+	if err != nil {
+		t.Fatalf("Migrate dry run: %v", err)
+	}
+	if report.To != report.From || len(report.Pending) != len(migrations) || report.Backup != "" {
+		t.Errorf("unexpected dry-run report: %+v", report)
+	}
+	after, _ := schemaOf(t, path)
+	if !reflect.DeepEqual(before, after) {
+		t.Error("dry run changed the schema")
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.sqlite")
+	if _, err := Migrate(missing, true); err != nil {
+		t.Fatalf("dry run on missing file: %v", err)
+	}
+	if _, err := os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Error("dry run created the database file")
+	}
+}
+
+func TestMigrate_FailedMigrationRollsBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.sqlite")
+	if _, err := Migrate(path, false); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	saved := migrations
+	defer func() { migrations = saved }()
+	next := LatestSchemaVersion() + 1
+	migrations = append(append([]migration(nil), saved...), migration{next, "broken", func(tx *sql.Tx) error {
+		if err := execAll(tx, "CREATE TABLE half_done (id INTEGER)"); err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}})
+
+	report, err := Migrate(path, false)
+	if err == nil {
+		t.Fatal("expected migration error")
+	}
+	if report == nil || report.Backup == "" {
+		t.Errorf("expected a backup before the failing migration, got %+v", report)
+	}
+	tables, _ := schemaOf(t, path)
+	if _, ok := tables["half_done"]; ok {
+		t.Error("failed migration was not rolled back")
+	}
+	migrations = saved
+	if report, err := Migrate(path, true); err != nil || report.From != LatestSchemaVersion() {
+		t.Errorf("version after failed migration = %+v, %v; want %d", report, err, LatestSchemaVersion())
+	}
+}
+
+func TestMigrate_RefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.sqlite")
+	if _, err := Migrate(path, false); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'future', '')", LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	db.Close()
+
+	if _, err := New(path); err == nil {
+		t.Fatal("New should refuse a database from a newer build")
+	}
+}
+
+func TestMigrations_NumberedSequentially(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d (%s) has version %d, want %d", i, m.name, m.version, i+1)
+		}
+	}
+}
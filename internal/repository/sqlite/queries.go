@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+var _ app.StateQueries = (*Store)(nil)
+
+// placeholders returns "?, ?, ..." for n parameters.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// stringArgs converts ss to query arguments.
+func stringArgs(ss []string) []any {
+	args := make([]any, len(ss))
+	for i, s := range ss {
+		args[i] = s
+	}
+	return args
+}
+
+// projectClause returns the condition and arguments that restrict rows to
+// those visible in project (see app.InProject), or "" for no restriction.
+func projectClause(project string) (string, []any) {
+	if project == "" || project == app.AllProjects {
+		return "", nil
+	}
+	return " AND project IN ('', ?)", []any{project}
+}
+
+// UnreadCount implements app.StateQueries.
+func (s *Store) UnreadCount(agent, project string) (int, error) {
+	cond, args := projectClause(project)
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM messages WHERE read_flag = 0 AND to_agent IN (?, 'all')"+cond, append([]any{agent}, args...)...).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("unread count: %w", err)
+	}
+	return n, nil
+}
+
+// UnreadByRecipient implements app.StateQueries.
+func (s *Store) UnreadByRecipient(project string) (map[string]app.Backlog, error) {
+	cond, args := projectClause(project)
+	return s.backlog("SELECT to_agent, timestamp FROM messages WHERE read_flag = 0"+cond, args, "unread by recipient")
+}
+
+// PendingByAssignee implements app.StateQueries.
+func (s *Store) PendingByAssignee(project string) (map[string]app.Backlog, error) {
+	cond, args := projectClause(project)
+	return s.backlog("SELECT assigned_to, created_at FROM tasks WHERE status = 'pending'"+cond, args, "pending by assignee")
+}
+
+// AgentWorkspace implements app.StateQueries.
+func (s *Store) AgentWorkspace(agent string) (string, error) {
+	var ws string
+	err := s.db.QueryRow(`SELECT workspace FROM (
+	SELECT workspace, 0 AS pref FROM presence WHERE agent = ? AND workspace != ''
+	UNION ALL
+	SELECT workspace, 1 AS pref FROM registered_agents WHERE name = ? AND workspace != ''
+) ORDER BY pref LIMIT 1`, agent, agent).Scan(&ws)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("agent workspace: %w", err)
+	}
+	return ws, nil
+}
+
+// backlog aggregates (recipient, timestamp) rows. Timestamps are compared
+// after parsing because RFC3339Nano strings do not sort chronologically.
+func (s *Store) backlog(query string, args []any, context string) (map[string]app.Backlog, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", context, err)
+	}
+	defer rows.Close()
+	out := make(map[string]app.Backlog)
+	for rows.Next() {
+		var who, ts string
+		if err := rows.Scan(&who, &ts); err != nil {
+			return nil, err
+		}
+		t, err := parseTime(ts, context)
+		if err != nil {
+			return nil, err
+		}
+		b := out[who]
+		b.Count++
+		if t.After(b.Latest) {
+			b.Latest = t
+		}
+		out[who] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s iteration: %w", context, err)
+	}
+	return out, nil
+}
+
+// TaskStatusCounts implements app.StateQueries.
+func (s *Store) TaskStatusCounts(project string, assignees ...string) (map[string]int, error) {
+	query := "SELECT status, COUNT(*) FROM tasks WHERE 1 = 1"
+	args := stringArgs(assignees)
+	if len(assignees) > 0 {
+		query += " AND assigned_to IN (" + placeholders(len(assignees)) + ")"
+	}
+	cond, projectArgs := projectClause(project)
+	query += cond + " GROUP BY status"
+	rows, err := s.db.Query(query, append(args, projectArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("task status counts: %w", err)
+	}
+	defer rows.Close()
+	out := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("task status counts iteration: %w", err)
+	}
+	return out, nil
+}
+
+// TasksByStatus implements app.StateQueries.
+func (s *Store) TasksByStatus(statuses ...string) ([]domain.Task, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.Query("SELECT "+taskColumns+" FROM tasks WHERE status IN ("+placeholders(len(statuses))+") ORDER BY id", stringArgs(statuses)...)
+	if err != nil {
+		return nil, fmt.Errorf("tasks by status: %w", err)
+	}
+	defer rows.Close()
+	var out []domain.Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("tasks by status iteration: %w", err)
+	}
+	return out, nil
+}
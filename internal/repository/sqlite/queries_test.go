@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// loadOnly hides Store's query methods so app.QueriesFor falls back to
+// scanning a full Load; used as the reference implementation.
+type loadOnly struct{ s *Store }
+
+func (l loadOnly) Load() (*domain.CollabState, error) { return l.s.Load() }
+func (l loadOnly) Save(st *domain.CollabState) error  { return l.s.Save(st) }
+
+func queriesFixture(t *testing.T) *Store {
+	t.Helper()
+	s := newTestStore(t)
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	state := domain.NewCollabState()
+	state.Messages = []domain.Message{
+		{ID: 1, From: "cursor", To: "claude-code", Content: "a", Timestamp: base.Add(100 * time.Millisecond), Project: "/work/alpha"},
+		{ID: 2, From: "cursor", To: "claude-code", Content: "b", Timestamp: base.Add(120 * time.Millisecond), Project: "/work/beta"},
+		{ID: 3, From: "cursor", To: "all", Content: "c", Timestamp: base},
+		{ID: 4, From: "cursor", To: "codex", Content: "d", Timestamp: base, Read: true},
+	}
+	state.Tasks = []domain.Task{
+		{ID: 1, Title: "t1", Status: "pending", AssignedTo: "claude-code", CreatedAt: base, UpdatedAt: base, Project: "/work/alpha"},
+		{ID: 2, Title: "t2", Status: "pending", AssignedTo: "any", CreatedAt: base.Add(time.Second), UpdatedAt: base, Project: "/work/beta"},
+		{ID: 3, Title: "t3", Status: "cancelled", AssignedTo: "claude-code", CreatedAt: base, UpdatedAt: base},
+		{ID: 4, Title: "t4", Status: "completed", AssignedTo: "codex", CreatedAt: base, UpdatedAt: base},
+		{ID: 5, Title: "t5", Status: "in_progress", AssignedTo: "codex", CreatedAt: base, UpdatedAt: base},
+		{ID: 6, Title: "t6", Status: "scheduled", AssignedTo: "codex", CreatedAt: base, UpdatedAt: base, NotBefore: base.Add(time.Hour)},
+	}
+	state.Presence["claude-code"] = &domain.Presence{Agent: "claude-code", Status: "working", Workspace: "/work/alpha", LastSeen: base}
+	state.Presence["codex"] = &domain.Presence{Agent: "codex", Status: "idle", LastSeen: base}
+	state.RegisteredAgents["codex"] = &domain.RegisteredAgent{Name: "codex", Workspace: "/work/beta", RegisteredAt: base, LastSeen: base}
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return s
+}
+
+func TestQueries_MatchFullLoad(t *testing.T) {
+	s := queriesFixture(t)
+	ref := app.QueriesFor(loadOnly{s})
+	if _, ok := app.QueriesFor(s).(*Store); !ok {
+		t.Fatal("QueriesFor(*Store) should return the store itself")
+	}
+
+	for _, project := range []string{"", app.AllProjects, "/work/alpha", "/work/beta"} {
+		for _, agent := range []string{"claude-code", "codex", "nobody"} {
+			got, err := s.UnreadCount(agent, project)
+			want, _ := ref.UnreadCount(agent, project)
+			if err != nil || got != want {
+				t.Errorf("UnreadCount(%q, %q) = %d, %v; want %d", agent, project, got, err, want)
+			}
+		}
+
+		gotU, err := s.UnreadByRecipient(project)
+		wantU, _ := ref.UnreadByRecipient(project)
+		if err != nil || !backlogsEqual(gotU, wantU) {
+			t.Errorf("UnreadByRecipient(%q) = %v, %v; want %v", project, gotU, err, wantU)
+		}
+		gotP, err := s.PendingByAssignee(project)
+		wantP, _ := ref.PendingByAssignee(project)
+		if err != nil || !backlogsEqual(gotP, wantP) {
+			t.Errorf("PendingByAssignee(%q) = %v, %v; want %v", project, gotP, err, wantP)
+		}
+		if _, ok := gotP["codex"]; ok {
+			t.Error("PendingByAssignee counts a scheduled task; the worker manager would spawn for it")
+		}
+
+		for _, assignees := range [][]string{nil, {"claude-code", "any"}, {"codex"}} {
+			got, err := s.TaskStatusCounts(project, assignees...)
+			want, _ := ref.TaskStatusCounts(project, assignees...)
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("TaskStatusCounts(%q, %v) = %v, %v; want %v", project, assignees, got, err, want)
+			}
+		}
+	}
+	if n, _ := s.UnreadCount("claude-code", "/work/alpha"); n != 2 {
+		t.Errorf("unread for claude-code in /work/alpha = %d, want 2 (its message and the untagged broadcast)", n)
+	}
+
+	for agent, want := range map[string]string{"claude-code": "/work/alpha", "codex": "/work/beta", "nobody": ""} {
+		got, err := s.AgentWorkspace(agent)
+		refWS, _ := ref.AgentWorkspace(agent)
+		if err != nil || got != want || refWS != want {
+			t.Errorf("AgentWorkspace(%q) = %q, %v (full load %q); want %q", agent, got, err, refWS, want)
+		}
+	}
+
+	got, err := s.TasksByStatus("completed", "in_progress")
+	if err != nil || len(got) != 2 || got[0].ID != 4 || got[1].ID != 5 {
+		t.Errorf("TasksByStatus = %+v, %v; want tasks 4, 5", got, err)
+	}
+}
+
+func TestUnreadByRecipient_LatestIsChronological(t *testing.T) {
+	s := queriesFixture(t)
+	got, err := s.UnreadByRecipient("")
+	if err != nil {
+		t.Fatalf("UnreadByRecipient: %v", err)
+	}
+	// "…05.12Z" sorts before "…05.1Z" as text; Latest must still be the 120ms message.
+	want := time.Date(2026, 1, 2, 3, 4, 5, int(120*time.Millisecond), time.UTC)
+	if b := got["claude-code"]; b.Count != 2 || !b.Latest.Equal(want) {
+		t.Errorf("claude-code backlog = %+v, want count 2 latest %v", b, want)
+	}
+}
+
+func TestAgentBacklog(t *testing.T) {
+	s := queriesFixture(t)
+	// claude-code works in /work/alpha: the /work/beta message and task are not its backlog.
+	unread, pending, err := app.AgentBacklog(s, "claude-code", "")
+	if err != nil {
+		t.Fatalf("AgentBacklog: %v", err)
+	}
+	if unread != 2 || pending != 1 {
+		t.Errorf("unread=%d pending=%d, want 2, 1", unread, pending)
+	}
+	// Agents without a workspace fall back to the given one.
+	if unread, pending, _ = app.AgentBacklog(s, "nobody", "/work/beta"); unread != 1 || pending != 1 {
+		t.Errorf("fallback backlog unread=%d pending=%d, want 1, 1", unread, pending)
+	}
+}
+
+func backlogsEqual(a, b map[string]app.Backlog) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v.Count != w.Count || !v.Latest.Equal(w.Latest) {
+			return false
+		}
+	}
+	return true
+}
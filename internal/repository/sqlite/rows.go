@@ -0,0 +1,342 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// table describes how one slice of CollabState maps onto a SQLite table.
+// The first keyCols columns form the table's primary key.
+type table struct {
+	name    string
+	cols    []string
+	keyCols int
+	rows    func(*domain.CollabState) [][]any
+}
+
+// snapshotRow is the persisted form of one row: its primary-key values and a
+// fingerprint of all column values, used to detect changes between saves.
+type snapshotRow struct {
+	key         []any
+	fingerprint string
+	vals        []any
+}
+
+// snapshot maps table name -> encoded primary key -> row.
+type snapshot map[string]map[string]snapshotRow
+
+// tables lists every persisted table in write order.
+var tables = []table{
+	{
+		name:    "meta",
+		cols:    []string{"key", "value"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			return [][]any{
+				{"next_msg_id", fmt.Sprintf("%d", st.NextMsgID)},
+				{"next_task_id", fmt.Sprintf("%d", st.NextTaskID)},
+				{"next_note_id", fmt.Sprintf("%d", st.NextNoteID)},
+				{"active_plan_id", st.ActivePlanID},
+				{"driver_id", st.DriverID},
+			}
+		},
+	},
+	{
+		name:    "messages",
+		cols:    []string{"id", "from_agent", "to_agent", "content", "timestamp", "read_flag", "project"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.Messages))
+			for _, m := range st.Messages {
+				readFlag := 0
+				if m.Read {
+					readFlag = 1
+				}
+				out = append(out, []any{m.ID, m.From, m.To, m.Content, formatTime(m.Timestamp), readFlag, m.Project})
+			}
+			return out
+		},
+	},
+	{
+		name:    "tasks",
+		cols:    []string{"id", "title", "description", "status", "assigned_to", "created_by", "created_at", "updated_at", "priority", "blocked_by", "dependencies", "context_id", "worker_type", "capabilities", "result_summary", "expected_duration_sec", "progress_description", "progress_percent", "last_progress_at", "project", "parent_task_id", "attempts", "max_attempts", "result", "not_before", "schedule", "scheduled_from", "labels", "plan_id", "verify", "verification", "plan_item_id"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.Tasks))
+			for _, t := range st.Tasks {
+				out = append(out, []any{t.ID, t.Title, t.Description, t.Status, t.AssignedTo, t.CreatedBy, formatTime(t.CreatedAt), formatTime(t.UpdatedAt), t.Priority, t.BlockedBy, marshalJSON(t.Dependencies), t.ContextID, t.WorkerType, marshalJSON(t.Capabilities), t.ResultSummary, t.ExpectedDurationSec, t.ProgressDescription, t.ProgressPercent, formatOptionalTime(t.LastProgressAt), t.Project, t.ParentTaskID, marshalJSON(t.Attempts), t.MaxAttempts, marshalJSON(t.Result), formatOptionalTime(t.NotBefore), t.Schedule, t.ScheduledFrom, marshalJSON(t.Labels), t.PlanID, marshalJSON(t.Verify), marshalJSON(t.Verification), t.PlanItemID})
+			}
+			return out
+		},
+	},
+	{
+		name:    "presence",
+		cols:    []string{"agent", "status", "current_task_id", "note", "workspace", "last_seen"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.Presence))
+			for _, p := range st.Presence {
+				if p == nil {
+					continue
+				}
+				out = append(out, []any{p.Agent, p.Status, p.CurrentTaskID, p.Note, p.Workspace, formatTime(p.LastSeen)})
+			}
+			return out
+		},
+	},
+	{
+		name:    "session_notes",
+		cols:    []string{"id", "author", "content", "category", "timestamp", "project"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.SessionNotes))
+			for _, n := range st.SessionNotes {
+				out = append(out, []any{n.ID, n.Author, n.Content, n.Category, formatTime(n.Timestamp), n.Project})
+			}
+			return out
+		},
+	},
+	{
+		name:    "plans",
+		cols:    []string{"id", "title", "goal", "context", "created_by", "created_at", "updated_at", "status", "project"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.Plans))
+			for _, plan := range st.Plans {
+				if plan == nil {
+					continue
+				}
+				out = append(out, []any{plan.ID, plan.Title, plan.Goal, plan.Context, plan.CreatedBy, formatTime(plan.CreatedAt), formatTime(plan.UpdatedAt), plan.Status, plan.Project})
+			}
+			return out
+		},
+	},
+	{
+		name:    "plan_items",
+		cols:    []string{"plan_id", "item_id", "title", "description", "reasoning", "acceptance", "constraints", "status", "owner", "dependencies", "blockers", "notes", "priority", "updated_by", "updated_at"},
+		keyCols: 2,
+		rows: func(st *domain.CollabState) [][]any {
+			var out [][]any
+			for _, plan := range st.Plans {
+				if plan == nil {
+					continue
+				}
+				for _, item := range plan.Items {
+					out = append(out, []any{plan.ID, item.ID, item.Title, item.Description, item.Reasoning, marshalJSON(item.Acceptance), marshalJSON(item.Constraints), item.Status, item.Owner, marshalJSON(item.Dependencies), marshalJSON(item.Blockers), marshalJSON(item.Notes), item.Priority, item.UpdatedBy, formatTime(item.UpdatedAt)})
+				}
+			}
+			return out
+		},
+	},
+	{
+		name:    "agent_contexts",
+		cols:    []string{"agent", "last_checked_msg_id", "last_checked_task_id", "last_check_time"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.AgentContexts))
+			for _, ac := range st.AgentContexts {
+				if ac == nil {
+					continue
+				}
+				out = append(out, []any{ac.Agent, ac.LastCheckedMsgID, ac.LastCheckedTaskID, formatTime(ac.LastCheckTime)})
+			}
+			return out
+		},
+	},
+	{
+		name:    "file_locks",
+		cols:    []string{"project", "path", "locked_by", "reason", "locked_at", "expires_at"},
+		keyCols: 2,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.FileLocks))
+			for _, fl := range st.FileLocks {
+				if fl == nil {
+					continue
+				}
+				out = append(out, []any{fl.Project, fl.Path, fl.LockedBy, fl.Reason, formatTime(fl.LockedAt), formatTime(fl.ExpiresAt)})
+			}
+			return out
+		},
+	},
+	{
+		name:    "agent_instances",
+		cols:    []string{"instance_id", "agent_type", "role", "capabilities", "max_tasks", "status", "current_tasks", "workspace", "last_heartbeat", "progress", "progress_step", "progress_total_steps", "progress_updated_at"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.AgentInstances))
+			for _, ai := range st.AgentInstances {
+				if ai == nil {
+					continue
+				}
+				out = append(out, []any{ai.InstanceID, ai.AgentType, string(ai.Role), marshalJSON(ai.Capabilities), ai.MaxTasks, ai.Status, marshalJSON(ai.CurrentTasks), ai.Workspace, formatTime(ai.LastHeartbeat), ai.Progress, ai.ProgressStep, ai.ProgressTotalSteps, formatOptionalTime(ai.ProgressUpdatedAt)})
+			}
+			return out
+		},
+	},
+	{
+		name:    "work_contexts",
+		cols:    []string{"id", "task_id", "relevant_files", "background", "constraints", "shared_notes", "parent_ctx_id"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.WorkContexts))
+			for _, wc := range st.WorkContexts {
+				if wc == nil {
+					continue
+				}
+				out = append(out, []any{wc.ID, wc.TaskID, marshalJSON(wc.RelevantFiles), wc.Background, marshalJSON(wc.Constraints), marshalJSON(wc.SharedNotes), wc.ParentCtxID})
+			}
+			return out
+		},
+	},
+	{
+		name:    "registered_agents",
+		cols:    []string{"name", "display_name", "capabilities", "workspace", "project", "registered_at", "last_seen"},
+		keyCols: 1,
+		rows: func(st *domain.CollabState) [][]any {
+			out := make([][]any, 0, len(st.RegisteredAgents))
+			for _, ra := range st.RegisteredAgents {
+				if ra == nil {
+					continue
+				}
+				out = append(out, []any{ra.Name, ra.DisplayName, marshalJSON(ra.Capabilities), ra.Workspace, ra.Project, formatTime(ra.RegisteredAt), formatTime(ra.LastSeen)})
+			}
+			return out
+		},
+	},
+}
+
+// formatTime formats t as RFC3339Nano, the format used for every timestamp column.
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatOptionalTime is formatTime, but stores the zero time as an empty string.
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return formatTime(t)
+}
+
+// marshalJSON encodes v for a JSON text column.
+func marshalJSON(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// encodeValues renders column values into a comparable string.
+func encodeValues(vals []any) string {
+	var b strings.Builder
+	for _, v := range vals {
+		switch v := v.(type) {
+		case string:
+			// Length-prefix strings so separators inside values can't collide.
+			b.WriteString(strconv.Itoa(len(v)))
+			b.WriteByte(':')
+			b.WriteString(v)
+		case int:
+			b.WriteString(strconv.Itoa(v))
+		default:
+			fmt.Fprint(&b, v)
+		}
+		b.WriteByte(0x1f)
+	}
+	return b.String()
+}
+
+// snapshotOf computes the rows that state persists to.
+func snapshotOf(state *domain.CollabState) snapshot {
+	snap := make(snapshot, len(tables))
+	for _, t := range tables {
+		rows := t.rows(state)
+		m := make(map[string]snapshotRow, len(rows))
+		for _, vals := range rows {
+			key := vals[:t.keyCols]
+			m[encodeValues(key)] = snapshotRow{key: key, fingerprint: encodeValues(vals), vals: vals}
+		}
+		snap[t.name] = m
+	}
+	return snap
+}
+
+// upsertSQL returns the INSERT OR REPLACE statement for t.
+func (t table) upsertSQL() string {
+	return fmt.Sprintf("INSERT OR REPLACE INTO %s (%s) VALUES (%s)", t.name, strings.Join(t.cols, ", "), placeholders(len(t.cols)))
+}
+
+// deleteSQL returns the DELETE-by-primary-key statement for t.
+func (t table) deleteSQL() string {
+	conds := make([]string, t.keyCols)
+	for i := range conds {
+		conds[i] = t.cols[i] + " = ?"
+	}
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, strings.Join(conds, " AND "))
+}
+
+// writeFull replaces the contents of every table with snap.
+func writeFull(tx *sql.Tx, snap snapshot) error {
+	for _, t := range tables {
+		if _, err := tx.Exec("DELETE FROM " + t.name); err != nil {
+			return err
+		}
+		if err := upsertRows(tx, t, snap[t.name], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDiff writes only the rows that differ between prev and next:
+// new or changed rows are upserted, rows missing from next are deleted.
+func writeDiff(tx *sql.Tx, prev, next snapshot) error {
+	for _, t := range tables {
+		if err := upsertRows(tx, t, next[t.name], prev[t.name]); err != nil {
+			return err
+		}
+		var stmt *sql.Stmt
+		for k, old := range prev[t.name] {
+			if _, ok := next[t.name][k]; ok {
+				continue
+			}
+			if stmt == nil {
+				var err error
+				if stmt, err = tx.Prepare(t.deleteSQL()); err != nil {
+					return fmt.Errorf("%s: %w", t.name, err)
+				}
+				defer stmt.Close()
+			}
+			if _, err := stmt.Exec(old.key...); err != nil {
+				return fmt.Errorf("%s delete: %w", t.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// upsertRows writes every row in rows whose fingerprint differs from prev.
+// A nil prev writes all rows.
+func upsertRows(tx *sql.Tx, t table, rows, prev map[string]snapshotRow) error {
+	var stmt *sql.Stmt
+	for k, r := range rows {
+		if old, ok := prev[k]; ok && old.fingerprint == r.fingerprint {
+			continue
+		}
+		if stmt == nil {
+			var err error
+			if stmt, err = tx.Prepare(t.upsertSQL()); err != nil {
+				return fmt.Errorf("%s: %w", t.name, err)
+			}
+			defer stmt.Close()
+		}
+		if _, err := stmt.Exec(r.vals...); err != nil {
+			return fmt.Errorf("%s upsert: %w", t.name, err)
+		}
+	}
+	return nil
+}
@@ -1,12 +1,12 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -15,227 +15,34 @@ import (
 	"github.com/jaakkos/stringwork/internal/domain"
 )
 
-const schema = `
-CREATE TABLE IF NOT EXISTS messages (
-	id INTEGER PRIMARY KEY,
-	from_agent TEXT NOT NULL,
-	to_agent TEXT NOT NULL,
-	content TEXT NOT NULL,
-	timestamp TEXT NOT NULL,
-	read_flag INTEGER NOT NULL DEFAULT 0
-);
-CREATE TABLE IF NOT EXISTS tasks (
-	id INTEGER PRIMARY KEY,
-	title TEXT NOT NULL,
-	description TEXT NOT NULL,
-	status TEXT NOT NULL,
-	assigned_to TEXT NOT NULL,
-	created_by TEXT NOT NULL,
-	created_at TEXT NOT NULL,
-	updated_at TEXT NOT NULL,
-	priority INTEGER NOT NULL DEFAULT 3,
-	blocked_by TEXT NOT NULL DEFAULT '',
-	dependencies TEXT NOT NULL DEFAULT '[]',
-	context_id TEXT NOT NULL DEFAULT '',
-	worker_type TEXT NOT NULL DEFAULT '',
-	capabilities TEXT NOT NULL DEFAULT '[]',
-	result_summary TEXT NOT NULL DEFAULT '',
-	expected_duration_sec INTEGER NOT NULL DEFAULT 0,
-	progress_description TEXT NOT NULL DEFAULT '',
-	progress_percent INTEGER NOT NULL DEFAULT 0,
-	last_progress_at TEXT NOT NULL DEFAULT ''
-);
-CREATE TABLE IF NOT EXISTS agent_instances (
-	instance_id TEXT PRIMARY KEY,
-	agent_type TEXT NOT NULL,
-	role TEXT NOT NULL,
-	capabilities TEXT NOT NULL DEFAULT '[]',
-	max_tasks INTEGER NOT NULL DEFAULT 1,
-	status TEXT NOT NULL DEFAULT 'offline',
-	current_tasks TEXT NOT NULL DEFAULT '[]',
-	workspace TEXT NOT NULL DEFAULT '',
-	last_heartbeat TEXT NOT NULL,
-	progress TEXT NOT NULL DEFAULT '',
-	progress_step INTEGER NOT NULL DEFAULT 0,
-	progress_total_steps INTEGER NOT NULL DEFAULT 0,
-	progress_updated_at TEXT NOT NULL DEFAULT ''
-);
-CREATE TABLE IF NOT EXISTS work_contexts (
-	id TEXT PRIMARY KEY,
-	task_id INTEGER NOT NULL,
-	relevant_files TEXT NOT NULL DEFAULT '[]',
-	background TEXT NOT NULL DEFAULT '',
-	constraints TEXT NOT NULL DEFAULT '[]',
-	shared_notes TEXT NOT NULL DEFAULT '{}',
-	parent_ctx_id TEXT NOT NULL DEFAULT ''
-);
-CREATE TABLE IF NOT EXISTS presence (
-	agent TEXT PRIMARY KEY,
-	status TEXT NOT NULL,
-	current_task_id INTEGER NOT NULL DEFAULT 0,
-	note TEXT NOT NULL DEFAULT '',
-	workspace TEXT NOT NULL DEFAULT '',
-	last_seen TEXT NOT NULL
-);
-CREATE TABLE IF NOT EXISTS session_notes (
-	id INTEGER PRIMARY KEY,
-	author TEXT NOT NULL,
-	content TEXT NOT NULL,
-	category TEXT NOT NULL,
-	timestamp TEXT NOT NULL
-);
-CREATE TABLE IF NOT EXISTS plans (
-	id TEXT PRIMARY KEY,
-	title TEXT NOT NULL,
-	goal TEXT NOT NULL,
-	context TEXT NOT NULL,
-	created_by TEXT NOT NULL,
-	created_at TEXT NOT NULL,
-	updated_at TEXT NOT NULL,
-	status TEXT NOT NULL
-);
-CREATE TABLE IF NOT EXISTS plan_items (
-	plan_id TEXT NOT NULL,
-	item_id TEXT NOT NULL,
-	title TEXT NOT NULL,
-	description TEXT NOT NULL,
-	reasoning TEXT NOT NULL DEFAULT '',
-	acceptance TEXT NOT NULL DEFAULT '[]',
-	constraints TEXT NOT NULL DEFAULT '[]',
-	status TEXT NOT NULL,
-	owner TEXT NOT NULL,
-	dependencies TEXT NOT NULL DEFAULT '[]',
-	blockers TEXT NOT NULL DEFAULT '[]',
-	notes TEXT NOT NULL DEFAULT '[]',
-	priority INTEGER NOT NULL DEFAULT 2,
-	updated_by TEXT NOT NULL,
-	updated_at TEXT NOT NULL,
-	PRIMARY KEY (plan_id, item_id),
-	FOREIGN KEY (plan_id) REFERENCES plans(id)
-);
-CREATE TABLE IF NOT EXISTS agent_contexts (
-	agent TEXT PRIMARY KEY,
-	last_checked_msg_id INTEGER NOT NULL DEFAULT 0,
-	last_checked_task_id INTEGER NOT NULL DEFAULT 0,
-	last_check_time TEXT NOT NULL
-);
-CREATE TABLE IF NOT EXISTS file_locks (
-	path TEXT PRIMARY KEY,
-	locked_by TEXT NOT NULL,
-	reason TEXT NOT NULL,
-	locked_at TEXT NOT NULL,
-	expires_at TEXT NOT NULL
-);
-CREATE TABLE IF NOT EXISTS registered_agents (
-	name TEXT PRIMARY KEY,
-	display_name TEXT NOT NULL DEFAULT '',
-	capabilities TEXT NOT NULL DEFAULT '[]',
-	workspace TEXT NOT NULL DEFAULT '',
-	project TEXT NOT NULL DEFAULT '',
-	registered_at TEXT NOT NULL,
-	last_seen TEXT NOT NULL
-);
-CREATE TABLE IF NOT EXISTS meta (
-	key TEXT PRIMARY KEY,
-	value TEXT NOT NULL
-);
-`
-
-// migrations add columns/tables that may not exist in older databases.
-// Errors are ignored when the column/table already exists.
-
-// indexes for common query patterns (read_messages, list_tasks, notifications)
-const indexes = `
-CREATE INDEX IF NOT EXISTS idx_messages_to_read ON messages(to_agent, read_flag);
-CREATE INDEX IF NOT EXISTS idx_tasks_status_assigned ON tasks(status, assigned_to);
-`
-
 // Store implements app.StateRepository using SQLite.
 type Store struct {
 	db *sql.DB
+
+	mu       sync.Mutex
+	snapshot snapshot // rows as of the last Load or Save; nil forces a full rewrite
 }
 
-// New opens the SQLite database at path (creating parent dirs and schema) and returns a StateRepository.
+// dsnParams configures every pooled connection: wait up to 10s for another
+// process's write lock instead of failing with SQLITE_BUSY, use WAL so readers
+// never block writers, and start write transactions with BEGIN IMMEDIATE so
+// the lock is taken before the state is read (see Update).
+const dsnParams = "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+// New opens the SQLite database at path (creating parent dirs and migrating the schema) and returns a StateRepository.
 func New(path string) (app.StateRepository, error) {
-	dir := filepath.Dir(path)
-	if dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("sqlite mkdir: %w", err)
-		}
+	// Bring the schema up to date first (see migrate.go); this backs up and
+	// upgrades databases written by older versions.
+	if _, err := Migrate(path, false); err != nil {
+		return nil, err
 	}
-	db, err := sql.Open("sqlite", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	db, err := sql.Open("sqlite", path+dsnParams)
 	if err != nil {
 		return nil, fmt.Errorf("sqlite open: %w", err)
 	}
-	if _, err := db.Exec(schema); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("sqlite schema: %w", err)
-	}
-	if _, err := db.Exec(indexes); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("sqlite indexes: %w", err)
-	}
-	// Run migrations for existing databases (ignore errors for already-applied migrations).
-	_ = runMigrations(db)
 	return &Store{db: db}, nil
 }
 
-// runMigrations applies schema migrations for older databases. Errors are
-// silently ignored because some may already be applied.
-func runMigrations(db *sql.DB) error {
-	_, _ = db.Exec("ALTER TABLE presence ADD COLUMN workspace TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN context_id TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN worker_type TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN capabilities TEXT NOT NULL DEFAULT '[]'")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN result_summary TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN expected_duration_sec INTEGER NOT NULL DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN progress_description TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN progress_percent INTEGER NOT NULL DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE tasks ADD COLUMN last_progress_at TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec(schemaAgentInstances)
-	_, _ = db.Exec("ALTER TABLE agent_instances ADD COLUMN progress TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec("ALTER TABLE agent_instances ADD COLUMN progress_step INTEGER NOT NULL DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE agent_instances ADD COLUMN progress_total_steps INTEGER NOT NULL DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE agent_instances ADD COLUMN progress_updated_at TEXT NOT NULL DEFAULT ''")
-	_, _ = db.Exec(schemaWorkContexts)
-	_, _ = db.Exec(schemaRegisteredAgents)
-	return nil
-}
-
-const schemaRegisteredAgents = `
-CREATE TABLE IF NOT EXISTS registered_agents (
-	name TEXT PRIMARY KEY,
-	display_name TEXT NOT NULL DEFAULT '',
-	capabilities TEXT NOT NULL DEFAULT '[]',
-	workspace TEXT NOT NULL DEFAULT '',
-	project TEXT NOT NULL DEFAULT '',
-	registered_at TEXT NOT NULL,
-	last_seen TEXT NOT NULL
-)`
-
-const schemaAgentInstances = `
-CREATE TABLE IF NOT EXISTS agent_instances (
-	instance_id TEXT PRIMARY KEY,
-	agent_type TEXT NOT NULL,
-	role TEXT NOT NULL,
-	capabilities TEXT NOT NULL DEFAULT '[]',
-	max_tasks INTEGER NOT NULL DEFAULT 1,
-	status TEXT NOT NULL DEFAULT 'offline',
-	current_tasks TEXT NOT NULL DEFAULT '[]',
-	workspace TEXT NOT NULL DEFAULT '',
-	last_heartbeat TEXT NOT NULL
-)`
-const schemaWorkContexts = `
-CREATE TABLE IF NOT EXISTS work_contexts (
-	id TEXT PRIMARY KEY,
-	task_id INTEGER NOT NULL,
-	relevant_files TEXT NOT NULL DEFAULT '[]',
-	background TEXT NOT NULL DEFAULT '',
-	constraints TEXT NOT NULL DEFAULT '[]',
-	shared_notes TEXT NOT NULL DEFAULT '{}',
-	parent_ctx_id TEXT NOT NULL DEFAULT ''
-)`
-
 // Close releases the database connection. Call on shutdown for clean exit.
 func (s *Store) Close() error {
 	if s.db == nil {
@@ -268,11 +75,98 @@ func parseJSON(b []byte, v interface{}, context string) error {
 	return nil
 }
 
+// taskColumns is the column list scanTask expects, in order.
+const taskColumns = "id, title, description, status, assigned_to, created_by, created_at, updated_at, priority, blocked_by, dependencies, context_id, worker_type, capabilities, result_summary, expected_duration_sec, progress_description, progress_percent, last_progress_at, project, parent_task_id, attempts, max_attempts, result, not_before, schedule, scheduled_from, labels, plan_id, verify, verification, plan_item_id"
+
+// scanTask scans one row selected with taskColumns.
+func scanTask(rows *sql.Rows) (domain.Task, error) {
+	var t domain.Task
+	var ca, ua, deps, caps, lastProgressAt, attempts, result, notBefore, labels, verify, verification string
+	if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &t.AssignedTo, &t.CreatedBy, &ca, &ua, &t.Priority, &t.BlockedBy, &deps, &t.ContextID, &t.WorkerType, &caps, &t.ResultSummary, &t.ExpectedDurationSec, &t.ProgressDescription, &t.ProgressPercent, &lastProgressAt, &t.Project, &t.ParentTaskID, &attempts, &t.MaxAttempts, &result, &notBefore, &t.Schedule, &t.ScheduledFrom, &labels, &t.PlanID, &verify, &verification, &t.PlanItemID); err != nil {
+		return t, err
+	}
+	var err error
+	if t.CreatedAt, err = parseTime(ca, "tasks"); err != nil {
+		return t, err
+	}
+	if t.UpdatedAt, err = parseTime(ua, "tasks"); err != nil {
+		return t, err
+	}
+	if lastProgressAt != "" {
+		if t.LastProgressAt, err = parseTime(lastProgressAt, "tasks last_progress_at"); err != nil {
+			t.LastProgressAt = time.Time{}
+		}
+	}
+	if notBefore != "" {
+		if t.NotBefore, err = parseTime(notBefore, "tasks not_before"); err != nil {
+			return t, err
+		}
+	}
+	if err := parseJSON([]byte(deps), &t.Dependencies, "tasks dependencies"); err != nil {
+		return t, err
+	}
+	if caps != "" && caps != "[]" {
+		_ = parseJSON([]byte(caps), &t.Capabilities, "tasks capabilities")
+	}
+	if labels != "" && labels != "[]" && labels != "null" {
+		if err := parseJSON([]byte(labels), &t.Labels, "tasks labels"); err != nil {
+			return t, err
+		}
+	}
+	if attempts != "" && attempts != "[]" && attempts != "null" {
+		if err := parseJSON([]byte(attempts), &t.Attempts, "tasks attempts"); err != nil {
+			return t, err
+		}
+	}
+	if result != "" && result != "null" {
+		if err := parseJSON([]byte(result), &t.Result, "tasks result"); err != nil {
+			return t, err
+		}
+	}
+	if verify != "" && verify != "[]" && verify != "null" {
+		if err := parseJSON([]byte(verify), &t.Verify, "tasks verify"); err != nil {
+			return t, err
+		}
+	}
+	if verification != "" && verification != "null" {
+		if err := parseJSON([]byte(verification), &t.Verification, "tasks verification"); err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
 // Load implements app.StateRepository.
 func (s *Store) Load() (*domain.CollabState, error) {
+	// A read transaction gives every table the same snapshot even while
+	// another process is writing.
+	tx, err := s.db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin read: %w", err)
+	}
+	defer tx.Rollback()
+	state, err := loadState(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.snapshot = snapshotOf(state)
+	s.mu.Unlock()
+
+	return state, nil
+}
+
+// querier is the read side shared by *sql.DB and *sql.Tx.
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// loadState reads the full state through q.
+func loadState(q querier) (*domain.CollabState, error) {
 	state := domain.NewCollabState()
 
-	rows, err := s.db.Query("SELECT key, value FROM meta")
+	rows, err := q.Query("SELECT key, value FROM meta")
 	if err != nil {
 		return nil, fmt.Errorf("meta: %w", err)
 	}
@@ -311,7 +205,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		state.DriverID = v
 	}
 
-	rows, err = s.db.Query("SELECT id, from_agent, to_agent, content, timestamp, read_flag FROM messages ORDER BY id")
+	rows, err = q.Query("SELECT id, from_agent, to_agent, content, timestamp, read_flag, project FROM messages ORDER BY id")
 	if err != nil {
 		return nil, fmt.Errorf("messages: %w", err)
 	}
@@ -319,7 +213,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		var m domain.Message
 		var ts string
 		var readFlag int
-		if err := rows.Scan(&m.ID, &m.From, &m.To, &m.Content, &ts, &readFlag); err != nil {
+		if err := rows.Scan(&m.ID, &m.From, &m.To, &m.Content, &ts, &readFlag, &m.Project); err != nil {
 			_ = rows.Close()
 			return nil, err
 		}
@@ -337,42 +231,16 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("messages iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT id, title, description, status, assigned_to, created_by, created_at, updated_at, priority, blocked_by, dependencies, context_id, worker_type, capabilities, result_summary, expected_duration_sec, progress_description, progress_percent, last_progress_at FROM tasks ORDER BY id")
+	rows, err = q.Query("SELECT " + taskColumns + " FROM tasks ORDER BY id")
 	if err != nil {
 		return nil, fmt.Errorf("tasks: %w", err)
 	}
 	for rows.Next() {
-		var t domain.Task
-		var ca, ua, deps, contextID, workerType, caps, resultSummary, progressDesc, lastProgressAt string
-		if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &t.AssignedTo, &t.CreatedBy, &ca, &ua, &t.Priority, &t.BlockedBy, &deps, &contextID, &workerType, &caps, &resultSummary, &t.ExpectedDurationSec, &progressDesc, &t.ProgressPercent, &lastProgressAt); err != nil {
-			_ = rows.Close()
-			return nil, err
-		}
-		t.ContextID = contextID
-		t.WorkerType = workerType
-		t.ResultSummary = resultSummary
-		t.ProgressDescription = progressDesc
-		var err error
-		if t.CreatedAt, err = parseTime(ca, "tasks"); err != nil {
-			_ = rows.Close()
-			return nil, err
-		}
-		if t.UpdatedAt, err = parseTime(ua, "tasks"); err != nil {
-			_ = rows.Close()
-			return nil, err
-		}
-		if lastProgressAt != "" {
-			if t.LastProgressAt, err = parseTime(lastProgressAt, "tasks last_progress_at"); err != nil {
-				t.LastProgressAt = time.Time{}
-			}
-		}
-		if err := parseJSON([]byte(deps), &t.Dependencies, "tasks dependencies"); err != nil {
+		t, err := scanTask(rows)
+		if err != nil {
 			_ = rows.Close()
 			return nil, err
 		}
-		if caps != "" && caps != "[]" {
-			_ = parseJSON([]byte(caps), &t.Capabilities, "tasks capabilities")
-		}
 		state.Tasks = append(state.Tasks, t)
 	}
 	_ = rows.Close()
@@ -380,7 +248,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("tasks iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT agent, status, current_task_id, note, workspace, last_seen FROM presence")
+	rows, err = q.Query("SELECT agent, status, current_task_id, note, workspace, last_seen FROM presence")
 	if err != nil {
 		return nil, fmt.Errorf("presence: %w", err)
 	}
@@ -404,14 +272,14 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("presence iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT id, author, content, category, timestamp FROM session_notes ORDER BY id")
+	rows, err = q.Query("SELECT id, author, content, category, timestamp, project FROM session_notes ORDER BY id")
 	if err != nil {
 		return nil, fmt.Errorf("session_notes: %w", err)
 	}
 	for rows.Next() {
 		var n domain.SessionNote
 		var ts string
-		if err := rows.Scan(&n.ID, &n.Author, &n.Content, &n.Category, &ts); err != nil {
+		if err := rows.Scan(&n.ID, &n.Author, &n.Content, &n.Category, &ts, &n.Project); err != nil {
 			_ = rows.Close()
 			return nil, err
 		}
@@ -428,14 +296,14 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("session_notes iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT id, title, goal, context, created_by, created_at, updated_at, status FROM plans")
+	rows, err = q.Query("SELECT id, title, goal, context, created_by, created_at, updated_at, status, project FROM plans")
 	if err != nil {
 		return nil, fmt.Errorf("plans: %w", err)
 	}
 	for rows.Next() {
 		var plan domain.Plan
 		var ca, ua string
-		if err := rows.Scan(&plan.ID, &plan.Title, &plan.Goal, &plan.Context, &plan.CreatedBy, &ca, &ua, &plan.Status); err != nil {
+		if err := rows.Scan(&plan.ID, &plan.Title, &plan.Goal, &plan.Context, &plan.CreatedBy, &ca, &ua, &plan.Status, &plan.Project); err != nil {
 			_ = rows.Close()
 			return nil, err
 		}
@@ -456,7 +324,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("plans iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT plan_id, item_id, title, description, reasoning, acceptance, constraints, status, owner, dependencies, blockers, notes, priority, updated_by, updated_at FROM plan_items ORDER BY plan_id, item_id")
+	rows, err = q.Query("SELECT plan_id, item_id, title, description, reasoning, acceptance, constraints, status, owner, dependencies, blockers, notes, priority, updated_by, updated_at FROM plan_items ORDER BY plan_id, item_id")
 	if err != nil {
 		return nil, fmt.Errorf("plan_items: %w", err)
 	}
@@ -499,7 +367,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("plan_items iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT agent, last_checked_msg_id, last_checked_task_id, last_check_time FROM agent_contexts")
+	rows, err = q.Query("SELECT agent, last_checked_msg_id, last_checked_task_id, last_check_time FROM agent_contexts")
 	if err != nil {
 		return nil, fmt.Errorf("agent_contexts: %w", err)
 	}
@@ -523,14 +391,14 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		return nil, fmt.Errorf("agent_contexts iteration: %w", err)
 	}
 
-	rows, err = s.db.Query("SELECT path, locked_by, reason, locked_at, expires_at FROM file_locks")
+	rows, err = q.Query("SELECT path, locked_by, reason, locked_at, expires_at, project FROM file_locks")
 	if err != nil {
 		return nil, fmt.Errorf("file_locks: %w", err)
 	}
 	for rows.Next() {
 		var fl domain.FileLock
 		var la, ex string
-		if err := rows.Scan(&fl.Path, &fl.LockedBy, &fl.Reason, &la, &ex); err != nil {
+		if err := rows.Scan(&fl.Path, &fl.LockedBy, &fl.Reason, &la, &ex, &fl.Project); err != nil {
 			_ = rows.Close()
 			return nil, err
 		}
@@ -546,7 +414,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 			return nil, err
 		}
 		fl.ExpiresAt = exAt
-		state.FileLocks[fl.Path] = &fl
+		state.FileLocks[app.FileLockKey(fl.Project, fl.Path)] = &fl
 	}
 	_ = rows.Close()
 	if err := rows.Err(); err != nil {
@@ -575,7 +443,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 	}
 
 	// agent_instances (table may not exist in very old DBs; only skip "no such table")
-	rows, err = s.db.Query("SELECT instance_id, agent_type, role, capabilities, max_tasks, status, current_tasks, workspace, last_heartbeat, progress, progress_step, progress_total_steps, progress_updated_at FROM agent_instances")
+	rows, err = q.Query("SELECT instance_id, agent_type, role, capabilities, max_tasks, status, current_tasks, workspace, last_heartbeat, progress, progress_step, progress_total_steps, progress_updated_at FROM agent_instances")
 	if err != nil && !isNoSuchTableErr(err) {
 		return nil, fmt.Errorf("agent_instances: %w", err)
 	}
@@ -613,7 +481,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 	}
 
 	// work_contexts (table may not exist in very old DBs; only skip "no such table")
-	rows, err = s.db.Query("SELECT id, task_id, relevant_files, background, constraints, shared_notes, parent_ctx_id FROM work_contexts")
+	rows, err = q.Query("SELECT id, task_id, relevant_files, background, constraints, shared_notes, parent_ctx_id FROM work_contexts")
 	if err != nil && !isNoSuchTableErr(err) {
 		return nil, fmt.Errorf("work_contexts: %w", err)
 	}
@@ -649,7 +517,7 @@ func (s *Store) Load() (*domain.CollabState, error) {
 	}
 
 	// registered_agents (table may not exist in very old DBs; only skip "no such table")
-	rows, err = s.db.Query("SELECT name, display_name, capabilities, workspace, project, registered_at, last_seen FROM registered_agents")
+	rows, err = q.Query("SELECT name, display_name, capabilities, workspace, project, registered_at, last_seen FROM registered_agents")
 	if err != nil && !isNoSuchTableErr(err) {
 		return nil, fmt.Errorf("registered_agents: %w", err)
 	}
@@ -681,160 +549,97 @@ func (s *Store) Load() (*domain.CollabState, error) {
 		}
 	}
 
+	if err := loadArchivedDependencies(q, state); err != nil {
+		return nil, err
+	}
 	return state, nil
 }
 
 // Save implements app.StateRepository.
+//
+// Save is incremental: it compares the state against the rows seen by the
+// most recent Load or Save on this Store and only writes rows that were
+// added or changed, and deletes rows that disappeared. Without such a
+// snapshot (first Save on a fresh Store) every table is replaced in full.
 func (s *Store) Save(state *domain.CollabState) error {
 	if state == nil {
 		return fmt.Errorf("state is nil")
 	}
+	next := snapshotOf(state)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	for _, t := range []string{"messages", "tasks", "presence", "session_notes", "plan_items", "plans", "agent_contexts", "file_locks", "agent_instances", "work_contexts", "registered_agents", "meta"} {
-		if _, err := tx.Exec("DELETE FROM " + t); err != nil {
-			return err
-		}
+	if s.snapshot == nil {
+		err = writeFull(tx, next)
+	} else {
+		err = writeDiff(tx, s.snapshot, next)
 	}
-
-	meta := map[string]string{
-		"next_msg_id":    fmt.Sprintf("%d", state.NextMsgID),
-		"next_task_id":   fmt.Sprintf("%d", state.NextTaskID),
-		"next_note_id":   fmt.Sprintf("%d", state.NextNoteID),
-		"active_plan_id": state.ActivePlanID,
-		"driver_id":      state.DriverID,
+	if err != nil {
+		return err
 	}
-	for k, v := range meta {
-		if _, err := tx.Exec("INSERT INTO meta (key, value) VALUES (?, ?)", k, v); err != nil {
-			return err
-		}
+	if err := appendEvents(tx, state.PendingEvents); err != nil {
+		return err
 	}
-
-	for _, m := range state.Messages {
-		readFlag := 0
-		if m.Read {
-			readFlag = 1
-		}
-		if _, err := tx.Exec("INSERT INTO messages (id, from_agent, to_agent, content, timestamp, read_flag) VALUES (?, ?, ?, ?, ?, ?)",
-			m.ID, m.From, m.To, m.Content, m.Timestamp.Format(time.RFC3339Nano), readFlag); err != nil {
-			return err
-		}
+	if err := archiveTasks(tx, state.PendingArchive); err != nil {
+		return err
 	}
-
-	for _, t := range state.Tasks {
-		deps, _ := json.Marshal(t.Dependencies)
-		caps, _ := json.Marshal(t.Capabilities)
-		lastProgressAt := ""
-		if !t.LastProgressAt.IsZero() {
-			lastProgressAt = t.LastProgressAt.Format(time.RFC3339Nano)
-		}
-		if _, err := tx.Exec("INSERT INTO tasks (id, title, description, status, assigned_to, created_by, created_at, updated_at, priority, blocked_by, dependencies, context_id, worker_type, capabilities, result_summary, expected_duration_sec, progress_description, progress_percent, last_progress_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
-			t.ID, t.Title, t.Description, t.Status, t.AssignedTo, t.CreatedBy, t.CreatedAt.Format(time.RFC3339Nano), t.UpdatedAt.Format(time.RFC3339Nano), t.Priority, t.BlockedBy, string(deps), t.ContextID, t.WorkerType, string(caps), t.ResultSummary, t.ExpectedDurationSec, t.ProgressDescription, t.ProgressPercent, lastProgressAt); err != nil {
-			return err
-		}
+	if err := storeArtifacts(tx, state.PendingArtifacts); err != nil {
+		return err
 	}
-
-	for _, p := range state.Presence {
-		if p == nil {
-			continue
-		}
-		if _, err := tx.Exec("INSERT INTO presence (agent, status, current_task_id, note, workspace, last_seen) VALUES (?, ?, ?, ?, ?, ?)",
-			p.Agent, p.Status, p.CurrentTaskID, p.Note, p.Workspace, p.LastSeen.Format(time.RFC3339Nano)); err != nil {
-			return err
-		}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	s.snapshot = next
+	return nil
+}
 
-	for _, n := range state.SessionNotes {
-		if _, err := tx.Exec("INSERT INTO session_notes (id, author, content, category, timestamp) VALUES (?, ?, ?, ?, ?)",
-			n.ID, n.Author, n.Content, n.Category, n.Timestamp.Format(time.RFC3339Nano)); err != nil {
-			return err
-		}
-	}
+var _ app.AtomicUpdater = (*Store)(nil)
 
-	for _, plan := range state.Plans {
-		if plan == nil {
-			continue
-		}
-		if _, err := tx.Exec("INSERT INTO plans (id, title, goal, context, created_by, created_at, updated_at, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-			plan.ID, plan.Title, plan.Goal, plan.Context, plan.CreatedBy, plan.CreatedAt.Format(time.RFC3339Nano), plan.UpdatedAt.Format(time.RFC3339Nano), plan.Status); err != nil {
-			return err
-		}
-		for _, item := range plan.Items {
-			acc, _ := json.Marshal(item.Acceptance)
-			con, _ := json.Marshal(item.Constraints)
-			deps, _ := json.Marshal(item.Dependencies)
-			block, _ := json.Marshal(item.Blockers)
-			notes, _ := json.Marshal(item.Notes)
-			if _, err := tx.Exec("INSERT INTO plan_items (plan_id, item_id, title, description, reasoning, acceptance, constraints, status, owner, dependencies, blockers, notes, priority, updated_by, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
-				plan.ID, item.ID, item.Title, item.Description, item.Reasoning, string(acc), string(con), item.Status, item.Owner, string(deps), string(block), string(notes), item.Priority, item.UpdatedBy, item.UpdatedAt.Format(time.RFC3339Nano)); err != nil {
-				return err
-			}
-		}
+// Update implements app.AtomicUpdater. It loads the state, runs fn and writes
+// the changed rows (plus fn's pending events) in one BEGIN IMMEDIATE
+// transaction. Processes sharing the database file therefore take turns:
+// a second writer waits on the lock (busy_timeout) and then sees the first
+// writer's changes, so neither rows nor ID counters are lost.
+func (s *Store) Update(fn func(*domain.CollabState) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
 	}
+	defer tx.Rollback()
 
-	for _, ac := range state.AgentContexts {
-		if ac == nil {
-			continue
-		}
-		if _, err := tx.Exec("INSERT INTO agent_contexts (agent, last_checked_msg_id, last_checked_task_id, last_check_time) VALUES (?, ?, ?, ?)",
-			ac.Agent, ac.LastCheckedMsgID, ac.LastCheckedTaskID, ac.LastCheckTime.Format(time.RFC3339Nano)); err != nil {
-			return err
-		}
+	state, err := loadState(tx)
+	if err != nil {
+		return fmt.Errorf("state load: %w", err)
 	}
-
-	for _, fl := range state.FileLocks {
-		if fl == nil {
-			continue
-		}
-		if _, err := tx.Exec("INSERT INTO file_locks (path, locked_by, reason, locked_at, expires_at) VALUES (?, ?, ?, ?, ?)",
-			fl.Path, fl.LockedBy, fl.Reason, fl.LockedAt.Format(time.RFC3339Nano), fl.ExpiresAt.Format(time.RFC3339Nano)); err != nil {
-			return err
-		}
+	prev := snapshotOf(state)
+	if err := fn(state); err != nil {
+		return err
 	}
-
-	for _, ai := range state.AgentInstances {
-		if ai == nil {
-			continue
-		}
-		caps, _ := json.Marshal(ai.Capabilities)
-		curTasks, _ := json.Marshal(ai.CurrentTasks)
-		progressUpdAt := ""
-		if !ai.ProgressUpdatedAt.IsZero() {
-			progressUpdAt = ai.ProgressUpdatedAt.Format(time.RFC3339Nano)
-		}
-		if _, err := tx.Exec("INSERT INTO agent_instances (instance_id, agent_type, role, capabilities, max_tasks, status, current_tasks, workspace, last_heartbeat, progress, progress_step, progress_total_steps, progress_updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
-			ai.InstanceID, ai.AgentType, string(ai.Role), string(caps), ai.MaxTasks, ai.Status, string(curTasks), ai.Workspace, ai.LastHeartbeat.Format(time.RFC3339Nano), ai.Progress, ai.ProgressStep, ai.ProgressTotalSteps, progressUpdAt); err != nil {
-			return err
-		}
+	next := snapshotOf(state)
+	if err := writeDiff(tx, prev, next); err != nil {
+		return err
 	}
-
-	for _, wc := range state.WorkContexts {
-		if wc == nil {
-			continue
-		}
-		rf, _ := json.Marshal(wc.RelevantFiles)
-		con, _ := json.Marshal(wc.Constraints)
-		sn, _ := json.Marshal(wc.SharedNotes)
-		if _, err := tx.Exec("INSERT INTO work_contexts (id, task_id, relevant_files, background, constraints, shared_notes, parent_ctx_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			wc.ID, wc.TaskID, string(rf), wc.Background, string(con), string(sn), wc.ParentCtxID); err != nil {
-			return err
-		}
+	if err := appendEvents(tx, state.PendingEvents); err != nil {
+		return err
 	}
-
-	for _, ra := range state.RegisteredAgents {
-		if ra == nil {
-			continue
-		}
-		caps, _ := json.Marshal(ra.Capabilities)
-		if _, err := tx.Exec("INSERT INTO registered_agents (name, display_name, capabilities, workspace, project, registered_at, last_seen) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			ra.Name, ra.DisplayName, string(caps), ra.Workspace, ra.Project, ra.RegisteredAt.Format(time.RFC3339Nano), ra.LastSeen.Format(time.RFC3339Nano)); err != nil {
-			return err
-		}
+	if err := archiveTasks(tx, state.PendingArchive); err != nil {
+		return err
+	}
+	if err := storeArtifacts(tx, state.PendingArtifacts); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
-	return tx.Commit()
+	s.mu.Lock()
+	s.snapshot = next
+	s.mu.Unlock()
+	return nil
 }
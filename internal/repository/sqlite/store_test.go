@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jaakkos/stringwork/internal/app"
 	"github.com/jaakkos/stringwork/internal/domain"
 )
 
@@ -223,3 +224,33 @@ func TestNew_failsOnInvalidDir(t *testing.T) {
 		t.Error("New should fail when parent is not a directory")
 	}
 }
+
+func TestStore_ProjectRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now()
+	state := domain.NewCollabState()
+	state.Messages = []domain.Message{{ID: 1, From: "a", To: "b", Content: "x", Timestamp: now, Project: "/p"}}
+	state.Tasks = []domain.Task{{ID: 1, Title: "t", Status: "pending", CreatedAt: now, UpdatedAt: now, Project: "/p"}}
+	state.SessionNotes = []domain.SessionNote{{ID: 1, Author: "a", Content: "n", Category: "note", Timestamp: now, Project: "/p"}}
+	state.Plans["p1"] = &domain.Plan{ID: "p1", Title: "plan", Status: "active", CreatedAt: now, UpdatedAt: now, Project: "/p"}
+	state.FileLocks[app.FileLockKey("/p", "/p/f.go")] = &domain.FileLock{Path: "/p/f.go", LockedBy: "a", LockedAt: now, ExpiresAt: now.Add(time.Hour), Project: "/p"}
+	if err := s.Save(state); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Messages[0].Project != "/p" || got.Tasks[0].Project != "/p" || got.SessionNotes[0].Project != "/p" ||
+		got.Plans["p1"].Project != "/p" || got.FileLocks[app.FileLockKey("/p", "/p/f.go")].Project != "/p" {
+		t.Errorf("project not round-tripped: msg=%q task=%q note=%q plan=%q lock=%q",
+			got.Messages[0].Project, got.Tasks[0].Project, got.SessionNotes[0].Project,
+			got.Plans["p1"].Project, got.FileLocks[app.FileLockKey("/p", "/p/f.go")].Project)
+	}
+
+	ts, err := s.TasksByStatus("pending")
+	if err != nil || len(ts) != 1 || ts[0].Project != "/p" {
+		t.Errorf("TasksByStatus project = %+v, %v", ts, err)
+	}
+}
@@ -0,0 +1,139 @@
+-- Schema as written by releases before versioned migrations: registered agents.
+-- Used by migrate_test.go to check upgrades; do not edit.
+
+CREATE TABLE messages (
+	id INTEGER PRIMARY KEY,
+	from_agent TEXT NOT NULL,
+	to_agent TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp TEXT NOT NULL,
+	read_flag INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE tasks (
+	id INTEGER PRIMARY KEY,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	status TEXT NOT NULL,
+	assigned_to TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 3,
+	blocked_by TEXT NOT NULL DEFAULT '',
+	dependencies TEXT NOT NULL DEFAULT '[]',
+	context_id TEXT NOT NULL DEFAULT '',
+	worker_type TEXT NOT NULL DEFAULT '',
+	capabilities TEXT NOT NULL DEFAULT '[]',
+	result_summary TEXT NOT NULL DEFAULT '',
+	expected_duration_sec INTEGER NOT NULL DEFAULT 0,
+	progress_description TEXT NOT NULL DEFAULT '',
+	progress_percent INTEGER NOT NULL DEFAULT 0,
+	last_progress_at TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE presence (
+	agent TEXT PRIMARY KEY,
+	status TEXT NOT NULL,
+	current_task_id INTEGER NOT NULL DEFAULT 0,
+	note TEXT NOT NULL DEFAULT '',
+	last_seen TEXT NOT NULL,
+	workspace TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE session_notes (
+	id INTEGER PRIMARY KEY,
+	author TEXT NOT NULL,
+	content TEXT NOT NULL,
+	category TEXT NOT NULL,
+	timestamp TEXT NOT NULL
+);
+CREATE TABLE plans (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	goal TEXT NOT NULL,
+	context TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	status TEXT NOT NULL
+);
+CREATE TABLE plan_items (
+	plan_id TEXT NOT NULL,
+	item_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	reasoning TEXT NOT NULL DEFAULT '',
+	acceptance TEXT NOT NULL DEFAULT '[]',
+	constraints TEXT NOT NULL DEFAULT '[]',
+	status TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	dependencies TEXT NOT NULL DEFAULT '[]',
+	blockers TEXT NOT NULL DEFAULT '[]',
+	notes TEXT NOT NULL DEFAULT '[]',
+	priority INTEGER NOT NULL DEFAULT 2,
+	updated_by TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (plan_id, item_id),
+	FOREIGN KEY (plan_id) REFERENCES plans(id)
+);
+CREATE TABLE agent_contexts (
+	agent TEXT PRIMARY KEY,
+	last_checked_msg_id INTEGER NOT NULL DEFAULT 0,
+	last_checked_task_id INTEGER NOT NULL DEFAULT 0,
+	last_check_time TEXT NOT NULL
+);
+CREATE TABLE file_locks (
+	path TEXT PRIMARY KEY,
+	locked_by TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	locked_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL
+);
+CREATE TABLE meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE agent_instances (
+	instance_id TEXT PRIMARY KEY,
+	agent_type TEXT NOT NULL,
+	role TEXT NOT NULL,
+	capabilities TEXT NOT NULL DEFAULT '[]',
+	max_tasks INTEGER NOT NULL DEFAULT 1,
+	status TEXT NOT NULL DEFAULT 'offline',
+	current_tasks TEXT NOT NULL DEFAULT '[]',
+	workspace TEXT NOT NULL DEFAULT '',
+	last_heartbeat TEXT NOT NULL,
+	progress TEXT NOT NULL DEFAULT '',
+	progress_step INTEGER NOT NULL DEFAULT 0,
+	progress_total_steps INTEGER NOT NULL DEFAULT 0,
+	progress_updated_at TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE work_contexts (
+	id TEXT PRIMARY KEY,
+	task_id INTEGER NOT NULL,
+	relevant_files TEXT NOT NULL DEFAULT '[]',
+	background TEXT NOT NULL DEFAULT '',
+	constraints TEXT NOT NULL DEFAULT '[]',
+	shared_notes TEXT NOT NULL DEFAULT '{}',
+	parent_ctx_id TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE registered_agents (
+	name TEXT PRIMARY KEY,
+	display_name TEXT NOT NULL DEFAULT '',
+	capabilities TEXT NOT NULL DEFAULT '[]',
+	workspace TEXT NOT NULL DEFAULT '',
+	project TEXT NOT NULL DEFAULT '',
+	registered_at TEXT NOT NULL,
+	last_seen TEXT NOT NULL
+);
+CREATE INDEX idx_messages_to_read ON messages(to_agent, read_flag);
+CREATE INDEX idx_tasks_status_assigned ON tasks(status, assigned_to);
+
+INSERT INTO meta (key, value) VALUES ('next_msg_id', '3'), ('next_task_id', '2'), ('next_note_id', '2'), ('active_plan_id', 'plan-1');
+INSERT INTO messages (id, from_agent, to_agent, content, timestamp, read_flag) VALUES (1, 'cursor', 'claude-code', 'hello', '2025-06-01T10:00:00Z', 1), (2, 'claude-code', 'cursor', 'done', '2025-06-01T10:00:00Z', 0);
+INSERT INTO tasks (id, title, description, status, assigned_to, created_by, created_at, updated_at, priority) VALUES (1, 'Fix login', 'desc', 'in_progress', 'claude-code', 'cursor', '2025-06-01T10:00:00Z', '2025-06-01T10:00:00Z', 2);
+INSERT INTO presence (agent, status, current_task_id, note, last_seen, workspace) VALUES ('cursor', 'working', 1, '', '2025-06-01T10:00:00Z', '/work/app');
+INSERT INTO session_notes (id, author, content, category, timestamp) VALUES (1, 'cursor', 'use sqlite', 'decision', '2025-06-01T10:00:00Z');
+INSERT INTO plans (id, title, goal, context, created_by, created_at, updated_at, status) VALUES ('plan-1', 'Plan', 'Goal', '', 'cursor', '2025-06-01T10:00:00Z', '2025-06-01T10:00:00Z', 'active');
+INSERT INTO plan_items (plan_id, item_id, title, description, status, owner, updated_by, updated_at) VALUES ('plan-1', '1', 'Step', '', 'pending', 'cursor', 'cursor', '2025-06-01T10:00:00Z');
+INSERT INTO file_locks (path, locked_by, reason, locked_at, expires_at) VALUES ('/work/app/main.go', 'cursor', 'editing', '2025-06-01T10:00:00Z', '2099-01-01T00:00:00Z');
+INSERT INTO agent_instances (instance_id, agent_type, role, status, last_heartbeat) VALUES ('claude-code', 'claude-code', 'worker', 'busy', '2025-06-01T10:00:00Z');
+INSERT INTO registered_agents (name, registered_at, last_seen) VALUES ('gemini', '2025-06-01T10:00:00Z', '2025-06-01T10:00:00Z');
@@ -0,0 +1,59 @@
+package collab
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestCreateTask_ArchivesFinishedTasks(t *testing.T) {
+	repo := newMockRepository()
+	pol := newMockPolicy()
+	pol.taskRetentionDays = 30
+	logger := log.New(io.Discard, "", 0)
+	svc := newTestServiceWith(repo, pol, logger)
+	srv := testServer(svc, logger)
+
+	old := time.Now().AddDate(0, 0, -45)
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Shipped login", Status: "completed", AssignedTo: "claude-code", CreatedBy: "cursor", UpdatedAt: old, ResultSummary: "merged"},
+		{ID: 2, Title: "Still open", Status: "pending", AssignedTo: "claude-code", CreatedBy: "cursor", UpdatedAt: old},
+	}
+	repo.state.NextTaskID = 3
+
+	if _, err := callTool(t, srv, "create_task", map[string]any{
+		"title": "New work", "created_by": "cursor", "assigned_to": "claude-code",
+	}); err != nil {
+		t.Fatalf("create_task: %v", err)
+	}
+	if len(repo.state.Tasks) != 2 || repo.state.Tasks[0].ID != 2 || repo.state.Tasks[1].ID != 3 {
+		t.Fatalf("live tasks = %+v, want #2 and #3", repo.state.Tasks)
+	}
+	if len(repo.archived) != 1 || repo.archived[0].Task.ID != 1 {
+		t.Fatalf("archived = %+v, want #1", repo.archived)
+	}
+
+	result, err := callTool(t, srv, "list_tasks", map[string]any{"project": app.AllProjects})
+	if err != nil {
+		t.Fatalf("list_tasks: %v", err)
+	}
+	if text := resultText(t, result); strings.Contains(text, "Shipped login") {
+		t.Errorf("archived task listed without include_archived:\n%s", text)
+	}
+	result, err = callTool(t, srv, "list_tasks", map[string]any{"project": app.AllProjects, "status": "completed", "include_archived": true})
+	if err != nil {
+		t.Fatalf("list_tasks include_archived: %v", err)
+	}
+	text := resultText(t, result)
+	if !strings.Contains(text, "Task #1 [completed, archived") || !strings.Contains(text, "Result: merged") {
+		t.Errorf("expected archived task in listing:\n%s", text)
+	}
+	if strings.Contains(text, "Still open") {
+		t.Errorf("status filter ignored:\n%s", text)
+	}
+}
@@ -1,6 +1,11 @@
 package collab
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/app"
+)
 
 // requireFloat64 extracts a float64 from args by key. Returns a clear error distinguishing
 // "missing" from "wrong type" — safe against nil values (no panic).
@@ -32,3 +37,30 @@ func optionalFloat64(args map[string]any, key string, fallback float64) float64
 	}
 	return fallback
 }
+
+// optionalStrings extracts the strings of an array argument, skipping other
+// elements. Returns nil if the argument is absent.
+func optionalStrings(args map[string]any, key string) []string {
+	items, _ := args[key].([]any)
+	var out []string
+	for _, x := range items {
+		if s, ok := x.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// optionalTime parses a time argument with app.ParseQueryTime. Returns the
+// zero time if the argument is absent or empty.
+func optionalTime(args map[string]any, key string) (time.Time, error) {
+	v, _ := args[key].(string)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := app.ParseQueryTime(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", key, err)
+	}
+	return t, nil
+}
@@ -1,6 +1,7 @@
 package collab
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -99,3 +100,23 @@ func TestOptionalFloat64(t *testing.T) {
 		})
 	}
 }
+
+func TestOptionalStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]any
+		want []string
+	}{
+		{"present", map[string]any{"files": []any{"a.go", "b.go"}}, []string{"a.go", "b.go"}},
+		{"skips non-strings and empty", map[string]any{"files": []any{"a.go", 3.0, ""}}, []string{"a.go"}},
+		{"missing", map[string]any{}, nil},
+		{"wrong type", map[string]any{"files": "a.go"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optionalStrings(tt.args, "files"); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+// AuthorizationMiddleware returns a mcp-go ToolHandlerMiddleware that enforces
+// the per-role and per-agent tool permissions of the authorization config.
+// It must run after IdentityMiddleware, which vouches for the caller's
+// identity arguments. Only the driver may set a task's own verification
+// commands, since they run as shell commands on the server. Denied calls fail
+// with a "permission denied" error and are journaled as tool_denied events.
+func AuthorizationMiddleware(svc *app.CollabService, creds *app.AgentCredentials, logger *log.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			agent := AuthenticatedAgent(ctx)
+			role := callerRole(svc, agent)
+			names := callerNames(req, agent, creds)
+
+			// Per-agent entries only apply to authenticated agents; anyone
+			// else could claim the name.
+			var perms policy.ToolPermissions
+			if agent != "" {
+				perms = svc.Policy().ToolPermissions(role, names...)
+			} else {
+				perms = svc.Policy().ToolPermissions(role)
+			}
+
+			reason, taskID := authorizeCall(svc, role, perms, req, names)
+			if reason == "" {
+				return next(ctx, req)
+			}
+
+			actor := agent
+			if actor == "" && len(names) > 0 {
+				actor = names[0]
+			}
+			logger.Printf("Authorization: denied %s to %s (%s): %s", req.Params.Name, actor, role, reason)
+			_ = svc.Run(func(state *domain.CollabState) error {
+				app.RecordEvent(state, domain.Event{
+					Type:    domain.EventToolDenied,
+					Actor:   actor,
+					TaskID:  taskID,
+					Ref:     req.Params.Name,
+					Project: app.ProjectKey(svc.Policy().WorkspaceRoot()),
+					Data:    map[string]string{"role": role, "reason": reason},
+				})
+				return nil
+			})
+			return nil, fmt.Errorf("permission denied: %s", reason)
+		}
+	}
+}
+
+// callerRole maps an authenticated agent to its authorization role.
+func callerRole(svc *app.CollabService, agent string) string {
+	switch {
+	case agent == "":
+		return policy.RoleAnonymous
+	case agent == driverName(svc):
+		return policy.RoleDriver
+	default:
+		return policy.RoleWorker
+	}
+}
+
+func driverName(svc *app.CollabService) string {
+	if o := svc.Policy().Orchestration(); o != nil {
+		return o.Driver
+	}
+	return ""
+}
+
+// callerNames returns the names the caller acts under: the authenticated agent
+// and its aliases, or else the identity arguments of the call.
+func callerNames(req mcp.CallToolRequest, agent string, creds *app.AgentCredentials) []string {
+	if agent != "" {
+		return creds.Names(agent)
+	}
+	var names []string
+	args := req.GetArguments()
+	for _, key := range identityArgs[req.Params.Name] {
+		if name, _ := args[key].(string); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ownTaskArg names the task argument that own_tasks_only checks, per tool.
+var ownTaskArg = map[string]string{
+	"update_task":     "id",
+	"create_subtasks": "parent_task_id",
+}
+
+var ownTaskVerb = map[string]string{
+	"update_task":     "update",
+	"create_subtasks": "split",
+}
+
+// setsVerifyCommands reports whether create_task args shape the verification
+// commands the server runs: verify itself, or a template whose verify
+// commands take {variables}.
+func setsVerifyCommands(svc *app.CollabService, args map[string]any) bool {
+	if len(optionalStrings(args, "verify")) > 0 {
+		return true
+	}
+	name, _ := args["template"].(string)
+	tpl, ok := svc.Policy().TaskTemplates()[name]
+	return ok && len(app.TemplateVars(policy.TaskTemplate{Verify: tpl.Verify})) > 0
+}
+
+// authorizeCall returns why the call is not permitted ("" if it is) and the
+// task it concerns, if any.
+func authorizeCall(svc *app.CollabService, role string, perms policy.ToolPermissions, req mcp.CallToolRequest, names []string) (string, int) {
+	tool := req.Params.Name
+	if !perms.Permits(tool) {
+		return fmt.Sprintf("%s is not allowed to call %s", callerLabel(names), tool), 0
+	}
+	args := req.GetArguments()
+	if tool == "create_task" && role != policy.RoleDriver && setsVerifyCommands(svc, args) {
+		return fmt.Sprintf("%s may not set verification commands; only the driver may", callerLabel(names)), 0
+	}
+	switch {
+	case tool == "create_task" && perms.DenyAssignAny:
+		if assignee, _ := args["assigned_to"].(string); assignee == "" || assignee == "any" {
+			return fmt.Sprintf("%s may not create tasks for 'any' agent; set assigned_to", callerLabel(names)), 0
+		}
+	case ownTaskArg[tool] != "" && perms.OwnTasksOnly:
+		id, err := requireFloat64(args, ownTaskArg[tool])
+		if err != nil {
+			return "", 0 // the tool reports the missing id
+		}
+		var assignee string
+		found := false
+		_ = svc.Query(func(state *domain.CollabState) error {
+			for _, t := range state.Tasks {
+				if t.ID == int(id) {
+					assignee, found = t.AssignedTo, true
+					break
+				}
+			}
+			return nil
+		})
+		if found && !slices.Contains(names, assignee) && !(tool == "update_task" && assignee == "any" && claimsTask(args, names)) {
+			return fmt.Sprintf("%s may only %s its own tasks; task #%d is assigned to %s", callerLabel(names), ownTaskVerb[tool], int(id), assignee), int(id)
+		}
+	}
+	return "", 0
+}
+
+// claimsTask reports whether update_task args only start the task for the
+// caller: status in_progress, assigned to the caller or left for update_task to
+// assign to it. own_tasks_only lets callers claim tasks assigned to 'any' so.
+func claimsTask(args map[string]any, names []string) bool {
+	if status, _ := args["status"].(string); status != "in_progress" {
+		return false
+	}
+	assignee, ok := args["assigned_to"].(string)
+	return !ok || slices.Contains(names, assignee)
+}
+
+func callerLabel(names []string) string {
+	if len(names) == 0 {
+		return "an unauthenticated client"
+	}
+	return names[0]
+}
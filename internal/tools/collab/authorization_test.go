@@ -0,0 +1,109 @@
+package collab
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	svc, repo := newTestService()
+	svc.Policy().(*mockPolicy).taskTemplates = map[string]policy.TaskTemplate{
+		"add-tests": {Title: "Test {package}", Verify: []string{"go test ./{package}/..."}},
+		"lint":      {Title: "Lint {package}", Verify: []string{"go vet ./..."}},
+	}
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "mine", AssignedTo: "claude-code", Status: "pending"},
+		{ID: 2, Title: "theirs", AssignedTo: "codex", Status: "pending"},
+		{ID: 3, Title: "open", AssignedTo: "any", Status: "pending"},
+	}
+	creds := app.NewAgentCredentials()
+	creds.Protect("cursor")
+	creds.Issue("claude-code-1", "claude-code")
+	mw := AuthorizationMiddleware(svc, creds, log.New(io.Discard, "", 0))
+	next := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+
+	tests := []struct {
+		name      string
+		agent     string
+		tool      string
+		args      map[string]any
+		wantError string
+	}{
+		{"driver cancels", "cursor", "cancel_agent", map[string]any{"agent": "codex", "cancelled_by": "cursor"}, ""},
+		{"driver assigns any", "cursor", "create_task", map[string]any{"title": "t", "created_by": "cursor"}, ""},
+		{"driver updates worker task", "cursor", "update_task", map[string]any{"id": float64(2), "updated_by": "cursor"}, ""},
+		{"worker cancels", "claude-code-1", "cancel_agent", map[string]any{"agent": "codex", "cancelled_by": "claude-code-1"}, "not allowed to call cancel_agent"},
+		{"worker creates plan", "claude-code-1", "create_plan", map[string]any{"id": "p", "created_by": "claude-code-1"}, "not allowed to call create_plan"},
+		{"worker assigns any", "claude-code-1", "create_task", map[string]any{"title": "t", "assigned_to": "any"}, "for 'any' agent"},
+		{"worker omits assignee", "claude-code-1", "create_task", map[string]any{"title": "t"}, "for 'any' agent"},
+		{"worker assigns driver", "claude-code-1", "create_task", map[string]any{"title": "t", "assigned_to": "cursor"}, ""},
+		{"worker updates own task via type", "claude-code-1", "update_task", map[string]any{"id": float64(1)}, ""},
+		{"worker updates other task", "claude-code-1", "update_task", map[string]any{"id": float64(2)}, "task #2 is assigned to codex"},
+		{"worker splits own task", "claude-code-1", "create_subtasks", map[string]any{"parent_task_id": float64(1)}, ""},
+		{"worker splits other task", "claude-code-1", "create_subtasks", map[string]any{"parent_task_id": float64(2)}, "may only split its own tasks"},
+		{"worker claims any task", "claude-code-1", "update_task", map[string]any{"id": float64(3), "status": "in_progress"}, ""},
+		{"worker claims any task for itself", "claude-code-1", "update_task", map[string]any{"id": float64(3), "status": "in_progress", "assigned_to": "claude-code"}, ""},
+		{"worker claims any task for another", "claude-code-1", "update_task", map[string]any{"id": float64(3), "status": "in_progress", "assigned_to": "codex"}, "task #3 is assigned to any"},
+		{"worker completes any task", "claude-code-1", "update_task", map[string]any{"id": float64(3), "status": "completed"}, "task #3 is assigned to any"},
+		{"unknown task is left to the tool", "claude-code-1", "update_task", map[string]any{"id": float64(99)}, ""},
+		{"anonymous cancels", "", "cancel_agent", map[string]any{"agent": "codex", "cancelled_by": "gemini"}, "gemini is not allowed"},
+		{"anonymous updates by claimed name", "", "update_task", map[string]any{"id": float64(2), "updated_by": "codex"}, ""},
+		{"driver sets verify", "cursor", "create_task", map[string]any{"title": "t", "verify": []any{"go test ./..."}}, ""},
+		{"worker sets verify", "claude-code-1", "create_task", map[string]any{"title": "t", "assigned_to": "cursor", "verify": []any{"rm -rf /"}}, "may not set verification commands"},
+		{"anonymous sets verify", "", "create_task", map[string]any{"title": "t", "assigned_to": "cursor", "created_by": "gemini", "verify": []any{"true"}}, "gemini may not set verification commands"},
+		{"worker fills verify template", "claude-code-1", "create_task", map[string]any{"template": "add-tests", "assigned_to": "cursor", "vars": map[string]any{"package": "x; sh"}}, "may not set verification commands"},
+		{"worker uses fixed verify template", "claude-code-1", "create_task", map[string]any{"template": "lint", "assigned_to": "cursor", "vars": map[string]any{"package": "x"}}, ""},
+		{"driver fills verify template", "cursor", "create_task", map[string]any{"template": "add-tests", "vars": map[string]any{"package": "x"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.agent != "" {
+				ctx = WithAuthenticatedAgent(ctx, tt.agent)
+			}
+			var req mcp.CallToolRequest
+			req.Params.Name = tt.tool
+			req.Params.Arguments = tt.args
+			_, err := mw(next)(ctx, req)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "permission denied") || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("error = %v, want permission denied containing %q", err, tt.wantError)
+			}
+		})
+	}
+
+	var denied []domain.Event
+	for _, e := range repo.events {
+		if e.Type == domain.EventToolDenied {
+			denied = append(denied, e)
+		}
+	}
+	if len(denied) != 12 {
+		t.Fatalf("tool_denied events = %d, want 12", len(denied))
+	}
+	if e := denied[4]; e.Actor != "claude-code-1" || e.Ref != "update_task" || e.TaskID != 2 || e.Data["role"] != "worker" {
+		t.Errorf("update_task denial = %+v", e)
+	}
+	if e := denied[5]; e.Ref != "create_subtasks" || e.TaskID != 2 {
+		t.Errorf("create_subtasks denial = %+v", e)
+	}
+	if e := denied[8]; e.Actor != "gemini" || e.Data["role"] != "anonymous" {
+		t.Errorf("anonymous denial = %+v", e)
+	}
+}
@@ -45,7 +45,7 @@ func registerCancelAgent(s *server.MCPServer, svc *app.CollabService, logger *lo
 			var agentFound bool
 
 			// Phase 1: Cancel all in-progress tasks and send STOP message.
-			if err := svc.Run(func(state *domain.CollabState) error {
+			if err := svc.RunAs(cancelledBy, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(cancelledBy, state, false, false, extra...); err != nil {
 					return err
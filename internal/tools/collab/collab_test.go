@@ -16,10 +16,14 @@ import (
 	"github.com/jaakkos/stringwork/internal/policy"
 )
 
-// mockRepository implements app.StateRepository for tests. State is kept in memory.
+// mockRepository implements app.StateRepository, app.EventJournal and
+// app.TaskArchive for tests. State, journaled events and archived tasks are
+// kept in memory.
 type mockRepository struct {
-	state *domain.CollabState
-	mu    sync.Mutex
+	state    *domain.CollabState
+	events   []domain.Event
+	archived []domain.ArchivedTask
+	mu       sync.Mutex
 }
 
 func newMockRepository() *mockRepository {
@@ -36,12 +40,51 @@ func (m *mockRepository) Save(state *domain.CollabState) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.state = state
+	for _, e := range state.PendingEvents {
+		e.ID = int64(len(m.events) + 1)
+		m.events = append(m.events, e)
+	}
+	m.archived = append(m.archived, state.PendingArchive...)
 	return nil
 }
 
+// ArchivedTasks filters by status and project only.
+func (m *mockRepository) ArchivedTasks(f app.ArchiveFilter) ([]domain.ArchivedTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []domain.ArchivedTask
+	for _, a := range m.archived {
+		if (f.Status == "" || a.Task.Status == f.Status) && app.InProject(a.Task.Project, f.Project) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// Events filters by task and agent only, which is all the tool tests need.
+func (m *mockRepository) Events(f app.EventFilter) ([]domain.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []domain.Event
+	for _, e := range m.events {
+		if f.TaskID != 0 && e.TaskID != f.TaskID {
+			continue
+		}
+		if f.Agent != "" && e.Actor != f.Agent && e.Target != f.Agent {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
 // mockPolicy implements app.Policy for tests.
 type mockPolicy struct {
-	workspaceRoot string
+	workspaceRoot     string
+	taskRetentionDays int
+	taskTemplates     map[string]policy.TaskTemplate
+	verification      policy.VerificationConfig
+	planFile          string
 }
 
 func newMockPolicy() *mockPolicy {
@@ -51,6 +94,7 @@ func newMockPolicy() *mockPolicy {
 
 func (m *mockPolicy) MessageRetentionMax() int       { return 1000 }
 func (m *mockPolicy) MessageRetentionDays() int      { return 30 }
+func (m *mockPolicy) TaskRetentionDays() int         { return m.taskRetentionDays }
 func (m *mockPolicy) PresenceTTLSeconds() int        { return 300 }
 func (m *mockPolicy) StateFile() string              { return "" }
 func (m *mockPolicy) SignalFilePath() string         { return "" }
@@ -67,6 +111,14 @@ func (m *mockPolicy) Orchestration() *policy.OrchestrationConfig {
 	}
 }
 
+func (m *mockPolicy) ToolPermissions(role string, agents ...string) policy.ToolPermissions {
+	return policy.DefaultAuthorization().Permissions(role, agents...)
+}
+
+func (m *mockPolicy) TaskTemplates() map[string]policy.TaskTemplate { return m.taskTemplates }
+func (m *mockPolicy) Verification() policy.VerificationConfig       { return m.verification }
+func (m *mockPolicy) PlanFile() string                              { return m.planFile }
+
 func (m *mockPolicy) ValidatePath(path string) (string, error) {
 	abs, err := filepath.Abs(path)
 	if err != nil {
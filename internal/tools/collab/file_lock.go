@@ -13,6 +13,15 @@ import (
 	"github.com/jaakkos/stringwork/internal/domain"
 )
 
+// lockProject returns the project the caller's locks belong to. Locks are
+// always taken in one project, so the all-projects filter means untagged.
+func lockProject(svc *app.CollabService, state *domain.CollabState, args map[string]any, agent string) string {
+	if project := callerProject(svc, state, args, agent); project != app.AllProjects {
+		return project
+	}
+	return ""
+}
+
 func cleanupExpiredLocks(state *domain.CollabState) int {
 	now := time.Now()
 	removed := 0
@@ -93,7 +102,8 @@ func handleLock(svc *app.CollabService, args map[string]any, logger *log.Logger)
 
 		cleanupExpiredLocks(state)
 
-		if existing, exists := state.FileLocks[path]; exists && existing != nil {
+		project := lockProject(svc, state, args, agent)
+		if _, existing := app.FindFileLock(state, project, path); existing != nil {
 			if existing.LockedBy != agent {
 				return fmt.Errorf("file locked by %s until %s: %s",
 					existing.LockedBy, existing.ExpiresAt.Format("15:04:05"), existing.Reason)
@@ -106,15 +116,17 @@ func handleLock(svc *app.CollabService, args map[string]any, logger *log.Logger)
 		}
 
 		now := time.Now()
-		state.FileLocks[path] = &domain.FileLock{
+		lock := &domain.FileLock{
 			Path:      path,
 			LockedBy:  agent,
 			Reason:    reason,
 			LockedAt:  now,
 			ExpiresAt: now.Add(time.Duration(duration) * time.Minute),
+			Project:   project,
 		}
+		state.FileLocks[app.FileLockKey(project, path)] = lock
 		lockResult = mcp.NewToolResultText(fmt.Sprintf("Locked %s for %d minutes. Expires at %s",
-			path, duration, state.FileLocks[path].ExpiresAt.Format("15:04:05")))
+			path, duration, lock.ExpiresAt.Format("15:04:05")))
 		return nil
 	}); runErr != nil {
 		return nil, runErr
@@ -144,8 +156,8 @@ func handleUnlock(svc *app.CollabService, args map[string]any, logger *log.Logge
 		}
 
 		cleanupExpiredLocks(state)
-		lock, exists := state.FileLocks[path]
-		if !exists || lock == nil {
+		key, lock := app.FindFileLock(state, lockProject(svc, state, args, agent), path)
+		if lock == nil {
 			lockResult = mcp.NewToolResultText(fmt.Sprintf("%s is not locked", path))
 			return nil
 		}
@@ -153,7 +165,7 @@ func handleUnlock(svc *app.CollabService, args map[string]any, logger *log.Logge
 			return fmt.Errorf("cannot unlock: file locked by %s (use force=true to override)", lock.LockedBy)
 		}
 		wasBy := lock.LockedBy
-		delete(state.FileLocks, path)
+		delete(state.FileLocks, key)
 		if wasBy != agent {
 			lockResult = mcp.NewToolResultText(fmt.Sprintf("Force-unlocked %s (was locked by %s)", path, wasBy))
 		} else {
@@ -181,8 +193,9 @@ func handleCheck(svc *app.CollabService, args map[string]any, logger *log.Logger
 	var lockResult *mcp.CallToolResult
 	err = svc.Run(func(state *domain.CollabState) error {
 		cleanupExpiredLocks(state)
-		lock, exists := state.FileLocks[path]
-		if !exists || lock == nil {
+		agent, _ := args["agent"].(string)
+		_, lock := app.FindFileLock(state, callerProject(svc, state, args, agent), path)
+		if lock == nil {
 			lockResult = mcp.NewToolResultText(fmt.Sprintf(`{"locked":false,"path":"%s"}`, escapeJSON(path)))
 			return nil
 		}
@@ -206,8 +219,9 @@ func handleList(svc *app.CollabService, args map[string]any, logger *log.Logger)
 			result = "No active file locks"
 			return nil
 		}
-		for p, lock := range state.FileLocks {
-			if lock == nil {
+		project := callerProject(svc, state, args, filterAgent)
+		for _, lock := range state.FileLocks {
+			if lock == nil || !app.InProject(lock.Project, project) {
 				continue
 			}
 			if filterAgent != "" && lock.LockedBy != filterAgent {
@@ -215,7 +229,7 @@ func handleList(svc *app.CollabService, args map[string]any, logger *log.Logger)
 			}
 			timeLeft := time.Until(lock.ExpiresAt).Round(time.Minute)
 			result += fmt.Sprintf("- **%s** (locked by %s, %v remaining)\n  Reason: %s\n",
-				p, lock.LockedBy, timeLeft, lock.Reason)
+				lock.Path, lock.LockedBy, timeLeft, lock.Reason)
 		}
 		return nil
 	})
@@ -0,0 +1,119 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// registerGetHistory registers the get_history tool.
+func registerGetHistory(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("get_history",
+			mcp.WithDescription("Show the event journal: task creation and status changes, messages, locks, worker spawns and watchdog recoveries. Use it to see who did what and when, e.g. why a task went back to pending."),
+			mcp.WithNumber("task_id", mcp.Description("Only events for this task")),
+			mcp.WithString("agent", mcp.Description("Only events caused by or affecting this agent")),
+			mcp.WithString("type", mcp.Description("Comma-separated event types (e.g. 'task_status_changed,watchdog_recovered')")),
+			mcp.WithString("since", mcp.Description("Only events after this point: a duration ago ('2h', '30m') or an RFC3339 time")),
+			mcp.WithNumber("limit", mcp.Description("Maximum number of events, most recent (default: 30, max: 200)")),
+			mcp.WithString("project", mcp.Description("Project (workspace path) to show; 'all' for every project (default: server workspace)")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			f := app.EventFilter{Limit: 30}
+			if v, ok := args["task_id"].(float64); ok {
+				f.TaskID = int(v)
+			}
+			f.Agent, _ = args["agent"].(string)
+			if v, ok := args["type"].(string); ok && v != "" {
+				for _, t := range strings.Split(v, ",") {
+					if t = strings.TrimSpace(t); t != "" {
+						f.Types = append(f.Types, t)
+					}
+				}
+			}
+			if v, ok := args["since"].(string); ok && v != "" {
+				since, err := parseSince(v, time.Now())
+				if err != nil {
+					return nil, err
+				}
+				f.Since = since
+			}
+			if v, ok := args["limit"].(float64); ok {
+				f.Limit = int(v)
+				if f.Limit < 1 {
+					f.Limit = 1
+				}
+				if f.Limit > 200 {
+					f.Limit = 200
+				}
+			}
+			f.Project = callerProject(svc, nil, args, "")
+
+			events, err := svc.Events(f)
+			if err != nil {
+				return nil, err
+			}
+			if len(events) == 0 {
+				return mcp.NewToolResultText("No events"), nil
+			}
+
+			var buf strings.Builder
+			fmt.Fprintf(&buf, "Events (%d, oldest first):\n", len(events))
+			for _, e := range events {
+				buf.WriteString(formatEvent(e))
+				buf.WriteByte('\n')
+			}
+			logger.Printf("Listed %d events", len(events))
+			return mcp.NewToolResultText(buf.String()), nil
+		},
+	)
+}
+
+// parseSince accepts a duration before now ("2h") or an RFC3339 timestamp.
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be a duration like '2h' or an RFC3339 time, got %q", v)
+	}
+	return t, nil
+}
+
+// formatEvent renders one journal entry on a single line.
+func formatEvent(e domain.Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d %s %s", e.ID, e.Timestamp.Format("2006-01-02 15:04:05"), e.Type)
+	if e.TaskID != 0 {
+		fmt.Fprintf(&b, " task=#%d", e.TaskID)
+	}
+	if e.Actor != "" {
+		fmt.Fprintf(&b, " by=%s", e.Actor)
+	}
+	if e.Target != "" {
+		fmt.Fprintf(&b, " target=%s", e.Target)
+	}
+	if e.Ref != "" {
+		fmt.Fprintf(&b, " ref=%s", e.Ref)
+	}
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%q", k, e.Data[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package collab
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetHistory_TaskLifecycle(t *testing.T) {
+	svc, _ := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	if _, err := callTool(t, srv, "create_task", map[string]any{
+		"title": "Write docs", "created_by": "cursor", "assigned_to": "claude-code",
+	}); err != nil {
+		t.Fatalf("create_task: %v", err)
+	}
+	if _, err := callTool(t, srv, "update_task", map[string]any{
+		"id": float64(1), "status": "in_progress", "updated_by": "claude-code",
+	}); err != nil {
+		t.Fatalf("update_task: %v", err)
+	}
+
+	result, err := callTool(t, srv, "get_history", map[string]any{"task_id": float64(1)})
+	if err != nil {
+		t.Fatalf("get_history: %v", err)
+	}
+	text := resultText(t, result)
+	created := strings.Index(text, "task_created task=#1 by=cursor")
+	changed := strings.Index(text, `task_status_changed task=#1 by=claude-code target=claude-code from="pending" to="in_progress"`)
+	if created < 0 || changed < created {
+		t.Errorf("expected task_created then task_status_changed, got:\n%s", text)
+	}
+}
+
+func TestGetHistory_InvalidSince(t *testing.T) {
+	svc, _ := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+	if _, err := callTool(t, srv, "get_history", map[string]any{"since": "yesterday"}); err == nil {
+		t.Error("expected error for invalid since")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if got, err := parseSince("2h", now); err != nil || !got.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("parseSince(2h) = %v, %v", got, err)
+	}
+	if got, err := parseSince("2026-04-30T10:00:00Z", now); err != nil || got.Day() != 30 {
+		t.Errorf("parseSince(RFC3339) = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,102 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+)
+
+// identityArgs lists, per tool, the arguments that name the calling agent.
+// Arguments naming someone else (send_message "to", cancel_agent "agent",
+// get_history "agent") are not listed.
+var identityArgs = map[string][]string{
+	"send_message":        {"from"},
+	"read_messages":       {"for"},
+	"create_task":         {"created_by"},
+	"update_task":         {"updated_by"},
+	"create_subtasks":     {"created_by"},
+	"create_plan":         {"created_by"},
+	"update_plan":         {"updated_by"},
+	"execute_plan":        {"created_by"},
+	"import_plan":         {"created_by"},
+	"get_session_context": {"for"},
+	"set_presence":        {"agent"},
+	"append_session_note": {"author"},
+	"handoff":             {"from"},
+	"claim_next":          {"agent"},
+	"request_review":      {"from"},
+	"lock_file":           {"agent"},
+	"register_agent":      {"name"},
+	"heartbeat":           {"agent"},
+	"cancel_agent":        {"cancelled_by"},
+	"report_progress":     {"agent"},
+	"update_work_context": {"author"},
+	"list_projects":       {"agent"},
+}
+
+type authenticatedAgentKey struct{}
+
+// WithAuthenticatedAgent returns a context carrying the agent the transport
+// authenticated the request as (bearer token, or the driver's own stdio or
+// unix socket connection).
+func WithAuthenticatedAgent(ctx context.Context, agent string) context.Context {
+	return context.WithValue(ctx, authenticatedAgentKey{}, agent)
+}
+
+// AuthenticatedAgent returns the agent set by WithAuthenticatedAgent, or "".
+func AuthenticatedAgent(ctx context.Context) string {
+	agent, _ := ctx.Value(authenticatedAgentKey{}).(string)
+	return agent
+}
+
+// IdentityMiddleware returns a mcp-go ToolHandlerMiddleware that binds
+// authenticated sessions to their agent and rejects tool calls whose identity
+// arguments (see identityArgs) name a different agent. Unauthenticated callers
+// may use any name that is not protected by creds.
+func IdentityMiddleware(registry *app.SessionRegistry, creds *app.AgentCredentials) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			agent := AuthenticatedAgent(ctx)
+			if session := server.ClientSessionFromContext(ctx); session != nil {
+				sid := session.SessionID()
+				bound := registry.Identity(sid)
+				switch {
+				case agent != "" && !registry.BindIdentity(sid, agent):
+					return nil, fmt.Errorf("session is authenticated as %q, not %q", bound, agent)
+				case agent == "" && bound != "":
+					return nil, fmt.Errorf("session is authenticated as %q but this request has no credentials", bound)
+				}
+			}
+			if err := checkIdentityArgs(req, agent, creds); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// checkIdentityArgs verifies that every identity argument of the call is a
+// name the caller may act under.
+func checkIdentityArgs(req mcp.CallToolRequest, agent string, creds *app.AgentCredentials) error {
+	args := req.GetArguments()
+	for _, key := range identityArgs[req.Params.Name] {
+		name, _ := args[key].(string)
+		if name == "" {
+			continue
+		}
+		if agent != "" {
+			if !creds.Allows(agent, name) {
+				return fmt.Errorf("%s=%q does not match the authenticated agent %q", key, name, agent)
+			}
+			continue
+		}
+		if creds.Protected(name) {
+			return fmt.Errorf("%s=%q requires authentication as that agent", key, name)
+		}
+	}
+	return nil
+}
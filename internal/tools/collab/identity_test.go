@@ -0,0 +1,86 @@
+package collab
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+)
+
+// stubSession is a minimal server.ClientSession for middleware tests.
+type stubSession struct{ id string }
+
+func (s stubSession) Initialize()                                         {}
+func (s stubSession) Initialized() bool                                   { return true }
+func (s stubSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s stubSession) SessionID() string                                   { return s.id }
+
+func identityCall(t *testing.T, registry *app.SessionRegistry, creds *app.AgentCredentials, sessionID, agent, tool string, args map[string]any) error {
+	t.Helper()
+	ctx := server.NewMCPServer("test", "0").WithContext(context.Background(), stubSession{id: sessionID})
+	if agent != "" {
+		ctx = WithAuthenticatedAgent(ctx, agent)
+	}
+	var req mcp.CallToolRequest
+	req.Params.Name = tool
+	req.Params.Arguments = args
+	next := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	_, err := IdentityMiddleware(registry, creds)(next)(ctx, req)
+	return err
+}
+
+func TestIdentityMiddleware(t *testing.T) {
+	registry := app.NewSessionRegistry()
+	creds := app.NewAgentCredentials()
+	creds.Protect("cursor")
+	creds.Issue("claude-code-1", "claude-code")
+
+	tests := []struct {
+		name      string
+		session   string
+		agent     string
+		tool      string
+		args      map[string]any
+		wantError string
+	}{
+		{"driver as itself", "s-driver", "cursor", "send_message", map[string]any{"from": "cursor", "to": "claude-code-1"}, ""},
+		{"worker as itself", "s-worker", "claude-code-1", "update_task", map[string]any{"updated_by": "claude-code-1"}, ""},
+		{"worker under its type", "s-worker", "claude-code-1", "heartbeat", map[string]any{"agent": "claude-code"}, ""},
+		{"worker impersonates driver", "s-worker", "claude-code-1", "send_message", map[string]any{"from": "cursor"}, "does not match the authenticated agent"},
+		{"worker cancels someone", "s-worker", "claude-code-1", "cancel_agent", map[string]any{"agent": "codex", "cancelled_by": "cursor"}, "cancelled_by"},
+		{"target args are not identities", "s-driver", "cursor", "cancel_agent", map[string]any{"agent": "claude-code-1", "cancelled_by": "cursor"}, ""},
+		{"anonymous as protected driver", "s-anon", "", "send_message", map[string]any{"from": "cursor"}, "requires authentication"},
+		{"anonymous as protected type", "s-anon", "", "set_presence", map[string]any{"agent": "claude-code"}, "requires authentication"},
+		{"anonymous as unknown agent", "s-anon", "", "send_message", map[string]any{"from": "gemini"}, ""},
+		{"bound session switches agent", "s-worker", "cursor", "list_tasks", nil, `authenticated as "claude-code-1"`},
+		{"bound session drops credentials", "s-worker", "", "list_tasks", nil, "has no credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := identityCall(t, registry, creds, tt.session, tt.agent, tt.tool, tt.args)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantError)
+			}
+		})
+	}
+
+	if got := registry.Identity("s-worker"); got != "claude-code-1" {
+		t.Errorf("s-worker identity = %q", got)
+	}
+	registry.RemoveSession("s-worker")
+	if got := registry.Identity("s-worker"); got != "" {
+		t.Errorf("identity after RemoveSession = %q", got)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/policy"
 )
 
 // AgentNameForClient maps MCP client names to stringwork agent identifiers.
@@ -122,10 +126,11 @@ The workspace is set via set_presence workspace='<path>'. When changed:
     - The server's file path validation follows the new workspace
     - Auto-spawned agents use it as their working directory
     - Project info in get_session_context updates automatically
+    - Tasks, messages, notes, plans and locks are scoped to that project
 
 ## Driver / Worker Mode (when configured)
 
-- **Driver**: Create tasks with assigned_to='any' to auto-assign to workers. Use worker_status to see the worker pool with real-time progress, process activity, and SLA status. Use cancel_agent to stop stuck workers. Set expected_duration_seconds on tasks for SLA monitoring.
+- **Driver**: Create tasks with assigned_to='any' to auto-assign to workers. Use worker_status to see the worker pool with real-time progress, process activity, and SLA status. Use cancel_agent to stop stuck workers and get_worker_log to read a worker's output when it fails. Set expected_duration_seconds on tasks for SLA monitoring.
 - **Workers**: Use claim_next to get tasks. MANDATORY: call heartbeat every 60-90 seconds AND report_progress every 2-3 minutes. The server monitors these — missing reports trigger escalating alerts to the driver. Report back via send_message when done. Obey STOP signals immediately.
 
 ## Rules
@@ -164,7 +169,8 @@ func InstructionsForRole(agent string, driverID string) string {
 
 ## Reporting
 - Workers send_message to you with progress updates and findings; always acknowledge and update task status.
-- If a worker hasn't sent an update in a while, check worker_status and consider cancelling.`
+- If a worker hasn't sent an update in a while, check worker_status and consider cancelling.
+- If a worker run fails, get_worker_log instance='<worker>' shows the end of its output (grep='error' to filter).`
 	}
 	if driverID != "" {
 		return `You are a **worker** in the pair programming system. The driver is ` + driverID + `.
@@ -284,6 +290,7 @@ The workspace is set via set_presence workspace='<path>'. When changed:
     - The server's file path validation follows the new workspace
     - Auto-spawned agents use it as their working directory
     - Project info in get_session_context updates automatically
+    - Tasks, messages, notes, plans and locks are scoped to that project
 
 ## Rules
 
@@ -300,7 +307,7 @@ The workspace is set via set_presence workspace='<path>'. When changed:
 }
 
 // registerPrompts registers reusable prompt templates with the mcp-go server.
-func registerPrompts(s *server.MCPServer) {
+func registerPrompts(s *server.MCPServer, svc *app.CollabService) {
 	s.AddPrompt(
 		mcp.NewPrompt("pair-respond",
 			mcp.WithPromptDescription("Process unread messages and pending tasks from your pair. Use this when auto-spawned or when you want to catch up on pair activity."),
@@ -403,4 +410,67 @@ Use git diff to see changes, read files for context. Send your review via send_m
 			}, nil
 		},
 	)
+
+	s.AddPrompt(
+		mcp.NewPrompt("task-templates",
+			mcp.WithPromptDescription("List the task templates configured for this server and how to create tasks from them."),
+		),
+		func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return &mcp.GetPromptResult{
+				Description: "Available task templates",
+				Messages: []mcp.PromptMessage{
+					{
+						Role:    mcp.RoleUser,
+						Content: mcp.TextContent{Type: "text", Text: formatTaskTemplates(svc.Policy().TaskTemplates())},
+					},
+				},
+			}, nil
+		},
+	)
+}
+
+// formatTaskTemplates renders the configured task templates for the
+// task-templates prompt.
+func formatTaskTemplates(templates map[string]policy.TaskTemplate) string {
+	if len(templates) == 0 {
+		return `No task templates are configured. Add them under task_templates in the server config, e.g.:
+
+task_templates:
+  add-tests:
+    title: "Add tests for {package}"
+    description: "Raise test coverage of {package}."
+    capabilities: [testing]
+    expected_duration_seconds: 900
+    acceptance: ["go test ./{package}/... passes"]`
+	}
+	var b strings.Builder
+	b.WriteString("Create a task from a template with create_task template='<name>' vars={...} created_by='<you>'. Explicit arguments (title, description, priority, assigned_to, ...) override the template.\n")
+	for _, name := range app.TaskTemplateNames(templates) {
+		tpl := templates[name]
+		fmt.Fprintf(&b, "\n## %s\n\nTitle: %s\n", name, tpl.Title)
+		if vars := app.TemplateVars(tpl); len(vars) > 0 {
+			var parts []string
+			for _, v := range vars {
+				if def, ok := tpl.Vars[v]; ok {
+					parts = append(parts, fmt.Sprintf("%s (default %q)", v, def))
+				} else {
+					parts = append(parts, v+" (required)")
+				}
+			}
+			fmt.Fprintf(&b, "Vars: %s\n", strings.Join(parts, ", "))
+		}
+		if tpl.WorkerType != "" {
+			fmt.Fprintf(&b, "Worker type: %s\n", tpl.WorkerType)
+		}
+		if len(tpl.Capabilities) > 0 {
+			fmt.Fprintf(&b, "Capabilities: %s\n", strings.Join(tpl.Capabilities, ", "))
+		}
+		if tpl.ExpectedDurationSec > 0 {
+			fmt.Fprintf(&b, "Expected duration: %s\n", time.Duration(tpl.ExpectedDurationSec)*time.Second)
+		}
+		if len(tpl.Acceptance) > 0 {
+			fmt.Fprintf(&b, "Acceptance: %s\n", strings.Join(tpl.Acceptance, "; "))
+		}
+	}
+	return b.String()
 }
@@ -49,6 +49,7 @@ func registerSendMessage(s *server.MCPServer, svc *app.CollabService, logger *lo
 					Content:   content,
 					Timestamp: time.Now(),
 					Read:      false,
+					Project:   callerProject(svc, state, args, from),
 				}
 				state.Messages = append(state.Messages, msg)
 				msgID = state.NextMsgID
@@ -78,6 +79,7 @@ func registerReadMessages(s *server.MCPServer, svc *app.CollabService, logger *l
 			mcp.WithBoolean("unread_only", mcp.Description("Only show unread messages (default: false)")),
 			mcp.WithNumber("limit", mcp.Description("Maximum number of messages to return (default: 10)")),
 			mcp.WithBoolean("mark_read", mcp.Description("Mark returned messages as read (default: true)")),
+			mcp.WithString("project", mcp.Description("Project (workspace path) to read from; 'all' for every project (default: your workspace)")),
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args := req.GetArguments()
@@ -113,9 +115,13 @@ func registerReadMessages(s *server.MCPServer, svc *app.CollabService, logger *l
 					return err
 				}
 
+				project := callerProject(svc, state, args, recipient)
 				collected := make([]domain.Message, 0, limit)
 				for i := len(state.Messages) - 1; i >= 0 && len(collected) < limit; i-- {
 					msg := state.Messages[i]
+					if !app.InProject(msg.Project, project) {
+						continue
+					}
 					if msg.To == recipient || msg.To == "all" {
 						if unreadOnly && msg.Read {
 							continue
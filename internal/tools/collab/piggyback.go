@@ -8,7 +8,6 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/jaakkos/stringwork/internal/app"
-	"github.com/jaakkos/stringwork/internal/domain"
 )
 
 // suppressBannerTools lists tools that already display unread state or would
@@ -69,34 +68,28 @@ func agentFromContext(ctx context.Context, registry *app.SessionRegistry) string
 	return registry.GetAgent(session.SessionID())
 }
 
-// buildBanner checks state for the given agent and returns a notification
-// banner string. Returns "" if there is nothing to report.
+// buildBanner queries the repository for the given agent's project and returns a
+// notification banner string. Returns "" if there is nothing to report.
 // If the agent has cancelled tasks, a STOP directive is returned instead of a normal banner.
 func buildBanner(svc *app.CollabService, agent string) string {
 	if agent == "" {
 		return ""
 	}
 
-	var unread, pending, cancelled int
-	_ = svc.Query(func(state *domain.CollabState) error {
-		for _, msg := range state.Messages {
-			if (msg.To == agent || msg.To == "all") && !msg.Read {
-				unread++
-			}
-		}
-		for _, task := range state.Tasks {
-			if task.AssignedTo != agent && task.AssignedTo != "any" {
-				continue
-			}
-			switch task.Status {
-			case "pending":
-				pending++
-			case "cancelled":
-				cancelled++
-			}
-		}
-		return nil
-	})
+	q := svc.Queries()
+	project, err := app.QueryAgentProject(q, agent, svc.Policy().WorkspaceRoot())
+	if err != nil {
+		return ""
+	}
+	unread, err := q.UnreadCount(agent, project)
+	if err != nil {
+		return ""
+	}
+	counts, err := q.TaskStatusCounts(project, agent, "any")
+	if err != nil {
+		return ""
+	}
+	pending, cancelled := counts["pending"], counts["cancelled"]
 
 	// Cancellation takes priority — inject a hard STOP directive
 	if cancelled > 0 {
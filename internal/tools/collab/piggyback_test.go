@@ -136,6 +136,23 @@ func TestBuildBanner_IncludesAnyAssignedTasks(t *testing.T) {
 	}
 }
 
+func TestBuildBanner_ScopedToAgentProject(t *testing.T) {
+	svc, repo := newPiggybackTestService()
+	repo.state.Presence["cursor"] = &domain.Presence{Agent: "cursor", Workspace: "/work/alpha"}
+	repo.state.Messages = []domain.Message{
+		{ID: 1, From: "claude-code", To: "cursor", Content: "other project", Timestamp: time.Now(), Project: "/work/beta"},
+		{ID: 2, From: "claude-code", To: "cursor", Content: "this project", Timestamp: time.Now(), Project: "/work/alpha"},
+	}
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "elsewhere", AssignedTo: "cursor", Status: "cancelled", Project: "/work/beta"},
+		{ID: 2, Title: "elsewhere", AssignedTo: "any", Status: "pending", Project: "/work/beta"},
+	}
+	banner := buildBanner(svc, "cursor")
+	if !strings.Contains(banner, "You have 1 unread message(s).") {
+		t.Errorf("banner = %q, want only the message and no tasks of /work/alpha", banner)
+	}
+}
+
 func TestAppendBannerToResult(t *testing.T) {
 	result := &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -18,7 +20,7 @@ func registerCreatePlan(s *server.MCPServer, svc *app.CollabService, logger *log
 	s.AddTool(
 		mcp.NewTool("create_plan",
 			mcp.WithDescription("Create a new shared plan for collaborative work. Both agents can add items and update progress."),
-			mcp.WithString("id", mcp.Required(), mcp.Description("Unique plan ID (e.g., 'auth-refactor', 'p0-features')")),
+			mcp.WithString("id", mcp.Required(), mcp.Description("Unique plan ID: letters, digits, '.', '_' and '-' (e.g., 'auth-refactor', 'p0-features')")),
 			mcp.WithString("title", mcp.Required(), mcp.Description("Plan title")),
 			mcp.WithString("goal", mcp.Required(), mcp.Description("What we're trying to achieve")),
 			mcp.WithString("context", mcp.Description("Background, constraints, or scope")),
@@ -41,6 +43,9 @@ func registerCreatePlan(s *server.MCPServer, svc *app.CollabService, logger *log
 			if id == "" || title == "" || goal == "" || createdBy == "" {
 				return nil, fmt.Errorf("id, title, goal, and created_by are required")
 			}
+			if err := app.ValidatePlanID(id); err != nil {
+				return nil, err
+			}
 
 			if err := svc.Run(func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
@@ -62,6 +67,7 @@ func registerCreatePlan(s *server.MCPServer, svc *app.CollabService, logger *log
 					CreatedAt: now,
 					UpdatedAt: now,
 					Status:    "active",
+					Project:   callerProject(svc, state, args, createdBy),
 				}
 				state.Plans[id] = plan
 				if setActive {
@@ -88,6 +94,7 @@ func registerGetPlan(s *server.MCPServer, svc *app.CollabService, logger *log.Lo
 		mcp.NewTool("get_plan",
 			mcp.WithDescription("Get a shared plan with all items and their status. Shows the active plan if no ID specified."),
 			mcp.WithString("id", mcp.Description("Plan ID (omit to get the active plan)")),
+			mcp.WithString("project", mcp.Description("Project (workspace path) whose active plan to show when id is omitted (default: server workspace)")),
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args := req.GetArguments()
@@ -98,7 +105,7 @@ func registerGetPlan(s *server.MCPServer, svc *app.CollabService, logger *log.Lo
 			if err := svc.Query(func(state *domain.CollabState) error {
 				planID := id
 				if planID == "" {
-					planID = state.ActivePlanID
+					planID = app.ActivePlanID(state, callerProject(svc, state, args, ""))
 				}
 				if planID == "" {
 					result = "No active plan. Use create_plan to start one."
@@ -223,7 +230,7 @@ func registerUpdatePlan(s *server.MCPServer, svc *app.CollabService, logger *log
 
 			now := time.Now()
 			var res *mcp.CallToolResult
-			err := svc.Run(func(state *domain.CollabState) error {
+			err := svc.RunAs(updatedBy, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(updatedBy, state, false, false, extra...); err != nil {
 					return err
@@ -429,3 +436,219 @@ func updatePlanItem(plan *domain.Plan, state *domain.CollabState, args map[strin
 	logger.Printf("Plan item %q updated by %s: %v", itemID, updatedBy, changes)
 	return mcp.NewToolResultText(fmt.Sprintf("Updated item [%s]: %v", itemID, changes)), nil
 }
+
+// registerExecutePlan registers the execute_plan tool.
+func registerExecutePlan(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, orch *app.TaskOrchestrator) {
+	s.AddTool(
+		mcp.NewTool("execute_plan",
+			mcp.WithDescription("Turn plan items into tasks so workers can claim them. Item dependencies become task dependencies, owners become assignees, and acceptance criteria and constraints go into the task's work context. From then on the item's status follows its task's, and updating the item updates the task. Items that already have a task, or are completed or cancelled, are skipped."),
+			mcp.WithString("plan_id", mcp.Description("Plan ID (omit to use the active plan)")),
+			mcp.WithArray("items", mcp.Description("Item IDs to execute, with the unfinished items they depend on (default: all items)"), mcp.WithStringItems()),
+			mcp.WithString("created_by", mcp.Required(), mcp.Description("Agent executing the plan")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			createdBy, err := requireString(args, "created_by")
+			if err != nil {
+				return nil, err
+			}
+			planID, _ := args["plan_id"].(string)
+			itemIDs := optionalStrings(args, "items")
+
+			var exec app.PlanExecution
+			var tasks map[int]domain.Task
+			if err := svc.RunAs(createdBy, func(state *domain.CollabState) error {
+				extra := app.RegisteredAgentNames(state)
+				if err := app.ValidateAgent(createdBy, state, false, false, extra...); err != nil {
+					return err
+				}
+				if planID == "" {
+					planID = app.ActivePlanID(state, callerProject(svc, state, args, createdBy))
+				}
+				if planID == "" {
+					return fmt.Errorf("no plan specified and no active plan")
+				}
+				plan, exists := state.Plans[planID]
+				if !exists || plan == nil {
+					return fmt.Errorf("plan %q not found", planID)
+				}
+				var err error
+				if exec, err = app.ExecutePlan(state, plan, itemIDs, createdBy, time.Now()); err != nil {
+					return err
+				}
+				tasks = make(map[int]domain.Task, len(exec.Created))
+				for _, c := range exec.Created {
+					task := findTask(state, c.TaskID)
+					if orch != nil && createdBy == state.DriverID && task.AssignedTo == "any" && task.Status == "pending" {
+						orch.AssignTask(task, state)
+					}
+					tasks[c.TaskID] = *task
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			logger.Printf("Plan %q executed by %s: %d tasks created", planID, createdBy, len(exec.Created))
+			var b strings.Builder
+			fmt.Fprintf(&b, "Plan %q: %d tasks created\n", planID, len(exec.Created))
+			for _, c := range exec.Created {
+				t := tasks[c.TaskID]
+				fmt.Fprintf(&b, "  [%s] %s -> task #%d (%s, assigned to %s", c.ItemID, c.Title, t.ID, t.Status, t.AssignedTo)
+				if len(t.Dependencies) > 0 {
+					fmt.Fprintf(&b, ", depends on %v", t.Dependencies)
+				}
+				b.WriteString(")\n")
+			}
+			if len(exec.Skipped) > 0 {
+				b.WriteString("Skipped:\n")
+				for _, sk := range exec.Skipped {
+					if sk.TaskID != 0 {
+						fmt.Fprintf(&b, "  [%s] %s: already task #%d\n", sk.ItemID, sk.Title, sk.TaskID)
+					} else {
+						fmt.Fprintf(&b, "  [%s] %s: %s\n", sk.ItemID, sk.Title, sk.Reason)
+					}
+				}
+			}
+			return mcp.NewToolResultText(b.String()), nil
+		},
+	)
+}
+
+// registerImportPlan registers the import_plan tool.
+func registerImportPlan(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("import_plan",
+			mcp.WithDescription("Create a plan from markdown (the format export_plan writes): '# Title', '- ID:' and '- Goal:' lines, then one '## ID. Title @owner priority:N depends:a,b status:S' heading per item with its description, '- Reasoning:', and '- Acceptance:' / '- Constraints:' lists (acceptance as checkboxes). Pass replace=true to update an existing plan from the markdown."),
+			mcp.WithString("markdown", mcp.Description("Plan markdown (or use path)")),
+			mcp.WithString("path", mcp.Description("Markdown file in the workspace, e.g. 'PLAN.md'")),
+			mcp.WithString("plan_id", mcp.Description("Plan ID (overrides the markdown's '- ID:' line)")),
+			mcp.WithBoolean("replace", mcp.Description("Update the plan if it exists: items missing from the markdown are removed (default: false)")),
+			mcp.WithBoolean("set_active", mcp.Description("Set this as the active plan (default: true)")),
+			mcp.WithString("project", mcp.Description("Project (workspace path) of a new plan (default: caller's workspace)")),
+			mcp.WithString("created_by", mcp.Required(), mcp.Description("Agent importing the plan")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			createdBy, err := requireString(args, "created_by")
+			if err != nil {
+				return nil, err
+			}
+			text, _ := args["markdown"].(string)
+			if path, _ := args["path"].(string); path != "" {
+				if text != "" {
+					return nil, fmt.Errorf("pass markdown or path, not both")
+				}
+				abs, err := svc.Policy().ValidatePath(path)
+				if err != nil {
+					return nil, err
+				}
+				data, err := os.ReadFile(abs)
+				if err != nil {
+					return nil, err
+				}
+				text = string(data)
+			}
+			if text == "" {
+				return nil, fmt.Errorf("markdown or path is required")
+			}
+			parsed, err := app.ParsePlanMarkdown(text)
+			if err != nil {
+				return nil, err
+			}
+			if id, _ := args["plan_id"].(string); id != "" {
+				parsed.ID = id
+			}
+			replace, _ := args["replace"].(bool)
+			setActive := true
+			if v, ok := args["set_active"].(bool); ok {
+				setActive = v
+			}
+
+			var plan domain.Plan
+			var created bool
+			if err := svc.RunAs(createdBy, func(state *domain.CollabState) error {
+				extra := app.RegisteredAgentNames(state)
+				if err := app.ValidateAgent(createdBy, state, false, false, extra...); err != nil {
+					return err
+				}
+				for _, it := range parsed.Items {
+					if it.Owner != "" && it.Owner != "unassigned" {
+						if err := app.ValidateAgent(it.Owner, state, false, false, extra...); err != nil {
+							return fmt.Errorf("item %q: %w", it.ID, err)
+						}
+					}
+				}
+				stored, isNew, err := app.ImportPlan(state, parsed, callerProject(svc, state, args, createdBy), createdBy, replace, time.Now())
+				if err != nil {
+					return err
+				}
+				if setActive {
+					state.ActivePlanID = stored.ID
+				}
+				plan, created = *stored, isNew
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			verb := "updated"
+			if created {
+				verb = "created"
+			}
+			logger.Printf("Plan %q %s from markdown by %s", plan.ID, verb, createdBy)
+			result := fmt.Sprintf("Plan %s: %s (%s, %d items)\n\nGoal: %s", verb, plan.Title, plan.ID, len(plan.Items), plan.Goal)
+			if setActive {
+				result += "\n\n(Set as active plan)"
+			}
+			return mcp.NewToolResultText(result), nil
+		},
+	)
+}
+
+// registerExportPlan registers the export_plan tool.
+func registerExportPlan(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("export_plan",
+			mcp.WithDescription("Export a plan as markdown that import_plan reads back: items as '##' headings with owner, priority, dependency and status annotations, and acceptance criteria as checkboxes. Optionally writes it to a file in the workspace."),
+			mcp.WithString("plan_id", mcp.Description("Plan ID (omit to use the active plan)")),
+			mcp.WithString("project", mcp.Description("Project (workspace path) whose active plan to export when plan_id is omitted (default: server workspace)")),
+			mcp.WithString("path", mcp.Description("Also write the markdown to this file in the workspace, e.g. 'PLAN.md'")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			planID, _ := args["plan_id"].(string)
+			var text string
+			if err := svc.Query(func(state *domain.CollabState) error {
+				if planID == "" {
+					planID = app.ActivePlanID(state, callerProject(svc, state, args, ""))
+				}
+				if planID == "" {
+					return fmt.Errorf("no plan specified and no active plan")
+				}
+				plan, exists := state.Plans[planID]
+				if !exists || plan == nil {
+					return fmt.Errorf("plan %q not found", planID)
+				}
+				text = app.PlanMarkdown(plan)
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			if path, _ := args["path"].(string); path != "" {
+				abs, err := svc.Policy().ValidatePath(path)
+				if err != nil {
+					return nil, err
+				}
+				if err := os.WriteFile(abs, []byte(text), 0o644); err != nil {
+					return nil, err
+				}
+				logger.Printf("Plan %q exported to %s", planID, abs)
+				return mcp.NewToolResultText(fmt.Sprintf("Wrote plan %q to %s\n\n%s", planID, abs, text)), nil
+			}
+			logger.Printf("Plan %q exported", planID)
+			return mcp.NewToolResultText(text), nil
+		},
+	)
+}
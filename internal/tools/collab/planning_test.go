@@ -3,6 +3,8 @@ package collab
 import (
 	"io"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -105,6 +107,27 @@ func TestCreatePlan_InvalidCreator(t *testing.T) {
 	}
 }
 
+func TestCreatePlan_InvalidID(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	args := map[string]any{
+		"id":         "../../.ssh/authorized_keys",
+		"title":      "Plan",
+		"goal":       "Goal",
+		"created_by": "cursor",
+	}
+
+	_, err := callTool(t, srv, "create_plan", args)
+	if err == nil || !strings.Contains(err.Error(), "invalid plan ID") {
+		t.Errorf("expected invalid plan ID error, got %v", err)
+	}
+	if len(repo.state.Plans) != 0 {
+		t.Errorf("plans = %v, want none", repo.state.Plans)
+	}
+}
+
 func TestCreatePlan_DuplicateID(t *testing.T) {
 	svc, repo := newTestService()
 	logger := log.New(io.Discard, "", 0)
@@ -782,3 +805,136 @@ func TestUpdatePlan_InvalidAction(t *testing.T) {
 		t.Error("expected error for invalid action")
 	}
 }
+
+// ========== execute_plan tests ==========
+
+func TestExecutePlan_CreatesTasks(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	repo.state.Plans["plan"] = &domain.Plan{
+		ID: "plan",
+		Items: []domain.PlanItem{
+			{ID: "1", Title: "Store", Status: "pending", Priority: 2},
+			{ID: "2", Title: "Handlers", Status: "pending", Owner: "claude-code", Priority: 1, Dependencies: []string{"1"}},
+			{ID: "3", Title: "Old", Status: "completed"},
+		},
+		Status: "active",
+	}
+	repo.state.ActivePlanID = "plan"
+
+	result, err := callTool(t, srv, "execute_plan", map[string]any{"created_by": "cursor"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := resultText(t, result)
+	if !strings.Contains(text, "2 tasks created") || !strings.Contains(text, "[2] Handlers -> task #2 (waiting, assigned to claude-code, depends on [1])") ||
+		!strings.Contains(text, "[3] Old: completed") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	if len(repo.state.Tasks) != 2 || repo.state.Tasks[0].PlanItemID != "1" || repo.state.Tasks[1].PlanItemID != "2" {
+		t.Fatalf("tasks = %+v", repo.state.Tasks)
+	}
+
+	// Completing the task completes the item.
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "completed", "updated_by": "cursor"}); err != nil {
+		t.Fatalf("update_task: %v", err)
+	}
+	if got := repo.state.Plans["plan"].Items[0].Status; got != "completed" {
+		t.Errorf("item status = %s, want completed", got)
+	}
+	if got := repo.state.Tasks[1].Status; got != "pending" {
+		t.Errorf("dependent task = %s, want pending", got)
+	}
+}
+
+func TestExecutePlan_Errors(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	if _, err := callTool(t, srv, "execute_plan", map[string]any{"created_by": "cursor"}); err == nil {
+		t.Error("expected error with no active plan")
+	}
+	repo.state.Plans["plan"] = &domain.Plan{ID: "plan", Items: []domain.PlanItem{{ID: "1", Title: "Item", Status: "pending"}}, Status: "active"}
+	if _, err := callTool(t, srv, "execute_plan", map[string]any{"plan_id": "plan", "items": []any{"7"}, "created_by": "cursor"}); err == nil {
+		t.Error("expected error for unknown item")
+	}
+	if _, err := callTool(t, srv, "execute_plan", map[string]any{"plan_id": "plan", "created_by": "nobody"}); err == nil {
+		t.Error("expected error for unknown agent")
+	}
+}
+
+// ========== import_plan / export_plan tests ==========
+
+func TestImportExportPlan(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	markdown := "# Auth\n\n- ID: auth\n- Goal: JWT auth\n\n## 1. Middleware @claude-code priority:1\n\n- Acceptance:\n  - [ ] Validates signature\n\n## 2. Rate limiter depends:1\n"
+	result, err := callTool(t, srv, "import_plan", map[string]any{"markdown": markdown, "created_by": "cursor"})
+	if err != nil {
+		t.Fatalf("import_plan: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Plan created: Auth (auth, 2 items)") {
+		t.Errorf("unexpected result: %s", text)
+	}
+	plan := repo.state.Plans["auth"]
+	if plan == nil || repo.state.ActivePlanID != "auth" || plan.CreatedBy != "cursor" || len(plan.Items) != 2 ||
+		plan.Items[0].Owner != "claude-code" || plan.Items[1].Dependencies[0] != "1" {
+		t.Fatalf("imported plan = %+v", plan)
+	}
+
+	if _, err := callTool(t, srv, "import_plan", map[string]any{"markdown": markdown, "created_by": "cursor"}); err == nil {
+		t.Error("expected error re-importing without replace")
+	}
+
+	path := filepath.Join(svc.Policy().WorkspaceRoot(), "PLAN.md")
+	result, err = callTool(t, srv, "export_plan", map[string]any{"path": path})
+	if err != nil {
+		t.Fatalf("export_plan: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, markdown) {
+		t.Errorf("export does not match the import:\n%s", text)
+	}
+
+	// Edit the written file and import it back over the plan.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), "## 2. Rate limiter depends:1", "## 2. Rate limiter depends:1 status:in_progress", 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callTool(t, srv, "import_plan", map[string]any{"path": path, "replace": true, "created_by": "cursor"}); err != nil {
+		t.Fatalf("import_plan replace: %v", err)
+	}
+	if got := repo.state.Plans["auth"].Items[1].Status; got != "in_progress" {
+		t.Errorf("item status after replace = %s", got)
+	}
+}
+
+func TestImportPlan_Errors(t *testing.T) {
+	svc, _ := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	tests := []struct {
+		name string
+		args map[string]any
+	}{
+		{"no markdown", map[string]any{"created_by": "cursor"}},
+		{"bad markdown", map[string]any{"markdown": "no title", "created_by": "cursor"}},
+		{"no goal", map[string]any{"markdown": "# T\n- ID: t\n", "created_by": "cursor"}},
+		{"unknown owner", map[string]any{"markdown": "# T\n- ID: t\n- Goal: g\n## 1. A @nobody\n", "created_by": "cursor"}},
+		{"outside workspace", map[string]any{"path": "/etc/passwd", "created_by": "cursor"}},
+	}
+	for _, tt := range tests {
+		if _, err := callTool(t, srv, "import_plan", tt.args); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
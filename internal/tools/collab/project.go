@@ -0,0 +1,77 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// callerProject returns the project a tool call operates in: the explicit
+// "project" argument if given (use "all" to disable scoping), else the
+// agent's workspace, else the server's workspace root.
+func callerProject(svc *app.CollabService, state *domain.CollabState, args map[string]any, agent string) string {
+	if v, ok := args["project"].(string); ok && v != "" {
+		if v == app.AllProjects {
+			return v
+		}
+		return app.ProjectKey(v)
+	}
+	return app.AgentProject(state, agent, svc.Policy().WorkspaceRoot())
+}
+
+// registerListProjects registers the list_projects tool.
+func registerListProjects(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("list_projects",
+			mcp.WithDescription("List the projects (workspaces) that have tasks, messages, plans or agents in the shared state. Switch projects with set_presence workspace='<path>'."),
+			mcp.WithString("agent", mcp.Description("Your agent name; your current project is marked with * (default: the authenticated agent)")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			agent, _ := args["agent"].(string)
+			if agent == "" {
+				agent = AuthenticatedAgent(ctx)
+			}
+			var projects []app.ProjectSummary
+			var current string
+			if err := svc.Query(func(state *domain.CollabState) error {
+				projects = app.ListProjects(state)
+				current = callerProject(svc, state, args, agent)
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			if len(projects) == 0 {
+				return mcp.NewToolResultText("No projects yet. Set one with set_presence workspace='<path>'."), nil
+			}
+
+			var buf strings.Builder
+			fmt.Fprintf(&buf, "Projects (%d):\n", len(projects))
+			for _, p := range projects {
+				marker := " "
+				if p.Project == current {
+					marker = "*"
+				}
+				fmt.Fprintf(&buf, "%s %s (%s)\n", marker, p.Name, p.Project)
+				fmt.Fprintf(&buf, "    %d open / %d tasks, %d messages, %d plans", p.OpenTasks, p.Tasks, p.Messages, p.Plans)
+				if !p.LastActivity.IsZero() {
+					fmt.Fprintf(&buf, ", last activity %s", p.LastActivity.Format(time.RFC3339))
+				}
+				buf.WriteByte('\n')
+				if len(p.Agents) > 0 {
+					fmt.Fprintf(&buf, "    Agents: %s\n", strings.Join(p.Agents, ", "))
+				}
+			}
+			logger.Printf("Listed %d projects", len(projects))
+			return mcp.NewToolResultText(buf.String()), nil
+		},
+	)
+}
@@ -0,0 +1,144 @@
+package collab
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestProjectScoping_TasksAndMessages(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	now := time.Now()
+	repo.state.Presence["cursor"] = &domain.Presence{Agent: "cursor", Status: "working", Workspace: "/work/alpha", LastSeen: now}
+
+	if _, err := callTool(t, srv, "create_task", map[string]any{
+		"title": "Alpha task", "created_by": "cursor", "assigned_to": "claude-code",
+	}); err != nil {
+		t.Fatalf("create_task: %v", err)
+	}
+	if _, err := callTool(t, srv, "send_message", map[string]any{
+		"from": "cursor", "to": "claude-code", "content": "alpha msg",
+	}); err != nil {
+		t.Fatalf("send_message: %v", err)
+	}
+	if got := repo.state.Tasks[0].Project; got != "/work/alpha" {
+		t.Errorf("task project = %q, want /work/alpha", got)
+	}
+	if got := repo.state.Messages[0].Project; got != "/work/alpha" {
+		t.Errorf("message project = %q, want /work/alpha", got)
+	}
+
+	// Other projects do not see alpha's records.
+	result, err := callTool(t, srv, "list_tasks", map[string]any{"project": "/work/beta"})
+	if err != nil {
+		t.Fatalf("list_tasks: %v", err)
+	}
+	if text := resultText(t, result); strings.Contains(text, "Alpha task") {
+		t.Errorf("alpha task leaked into beta project:\n%s", text)
+	}
+	result, err = callTool(t, srv, "list_tasks", map[string]any{"project": "/work/alpha/.stringwork/worktrees/claude-code"})
+	if err != nil {
+		t.Fatalf("list_tasks in alpha worktree: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Alpha task") {
+		t.Errorf("expected alpha task from worktree path:\n%s", text)
+	}
+
+	// The recipient has no workspace of its own, so it follows the server root.
+	result, err = callTool(t, srv, "read_messages", map[string]any{"for": "claude-code", "mark_read": false})
+	if err != nil {
+		t.Fatalf("read_messages: %v", err)
+	}
+	if text := resultText(t, result); text != "No messages" {
+		t.Errorf("expected no messages outside alpha, got:\n%s", text)
+	}
+	result, err = callTool(t, srv, "read_messages", map[string]any{"for": "claude-code", "project": "all"})
+	if err != nil {
+		t.Fatalf("read_messages project=all: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "alpha msg") {
+		t.Errorf("expected alpha msg with project=all:\n%s", text)
+	}
+}
+
+func TestListProjects(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	now := time.Now()
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "a", Status: "pending", UpdatedAt: now, Project: "/work/alpha"},
+		{ID: 2, Title: "b", Status: "completed", UpdatedAt: now.Add(-time.Hour), Project: "/work/beta"},
+	}
+	result, err := callTool(t, srv, "list_projects", map[string]any{})
+	if err != nil {
+		t.Fatalf("list_projects: %v", err)
+	}
+	text := resultText(t, result)
+	if !strings.Contains(text, "Projects (2)") || strings.Index(text, "alpha") > strings.Index(text, "beta") {
+		t.Errorf("unexpected list_projects output:\n%s", text)
+	}
+
+	// The caller's project is marked, not the server's workspace root.
+	repo.state.Presence["codex"] = &domain.Presence{Agent: "codex", Workspace: "/work/beta"}
+	result, err = callTool(t, srv, "list_projects", map[string]any{"agent": "codex"})
+	if err != nil {
+		t.Fatalf("list_projects: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "* beta (/work/beta)") || strings.Contains(text, "* alpha") {
+		t.Errorf("list_projects for codex does not mark /work/beta:\n%s", text)
+	}
+}
+
+func TestProjectScoping_FileLocks(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+	path := filepath.Join(svc.Policy().WorkspaceRoot(), "main.go")
+
+	lock := func(agent, project string) error {
+		_, err := callTool(t, srv, "lock_file", map[string]any{
+			"agent": agent, "path": path, "reason": "editing", "project": project,
+		})
+		return err
+	}
+	if err := lock("cursor", "/work/alpha"); err != nil {
+		t.Fatalf("lock in alpha: %v", err)
+	}
+	if err := lock("claude-code", "/work/beta"); err != nil {
+		t.Fatalf("lock of the same path in beta: %v", err)
+	}
+	if err := lock("claude-code", "/work/alpha"); err == nil || !strings.Contains(err.Error(), "locked by cursor") {
+		t.Errorf("lock held by cursor in alpha: err = %v", err)
+	}
+	if len(repo.state.FileLocks) != 2 {
+		t.Fatalf("locks = %v, want one per project", repo.state.FileLocks)
+	}
+
+	result, err := callTool(t, srv, "lock_file", map[string]any{"action": "check", "path": path, "project": "/work/beta"})
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, `"locked_by":"claude-code"`) {
+		t.Errorf("check in beta = %s", text)
+	}
+	if _, err := callTool(t, srv, "lock_file", map[string]any{
+		"action": "unlock", "agent": "claude-code", "path": path, "project": "/work/beta",
+	}); err != nil {
+		t.Fatalf("unlock in beta: %v", err)
+	}
+	for _, l := range repo.state.FileLocks {
+		if l.Project != "/work/alpha" || l.LockedBy != "cursor" {
+			t.Errorf("remaining lock = %+v, want alpha's", l)
+		}
+	}
+}
@@ -38,11 +38,35 @@ type ProcessInfoSnapshot struct {
 	WorkspaceDir string    `json:"workspace_dir"`
 }
 
+// WorkerPoolProvider can return the capacity and scaling state of the worker pool.
+type WorkerPoolProvider interface {
+	GetWorkerPool() WorkerPoolSnapshot
+}
+
+// WorkerPoolSnapshot is a snapshot of worker capacity across all worker types.
+type WorkerPoolSnapshot struct {
+	MaxWorkers int                  `json:"max_workers"` // 0 = no limit
+	Active     int                  `json:"active"`
+	Types      []WorkerTypeSnapshot `json:"types"`
+}
+
+// WorkerTypeSnapshot is the capacity of a single worker type.
+type WorkerTypeSnapshot struct {
+	Type         string        `json:"type"`
+	Autoscaled   bool          `json:"autoscaled"`
+	Instances    int           `json:"instances"`
+	MinInstances int           `json:"min_instances"`
+	Active       int           `json:"active"`
+	IdleTimeout  time.Duration `json:"idle_timeout"`
+}
+
 type registerOpts struct {
 	canceller        WorkerCanceller
 	knowledgeStore   *knowledge.KnowledgeStore
 	worktreeProvider WorktreeInfoProvider
 	processProvider  ProcessInfoProvider
+	poolProvider     WorkerPoolProvider
+	runStore         WorkerRunStore
 }
 
 // WithCanceller sets the WorkerCanceller for the cancel_agent tool.
@@ -65,6 +89,16 @@ func WithProcessProvider(p ProcessInfoProvider) RegisterOption {
 	return func(o *registerOpts) { o.processProvider = p }
 }
 
+// WithWorkerPoolProvider enables worker pool capacity and scaling info in worker_status output.
+func WithWorkerPoolProvider(p WorkerPoolProvider) RegisterOption {
+	return func(o *registerOpts) { o.poolProvider = p }
+}
+
+// WithWorkerRunStore enables the get_worker_log tool.
+func WithWorkerRunStore(r WorkerRunStore) RegisterOption {
+	return func(o *registerOpts) { o.runStore = r }
+}
+
 // Register registers the collaboration tools, prompt templates,
 // and piggyback middleware with the mcp-go server.
 // orch is optional; when set, create_task from the driver will auto-assign to workers.
@@ -78,15 +112,21 @@ func Register(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, r
 	registerSendMessage(s, svc, logger)
 	registerReadMessages(s, svc, logger)
 
-	// Task tools (3)
+	// Task tools (5)
 	registerCreateTask(s, svc, logger, orch)
 	registerListTasks(s, svc, logger)
-	registerUpdateTask(s, svc, logger)
+	registerUpdateTask(s, svc, logger, o.processProvider)
+	registerCreateSubtasks(s, svc, logger)
+	registerGetTaskHistory(s, svc, logger)
+	registerGetTaskResult(s, svc, logger)
 
-	// Planning tools (3)
+	// Planning tools (6)
 	registerCreatePlan(s, svc, logger)
 	registerGetPlan(s, svc, logger)
 	registerUpdatePlan(s, svc, logger)
+	registerExecutePlan(s, svc, logger, orch)
+	registerImportPlan(s, svc, logger)
+	registerExportPlan(s, svc, logger)
 
 	// Session tools (3)
 	registerGetSessionContext(s, svc, logger, registry)
@@ -105,8 +145,14 @@ func Register(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, r
 	registerRegisterAgent(s, svc, logger)
 	registerListAgents(s, svc, logger)
 
+	// Project tools (1)
+	registerListProjects(s, svc, logger)
+
+	// History tool (1)
+	registerGetHistory(s, svc, logger)
+
 	// Driver/worker tools (3)
-	registerWorkerStatus(s, svc, logger, o.worktreeProvider, o.processProvider)
+	registerWorkerStatus(s, svc, logger, o.worktreeProvider, o.processProvider, o.poolProvider)
 	registerHeartbeat(s, svc, logger)
 	registerCancelAgent(s, svc, logger, o.canceller)
 
@@ -117,13 +163,18 @@ func Register(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, r
 	registerGetWorkContext(s, svc, logger)
 	registerUpdateWorkContext(s, svc, logger)
 
+	// Worker log tool (1, optional)
+	if o.runStore != nil {
+		registerGetWorkerLog(s, o.runStore, logger)
+	}
+
 	// Knowledge tool (1, optional)
 	if o.knowledgeStore != nil {
 		registerQueryKnowledge(s, o.knowledgeStore, logger)
 	}
 
-	// Prompt templates (pair-respond, code-review, plan-feature)
-	registerPrompts(s)
+	// Prompt templates (pair-respond, code-review, plan-feature, task-templates)
+	registerPrompts(s, svc)
 
 	// Resources and resource templates (agent instructions, workflow guides)
 	registerResources(s, svc, logger)
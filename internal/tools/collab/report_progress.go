@@ -57,7 +57,7 @@ func registerReportProgress(s *server.MCPServer, svc *app.CollabService, logger
 				return nil, fmt.Errorf("agent, task_id, and description are required")
 			}
 
-			err := svc.Run(func(state *domain.CollabState) error {
+			err := svc.RunAs(agent, func(state *domain.CollabState) error {
 				now := time.Now()
 
 				// Update the task's progress
@@ -166,6 +166,7 @@ All agents share state via a global SQLite database.
 2. Do the work using your native tools.
 3. Report: `+"`"+`send_message from='%s' to='%s' content='summary'`+"`"+`
 4. Complete: `+"`"+`update_task id=X status='completed' updated_by='%s'`+"`"+`
+   Add a structured result when you have one: result_summary, files_changed, commits, tests ('passed'/'failed'/'not_run'), follow_ups, artifacts=[{name, content}].
 
 ### Delegating Work
 
@@ -196,17 +197,19 @@ All agents work from the same plan.
 4. `+"`"+`update_task id=X status='completed'`+"`"+` or `+"`"+`handoff`+"`"+`
 5. Repeat
 
-## Available MCP Tools (21)
+## Available MCP Tools (26)
 
 | Category | Tools |
 |----------|-------|
 | Messaging | send_message, read_messages |
-| Tasks | create_task, list_tasks, update_task |
+| Tasks | create_task, list_tasks, update_task, create_subtasks, get_task_history, get_task_result |
 | Planning | create_plan, get_plan, update_plan |
 | Session | get_session_context, set_presence, append_session_note |
 | Workflow | handoff, claim_next, request_review |
 | Files | lock_file |
 | Agents | register_agent, list_agents |
+| Projects | list_projects |
+| History | get_history |
 | Workers | worker_status, heartbeat |
 | Work Context | get_work_context, update_work_context |
 
@@ -334,8 +337,12 @@ read_messages for='<you>' unread_only=true
 create_task title='...' assigned_to='<pair>' created_by='<you>'
 list_tasks                                               # all tasks
 list_tasks assigned_to='<agent>' status='pending'        # filtered
+list_tasks status='completed' include_archived=true      # include archived tasks
 update_task id=X status='in_progress' updated_by='<you>'
 update_task id=X status='completed' updated_by='<you>'
+create_subtasks parent_task_id=X created_by='<you>' subtasks=[{title:'...'}, ...]   # split a task
+get_task_history task_id=X                               # every claim of a task and how it ended
+get_task_result task_id=X                                # structured result; artifact='<name>' for one artifact
 ` + "```" + `
 
 ## Planning
@@ -81,9 +81,14 @@ func registerGetSessionContext(s *server.MCPServer, svc *app.CollabService, logg
 				}
 				buf.WriteByte('\n')
 
+				project := callerProject(svc, state, args, agent)
+
 				unreadCount := 0
 				var unreadBuf strings.Builder
 				for _, msg := range state.Messages {
+					if !app.InProject(msg.Project, project) {
+						continue
+					}
 					if (msg.To == agent || msg.To == "all") && !msg.Read {
 						unreadCount++
 						fmt.Fprintf(&unreadBuf, "  From %s: %s\n", msg.From, app.Truncate(msg.Content, 100))
@@ -99,6 +104,9 @@ func registerGetSessionContext(s *server.MCPServer, svc *app.CollabService, logg
 				inProgressCount := 0
 				var taskBuf strings.Builder
 				for _, task := range state.Tasks {
+					if !app.InProject(task.Project, project) {
+						continue
+					}
 					if task.AssignedTo == agent || task.AssignedTo == "any" {
 						switch task.Status {
 						case "pending":
@@ -116,13 +124,19 @@ func registerGetSessionContext(s *server.MCPServer, svc *app.CollabService, logg
 				}
 				buf.WriteByte('\n')
 
-				if len(state.SessionNotes) > 0 {
+				var notes []domain.SessionNote
+				for _, note := range state.SessionNotes {
+					if app.InProject(note.Project, project) {
+						notes = append(notes, note)
+					}
+				}
+				if len(notes) > 0 {
 					buf.WriteString("Recent Session Notes:\n")
 					start := 0
-					if len(state.SessionNotes) > 5 {
-						start = len(state.SessionNotes) - 5
+					if len(notes) > 5 {
+						start = len(notes) - 5
 					}
-					for _, note := range state.SessionNotes[start:] {
+					for _, note := range notes[start:] {
 						fmt.Fprintf(&buf, "  [%s] %s: %s\n", note.Category, note.Author, app.Truncate(note.Content, 60))
 					}
 					buf.WriteByte('\n')
@@ -297,6 +311,7 @@ func registerAppendSessionNote(s *server.MCPServer, svc *app.CollabService, logg
 					Content:   content,
 					Category:  category,
 					Timestamp: time.Now(),
+					Project:   callerProject(svc, state, args, author),
 				}
 				state.SessionNotes = append(state.SessionNotes, note)
 				noteID = note.ID
@@ -0,0 +1,141 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// registerCreateSubtasks registers the create_subtasks tool.
+func registerCreateSubtasks(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("create_subtasks",
+			mcp.WithDescription("Split a task into subtasks. The parent's progress and status roll up from its subtasks: it completes when all of them have."),
+			mcp.WithNumber("parent_task_id", mcp.Required(), mcp.Description("Task to split")),
+			mcp.WithString("created_by", mcp.Required(), mcp.Description("Who is creating the subtasks")),
+			mcp.WithArray("subtasks", mcp.Required(), mcp.Description("Subtasks to create, in order"),
+				mcp.Items(map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"title":                     map[string]any{"type": "string", "description": "Short subtask title"},
+						"description":               map[string]any{"type": "string", "description": "Detailed description"},
+						"assigned_to":               map[string]any{"type": "string", "description": "Assignee (default: the parent's assignee)"},
+						"priority":                  map[string]any{"type": "number", "description": "1=critical, 2=high, 3=normal, 4=low (default: the parent's)"},
+						"relevant_files":            map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Files this subtask should focus on"},
+						"expected_duration_seconds": map[string]any{"type": "number", "description": "Expected duration in seconds"},
+					},
+					"required": []string{"title"},
+				})),
+			mcp.WithBoolean("sequential", mcp.Description("Make each subtask depend on the previous one (default: false)")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			id, err := requireFloat64(args, "parent_task_id")
+			if err != nil {
+				return nil, err
+			}
+			parentID := int(id)
+			createdBy, err := requireString(args, "created_by")
+			if err != nil {
+				return nil, err
+			}
+			items, _ := args["subtasks"].([]any)
+			if len(items) == 0 {
+				return nil, fmt.Errorf("subtasks is required")
+			}
+			sequential, _ := args["sequential"].(bool)
+
+			var created []string
+			if err := svc.RunAs(createdBy, func(state *domain.CollabState) error {
+				extra := app.RegisteredAgentNames(state)
+				if err := app.ValidateAgent(createdBy, state, false, false, extra...); err != nil {
+					return err
+				}
+				parent, err := app.ValidateParent(state, parentID)
+				if err != nil {
+					return err
+				}
+				// Copy what the subtasks inherit: appending below may move parent.
+				parentAssignee, parentPriority := parent.AssignedTo, parent.Priority
+				parentProject, parentCtxID := parent.Project, parent.ContextID
+
+				now := time.Now()
+				prevID := 0
+				for n, raw := range items {
+					item, ok := raw.(map[string]any)
+					if !ok {
+						return fmt.Errorf("subtasks[%d] must be an object", n)
+					}
+					title, _ := item["title"].(string)
+					if title == "" {
+						return fmt.Errorf("subtasks[%d].title is required", n)
+					}
+					description, _ := item["description"].(string)
+					assignedTo, _ := item["assigned_to"].(string)
+					if assignedTo == "" {
+						assignedTo = parentAssignee
+					}
+					if err := app.ValidateAgent(assignedTo, state, true, false, extra...); err != nil {
+						return err
+					}
+					priority := parentPriority
+					if p, ok := item["priority"].(float64); ok {
+						priority = min(max(int(p), 1), 4)
+					}
+					expected := 0
+					if eds, ok := item["expected_duration_seconds"].(float64); ok && eds > 0 {
+						expected = int(eds)
+					}
+					var relevantFiles []string
+					if rf, ok := item["relevant_files"].([]any); ok {
+						for _, x := range rf {
+							if s, ok := x.(string); ok {
+								relevantFiles = append(relevantFiles, s)
+							}
+						}
+					}
+
+					task := domain.Task{
+						ID:                  state.NextTaskID,
+						Title:               title,
+						Description:         description,
+						Status:              "pending",
+						AssignedTo:          assignedTo,
+						CreatedBy:           createdBy,
+						CreatedAt:           now,
+						UpdatedAt:           now,
+						Priority:            priority,
+						ExpectedDurationSec: expected,
+						Project:             parentProject,
+						ParentTaskID:        parentID,
+					}
+					if sequential && prevID != 0 {
+						task.Dependencies = []int{prevID}
+					}
+					state.Tasks = append(state.Tasks, task)
+					state.NextTaskID++
+					prevID = task.ID
+					if len(relevantFiles) > 0 {
+						ensureWorkContextForTask(state, task.ID, relevantFiles, "", nil, parentCtxID)
+					}
+					created = append(created, fmt.Sprintf("#%d %s (assigned to: %s)", task.ID, title, assignedTo))
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+
+			logger.Printf("Task #%d split into %d subtasks by %s", parentID, len(created), createdBy)
+			return mcp.NewToolResultText(fmt.Sprintf("Created %d subtasks of task #%d:\n  %s",
+				len(created), parentID, strings.Join(created, "\n  "))), nil
+		},
+	)
+}
@@ -0,0 +1,101 @@
+package collab
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestCreateSubtasks(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Feature", Status: "in_progress", AssignedTo: "claude-code", CreatedBy: "cursor", Priority: 2, Project: "/work"},
+	}
+	repo.state.NextTaskID = 2
+
+	srv := testServer(svc, logger)
+
+	result, err := callTool(t, srv, "create_subtasks", map[string]any{
+		"parent_task_id": float64(1),
+		"created_by":     "claude-code",
+		"sequential":     true,
+		"subtasks": []any{
+			map[string]any{"title": "Model"},
+			map[string]any{"title": "API", "assigned_to": "any", "priority": float64(3)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Created 2 subtasks of task #1") {
+		t.Errorf("unexpected result: %s", text)
+	}
+
+	model, api := repo.state.Tasks[1], repo.state.Tasks[2]
+	if model.ParentTaskID != 1 || model.AssignedTo != "claude-code" || model.Priority != 2 || model.Project != "/work" {
+		t.Errorf("first subtask should inherit from the parent: %+v", model)
+	}
+	if api.AssignedTo != "any" || api.Priority != 3 || len(api.Dependencies) != 1 || api.Dependencies[0] != 2 {
+		t.Errorf("second subtask = %+v", api)
+	}
+	if api.Status != "waiting" {
+		t.Errorf("sequential subtask status = %q, want waiting", api.Status)
+	}
+
+	// The parent cannot complete before its subtasks; it does once they have.
+	_, err = callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "completed", "updated_by": "claude-code"})
+	if err == nil || !strings.Contains(err.Error(), "subtasks not finished") {
+		t.Fatalf("expected subtasks error, got %v", err)
+	}
+	for _, id := range []float64{2, 3} {
+		if _, err := callTool(t, srv, "update_task", map[string]any{"id": id, "status": "completed", "updated_by": "claude-code"}); err != nil {
+			t.Fatalf("complete #%v: %v", id, err)
+		}
+	}
+	if parent := repo.state.Tasks[0]; parent.Status != "completed" || parent.ProgressPercent != 100 {
+		t.Errorf("parent = %s %d%%, want completed 100%%", parent.Status, parent.ProgressPercent)
+	}
+
+	result, err = callTool(t, srv, "list_tasks", map[string]any{"project": "all"})
+	if err != nil {
+		t.Fatalf("list_tasks: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "\n  Task #2 [completed] - Model") {
+		t.Errorf("subtasks should be indented under the parent:\n%s", text)
+	}
+}
+
+func TestCreateSubtasks_Errors(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Done", Status: "completed", AssignedTo: "claude-code", CreatedBy: "cursor"},
+	}
+	repo.state.NextTaskID = 2
+
+	srv := testServer(svc, logger)
+
+	tests := []struct {
+		args    map[string]any
+		wantErr string
+	}{
+		{map[string]any{"parent_task_id": float64(9), "created_by": "cursor", "subtasks": []any{map[string]any{"title": "x"}}}, "not found"},
+		{map[string]any{"parent_task_id": float64(1), "created_by": "cursor", "subtasks": []any{map[string]any{"title": "x"}}}, "is completed"},
+		{map[string]any{"parent_task_id": float64(1), "created_by": "cursor"}, "subtasks is required"},
+	}
+	for _, tt := range tests {
+		_, err := callTool(t, srv, "create_subtasks", tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("args %v: error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+	if len(repo.state.Tasks) != 1 {
+		t.Errorf("no subtasks should be created, got %d tasks", len(repo.state.Tasks))
+	}
+}
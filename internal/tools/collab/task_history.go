@@ -0,0 +1,107 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// registerGetTaskHistory registers the get_task_history tool.
+func registerGetTaskHistory(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("get_task_history",
+			mcp.WithDescription("Show every attempt at a task: which worker claimed it, when, how the attempt ended (completed, released, worker failure class, watchdog recovery), its last progress and a log excerpt. Use it before re-assigning a task that keeps coming back."),
+			mcp.WithNumber("task_id", mcp.Required(), mcp.Description("Task ID")),
+			mcp.WithBoolean("include_logs", mcp.Description("Include worker log excerpts (default: true)")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			id, err := requireFloat64(args, "task_id")
+			if err != nil {
+				return nil, err
+			}
+			taskID := int(id)
+			includeLogs := true
+			if v, ok := args["include_logs"].(bool); ok {
+				includeLogs = v
+			}
+
+			var task *domain.Task
+			if err := svc.Query(func(state *domain.CollabState) error {
+				for i := range state.Tasks {
+					if state.Tasks[i].ID == taskID {
+						t := state.Tasks[i]
+						task = &t
+						break
+					}
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			if task == nil {
+				archived, err := svc.ArchivedTasks(app.ArchiveFilter{TaskID: taskID})
+				if err != nil && !errors.Is(err, app.ErrNoArchive) {
+					return nil, err
+				}
+				if len(archived) == 0 {
+					return nil, fmt.Errorf("task #%d not found", taskID)
+				}
+				task = &archived[0].Task
+			}
+
+			logger.Printf("Task history for #%d (%d attempts)", taskID, len(task.Attempts))
+			return mcp.NewToolResultText(formatTaskHistory(task, includeLogs, time.Now())), nil
+		},
+	)
+}
+
+// formatTaskHistory renders a task's attempts, oldest first.
+func formatTaskHistory(t *domain.Task, includeLogs bool, now time.Time) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Task #%d [%s] - %s\n", t.ID, t.Status, t.Title)
+	limit := "unlimited"
+	if t.MaxAttempts > 0 {
+		limit = fmt.Sprintf("max %d", t.MaxAttempts)
+	}
+	fmt.Fprintf(&buf, "Attempts: %d (%s)\n", len(t.Attempts), limit)
+	if t.BlockedBy != "" {
+		fmt.Fprintf(&buf, "Blocked by: %s\n", t.BlockedBy)
+	}
+	if len(t.Attempts) == 0 {
+		buf.WriteString("\nNo attempts yet.\n")
+		return buf.String()
+	}
+	for _, a := range t.Attempts {
+		end, outcome := now, "running"
+		if !a.EndedAt.IsZero() {
+			end, outcome = a.EndedAt, a.Outcome
+		} else {
+			a.ProgressPercent, a.LastProgress = t.ProgressPercent, t.ProgressDescription
+		}
+		if a.ExitClass != "" {
+			outcome += " (" + a.ExitClass + ")"
+		}
+		fmt.Fprintf(&buf, "\n#%d %s: %s, started %s, %s\n", a.Number, a.Agent, outcome,
+			a.StartedAt.Format(time.RFC3339), end.Sub(a.StartedAt).Round(time.Second))
+		if a.Error != "" {
+			fmt.Fprintf(&buf, "  Error: %s\n", a.Error)
+		}
+		if a.LastProgress != "" || a.ProgressPercent > 0 {
+			fmt.Fprintf(&buf, "  Last progress: %d%% %s\n", a.ProgressPercent, a.LastProgress)
+		}
+		if includeLogs && a.LogExcerpt != "" {
+			fmt.Fprintf(&buf, "  Log:\n    %s\n", strings.ReplaceAll(a.LogExcerpt, "\n", "\n    "))
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,58 @@
+package collab
+
+import (
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+func TestGetTaskHistory(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "Flaky", "created_by": "cursor", "assigned_to": "claude-code", "max_attempts": float64(2)}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.state.Tasks[0].MaxAttempts != 2 {
+		t.Fatalf("max_attempts = %d, want 2", repo.state.Tasks[0].MaxAttempts)
+	}
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "in_progress", "updated_by": "claude-code"}); err != nil {
+		t.Fatal(err)
+	}
+	// A worker failure closes the attempt with its classification.
+	started := repo.state.Tasks[0].Attempts[0].StartedAt
+	repo.state.Tasks[0].Attempts[0] = domain.TaskAttempt{
+		Number: 1, Agent: "claude-code", StartedAt: started, EndedAt: started.Add(time.Minute),
+		Outcome: "worker_failed", ExitClass: "quota_exhausted", Error: "exited after 1m0s: exit status 1",
+		LogExcerpt: "QuotaError: quota exhausted",
+	}
+	repo.state.Tasks[0].Status = "pending"
+
+	result, err := callTool(t, srv, "get_task_history", map[string]any{"task_id": float64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resultText(t, result)
+	for _, want := range []string{"Attempts: 1 (max 2)", "#1 claude-code: worker_failed (quota_exhausted)", "Error: exited after 1m0s", "QuotaError"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("history missing %q:\n%s", want, text)
+		}
+	}
+
+	result, err = callTool(t, srv, "get_task_history", map[string]any{"task_id": float64(1), "include_logs": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(resultText(t, result), "QuotaError") {
+		t.Error("include_logs=false should omit log excerpts")
+	}
+
+	if _, err := callTool(t, srv, "get_task_history", map[string]any{"task_id": float64(9)}); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
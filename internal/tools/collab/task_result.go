@@ -0,0 +1,206 @@
+package collab
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+)
+
+// resultArgs are the update_task arguments that report a structured result.
+var resultArgs = []string{"result_summary", "files_changed", "commits", "tests", "test_details", "follow_ups", "artifacts"}
+
+// parseResultArgs builds a TaskResult and its artifacts from update_task
+// arguments. Returns nil if no result argument is present.
+func parseResultArgs(args map[string]any, reportedBy string) (*domain.TaskResult, []domain.Artifact, error) {
+	present := false
+	for _, k := range resultArgs {
+		if _, ok := args[k]; ok {
+			present = true
+			break
+		}
+	}
+	if !present {
+		return nil, nil, nil
+	}
+	result := &domain.TaskResult{ReportedBy: reportedBy}
+	result.Summary, _ = args["result_summary"].(string)
+	result.FilesChanged = optionalStrings(args, "files_changed")
+	result.Commits = optionalStrings(args, "commits")
+	result.Tests, _ = args["tests"].(string)
+	result.TestDetails, _ = args["test_details"].(string)
+	result.FollowUps = optionalStrings(args, "follow_ups")
+
+	items, _ := args["artifacts"].([]any)
+	var artifacts []domain.Artifact
+	for n, raw := range items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("artifacts[%d] must be an object", n)
+		}
+		name, _ := item["name"].(string)
+		content, _ := item["content"].(string)
+		contentType, _ := item["content_type"].(string)
+		a := domain.Artifact{Name: name, ContentType: contentType, Content: []byte(content)}
+		switch encoding, _ := item["encoding"].(string); encoding {
+		case "", "text":
+		case "base64":
+			data, err := base64.StdEncoding.DecodeString(content)
+			if err != nil {
+				return nil, nil, fmt.Errorf("artifacts[%d] (%s): invalid base64: %w", n, name, err)
+			}
+			a.Content = data
+		default:
+			return nil, nil, fmt.Errorf("artifacts[%d] (%s): unknown encoding %q (want text or base64)", n, name, encoding)
+		}
+		artifacts = append(artifacts, a)
+	}
+	return result, artifacts, nil
+}
+
+// registerGetTaskResult registers the get_task_result tool.
+func registerGetTaskResult(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("get_task_result",
+			mcp.WithDescription("Read the structured result a worker reported for a task: summary, files changed, commits, test outcome, follow-up suggestions and artifacts. Pass artifact to fetch one artifact's content."),
+			mcp.WithNumber("task_id", mcp.Required(), mcp.Description("Task ID")),
+			mcp.WithString("artifact", mcp.Description("Artifact name to return instead of the result overview")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			id, err := requireFloat64(args, "task_id")
+			if err != nil {
+				return nil, err
+			}
+			taskID := int(id)
+
+			if name, _ := args["artifact"].(string); name != "" {
+				a, err := svc.Artifact(taskID, name)
+				if errors.Is(err, app.ErrArtifactNotFound) {
+					return nil, fmt.Errorf("task #%d has no artifact %q", taskID, name)
+				}
+				if err != nil {
+					return nil, err
+				}
+				logger.Printf("Artifact %q of task #%d read (%d bytes)", name, taskID, len(a.Content))
+				return mcp.NewToolResultText(formatArtifact(a)), nil
+			}
+
+			var task *domain.Task
+			if err := svc.Query(func(state *domain.CollabState) error {
+				if t := findTask(state, taskID); t != nil {
+					c := *t
+					task = &c
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			if task == nil {
+				archived, err := svc.ArchivedTasks(app.ArchiveFilter{TaskID: taskID})
+				if err != nil && !errors.Is(err, app.ErrNoArchive) {
+					return nil, err
+				}
+				if len(archived) == 0 {
+					return nil, fmt.Errorf("task #%d not found", taskID)
+				}
+				task = &archived[0].Task
+			}
+			return mcp.NewToolResultText(formatTaskResult(task)), nil
+		},
+	)
+}
+
+// findTask returns the live task with id, or nil.
+func findTask(state *domain.CollabState, id int) *domain.Task {
+	for i := range state.Tasks {
+		if state.Tasks[i].ID == id {
+			return &state.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// formatTaskResult renders a task's structured result.
+func formatTaskResult(t *domain.Task) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Task #%d [%s] - %s\n", t.ID, t.Status, t.Title)
+	if v := t.Verification; v != nil {
+		fmt.Fprintf(&buf, "Verification: %s, run by %s at %s in %s\n", verificationLine(v), v.RanBy, v.RanAt.Format(time.RFC3339), v.Dir)
+		for _, c := range v.Checks {
+			fmt.Fprintf(&buf, "  $ %s (exit %d, %s)\n", c.Command, c.ExitCode, msDuration(c.DurationMs))
+		}
+	}
+	r := t.Result
+	if r == nil {
+		if t.ResultSummary != "" {
+			fmt.Fprintf(&buf, "Summary: %s\n", t.ResultSummary)
+		}
+		buf.WriteString("\nNo structured result reported.\n")
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, "Reported by %s at %s\n", r.ReportedBy, r.ReportedAt.Format(time.RFC3339))
+	if r.Summary != "" {
+		fmt.Fprintf(&buf, "\nSummary: %s\n", r.Summary)
+	}
+	if r.Tests != "" {
+		fmt.Fprintf(&buf, "Tests: %s\n", r.Tests)
+	}
+	if r.TestDetails != "" {
+		fmt.Fprintf(&buf, "  %s\n", strings.ReplaceAll(r.TestDetails, "\n", "\n  "))
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "\n%s:\n", title)
+		for _, s := range items {
+			fmt.Fprintf(&buf, "  - %s\n", s)
+		}
+	}
+	list("Files changed", r.FilesChanged)
+	list("Commits", r.Commits)
+	list("Follow-ups", r.FollowUps)
+	if len(r.Artifacts) > 0 {
+		buf.WriteString("\nArtifacts (fetch with get_task_result artifact=<name>):\n")
+		for _, a := range r.Artifacts {
+			fmt.Fprintf(&buf, "  - %s (%s, %d bytes)\n", a.Name, a.ContentType, a.Size)
+		}
+	}
+	return buf.String()
+}
+
+// resultLine summarizes a result on one line for list_tasks.
+func resultLine(r *domain.TaskResult) string {
+	var parts []string
+	if r.Summary != "" {
+		parts = append(parts, r.Summary)
+	}
+	if r.Tests != "" {
+		parts = append(parts, "tests "+r.Tests)
+	}
+	if n := len(r.Artifacts); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d artifacts", n))
+	}
+	parts = append(parts, "see get_task_result")
+	return strings.Join(parts, "; ")
+}
+
+// formatArtifact renders an artifact's content; binary content is base64.
+func formatArtifact(a *domain.Artifact) string {
+	header := fmt.Sprintf("Artifact %s of task #%d (%s, %d bytes, by %s)", a.Name, a.TaskID, a.ContentType, len(a.Content), a.CreatedBy)
+	if utf8.Valid(a.Content) {
+		return header + "\n\n" + string(a.Content)
+	}
+	return header + ", base64:\n\n" + base64.StdEncoding.EncodeToString(a.Content)
+}
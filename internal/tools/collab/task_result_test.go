@@ -0,0 +1,144 @@
+package collab
+
+import (
+	"encoding/base64"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaakkos/stringwork/internal/app"
+	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/repository/memory"
+)
+
+func TestUpdateTask_StructuredResult(t *testing.T) {
+	repo := memory.New()
+	logger := log.New(io.Discard, "", 0)
+	svc := app.NewCollabService(repo, newMockPolicy(), logger)
+	srv := testServer(svc, logger)
+
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "Fix parser", "created_by": "cursor", "assigned_to": "claude-code"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := callTool(t, srv, "update_task", map[string]any{
+		"id": float64(1), "status": "completed", "updated_by": "claude-code",
+		"result_summary": "Parser handles nested lists",
+		"files_changed":  []any{"parser.go", "parser_test.go"},
+		"commits":        []any{"abc1234"},
+		"tests":          "passed",
+		"test_details":   "go test ./... ok",
+		"follow_ups":     []any{"fuzz the parser"},
+		"artifacts": []any{
+			map[string]any{"name": "fix.patch", "content": "--- a/parser.go\n+++ b/parser.go\n"},
+			map[string]any{"name": "trace.bin", "content": base64.StdEncoding.EncodeToString([]byte{0, 0xff}), "encoding": "base64"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "result recorded (2 artifacts)") {
+		t.Errorf("update_task result = %q", text)
+	}
+
+	var task domain.Task
+	_ = svc.Query(func(s *domain.CollabState) error { task = s.Tasks[0]; return nil })
+	r := task.Result
+	if r == nil || r.ReportedBy != "claude-code" || !slices.Equal(r.FilesChanged, []string{"parser.go", "parser_test.go"}) || len(r.Artifacts) != 2 {
+		t.Fatalf("result = %+v", r)
+	}
+	if task.ResultSummary != "Parser handles nested lists" {
+		t.Errorf("ResultSummary = %q", task.ResultSummary)
+	}
+
+	result, err = callTool(t, srv, "get_task_result", map[string]any{"task_id": float64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resultText(t, result)
+	for _, want := range []string{"Summary: Parser handles nested lists", "Tests: passed", "  - parser_test.go", "  - abc1234", "  - fuzz the parser", "fix.patch (text/x-diff; charset=utf-8, 32 bytes)", "trace.bin (application/octet-stream, 2 bytes)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("get_task_result missing %q:\n%s", want, text)
+		}
+	}
+
+	result, err = callTool(t, srv, "get_task_result", map[string]any{"task_id": float64(1), "artifact": "fix.patch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.HasSuffix(text, "\n\n--- a/parser.go\n+++ b/parser.go\n") {
+		t.Errorf("artifact text = %q", text)
+	}
+	result, err = callTool(t, srv, "get_task_result", map[string]any{"task_id": float64(1), "artifact": "trace.bin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.HasSuffix(text, "base64:\n\nAP8=") {
+		t.Errorf("binary artifact = %q", text)
+	}
+	if _, err := callTool(t, srv, "get_task_result", map[string]any{"task_id": float64(1), "artifact": "missing.txt"}); err == nil {
+		t.Error("expected error for unknown artifact")
+	}
+
+	result, err = callTool(t, srv, "list_tasks", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Result: Parser handles nested lists; tests passed; 2 artifacts") {
+		t.Errorf("list_tasks missing result line:\n%s", text)
+	}
+}
+
+func TestUpdateTask_ResultValidation(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	svc := app.NewCollabService(memory.New(), newMockPolicy(), logger)
+	srv := testServer(svc, logger)
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "T", "created_by": "cursor", "assigned_to": "claude-code"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{"path in name", map[string]any{"artifacts": []any{map[string]any{"name": "../x", "content": "x"}}}, "path separators"},
+		{"duplicate name", map[string]any{"artifacts": []any{map[string]any{"name": "a", "content": "x"}, map[string]any{"name": "a", "content": "y"}}}, "duplicate"},
+		{"bad base64", map[string]any{"artifacts": []any{map[string]any{"name": "a", "content": "!!", "encoding": "base64"}}}, "invalid base64"},
+		{"too large", map[string]any{"artifacts": []any{map[string]any{"name": "a", "content": strings.Repeat("x", app.MaxArtifactSize+1)}}}, "limit"},
+	}
+	for _, tt := range tests {
+		args := map[string]any{"id": float64(1), "status": "completed", "updated_by": "claude-code"}
+		for k, v := range tt.args {
+			args[k] = v
+		}
+		_, err := callTool(t, srv, "update_task", args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+	_ = svc.Query(func(s *domain.CollabState) error {
+		if task := s.Tasks[0]; task.Status != "pending" || task.Result != nil {
+			t.Errorf("rejected updates changed the task: %+v", task)
+		}
+		return nil
+	})
+
+	// Without an artifact store only results without artifacts are accepted.
+	plain, repo := newTestService()
+	srv = testServer(plain, logger)
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "T", "created_by": "cursor", "assigned_to": "claude-code"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "updated_by": "claude-code",
+		"artifacts": []any{map[string]any{"name": "a", "content": "x"}}}); err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Errorf("artifacts without store: error = %v", err)
+	}
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "completed", "updated_by": "claude-code", "tests": "failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if r := repo.state.Tasks[0].Result; r == nil || r.Tests != "failed" || len(r.Artifacts) != 0 {
+		t.Errorf("result = %+v", r)
+	}
+}
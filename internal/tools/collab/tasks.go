@@ -2,8 +2,11 @@ package collab
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -17,8 +20,8 @@ import (
 func registerCreateTask(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, orch *app.TaskOrchestrator) {
 	s.AddTool(
 		mcp.NewTool("create_task",
-			mcp.WithDescription("Create a shared task for the pair programming session. Use this to coordinate work and track progress."),
-			mcp.WithString("title", mcp.Required(), mcp.Description("Short task title")),
+			mcp.WithDescription("Create a shared task for the pair programming session. Use this to coordinate work and track progress. Pass template (see the task-templates prompt) to start from a configured task template; explicit arguments override it."),
+			mcp.WithString("title", mcp.Description("Short task title (required unless template is given)")),
 			mcp.WithString("description", mcp.Description("Detailed task description")),
 			mcp.WithString("assigned_to", mcp.Description("Who should work on this (e.g., 'cursor', 'claude-code', 'any')")),
 			mcp.WithString("created_by", mcp.Required(), mcp.Description("Who created this task")),
@@ -29,6 +32,14 @@ func registerCreateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 			mcp.WithString("parent_context_id", mcp.Description("Parent work context ID for subtask inheritance")),
 			mcp.WithArray("depends_on", mcp.Description("Task IDs this task depends on")),
 			mcp.WithNumber("expected_duration_seconds", mcp.Description("Expected task duration in seconds. The watchdog alerts the driver if this SLA is exceeded. Example: 300 for a 5-minute task.")),
+			mcp.WithNumber("max_attempts", mcp.Description("Block the task instead of handing it out again once this many claims have failed to finish it (default: 0 = unlimited)")),
+			mcp.WithString("template", mcp.Description("Name of a task template from task_templates in the server config")),
+			mcp.WithObject("vars", mcp.Description("Values for the template's {variables}, e.g. {\"package\": \"internal/app\"}")),
+			mcp.WithString("not_before", mcp.Description("Hold the task as scheduled until this time: RFC3339 (e.g. '2026-01-02T03:00:00Z') or a delay from now (e.g. '30m', '2h')")),
+			mcp.WithArray("labels", mcp.Description("Labels for filtering with list_tasks, e.g. ['bug', 'frontend']"), mcp.WithStringItems()),
+			mcp.WithString("plan_id", mcp.Description("ID of the plan this task belongs to")),
+			mcp.WithArray("verify", mcp.Description("Verification commands (e.g. ['go test ./...']) that must succeed in the worker's workspace before the task can be completed; default: the template's, else verification.commands from the server config. Driver only"), mcp.WithStringItems()),
+			mcp.WithString("schedule", mcp.Description("Make the task recurring: a cron spec 'minute hour day-of-month month day-of-week' (e.g. '0 3 * * *' for nightly at 03:00) or @hourly, @daily, @weekly, @monthly. Each run is created as a new pending task; cancel this task to stop the schedule.")),
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args := req.GetArguments()
@@ -83,16 +94,82 @@ func registerCreateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 				}
 			}
 
+			maxAttempts := 0
+			if v, ok := args["max_attempts"].(float64); ok && v > 0 {
+				maxAttempts = int(v)
+			}
+
+			verify := optionalStrings(args, "verify")
+			templateName, _ := args["template"].(string)
+			var workerType string
+			var capabilities []string
+			if templateName != "" {
+				tpl, err := expandTemplateArgs(svc, templateName, args)
+				if err != nil {
+					return nil, err
+				}
+				if title == "" {
+					title = tpl.Title
+				}
+				if description == "" {
+					description = tpl.Description
+				}
+				if _, ok := args["priority"].(float64); !ok && tpl.Priority > 0 {
+					priority = tpl.Priority
+				}
+				if expectedDurationSec == 0 {
+					expectedDurationSec = tpl.ExpectedDurationSec
+				}
+				constraints = append(tpl.Constraints, constraints...)
+				workerType, capabilities = tpl.WorkerType, tpl.Capabilities
+				if len(verify) == 0 {
+					verify = tpl.Verify
+				}
+			} else if _, ok := args["vars"]; ok {
+				return nil, fmt.Errorf("vars requires template")
+			}
+
 			if title == "" || createdBy == "" {
 				return nil, fmt.Errorf("title and created_by are required")
 			}
+			labels, err := app.NormalizeLabels(optionalStrings(args, "labels"))
+			if err != nil {
+				return nil, err
+			}
+			planID, _ := args["plan_id"].(string)
+
+			now := time.Now()
+			var notBefore time.Time
+			if v, _ := args["not_before"].(string); v != "" {
+				t, err := parseNotBefore(v, now)
+				if err != nil {
+					return nil, err
+				}
+				notBefore = t
+			}
+			scheduleSpec, _ := args["schedule"].(string)
+			if scheduleSpec != "" {
+				sched, err := app.ParseSchedule(scheduleSpec)
+				if err != nil {
+					return nil, err
+				}
+				from := now
+				if notBefore.After(now) {
+					from = notBefore.Add(-time.Minute) // not_before itself may be the first run
+				}
+				if notBefore = sched.Next(from); notBefore.IsZero() {
+					return nil, fmt.Errorf("schedule %q never runs", scheduleSpec)
+				}
+			}
+			scheduled := notBefore.After(now)
 
 			if assignedTo == "" {
 				assignedTo = "any"
 			}
 
 			var taskID int
-			if err := svc.Run(func(state *domain.CollabState) error {
+			var waitingOn []int
+			if err := svc.RunAs(createdBy, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(createdBy, state, false, false, extra...); err != nil {
 					return err
@@ -101,17 +178,11 @@ func registerCreateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 					return err
 				}
 
-				for _, depID := range dependencies {
-					found := false
-					for _, t := range state.Tasks {
-						if t.ID == depID {
-							found = true
-							break
-						}
-					}
-					if !found {
-						return fmt.Errorf("dependency task #%d not found", depID)
-					}
+				if err := app.ValidateDependencies(state, state.NextTaskID, dependencies); err != nil {
+					return err
+				}
+				if _, ok := state.Plans[planID]; planID != "" && !ok {
+					return fmt.Errorf("plan %q not found", planID)
 				}
 
 				task := domain.Task{
@@ -121,30 +192,67 @@ func registerCreateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 					Status:              "pending",
 					AssignedTo:          assignedTo,
 					CreatedBy:           createdBy,
-					CreatedAt:           time.Now(),
-					UpdatedAt:           time.Now(),
+					CreatedAt:           now,
+					UpdatedAt:           now,
 					Priority:            priority,
 					Dependencies:        dependencies,
 					ExpectedDurationSec: expectedDurationSec,
+					MaxAttempts:         maxAttempts,
+					WorkerType:          workerType,
+					Capabilities:        capabilities,
+					Project:             callerProject(svc, state, args, createdBy),
+					Schedule:            scheduleSpec,
+					Labels:              labels,
+					PlanID:              planID,
+					Verify:              verify,
+				}
+				if scheduled {
+					// Dependencies are checked once the scheduler makes the task pending.
+					task.Status = "scheduled"
+					task.NotBefore = notBefore
+				} else if waitingOn = app.OpenDependencies(state, &task); len(waitingOn) > 0 {
+					task.Status = "waiting"
 				}
 				state.Tasks = append(state.Tasks, task)
 				taskID = state.NextTaskID
 				state.NextTaskID++
 
-				if orch != nil && state.DriverID != "" && createdBy == state.DriverID && assignedTo == "any" {
+				// Waiting and scheduled tasks stay unassigned until they become pending.
+				if orch != nil && state.DriverID != "" && createdBy == state.DriverID && assignedTo == "any" && len(waitingOn) == 0 && !scheduled {
 					orch.AssignTask(&state.Tasks[len(state.Tasks)-1], state)
 				}
 				if len(relevantFiles) > 0 || background != "" || len(constraints) > 0 {
 					ensureWorkContextForTask(state, taskID, relevantFiles, background, constraints, parentContextID)
 				}
+
+				if archived := app.ArchiveTasks(state, svc.Policy().TaskRetentionDays(), now); archived > 0 {
+					logger.Printf("Archived %d finished tasks", archived)
+				}
 				return nil
 			}); err != nil {
 				return nil, err
 			}
 
 			depInfo := ""
+			if scheduleSpec != "" {
+				depInfo = fmt.Sprintf(", recurring: %s, first run: %s", scheduleSpec, notBefore.Format(time.RFC3339))
+			} else if scheduled {
+				depInfo = ", scheduled for " + notBefore.Format(time.RFC3339)
+			}
 			if len(dependencies) > 0 {
-				depInfo = fmt.Sprintf(", depends on: %v", dependencies)
+				depInfo += fmt.Sprintf(", depends on: %v", dependencies)
+			}
+			if len(waitingOn) > 0 {
+				depInfo += fmt.Sprintf("; waiting on: %v", waitingOn)
+			}
+			if templateName != "" {
+				depInfo += ", template: " + templateName
+			}
+			if len(labels) > 0 {
+				depInfo += ", labels: " + strings.Join(labels, ", ")
+			}
+			if len(verify) > 0 {
+				depInfo += ", verify: " + strings.Join(verify, "; ")
 			}
 			priorityNames := map[int]string{1: "critical", 2: "high", 3: "normal", 4: "low"}
 			logger.Printf("Task #%d created by %s (priority: %s)", taskID, createdBy, priorityNames[priority])
@@ -154,60 +262,240 @@ func registerCreateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 	)
 }
 
+// parseNotBefore parses create_task's not_before: an RFC3339 time or a delay
+// from now such as "30m".
+func parseNotBefore(v string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid not_before %q: want an RFC3339 time or a delay like '30m'", v)
+	}
+	return now.Add(d), nil
+}
+
+// scheduleLine describes when a scheduled task next becomes pending.
+func scheduleLine(t *domain.Task) string {
+	if t.Schedule != "" {
+		return fmt.Sprintf("Schedule: %s, next run: %s", t.Schedule, t.NotBefore.Format(time.RFC3339))
+	}
+	return "Scheduled for: " + t.NotBefore.Format(time.RFC3339)
+}
+
+// verifyCompletion runs the verification commands of task taskID before
+// agent completes it, in the directory agent's worker process runs in (its
+// worktree, if any) or else agent's workspace. Returns nil when there is
+// nothing to verify, or when the update will be rejected anyway.
+func verifyCompletion(ctx context.Context, svc *app.CollabService, taskID int, agent string, pip ProcessInfoProvider) (*domain.Verification, error) {
+	cfg := svc.Policy().Verification()
+	var commands []string
+	var assignee, dir string
+	if err := svc.Query(func(state *domain.CollabState) error {
+		task := findTask(state, taskID)
+		if task == nil || task.Status == "completed" || task.Schedule != "" || len(app.OpenSubtasks(state, taskID)) > 0 {
+			return nil
+		}
+		commands = app.VerifyCommands(task, cfg)
+		assignee = task.AssignedTo
+		dir = app.VerificationDir(state, task, agent, svc.Policy().WorkspaceRoot())
+		return nil
+	}); err != nil || len(commands) == 0 {
+		return nil, err
+	}
+	if pip != nil {
+		procs := pip.GetProcessInfo()
+		for _, name := range []string{agent, assignee} {
+			if p, ok := procs[name]; ok && p.WorkspaceDir != "" {
+				dir = p.WorkspaceDir
+				break
+			}
+		}
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("cannot verify task #%d: no workspace known for %s", taskID, agent)
+	}
+	v := app.RunVerification(ctx, dir, commands, app.VerifyTimeout(cfg))
+	v.RanBy = agent
+	return &v, nil
+}
+
+// verificationLine summarizes a verification outcome in one line.
+func verificationLine(v *domain.Verification) string {
+	if c := app.FailedCheck(v); c != nil {
+		if c.TimedOut {
+			return fmt.Sprintf("failed: %s timed out after %s", c.Command, msDuration(c.DurationMs))
+		}
+		return fmt.Sprintf("failed: %s (exit %d)", c.Command, c.ExitCode)
+	}
+	var total int64
+	for _, c := range v.Checks {
+		total += c.DurationMs
+	}
+	noun := "commands"
+	if len(v.Checks) == 1 {
+		noun = "command"
+	}
+	return fmt.Sprintf("passed (%d %s, %s)", len(v.Checks), noun, msDuration(total))
+}
+
+// verificationFailure is the error update_task returns when verification fails.
+func verificationFailure(taskID int, v *domain.Verification) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "task #%d not completed: verification %s\nin %s", taskID, verificationLine(v), v.Dir)
+	if c := app.FailedCheck(v); c != nil && c.Output != "" {
+		fmt.Fprintf(&b, "\n\n$ %s\n%s", c.Command, c.Output)
+	}
+	b.WriteString("\n\nFix the problem and set status=completed again.")
+	return b.String()
+}
+
+func msDuration(ms int64) time.Duration {
+	return (time.Duration(ms) * time.Millisecond).Round(100 * time.Millisecond)
+}
+
+// expandTemplateArgs expands the task template name with create_task's vars.
+func expandTemplateArgs(svc *app.CollabService, name string, args map[string]any) (app.TemplatedTask, error) {
+	templates := svc.Policy().TaskTemplates()
+	tpl, ok := templates[name]
+	if !ok {
+		available := "none configured"
+		if len(templates) > 0 {
+			available = strings.Join(app.TaskTemplateNames(templates), ", ")
+		}
+		return app.TemplatedTask{}, fmt.Errorf("unknown task template %q (available: %s)", name, available)
+	}
+	vars := make(map[string]string)
+	raw, _ := args["vars"].(map[string]any)
+	for k, v := range raw {
+		vars[k] = fmt.Sprint(v)
+	}
+	return app.ExpandTaskTemplate(name, tpl, vars)
+}
+
 // registerListTasks registers the list_tasks tool.
 func registerListTasks(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
 	s.AddTool(
 		mcp.NewTool("list_tasks",
-			mcp.WithDescription("List shared tasks. Check this to see what work needs to be done."),
-			mcp.WithString("status", mcp.Description("Filter by status (default: 'all')"), mcp.Enum("all", "pending", "in_progress", "completed", "blocked")),
+			mcp.WithDescription("List shared tasks. Check this to see what work needs to be done. Filter by status, assignee, creator, labels, priority, plan, time range or text; long lists are paged (pass the returned cursor for the next page)."),
+			mcp.WithString("status", mcp.Description("Filter by status (default: 'all')"), mcp.Enum("all", "scheduled", "waiting", "pending", "in_progress", "completed", "blocked", "cancelled")),
 			mcp.WithString("assigned_to", mcp.Description("Filter by assignee")),
+			mcp.WithString("project", mcp.Description("Project (workspace path) to list; 'all' for every project (default: the assignee's or server's workspace)")),
+			mcp.WithBoolean("include_archived", mcp.Description("Also list finished tasks that were archived after task_retention_days (default: false)")),
+			mcp.WithString("created_by", mcp.Description("Filter by creator")),
+			mcp.WithArray("labels", mcp.Description("Only tasks carrying all of these labels"), mcp.WithStringItems()),
+			mcp.WithNumber("priority", mcp.Description("Filter by priority: 1=critical, 2=high, 3=normal, 4=low")),
+			mcp.WithString("plan_id", mcp.Description("Only tasks belonging to this plan")),
+			mcp.WithString("created_after", mcp.Description("Only tasks created at or after this time (RFC3339 or YYYY-MM-DD)")),
+			mcp.WithString("created_before", mcp.Description("Only tasks created before this time (RFC3339 or YYYY-MM-DD)")),
+			mcp.WithString("updated_after", mcp.Description("Only tasks updated at or after this time (RFC3339 or YYYY-MM-DD)")),
+			mcp.WithString("updated_before", mcp.Description("Only tasks updated before this time (RFC3339 or YYYY-MM-DD)")),
+			mcp.WithString("search", mcp.Description("Words that must all appear in the title or description (case-insensitive)")),
+			mcp.WithString("sort", mcp.Description("Sort order (default: 'id'); 'priority' lists the most urgent first"), mcp.Enum(app.TaskSortID, app.TaskSortPriority, app.TaskSortCreated, app.TaskSortUpdated)),
+			mcp.WithString("order", mcp.Description("Sort direction (default: 'asc')"), mcp.Enum("asc", "desc")),
+			mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Tasks per page (default: %d, max: %d)", app.DefaultTaskPageSize, app.MaxTaskPageSize))),
+			mcp.WithString("cursor", mcp.Description("Cursor from the previous page to continue listing")),
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args := req.GetArguments()
-			statusFilter := "all"
-			if v, ok := args["status"].(string); ok {
-				statusFilter = v
-			}
-
-			assignedFilter := ""
-			if v, ok := args["assigned_to"].(string); ok {
-				assignedFilter = v
+			q, err := taskQueryArgs(args)
+			if err != nil {
+				return nil, err
 			}
+			includeArchived, _ := args["include_archived"].(bool)
 
-			var result string
-			var count int
-			var needsCtxUpdate bool
+			var tasks []domain.Task
+			parents := make(map[int]bool)
 			// Use Query (read-only) for the listing itself.
 			if err := svc.Query(func(state *domain.CollabState) error {
-				if assignedFilter != "" {
+				if q.AssignedTo != "" {
 					extra := app.RegisteredAgentNames(state)
-					if err := app.ValidateAgent(assignedFilter, state, true, false, extra...); err != nil {
+					if err := app.ValidateAgent(q.AssignedTo, state, true, false, extra...); err != nil {
 						return err
 					}
 				}
-
-				count = 0
-				for _, task := range state.Tasks {
-					if statusFilter != "all" && task.Status != statusFilter {
-						continue
-					}
-					if assignedFilter != "" && task.AssignedTo != assignedFilter && task.AssignedTo != "any" {
-						continue
+				q.Project = callerProject(svc, state, args, q.AssignedTo)
+				tasks = slices.Clone(state.Tasks)
+				for _, t := range state.Tasks {
+					if t.ParentTaskID != 0 {
+						parents[t.ParentTaskID] = true
 					}
-					result += fmt.Sprintf("Task #%d [%s] - %s\n", task.ID, task.Status, task.Title)
-					if task.Description != "" {
-						result += fmt.Sprintf("  Description: %s\n", task.Description)
-					}
-					result += fmt.Sprintf("  Assigned to: %s, Created by: %s\n\n", task.AssignedTo, task.CreatedBy)
-					count++
 				}
-				needsCtxUpdate = assignedFilter != "" && assignedFilter != "any" && count > 0
 				return nil
 			}); err != nil {
 				return nil, err
 			}
+			archivedAt := make(map[int]time.Time)
+			if includeArchived {
+				f := app.ArchiveFilter{AssignedTo: q.AssignedTo, Project: q.Project}
+				if q.Status != "all" {
+					f.Status = q.Status
+				}
+				archived, err := svc.ArchivedTasks(f)
+				if err != nil {
+					return nil, err
+				}
+				for _, a := range archived {
+					tasks = append(tasks, a.Task)
+					archivedAt[a.Task.ID] = a.ArchivedAt
+				}
+			}
+			page, err := app.QueryTasks(tasks, q)
+			if err != nil {
+				return nil, err
+			}
+
+			// In ID order, subtasks are listed, indented, under their parent.
+			var entries []app.TaskTreeEntry
+			if q.Sort == app.TaskSortID && !q.Desc {
+				entries = app.TaskTree(page.Tasks)
+			} else {
+				for _, t := range page.Tasks {
+					entries = append(entries, app.TaskTreeEntry{Task: t})
+				}
+			}
+			var result string
+			for _, e := range entries {
+				task, indent := e.Task, strings.Repeat("  ", e.Depth)
+				at, archived := archivedAt[task.ID]
+				if archived {
+					result += fmt.Sprintf("%sTask #%d [%s, archived %s] - %s\n", indent, task.ID, task.Status, at.Format("2006-01-02"), task.Title)
+				} else {
+					result += fmt.Sprintf("%sTask #%d [%s] - %s\n", indent, task.ID, task.Status, task.Title)
+				}
+				if task.ParentTaskID != 0 && e.Depth == 0 {
+					result += fmt.Sprintf("%s  Subtask of: #%d\n", indent, task.ParentTaskID)
+				}
+				if task.Description != "" && !archived {
+					result += fmt.Sprintf("%s  Description: %s\n", indent, task.Description)
+				}
+				if len(task.Labels) > 0 {
+					result += fmt.Sprintf("%s  Labels: %s\n", indent, strings.Join(task.Labels, ", "))
+				}
+				if task.Status == "scheduled" {
+					result += fmt.Sprintf("%s  %s\n", indent, scheduleLine(&task))
+				} else if task.ScheduledFrom != 0 {
+					result += fmt.Sprintf("%s  Run of: #%d\n", indent, task.ScheduledFrom)
+				}
+				if parents[task.ID] && !archived {
+					result += fmt.Sprintf("%s  Progress: %d%% (%s)\n", indent, task.ProgressPercent, task.ProgressDescription)
+				}
+				if v := task.Verification; v != nil {
+					result += fmt.Sprintf("%s  Verification: %s\n", indent, verificationLine(v))
+				}
+				if r := task.Result; r != nil {
+					result += fmt.Sprintf("%s  Result: %s\n", indent, resultLine(r))
+				} else if archived && task.ResultSummary != "" {
+					result += fmt.Sprintf("%s  Result: %s\n", indent, task.ResultSummary)
+				}
+				result += fmt.Sprintf("%s  Assigned to: %s, Created by: %s\n\n", indent, task.AssignedTo, task.CreatedBy)
+			}
+			if page.NextCursor != "" {
+				result += fmt.Sprintf("Showing %d of %d tasks. Next page: cursor='%s'\n", len(page.Tasks), page.Total, page.NextCursor)
+			}
+
 			// Update agent context in a separate write pass (only when needed).
-			if needsCtxUpdate {
+			if assignedFilter := q.AssignedTo; assignedFilter != "" && assignedFilter != "any" && len(page.Tasks) > 0 {
 				_ = svc.Run(func(state *domain.CollabState) error {
 					if agentCtx, exists := state.AgentContexts[assignedFilter]; exists {
 						agentCtx.LastCheckedTaskID = state.NextTaskID - 1
@@ -224,44 +512,58 @@ func registerListTasks(s *server.MCPServer, svc *app.CollabService, logger *log.
 				})
 			}
 
-			if count == 0 {
+			if page.Total == 0 {
 				return mcp.NewToolResultText("No tasks found"), nil
 			}
+			if len(page.Tasks) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("No more tasks (%d in total)", page.Total)), nil
+			}
 
-			logger.Printf("Listed %d tasks", count)
+			logger.Printf("Listed %d of %d tasks", len(page.Tasks), page.Total)
 			return mcp.NewToolResultText(result), nil
 		},
 	)
 }
 
-func checkDependenciesCompleteState(state *domain.CollabState, taskID int) []int {
-	var task *domain.Task
-	for i := range state.Tasks {
-		if state.Tasks[i].ID == taskID {
-			task = &state.Tasks[i]
-			break
-		}
+// taskQueryArgs builds a task query from list_tasks arguments.
+func taskQueryArgs(args map[string]any) (app.TaskQuery, error) {
+	q := app.TaskQuery{Status: "all"}
+	if v, ok := args["status"].(string); ok {
+		q.Status = v
 	}
-	if task == nil || len(task.Dependencies) == 0 {
-		return nil
+	q.AssignedTo, _ = args["assigned_to"].(string)
+	q.CreatedBy, _ = args["created_by"].(string)
+	q.Labels = optionalStrings(args, "labels")
+	q.Priority = int(optionalFloat64(args, "priority", 0))
+	q.PlanID, _ = args["plan_id"].(string)
+	q.Text, _ = args["search"].(string)
+	q.Sort, _ = args["sort"].(string)
+	if q.Sort == "" {
+		q.Sort = app.TaskSortID
 	}
-	var incomplete []int
-	for _, depID := range task.Dependencies {
-		for _, t := range state.Tasks {
-			if t.ID == depID && t.Status != "completed" {
-				incomplete = append(incomplete, depID)
-				break
-			}
+	q.Desc = args["order"] == "desc"
+	q.Limit = int(optionalFloat64(args, "limit", 0))
+	q.Cursor, _ = args["cursor"].(string)
+	for key, dst := range map[string]*time.Time{
+		"created_after":  &q.CreatedAfter,
+		"created_before": &q.CreatedBefore,
+		"updated_after":  &q.UpdatedAfter,
+		"updated_before": &q.UpdatedBefore,
+	} {
+		t, err := optionalTime(args, key)
+		if err != nil {
+			return q, err
 		}
+		*dst = t
 	}
-	return incomplete
+	return q, nil
 }
 
 // registerUpdateTask registers the update_task tool.
-func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log.Logger) {
+func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, pip ProcessInfoProvider) {
 	s.AddTool(
 		mcp.NewTool("update_task",
-			mcp.WithDescription("Update a shared task's status, assignment, priority, or dependencies."),
+			mcp.WithDescription("Update a shared task's status, assignment, priority, or dependencies. When completing a task, report a structured result (summary, files changed, commits, test outcome, follow-ups, artifacts) for get_task_result. If the task has verification commands, completing it runs them in your workspace first; if one fails, the task stays as it was and the output is returned."),
 			mcp.WithNumber("id", mcp.Required(), mcp.Description("Task ID to update")),
 			mcp.WithString("status", mcp.Description("New status"), mcp.Enum("pending", "in_progress", "completed", "blocked", "cancelled")),
 			mcp.WithString("assigned_to", mcp.Description("New assignee")),
@@ -269,7 +571,28 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 			mcp.WithNumber("priority", mcp.Description("New priority: 1=critical, 2=high, 3=normal, 4=low")),
 			mcp.WithNumber("add_dependency", mcp.Description("Task ID to add as dependency")),
 			mcp.WithNumber("remove_dependency", mcp.Description("Task ID to remove from dependencies")),
+			mcp.WithArray("add_labels", mcp.Description("Labels to add"), mcp.WithStringItems()),
+			mcp.WithArray("remove_labels", mcp.Description("Labels to remove (case-insensitive)"), mcp.WithStringItems()),
 			mcp.WithString("blocked_by", mcp.Description("External blocker description (set to empty to clear)")),
+			mcp.WithNumber("max_attempts", mcp.Description("Claims allowed before the task is blocked instead of handed out again (0 = unlimited)")),
+			mcp.WithString("dependents", mcp.Description("With status=cancelled: 'cascade' cancels all unfinished tasks that depend on this one, 'orphan' drops the dependency so they can proceed. Without it, dependents keep waiting."), mcp.Enum(app.DependentsCascade, app.DependentsOrphan)),
+			mcp.WithString("result_summary", mcp.Description("Result: what was done, in a sentence or two")),
+			mcp.WithArray("files_changed", mcp.Description("Result: files created, modified or deleted"), mcp.WithStringItems()),
+			mcp.WithArray("commits", mcp.Description("Result: commit hashes (or refs) made for the task"), mcp.WithStringItems()),
+			mcp.WithString("tests", mcp.Description("Result: test outcome"), mcp.Enum(app.TestsPassed, app.TestsFailed, app.TestsNotRun)),
+			mcp.WithString("test_details", mcp.Description("Result: test command and summary, or the failures")),
+			mcp.WithArray("follow_ups", mcp.Description("Result: suggested follow-up work"), mcp.WithStringItems()),
+			mcp.WithArray("artifacts", mcp.Description("Result: named files stored with the task (patches, reports), up to 1 MiB each. Reporting an artifact name again replaces it."),
+				mcp.Items(map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"name":         map[string]any{"type": "string", "description": "File name, e.g. 'fix.patch' or 'report.md'"},
+						"content":      map[string]any{"type": "string", "description": "Artifact content"},
+						"encoding":     map[string]any{"type": "string", "enum": []string{"text", "base64"}, "description": "Encoding of content (default: text)"},
+						"content_type": map[string]any{"type": "string", "description": "MIME type (default: guessed from the name)"},
+					},
+					"required": []string{"name", "content"},
+				})),
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			args := req.GetArguments()
@@ -284,7 +607,45 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 			}
 
 			taskID := int(id)
-			if err := svc.Run(func(state *domain.CollabState) error {
+			dependentsMode, _ := args["dependents"].(string)
+			if dependentsMode != "" && args["status"] != "cancelled" {
+				return nil, fmt.Errorf("dependents is only valid with status=cancelled")
+			}
+			result, artifacts, err := parseResultArgs(args, updatedBy)
+			if err != nil {
+				return nil, err
+			}
+			addLabels, err := app.NormalizeLabels(optionalStrings(args, "add_labels"))
+			if err != nil {
+				return nil, err
+			}
+			removeLabels := optionalStrings(args, "remove_labels")
+
+			// The verification gate runs before the update, outside the state lock.
+			var verification *domain.Verification
+			if args["status"] == "completed" {
+				v, err := verifyCompletion(ctx, svc, taskID, updatedBy, pip)
+				if err != nil {
+					return nil, err
+				}
+				if v != nil && !v.Passed {
+					if err := svc.RunAs(updatedBy, func(state *domain.CollabState) error {
+						if task := findTask(state, taskID); task != nil {
+							app.RecordVerification(state, task, *v)
+							task.UpdatedAt = time.Now()
+						}
+						return nil
+					}); err != nil {
+						return nil, err
+					}
+					logger.Printf("Task #%d verification failed for %s", taskID, updatedBy)
+					return nil, errors.New(verificationFailure(taskID, v))
+				}
+				verification = v
+			}
+
+			var notes []string
+			if err := svc.RunAs(updatedBy, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(updatedBy, state, false, false, extra...); err != nil {
 					return err
@@ -299,13 +660,24 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 					oldAssignee := task.AssignedTo
 
 					if v, ok := args["status"].(string); ok {
+						if task.Schedule != "" && v != "cancelled" {
+							return fmt.Errorf("task #%d is a recurring schedule (%s); its runs are separate tasks, cancel it to stop the schedule", taskID, task.Schedule)
+						}
 						if v == "in_progress" {
-							incomplete := checkDependenciesCompleteState(state, task.ID)
-							if len(incomplete) > 0 {
+							if incomplete := app.OpenDependencies(state, task); len(incomplete) > 0 {
 								return fmt.Errorf("cannot start: dependencies not complete: %v", incomplete)
 							}
 						}
+						if v == "completed" {
+							if open := app.OpenSubtasks(state, task.ID); len(open) > 0 {
+								return fmt.Errorf("cannot complete: subtasks not finished: %v", open)
+							}
+						}
 						task.Status = v
+						if oldStatus == "scheduled" && v != "cancelled" {
+							task.NotBefore = time.Time{}
+							notes = append(notes, "released from schedule")
+						}
 					}
 					if v, ok := args["assigned_to"].(string); ok {
 						if err := app.ValidateAgent(v, state, true, false, extra...); err != nil {
@@ -313,6 +685,10 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 						}
 						task.AssignedTo = v
 					}
+					// Starting a task left to 'any' claims it for the caller.
+					if task.Status == "in_progress" && oldStatus != "in_progress" && task.AssignedTo == "any" {
+						task.AssignedTo = updatedBy
+					}
 
 					// --- CurrentTasks maintenance ---
 					// Remove from old owner when:
@@ -338,6 +714,9 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 						}
 						task.Priority = p
 					}
+					if v, ok := args["max_attempts"].(float64); ok {
+						task.MaxAttempts = max(int(v), 0)
+					}
 					if v, ok := args["blocked_by"].(string); ok {
 						task.BlockedBy = v
 						if v != "" && task.Status != "blocked" {
@@ -346,18 +725,8 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 					}
 					if depID, ok := args["add_dependency"].(float64); ok {
 						depIDInt := int(depID)
-						found := false
-						for _, t := range state.Tasks {
-							if t.ID == depIDInt {
-								found = true
-								break
-							}
-						}
-						if !found {
-							return fmt.Errorf("dependency task #%d not found", depIDInt)
-						}
-						if depIDInt == task.ID {
-							return fmt.Errorf("task cannot depend on itself")
+						if err := app.ValidateDependencies(state, task.ID, []int{depIDInt}); err != nil {
+							return err
 						}
 						alreadyDep := false
 						for _, d := range task.Dependencies {
@@ -380,8 +749,40 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 						}
 						task.Dependencies = newDeps
 					}
+					if len(addLabels) > 0 || len(removeLabels) > 0 {
+						labels := slices.DeleteFunc(slices.Clone(task.Labels), func(l string) bool {
+							return slices.ContainsFunc(removeLabels, func(r string) bool { return strings.EqualFold(l, r) })
+						})
+						task.Labels, _ = app.NormalizeLabels(append(labels, addLabels...))
+					}
 					task.UpdatedAt = time.Now()
+					if verification != nil {
+						app.RecordVerification(state, task, *verification)
+						notes = append(notes, "verification "+verificationLine(verification))
+					}
+					if result != nil {
+						if err := app.RecordResult(state, task, *result, artifacts, task.UpdatedAt); err != nil {
+							return err
+						}
+						notes = append(notes, fmt.Sprintf("result recorded (%d artifacts)", len(artifacts)))
+					}
 
+					if task.Status == "cancelled" && oldStatus != "cancelled" {
+						if dependentsMode != "" {
+							changed, err := app.ResolveDependents(state, taskID, dependentsMode, task.UpdatedAt)
+							if err != nil {
+								return err
+							}
+							if len(changed) > 0 {
+								notes = append(notes, fmt.Sprintf("dependents (%s): %v", dependentsMode, changed))
+							}
+						} else if deps := app.Dependents(state, taskID, false); len(deps) > 0 {
+							notes = append(notes, fmt.Sprintf("dependents %v keep waiting (pass dependents=cascade or orphan to resolve)", deps))
+						}
+					}
+					if released := app.SyncDependencies(state, task.UpdatedAt); len(released) > 0 {
+						notes = append(notes, fmt.Sprintf("unblocked: %v", released))
+					}
 					return nil
 				}
 				return fmt.Errorf("task #%d not found", taskID)
@@ -390,7 +791,11 @@ func registerUpdateTask(s *server.MCPServer, svc *app.CollabService, logger *log
 			}
 
 			logger.Printf("Task #%d updated by %s", taskID, updatedBy)
-			return mcp.NewToolResultText(fmt.Sprintf("Task #%d updated", taskID)), nil
+			msg := fmt.Sprintf("Task #%d updated", taskID)
+			if len(notes) > 0 {
+				msg += "; " + strings.Join(notes, "; ")
+			}
+			return mcp.NewToolResultText(msg), nil
 		},
 	)
 }
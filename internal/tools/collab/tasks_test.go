@@ -1,13 +1,21 @@
 package collab
 
 import (
+	"fmt"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
 	"github.com/jaakkos/stringwork/internal/domain"
+	"github.com/jaakkos/stringwork/internal/policy"
 )
 
 // ========== create_task tests ==========
@@ -433,6 +441,21 @@ func TestUpdateTask_Assignee(t *testing.T) {
 	}
 }
 
+func TestUpdateTask_ClaimAnyTask(t *testing.T) {
+	svc, repo := newTestService()
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Task", Status: "pending", AssignedTo: "any", CreatedBy: "cursor"},
+	}
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "in_progress", "updated_by": "claude-code"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.state.Tasks[0]; got.Status != "in_progress" || got.AssignedTo != "claude-code" {
+		t.Errorf("claimed task = %s, assigned to %q; want in_progress, claude-code", got.Status, got.AssignedTo)
+	}
+}
+
 func TestUpdateTask_Priority(t *testing.T) {
 	svc, repo := newTestService()
 	logger := log.New(io.Discard, "", 0)
@@ -646,3 +669,359 @@ func TestUpdateTask_UpdatesTimestamp(t *testing.T) {
 		t.Error("UpdatedAt should be updated to current time")
 	}
 }
+
+func TestCreateTask_WaitsOnOpenDependencies(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	srv := testServer(svc, logger)
+
+	_, _ = callTool(t, srv, "create_task", map[string]any{"title": "Task 1", "created_by": "cursor"})
+	result, err := callTool(t, srv, "create_task", map[string]any{
+		"title":      "Task 2",
+		"created_by": "cursor",
+		"depends_on": []interface{}{float64(1)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "waiting on: [1]") {
+		t.Errorf("result should mention waiting: %s", text)
+	}
+	if repo.state.Tasks[1].Status != "waiting" {
+		t.Errorf("status = %q, want waiting", repo.state.Tasks[1].Status)
+	}
+
+	// Completing the dependency releases the dependent.
+	result, err = callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "completed", "updated_by": "cursor"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "unblocked: [2]") {
+		t.Errorf("result should mention unblocked task: %s", text)
+	}
+	if repo.state.Tasks[1].Status != "pending" {
+		t.Errorf("status = %q, want pending", repo.state.Tasks[1].Status)
+	}
+}
+
+func TestUpdateTask_DependencyCycleRejected(t *testing.T) {
+	svc, repo := newTestService()
+	logger := log.New(io.Discard, "", 0)
+
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Task 1", Status: "pending", AssignedTo: "cursor", CreatedBy: "cursor"},
+		{ID: 2, Title: "Task 2", Status: "waiting", AssignedTo: "cursor", CreatedBy: "cursor", Dependencies: []int{1}},
+	}
+	repo.state.NextTaskID = 3
+
+	srv := testServer(svc, logger)
+
+	_, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "add_dependency": float64(2), "updated_by": "cursor"})
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Fatalf("expected dependency cycle error, got %v", err)
+	}
+	if len(repo.state.Tasks[0].Dependencies) != 0 {
+		t.Errorf("dependency should not be added, got %v", repo.state.Tasks[0].Dependencies)
+	}
+}
+
+func TestUpdateTask_CancelDependents(t *testing.T) {
+	for _, mode := range []string{"", "cascade", "orphan"} {
+		svc, repo := newTestService()
+		logger := log.New(io.Discard, "", 0)
+
+		repo.state.Tasks = []domain.Task{
+			{ID: 1, Title: "Task 1", Status: "pending", AssignedTo: "cursor", CreatedBy: "cursor"},
+			{ID: 2, Title: "Task 2", Status: "waiting", AssignedTo: "cursor", CreatedBy: "cursor", Dependencies: []int{1}},
+			{ID: 3, Title: "Task 3", Status: "waiting", AssignedTo: "cursor", CreatedBy: "cursor", Dependencies: []int{2}},
+		}
+		repo.state.NextTaskID = 4
+
+		srv := testServer(svc, logger)
+
+		args := map[string]any{"id": float64(1), "status": "cancelled", "updated_by": "cursor"}
+		if mode != "" {
+			args["dependents"] = mode
+		}
+		if _, err := callTool(t, srv, "update_task", args); err != nil {
+			t.Fatalf("%q: unexpected error: %v", mode, err)
+		}
+
+		want := map[string][2]string{
+			"":        {"waiting", "waiting"},
+			"cascade": {"cancelled", "cancelled"},
+			"orphan":  {"pending", "waiting"},
+		}[mode]
+		if got := [2]string{repo.state.Tasks[1].Status, repo.state.Tasks[2].Status}; got != want {
+			t.Errorf("%q: dependent statuses = %v, want %v", mode, got, want)
+		}
+	}
+
+	svc, _ := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "dependents": "cascade", "updated_by": "cursor"}); err == nil {
+		t.Error("expected error for dependents without status=cancelled")
+	}
+}
+
+func TestCreateTask_NotBefore(t *testing.T) {
+	svc, repo := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+
+	before := time.Now()
+	result, err := callTool(t, srv, "create_task", map[string]any{"title": "Re-check", "created_by": "cursor", "not_before": "30m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "scheduled for") {
+		t.Errorf("result should mention the schedule: %s", text)
+	}
+	task := repo.state.Tasks[0]
+	if task.Status != "scheduled" || task.NotBefore.Before(before.Add(30*time.Minute)) || task.NotBefore.After(time.Now().Add(30*time.Minute)) {
+		t.Errorf("task = %s, not before %v; want scheduled in 30m", task.Status, task.NotBefore)
+	}
+
+	result, err = callTool(t, srv, "list_tasks", map[string]any{"status": "scheduled"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Scheduled for: ") {
+		t.Errorf("list_tasks should show when the task is due: %s", text)
+	}
+
+	// A time in the past needs no waiting.
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "Now", "created_by": "cursor", "not_before": "2020-01-01T00:00:00Z"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.state.Tasks[1].Status; got != "pending" {
+		t.Errorf("past not_before: status = %q, want pending", got)
+	}
+
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "Bad", "created_by": "cursor", "not_before": "tomorrow"}); err == nil || !strings.Contains(err.Error(), "invalid not_before") {
+		t.Errorf("expected invalid not_before error, got %v", err)
+	}
+}
+
+func TestCreateTask_Schedule(t *testing.T) {
+	svc, repo := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+
+	result, err := callTool(t, srv, "create_task", map[string]any{"title": "Flaky-test sweep", "created_by": "cursor", "schedule": "0 3 * * *"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "recurring: 0 3 * * *, first run: ") {
+		t.Errorf("result should mention the schedule: %s", text)
+	}
+	task := repo.state.Tasks[0]
+	if task.Status != "scheduled" || task.Schedule != "0 3 * * *" || task.NotBefore.Hour() != 3 || task.NotBefore.Minute() != 0 ||
+		!task.NotBefore.After(time.Now()) || task.NotBefore.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("task = %s %q, next %v; want scheduled for the next 03:00", task.Status, task.Schedule, task.NotBefore)
+	}
+
+	for spec, wantErr := range map[string]string{"every night": "invalid schedule", "0 0 30 2 *": "never runs"} {
+		if _, err := callTool(t, srv, "create_task", map[string]any{"title": "Bad", "created_by": "cursor", "schedule": spec}); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%q: error = %v, want %q", spec, err, wantErr)
+		}
+	}
+}
+
+func TestUpdateTask_Scheduled(t *testing.T) {
+	svc, repo := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+
+	later := time.Now().Add(time.Hour)
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Re-check", Status: "scheduled", NotBefore: later, AssignedTo: "any", CreatedBy: "cursor"},
+		{ID: 2, Title: "Nightly", Status: "scheduled", Schedule: "@daily", NotBefore: later, AssignedTo: "any", CreatedBy: "cursor"},
+	}
+	repo.state.NextTaskID = 3
+
+	// Making a delayed task pending runs it now.
+	result, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "pending", "updated_by": "cursor"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "released from schedule") {
+		t.Errorf("result should mention the release: %s", text)
+	}
+	if task := repo.state.Tasks[0]; task.Status != "pending" || !task.NotBefore.IsZero() {
+		t.Errorf("task = %s, not before %v; want pending, cleared", task.Status, task.NotBefore)
+	}
+
+	// A recurring task only runs through its copies; it can only be cancelled.
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(2), "status": "in_progress", "updated_by": "cursor"}); err == nil || !strings.Contains(err.Error(), "recurring schedule") {
+		t.Errorf("expected recurring schedule error, got %v", err)
+	}
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(2), "status": "cancelled", "updated_by": "cursor"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.state.Tasks[1].Status; got != "cancelled" {
+		t.Errorf("recurring task status = %q, want cancelled", got)
+	}
+}
+
+func TestTaskLabels(t *testing.T) {
+	svc, repo := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+	repo.state.Plans["api"] = &domain.Plan{ID: "api", Title: "API"}
+
+	result, err := callTool(t, srv, "create_task", map[string]any{
+		"title": "Fix login", "created_by": "cursor", "labels": []any{"bug", " Bug ", "frontend"}, "plan_id": "api",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "labels: bug, frontend") {
+		t.Errorf("result should list the labels: %s", text)
+	}
+	if task := repo.state.Tasks[0]; !slices.Equal(task.Labels, []string{"bug", "frontend"}) || task.PlanID != "api" {
+		t.Errorf("task labels = %v, plan = %q", task.Labels, task.PlanID)
+	}
+	if _, err := callTool(t, srv, "create_task", map[string]any{"title": "X", "created_by": "cursor", "plan_id": "nope"}); err == nil || !strings.Contains(err.Error(), `plan "nope" not found`) {
+		t.Errorf("expected unknown plan error, got %v", err)
+	}
+
+	if _, err := callTool(t, srv, "update_task", map[string]any{
+		"id": float64(1), "updated_by": "cursor", "add_labels": []any{"urgent", "BUG"}, "remove_labels": []any{"FRONTEND"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := repo.state.Tasks[0].Labels; !slices.Equal(got, []string{"bug", "urgent"}) {
+		t.Errorf("labels after update = %v, want [bug urgent]", got)
+	}
+}
+
+func TestListTasks_Query(t *testing.T) {
+	svc, repo := newTestService()
+	srv := testServer(svc, log.New(io.Discard, "", 0))
+
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		task := domain.Task{ID: i, Title: fmt.Sprintf("Task %d", i), Status: "pending", AssignedTo: "any", CreatedBy: "cursor",
+			Priority: 3, CreatedAt: now, UpdatedAt: now}
+		if i%2 == 0 {
+			task.Labels = []string{"bug"}
+			task.Description = "crashes on login"
+		}
+		repo.state.Tasks = append(repo.state.Tasks, task)
+	}
+	repo.state.Tasks[4].Priority = 1
+	repo.state.NextTaskID = 6
+
+	result, err := callTool(t, srv, "list_tasks", map[string]any{"labels": []any{"bug"}, "search": "login"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := resultText(t, result)
+	if !strings.Contains(text, "Task #2 ") || !strings.Contains(text, "Task #4 ") || strings.Contains(text, "Task #1 ") || !strings.Contains(text, "Labels: bug") {
+		t.Errorf("filtered list = %s", text)
+	}
+
+	result, err = callTool(t, srv, "list_tasks", map[string]any{"sort": "priority", "limit": float64(2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text = resultText(t, result)
+	if !strings.HasPrefix(text, "Task #5 ") || !strings.Contains(text, "Task #1 ") || strings.Contains(text, "Task #2 ") {
+		t.Errorf("first page = %s", text)
+	}
+	_, cursor, ok := strings.Cut(text, "Showing 2 of 5 tasks. Next page: cursor='")
+	if !ok {
+		t.Fatalf("first page should offer a cursor: %s", text)
+	}
+	cursor = strings.TrimSuffix(strings.TrimSpace(cursor), "'")
+
+	result, err = callTool(t, srv, "list_tasks", map[string]any{"sort": "priority", "limit": float64(2), "cursor": cursor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text = resultText(t, result); !strings.HasPrefix(text, "Task #2 ") || !strings.Contains(text, "Task #3 ") {
+		t.Errorf("second page = %s", text)
+	}
+
+	if _, err := callTool(t, srv, "list_tasks", map[string]any{"created_after": "last week"}); err == nil || !strings.Contains(err.Error(), "created_after") {
+		t.Errorf("expected created_after error, got %v", err)
+	}
+}
+
+type fakeProcesses map[string]ProcessInfoSnapshot
+
+func (f fakeProcesses) GetProcessInfo() map[string]ProcessInfoSnapshot { return f }
+
+func TestUpdateTask_Verification(t *testing.T) {
+	repo := newMockRepository()
+	pol := newMockPolicy()
+	pol.verification = policy.VerificationConfig{Commands: []string{"test -f ok || { echo no ok file; exit 1; }"}}
+	svc := newTestServiceWith(repo, pol, log.New(io.Discard, "", 0))
+	worktree := t.TempDir()
+	srv := server.NewMCPServer("test", "1.0.0")
+	Register(srv, svc, log.New(io.Discard, "", 0), app.NewSessionRegistry(), nil,
+		WithProcessProvider(fakeProcesses{"codex": {WorkspaceDir: worktree}}))
+
+	project := t.TempDir()
+	repo.state.Tasks = []domain.Task{
+		{ID: 1, Title: "Fix login", Status: "in_progress", AssignedTo: "claude-code", CreatedBy: "cursor", Project: project,
+			Verify: []string{"test -f done.txt || { echo missing done.txt; exit 3; }"}},
+		{ID: 2, Title: "Fix logout", Status: "in_progress", AssignedTo: "codex", CreatedBy: "cursor", Project: project},
+	}
+	repo.state.NextTaskID = 3
+
+	// A failing command keeps the task in progress and returns its output.
+	_, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "completed", "updated_by": "claude-code"})
+	if err == nil || !strings.Contains(err.Error(), "(exit 3)") || !strings.Contains(err.Error(), "missing done.txt") {
+		t.Fatalf("expected verification failure with output, got %v", err)
+	}
+	task := repo.state.Tasks[0]
+	if task.Status != "in_progress" || task.Verification == nil || task.Verification.Passed || task.Verification.Dir != project {
+		t.Fatalf("task after failed verification = %s, %+v", task.Status, task.Verification)
+	}
+
+	if err := os.WriteFile(filepath.Join(project, "done.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := callTool(t, srv, "update_task", map[string]any{"id": float64(1), "status": "completed", "updated_by": "claude-code"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "verification passed (1 command") {
+		t.Errorf("result should report the verification: %s", text)
+	}
+	if task := repo.state.Tasks[0]; task.Status != "completed" || !task.Verification.Passed || task.Verification.RanBy != "claude-code" {
+		t.Errorf("task = %s, %+v; want completed, passed", task.Status, task.Verification)
+	}
+
+	// Without commands of its own, the task gets the configured default,
+	// run in the worker's worktree rather than the project.
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(2), "status": "completed", "updated_by": "codex"}); err == nil || !strings.Contains(err.Error(), "no ok file") {
+		t.Fatalf("expected the default verification to fail, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, "ok"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callTool(t, srv, "update_task", map[string]any{"id": float64(2), "status": "completed", "updated_by": "codex"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := repo.state.Tasks[1].Verification; v == nil || v.Dir != worktree {
+		t.Errorf("verification = %+v, want run in %s", v, worktree)
+	}
+
+	result, err = callTool(t, srv, "get_task_result", map[string]any{"task_id": float64(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Verification: passed") || !strings.Contains(text, "$ test -f done.txt") {
+		t.Errorf("get_task_result should show the verification: %s", text)
+	}
+
+	result, err = callTool(t, srv, "create_task", map[string]any{"title": "Refactor", "created_by": "cursor", "verify": []any{"make check"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "verify: make check") {
+		t.Errorf("result should list the verification: %s", text)
+	}
+	if got := repo.state.Tasks[2].Verify; !slices.Equal(got, []string{"make check"}) {
+		t.Errorf("verify = %v", got)
+	}
+}
@@ -0,0 +1,102 @@
+package collab
+
+import (
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaakkos/stringwork/internal/policy"
+)
+
+func templatePolicy() *mockPolicy {
+	pol := newMockPolicy()
+	pol.taskTemplates = map[string]policy.TaskTemplate{
+		"add-tests": {
+			Title:               "Add tests for {package}",
+			Description:         "Cover {package} with table tests.",
+			Capabilities:        []string{"testing"},
+			WorkerType:          "claude-code",
+			Constraints:         []string{"do not change {package} behavior"},
+			ExpectedDurationSec: 900,
+			Acceptance:          []string{"go test ./{package}/... passes"},
+			Priority:            2,
+		},
+		"security-review": {Title: "Security review of {area}", Vars: map[string]string{"area": "the repository"}},
+	}
+	return pol
+}
+
+func TestCreateTask_FromTemplate(t *testing.T) {
+	repo := newMockRepository()
+	logger := log.New(io.Discard, "", 0)
+	svc := newTestServiceWith(repo, templatePolicy(), logger)
+	srv := testServer(svc, logger)
+
+	result, err := callTool(t, srv, "create_task", map[string]any{
+		"template": "add-tests", "vars": map[string]any{"package": "internal/app"},
+		"constraints": []any{"no new deps"}, "created_by": "cursor", "assigned_to": "claude-code",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "Add tests for internal/app") || !strings.Contains(text, "template: add-tests") {
+		t.Errorf("result = %q", text)
+	}
+	task := repo.state.Tasks[0]
+	if task.Priority != 2 || task.ExpectedDurationSec != 900 || task.WorkerType != "claude-code" || !slices.Equal(task.Capabilities, []string{"testing"}) {
+		t.Errorf("task = %+v", task)
+	}
+	if !strings.HasSuffix(task.Description, "Acceptance criteria:\n- go test ./internal/app/... passes") {
+		t.Errorf("description = %q", task.Description)
+	}
+	wc := repo.state.WorkContexts[task.ContextID]
+	if wc == nil || !slices.Equal(wc.Constraints, []string{"do not change internal/app behavior", "no new deps"}) {
+		t.Errorf("work context = %+v", wc)
+	}
+
+	// Explicit arguments override the template; defaults fill missing vars.
+	if _, err := callTool(t, srv, "create_task", map[string]any{
+		"template": "security-review", "title": "Audit auth", "priority": float64(1),
+		"created_by": "cursor", "assigned_to": "claude-code",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if task := repo.state.Tasks[1]; task.Title != "Audit auth" || task.Priority != 1 {
+		t.Errorf("override: task = %+v", task)
+	}
+
+	for _, tt := range []struct {
+		args    map[string]any
+		wantErr string
+	}{
+		{map[string]any{"template": "nope"}, "available: add-tests, security-review"},
+		{map[string]any{"template": "add-tests"}, "needs vars: package"},
+		{map[string]any{"template": "add-tests", "vars": map[string]any{"package": "x", "pkg": "y"}}, `no variable "pkg"`},
+		{map[string]any{"title": "T", "vars": map[string]any{"package": "x"}}, "vars requires template"},
+	} {
+		tt.args["created_by"] = "cursor"
+		if _, err := callTool(t, srv, "create_task", tt.args); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("create_task %v: err = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatTaskTemplates(t *testing.T) {
+	text := formatTaskTemplates(templatePolicy().taskTemplates)
+	for _, want := range []string{
+		"## add-tests", "Vars: package (required)", "Worker type: claude-code", "Expected duration: 15m0s",
+		"## security-review", `Vars: area (default "the repository")`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "## add-tests") > strings.Index(text, "## security-review") {
+		t.Error("templates should be listed by name")
+	}
+	if text := formatTaskTemplates(nil); !strings.Contains(text, "No task templates") {
+		t.Errorf("empty = %q", text)
+	}
+}
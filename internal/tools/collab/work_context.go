@@ -146,9 +146,10 @@ func ensureWorkContextForTask(state *domain.CollabState, taskID int, relevantFil
 
 const defaultTaskContextLockMinutes = 60
 
-// autoLockTaskContextFiles locks RelevantFiles from the task's work context for the given agent.
-// validatePath is typically svc.Policy().ValidatePath. Skips paths that fail validation.
-func autoLockTaskContextFiles(state *domain.CollabState, contextID string, agent string, validatePath func(string) (string, error)) {
+// autoLockTaskContextFiles locks RelevantFiles from the task's work context for the given agent
+// in project (the task's). validatePath is typically svc.Policy().ValidatePath. Skips paths that
+// fail validation.
+func autoLockTaskContextFiles(state *domain.CollabState, contextID, project, agent string, validatePath func(string) (string, error)) {
 	if contextID == "" {
 		return
 	}
@@ -163,15 +164,16 @@ func autoLockTaskContextFiles(state *domain.CollabState, contextID string, agent
 		if err != nil {
 			continue
 		}
-		if existing, exists := state.FileLocks[path]; exists && existing != nil && existing.LockedBy != agent {
+		if _, existing := app.FindFileLock(state, project, path); existing != nil && existing.LockedBy != agent {
 			continue
 		}
-		state.FileLocks[path] = &domain.FileLock{
+		state.FileLocks[app.FileLockKey(project, path)] = &domain.FileLock{
 			Path:      path,
 			LockedBy:  agent,
 			Reason:    "task context scope",
 			LockedAt:  now,
 			ExpiresAt: expires,
+			Project:   project,
 		}
 	}
 }
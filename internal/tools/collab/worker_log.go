@@ -0,0 +1,135 @@
+package collab
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+)
+
+const (
+	defaultWorkerLogTail = 100
+	maxWorkerLogTail     = 2000
+	workerRunListLimit   = 20
+)
+
+// WorkerRunStore is implemented by app.WorkerRunLog. It gives get_worker_log
+// the recorded worker runs and their output.
+type WorkerRunStore interface {
+	Runs(instance string, limit int) []app.WorkerRun
+	ReadLog(q app.WorkerLogQuery) (app.WorkerLog, error)
+}
+
+// registerGetWorkerLog registers the get_worker_log tool.
+func registerGetWorkerLog(s *server.MCPServer, runs WorkerRunStore, logger *log.Logger) {
+	s.AddTool(
+		mcp.NewTool("get_worker_log",
+			mcp.WithDescription("Read the output of spawned worker processes. Every spawn and retry is stored as a run with its command, directory, start/end, exit code and error class. Returns the last lines of the latest run of an instance (or of run_id), optionally filtered by a regular expression; runs=true lists recent runs instead."),
+			mcp.WithString("instance", mcp.Description("Worker instance ID or agent type (e.g. 'claude-code-1', 'codex'); omit for any worker")),
+			mcp.WithNumber("run_id", mcp.Description("Run to read (default: the most recent run)")),
+			mcp.WithNumber("tail", mcp.Description(fmt.Sprintf("Number of lines from the end to return (default %d, max %d)", defaultWorkerLogTail, maxWorkerLogTail))),
+			mcp.WithString("grep", mcp.Description("Regular expression; only matching lines are returned (e.g. 'error|panic')")),
+			mcp.WithBoolean("runs", mcp.Description("List recent runs instead of returning output")),
+		),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := req.GetArguments()
+			instance, _ := args["instance"].(string)
+			if list, _ := args["runs"].(bool); list {
+				logger.Printf("Worker runs listed (instance=%q)", instance)
+				return mcp.NewToolResultText(formatWorkerRuns(runs.Runs(instance, workerRunListLimit), instance, time.Now())), nil
+			}
+
+			q := app.WorkerLogQuery{Instance: instance, Tail: defaultWorkerLogTail}
+			if v, ok := args["run_id"].(float64); ok && v > 0 {
+				q.RunID = int64(v)
+			}
+			if v, ok := args["tail"].(float64); ok && v > 0 {
+				q.Tail = min(int(v), maxWorkerLogTail)
+			}
+			q.Grep, _ = args["grep"].(string)
+
+			wl, err := runs.ReadLog(q)
+			if err != nil {
+				if q.RunID == 0 && instance != "" {
+					return nil, fmt.Errorf("no recorded runs for %s: %w", instance, err)
+				}
+				return nil, err
+			}
+			logger.Printf("Worker log of run #%d (%s): %d line(s)", wl.Run.ID, wl.Run.InstanceID, len(wl.Lines))
+			return mcp.NewToolResultText(formatWorkerLog(wl, q, time.Now())), nil
+		},
+	)
+}
+
+// formatWorkerRun renders the one-line summary of a run.
+func formatWorkerRun(r app.WorkerRun, now time.Time) string {
+	end, outcome := now, "running"
+	if !r.Running() {
+		end = r.EndedAt
+		switch {
+		case r.Error == "":
+			outcome = "ok"
+		case r.ErrorClass != "":
+			outcome = fmt.Sprintf("failed, exit %d (%s)", r.ExitCode, r.ErrorClass)
+		default:
+			outcome = fmt.Sprintf("failed, exit %d", r.ExitCode)
+		}
+	}
+	line := fmt.Sprintf("#%d %s attempt %d", r.ID, r.InstanceID, r.Attempt)
+	if r.TaskID > 0 {
+		line += fmt.Sprintf(" (task #%d)", r.TaskID)
+	}
+	return fmt.Sprintf("%s: %s, started %s, %s, %d bytes", line, outcome,
+		r.StartedAt.Format(time.RFC3339), end.Sub(r.StartedAt).Round(time.Second), r.OutputBytes)
+}
+
+// formatWorkerRuns renders a run list, newest first.
+func formatWorkerRuns(runs []app.WorkerRun, instance string, now time.Time) string {
+	if len(runs) == 0 {
+		if instance != "" {
+			return fmt.Sprintf("No recorded runs for %s.", instance)
+		}
+		return "No recorded worker runs."
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Recent worker runs (%d, newest first):\n", len(runs))
+	for _, r := range runs {
+		buf.WriteString(formatWorkerRun(r, now))
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("\nRead one with get_worker_log run_id=<id>.")
+	return buf.String()
+}
+
+// formatWorkerLog renders a run's header and the selected output lines.
+func formatWorkerLog(wl app.WorkerLog, q app.WorkerLogQuery, now time.Time) string {
+	var buf strings.Builder
+	buf.WriteString("Run " + formatWorkerRun(wl.Run, now) + "\n")
+	fmt.Fprintf(&buf, "Command: %s\n", strings.Join(wl.Run.Command, " "))
+	fmt.Fprintf(&buf, "Dir: %s\n", wl.Run.Dir)
+	if wl.Run.Error != "" {
+		fmt.Fprintf(&buf, "Error: %s\n", wl.Run.Error)
+	}
+	what := "lines"
+	if q.Grep != "" {
+		what = fmt.Sprintf("lines matching %q", q.Grep)
+	}
+	switch {
+	case wl.Matched == 0:
+		fmt.Fprintf(&buf, "\nNo %s.\n", what)
+		return buf.String()
+	case len(wl.Lines) < wl.Matched:
+		fmt.Fprintf(&buf, "\n--- last %d of %d %s ---\n", len(wl.Lines), wl.Matched, what)
+	default:
+		fmt.Fprintf(&buf, "\n--- %d %s ---\n", wl.Matched, what)
+	}
+	buf.WriteString(strings.Join(wl.Lines, "\n"))
+	buf.WriteByte('\n')
+	return buf.String()
+}
@@ -0,0 +1,74 @@
+package collab
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/jaakkos/stringwork/internal/app"
+)
+
+func TestGetWorkerLog(t *testing.T) {
+	svc, _ := newTestService()
+	logger := log.New(io.Discard, "", 0)
+	runs, err := app.NewWorkerRunLog(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewMCPServer("test", "1.0.0")
+	Register(srv, svc, logger, app.NewSessionRegistry(), nil, WithWorkerRunStore(runs))
+
+	if _, err := callTool(t, srv, "get_worker_log", map[string]any{"instance": "codex"}); err == nil {
+		t.Error("expected an error without recorded runs")
+	}
+
+	run, f, err := runs.Start(app.WorkerRun{InstanceID: "claude-code-1", AgentType: "claude-code", Attempt: 1, Command: []string{"claude", "-p", "go"}, Dir: "/ws", TaskID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(f, "step %d\n", i)
+	}
+	fmt.Fprintln(f, "Error: rate limit")
+	f.Close()
+	run.ExitCode, run.Error, run.ErrorClass = 1, "exited after 2s: exit status 1", "quota_exhausted"
+	if err := runs.Finish(run); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := callTool(t, srv, "get_worker_log", map[string]any{"instance": "claude-code", "tail": float64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := resultText(t, result)
+	for _, want := range []string{
+		"Run #1 claude-code-1 attempt 1 (task #3): failed, exit 1 (quota_exhausted)",
+		"Command: claude -p go",
+		"Error: exited after 2s",
+		"--- last 2 of 6 lines ---\nstep 5\nError: rate limit\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q:\n%s", want, text)
+		}
+	}
+
+	result, err = callTool(t, srv, "get_worker_log", map[string]any{"run_id": float64(1), "grep": "^step [12]$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "--- 2 lines matching \"^step [12]$\" ---\nstep 1\nstep 2\n") {
+		t.Errorf("grep output:\n%s", text)
+	}
+
+	result, err = callTool(t, srv, "get_worker_log", map[string]any{"runs": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := resultText(t, result); !strings.Contains(text, "#1 claude-code-1 attempt 1 (task #3)") {
+		t.Errorf("run list:\n%s", text)
+	}
+}
@@ -14,10 +14,10 @@ import (
 )
 
 // registerWorkerStatus registers the worker_status tool (driver-oriented: list workers and their status).
-func registerWorkerStatus(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, wtp WorktreeInfoProvider, pip ProcessInfoProvider) {
+func registerWorkerStatus(s *server.MCPServer, svc *app.CollabService, logger *log.Logger, wtp WorktreeInfoProvider, pip ProcessInfoProvider, wpp WorkerPoolProvider) {
 	s.AddTool(
 		mcp.NewTool("worker_status",
-			mcp.WithDescription("List all worker instances with status, progress, process activity, and worktree info. Shows what each worker is doing, how long since their last progress report, and whether their process is producing output."),
+			mcp.WithDescription("List all worker instances with status, progress, process activity, pool capacity/autoscaling, and worktree info. Shows what each worker is doing, how long since their last progress report, and whether their process is producing output."),
 		),
 		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			var result string
@@ -137,6 +137,30 @@ func registerWorkerStatus(s *server.MCPServer, svc *app.CollabService, logger *l
 					}
 				}
 
+				// Pool capacity and autoscaling
+				if wpp != nil {
+					pool := wpp.GetWorkerPool()
+					queued := 0
+					for _, t := range state.Tasks {
+						if t.Status == "pending" && t.AssignedTo == "any" {
+							queued++
+						}
+					}
+					limit := "no limit"
+					if pool.MaxWorkers > 0 {
+						limit = fmt.Sprintf("max_workers %d", pool.MaxWorkers)
+					}
+					result += fmt.Sprintf("\nWorker Pool: %d running (%s), %d pending task(s) for 'any'\n", pool.Active, limit, queued)
+					for _, wt := range pool.Types {
+						if wt.Autoscaled {
+							result += fmt.Sprintf("  - %s: %d running, autoscaling %d-%d (idle timeout %s)\n",
+								wt.Type, wt.Active, wt.MinInstances, wt.Instances, wt.IdleTimeout)
+						} else {
+							result += fmt.Sprintf("  - %s: %d/%d running\n", wt.Type, wt.Active, wt.Instances)
+						}
+					}
+				}
+
 				// Worktree info
 				if wtp != nil {
 					wts := wtp.ListWorktrees()
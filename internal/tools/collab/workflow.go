@@ -35,7 +35,7 @@ func registerHandoff(s *server.MCPServer, svc *app.CollabService, logger *log.Lo
 				return nil, fmt.Errorf("from, to, summary, and next_steps are required")
 			}
 			var taskInfo string
-			if err := svc.Run(func(state *domain.CollabState) error {
+			if err := svc.RunAs(from, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(from, state, false, false, extra...); err != nil {
 					return err
@@ -116,6 +116,7 @@ func registerHandoff(s *server.MCPServer, svc *app.CollabService, logger *log.Lo
 					Content:   fmt.Sprintf("## Handoff from %s\n\n### Summary\n%s\n\n### Next Steps\n%s", from, summary, nextSteps),
 					Timestamp: time.Now(),
 					Read:      false,
+					Project:   callerProject(svc, state, args, from),
 				}
 				state.Messages = append(state.Messages, msg)
 				state.NextMsgID++
@@ -148,14 +149,19 @@ func registerClaimNext(s *server.MCPServer, svc *app.CollabService, logger *log.
 			}
 
 			var result *mcp.CallToolResult
-			err := svc.Run(func(state *domain.CollabState) error {
+			err := svc.RunAs(agent, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(agent, state, false, false, extra...); err != nil {
 					return err
 				}
 
+				project := callerProject(svc, state, args, agent)
+
 				for i := len(state.Messages) - 1; i >= 0; i-- {
 					msg := state.Messages[i]
+					if !app.InProject(msg.Project, project) {
+						continue
+					}
 					if (msg.To == agent || msg.To == "all") && !msg.Read {
 						result = mcp.NewToolResultText(fmt.Sprintf(`{"action":"read_messages","priority":"high","from":"%s","preview":"%s"}`,
 							msg.From, escapeJSON(app.Truncate(msg.Content, 100))))
@@ -164,7 +170,7 @@ func registerClaimNext(s *server.MCPServer, svc *app.CollabService, logger *log.
 				}
 
 				for _, task := range state.Tasks {
-					if task.Status == "in_progress" && task.AssignedTo == agent {
+					if task.Status == "in_progress" && task.AssignedTo == agent && app.InProject(task.Project, project) {
 						result = mcp.NewToolResultText(fmt.Sprintf(`{"action":"continue_task","priority":"medium","task_id":%d,"title":"%s"}`,
 							task.ID, escapeJSON(task.Title)))
 						return nil
@@ -175,9 +181,8 @@ func registerClaimNext(s *server.MCPServer, svc *app.CollabService, logger *log.
 				var bestIdx int
 				for i := range state.Tasks {
 					task := &state.Tasks[i]
-					if task.Status == "pending" && (task.AssignedTo == agent || task.AssignedTo == "any") {
-						incomplete := checkDependenciesCompleteState(state, task.ID)
-						if len(incomplete) > 0 {
+					if task.Status == "pending" && (task.AssignedTo == agent || task.AssignedTo == "any") && app.InProject(task.Project, project) {
+						if len(app.OpenDependencies(state, task)) > 0 {
 							continue
 						}
 						if bestTask == nil || task.Priority < bestTask.Priority {
@@ -216,16 +221,19 @@ func registerClaimNext(s *server.MCPServer, svc *app.CollabService, logger *log.
 						}
 					}
 					if state.Tasks[bestIdx].ContextID != "" {
-						autoLockTaskContextFiles(state, state.Tasks[bestIdx].ContextID, agent, svc.Policy().ValidatePath)
+						autoLockTaskContextFiles(state, state.Tasks[bestIdx].ContextID, state.Tasks[bestIdx].Project, agent, svc.Policy().ValidatePath)
 					}
 					result = mcp.NewToolResultText(fmt.Sprintf("Claimed task #%d [%s]: %s\n\nDescription: %s",
 						bestTask.ID, priorityNames[bestTask.Priority], bestTask.Title, bestTask.Description))
 					return nil
 				}
 
-				if state.ActivePlanID != "" {
-					if plan, exists := state.Plans[state.ActivePlanID]; exists && plan != nil {
+				if planID := app.ActivePlanID(state, project); planID != "" {
+					if plan, exists := state.Plans[planID]; exists && plan != nil {
 						for _, item := range plan.Items {
+							if app.PlanItemTask(state, plan.ID, item.ID) != nil {
+								continue // executed: its task is offered above
+							}
 							if item.Status == "pending" && (item.Owner == agent || item.Owner == "" || item.Owner == "unassigned") {
 								result = mcp.NewToolResultText(fmt.Sprintf(`{"action":"work_on_plan_item","priority":"normal","plan":"%s","item_id":"%s","title":"%s"}`,
 									plan.ID, item.ID, escapeJSON(item.Title)))
@@ -285,7 +293,7 @@ func registerRequestReview(s *server.MCPServer, svc *app.CollabService, logger *
 			}
 
 			var taskID int
-			if err := svc.Run(func(state *domain.CollabState) error {
+			if err := svc.RunAs(from, func(state *domain.CollabState) error {
 				extra := app.RegisteredAgentNames(state)
 				if err := app.ValidateAgent(from, state, false, false, extra...); err != nil {
 					return err
@@ -304,6 +312,7 @@ func registerRequestReview(s *server.MCPServer, svc *app.CollabService, logger *
 					CreatedAt:   time.Now(),
 					UpdatedAt:   time.Now(),
 					Priority:    2,
+					Project:     callerProject(svc, state, args, from),
 				}
 				state.Tasks = append(state.Tasks, task)
 				taskID = task.ID